@@ -1,15 +1,23 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"app/internal/config"
 	"app/internal/domain/model"
 	"app/internal/handler"
 	"app/internal/infra/db"
+	"app/internal/infra/mailer"
+	"app/internal/infra/oidc"
 	infrarepo "app/internal/infra/repository"
+	"app/internal/infra/storage"
 	"app/internal/middleware"
+	"app/internal/repository"
+	"app/internal/security"
 	"app/internal/usecase"
 	"app/internal/validator"
 
@@ -18,6 +26,12 @@ import (
 	echomw "github.com/labstack/echo/v4/middleware"
 )
 
+// 退会申請の匿名化を確認する間隔
+const accountDeletionSweepInterval = time.Hour
+
+// 放置されたゲストカートを掃除する間隔
+const guestCartSweepInterval = time.Hour
+
 func main() {
 	// .env を読む
 	_ = godotenv.Load()
@@ -44,7 +58,19 @@ func main() {
 	if err := gormDB.AutoMigrate(
 		&model.User{},
 		&model.RefreshToken{},
+		&model.PasswordResetToken{},
+		&model.MagicLinkToken{},
+		&model.EmailVerificationToken{},
+		&model.MfaRecoveryCode{},
+		&model.LoginAttempt{},
+		&model.UserIdentity{},
 		&model.Product{},
+		&model.Category{},
+		&model.ProductCategory{},
+		&model.ProductOption{},
+		&model.ProductVariant{},
+		&model.ProductVariantValue{},
+		&model.ProductImage{},
 		&model.InventoryAdjustment{},
 		&model.Cart{},
 		&model.CartItem{},
@@ -52,13 +78,23 @@ func main() {
 		&model.OrderItem{},
 		&model.Address{},
 		&model.AuditLog{},
+		&model.ApiKey{},
+		&model.RolePermission{},
+		&model.SecurityEvent{},
 	); err != nil {
 		log.Fatalf("migrate error: %v", err)
 	}
+	//商品検索（pg_trgmのインデックスと検索用カラムの埋め直し）
+	if err := db.MigrateProductSearch(gormDB); err != nil {
+		log.Fatalf("migrate error: %v", err)
+	}
 
 	// Echoサーバを起動する
 	e := echo.New()
 
+	// c.RealIP()（ログイン制限・セキュリティイベントのIP）を信頼するプロキシ経由のときだけX-Forwarded-Forから取る
+	e.IPExtractor = middleware.ClientIPExtractor(cfg.TrustedProxies)
+
 	// CORS（フロント http://localhost:3000 からのアクセスを許可）
 	// Cookie(refresh)を使うので AllowCredentials を true にする
 	e.Use(echomw.CORSWithConfig(echomw.CORSConfig{
@@ -79,9 +115,11 @@ func main() {
 			echo.HeaderContentType,
 			echo.HeaderAccept,
 			echo.HeaderAuthorization,
-			"X-CSRF-Token",
+			middleware.CSRFHeaderName,
 			"X-Idempotency-Key",
 		},
+		// 429のRetry-Afterをフロントから読めるようにする
+		ExposeHeaders:    []string{"Retry-After"},
 		AllowCredentials: true,
 	}))
 
@@ -100,23 +138,106 @@ func main() {
 		return c.String(200, "ok")
 	})
 
+	// access token検証用の公開鍵（JWT_KEYS_DIR未設定なら空）
+	jwksH := handler.NewJWKSHandler(cfg)
+	jwksH.RegisterRoutes(e)
+
 	// DI（依存注入）
 	// Repository（GORM実装）
 	userRepo := infrarepo.NewUserGormRepository(gormDB)
+
+	//token_versionのキャッシュ（リクエストごとのDB読み込みを減らす。更新時に破棄、複数ノードならNOTIFYで他ノードにも）
+	if cfg.TokenCacheTTLSeconds > 0 {
+		tokenCache := infrarepo.NewTokenStateMemoryCache(time.Duration(cfg.TokenCacheTTLSeconds)*time.Second, cfg.TokenCacheMaxEntries)
+		var tokenCachePublisher repository.TokenStateInvalidationPublisher
+		if cfg.TokenCacheInvalidation == config.TokenCacheInvalidationPostgres {
+			tokenCachePublisher = infrarepo.NewTokenStatePgPublisher(gormDB)
+			go infrarepo.ListenTokenStateInvalidations(context.Background(), db.DSN(cfg), tokenCache)
+		}
+		userRepo = infrarepo.NewTokenStateCachedUserRepository(userRepo, tokenCache, tokenCachePublisher)
+	}
 	rtRepo := infrarepo.NewRefreshTokenGormRepository(gormDB)
+	resetRepo := infrarepo.NewPasswordResetTokenGormRepository(gormDB)
+	verifyRepo := infrarepo.NewEmailVerificationTokenGormRepository(gormDB)
+	recoveryRepo := infrarepo.NewMfaRecoveryCodeGormRepository(gormDB)
+	identityRepo := infrarepo.NewUserIdentityGormRepository(gormDB)
+
+	//ログイン失敗回数（複数ノードならpostgresで共有）
+	attemptRepo := infrarepo.NewLoginAttemptMemoryRepository()
+	if cfg.LoginAttemptStore == config.LoginAttemptStorePostgres {
+		attemptRepo = infrarepo.NewLoginAttemptGormRepository(gormDB)
+	}
+
+	//Mailer（MAIL_SINK_DIRがあればファイル出力、無ければログ出力）
+	var mail usecase.Mailer = mailer.NewLogMailer(cfg.MailFrom)
+	if cfg.MailSinkDir != "" {
+		fm, err := mailer.NewFileMailer(cfg.MailSinkDir, cfg.MailFrom)
+		if err != nil {
+			log.Fatalf("mailer error: %v", err)
+		}
+		mail = fm
+	}
+
+	//流出済みパスワード一覧（設定されていれば登録・再設定・変更で照合する）
+	var breachedPasswords validator.BreachedPasswordChecker
+	if cfg.BreachedPasswordsPath != "" {
+		bp, err := security.LoadBreachedPasswords(cfg.BreachedPasswordsPath)
+		if err != nil {
+			log.Fatalf("breached passwords error: %v", err)
+		}
+		log.Printf("breached passwords loaded: %d", bp.Len())
+		breachedPasswords = bp
+	}
 
 	//Validator（usecase.AuthValidator の実装）
-	authValidator := validator.NewAuthValidator(userRepo)
+	authValidator := validator.NewAuthValidator(userRepo, breachedPasswords)
+
+	//セキュリティイベント（ログイン・ログアウト・refresh再利用などの履歴）
+	securityEventRepo := infrarepo.NewSecurityEventGormRepository(gormDB)
 
 	//Usecase
-	authUC := usecase.NewAuthUsecase(cfg, userRepo, rtRepo, authValidator)
+	authUC := usecase.NewAuthUsecase(cfg, userRepo, rtRepo, authValidator, resetRepo, verifyRepo, recoveryRepo, attemptRepo, mail, securityEventRepo)
+
+	//ソーシャルログイン（OIDC_PROVIDERS に書いたproviderだけ）
+	var oidcProviders []usecase.OidcProvider
+	for _, p := range cfg.OidcProviders {
+		oidcProviders = append(oidcProviders, oidc.NewProvider(p, nil))
+	}
+	oidcUC := usecase.NewOidcUsecase(cfg, authUC, userRepo, identityRepo, oidcProviders)
+
+	//パスワードなしログイン（メールのワンタイムリンク）
+	magicLinkRepo := infrarepo.NewMagicLinkTokenGormRepository(gormDB)
+	magicLinkUC := usecase.NewMagicLinkUsecase(authUC, userRepo, magicLinkRepo, mail)
+
+	//カート（ゲストカートはログイン・登録時にユーザーのカートへ合算する）
+	productRepo := infrarepo.NewProductGormRepository(gormDB)
+	variantRepo := infrarepo.NewProductVariantGormRepository(gormDB)
+	cartRepoImpl := infrarepo.NewCartGormRepository(gormDB)
+	cartUC := usecase.NewCartUsecase(cfg, cartRepoImpl, cartRepoImpl, productRepo, variantRepo)
+	go runGuestCartSweeper(context.Background(), cartUC)
+
+	//個人データのエクスポート・退会（猶予期間の後に匿名化。注文は残す）
+	addrRepo := infrarepo.NewAddressGormRepository(gormDB)
+	orderRepo := infrarepo.NewOrderGormRepository(gormDB)
+	orderItemRepo := infrarepo.NewOrderItemGormRepository(gormDB)
+	accountUC := usecase.NewAccountUsecase(authUC, userRepo, addrRepo, orderRepo, orderItemRepo, identityRepo)
+	go runAccountDeletionSweeper(context.Background(), accountUC)
 
 	//Handler（ルーティング登録）
-	authH := handler.NewAuthHandler(cfg, authUC, userRepo)
+	authH := handler.NewAuthHandler(cfg, authUC, oidcUC, magicLinkUC, cartUC, accountUC, userRepo)
 	authH.RegisterRoutes(e)
 
+	//Sessions（ログイン中の端末一覧・失効）
+	sessionUC := usecase.NewSessionUsecase(rtRepo)
+	sessionH := handler.NewSessionHandler(cfg, sessionUC)
+	sessionH.RegisterRoutes(e, userRepo)
+
+	//自分のセキュリティイベント
+	securityEventUC := usecase.NewSecurityEventUsecase(securityEventRepo)
+	securityEventH := handler.NewSecurityEventHandler(cfg, securityEventUC)
+	securityEventH.RegisterRoutes(e, userRepo)
+
 	//Address
-	addrRepo := infrarepo.NewAddressGormRepository(gormDB)
 	addrUC := usecase.NewAddressUsecase(addrRepo)
 	addrHandler := handler.NewAddressHandler(addrUC)
 	authGroup := e.Group(
@@ -127,30 +248,87 @@ func main() {
 
 	addrHandler.RegisterRoutes(authGroup)
 
-	//Handler(強制ログアウト)
-	adminUserH := handler.NewAdminUserHandler(cfg, userRepo, authUC)
-	adminUserH.RegisterRoutes(e)
-
 	//監査ログ
 	auditRepo := infrarepo.NewAuditLogGormRepository(gormDB)
 
+	//スタッフのロールと権限（/admin のルートごとに確認。空なら初期ロールを入れる）
+	rolePermRepo := infrarepo.NewRolePermissionGormRepository(gormDB)
+	rbacUC := usecase.NewRbacUsecase(rolePermRepo, userRepo, auditRepo)
+	if err := rbacUC.SeedDefaults(context.Background()); err != nil {
+		log.Fatalf("rbac seed error: %v", err)
+	}
+	adminRoleH := handler.NewAdminRoleHandler(rbacUC)
+	adminRoleH.RegisterRoutes(e, cfg, userRepo)
+
+	//Handler(ユーザー管理・強制ログアウト)
+	adminUserUC := usecase.NewAdminUserUsecase(userRepo, orderRepo, rtRepo, rolePermRepo, auditRepo, accountUC)
+	adminUserH := handler.NewAdminUserHandler(cfg, userRepo, authUC, adminUserUC)
+	adminUserH.RegisterRoutes(e, rbacUC)
+
+	//なりすまし（サポート用。顧客として読み取り専用でログインし、リクエストごとに監査ログ）
+	impersonationUC := usecase.NewImpersonationUsecase(cfg, userRepo, auditRepo)
+	adminImpersonationH := handler.NewAdminImpersonationHandler(impersonationUC)
+	adminImpersonationH.RegisterRoutes(e, cfg, userRepo, rbacUC)
+	e.Use(middleware.ImpersonationAudit(impersonationUC))
+
+	//セキュリティイベントの検索（admin・権限のあるスタッフ）
+	adminSecurityEventH := handler.NewAdminSecurityEventHandler(securityEventUC)
+	adminSecurityEventH.RegisterRoutes(e, cfg, userRepo, rbacUC)
+
+	//APIキー（サーバー間連携。/admin の商品・在庫・注文APIで使える）
+	apiKeyRepo := infrarepo.NewApiKeyGormRepository(gormDB)
+	apiKeyUC := usecase.NewApiKeyUsecase(apiKeyRepo, userRepo, auditRepo)
+	adminApiKeyH := handler.NewAdminApiKeyHandler(apiKeyUC)
+	adminApiKeyH.RegisterRoutes(e, cfg, userRepo)
+
+	//ログインロックの確認・解除（admin）
+	adminLockoutUC := usecase.NewAdminLoginLockoutUsecase(attemptRepo, userRepo, auditRepo)
+	adminLockoutH := handler.NewAdminLoginLockoutHandler(adminLockoutUC)
+	adminLockoutH.RegisterRoutes(e, cfg, userRepo, rbacUC)
+
+	//商品画像の置き場所（ローカルディスク。UPLOAD_BASE_URLが/で始まればこのAPIが配信する）
+	fileStorage, err := storage.NewLocalStorage(cfg.UploadDir, cfg.UploadBaseURL)
+	if err != nil {
+		log.Fatalf("storage error: %v", err)
+	}
+	if strings.HasPrefix(cfg.UploadBaseURL, "/") {
+		e.Static(cfg.UploadBaseURL, cfg.UploadDir)
+	}
+
 	// Products
-	productRepo := infrarepo.NewProductGormRepository(gormDB)
 	inventoryRepo := infrarepo.NewInventoryGormRepository(gormDB)
-	productUC := usecase.NewProductUsecase(productRepo, inventoryRepo, auditRepo)
+	categoryRepo := infrarepo.NewCategoryGormRepository(gormDB)
+	productImageRepo := infrarepo.NewProductImageGormRepository(gormDB)
+	productUC := usecase.NewProductUsecase(productRepo, inventoryRepo, auditRepo, categoryRepo, variantRepo, productImageRepo, fileStorage)
 
 	productH := handler.NewProductHandler(productUC)
 	productH.RegisterRoutes(e)
 
 	adminProductH := handler.NewAdminProductHandler(productUC)
-	adminProductH.RegisterRoutes(e, cfg, userRepo)
+	adminProductH.RegisterRoutes(e, cfg, userRepo, apiKeyUC, rbacUC)
 
-	// Cart
-	cartRepoImpl := infrarepo.NewCartGormRepository(gormDB)
-	// usecase
-	cartUC := usecase.NewCartUsecase(cartRepoImpl, cartRepoImpl, productRepo)
-	cartH := handler.NewCartHandler(cartUC)
-	cartH.RegisterRoutes(e, cfg, userRepo)
+	// Variants（サイズ・色などのバリエーション。価格・在庫はSKUごと）
+	variantUC := usecase.NewProductVariantUsecase(productRepo, variantRepo, inventoryRepo, auditRepo)
+	adminVariantH := handler.NewAdminVariantHandler(variantUC)
+	adminVariantH.RegisterRoutes(e, cfg, userRepo, apiKeyUC, rbacUC)
+
+	// Product images（アップロード・並び替え・削除。縮小版はアップロード時に作る）
+	productImageUC := usecase.NewProductImageUsecase(cfg, productRepo, productImageRepo, fileStorage)
+	adminProductImageH := handler.NewAdminProductImageHandler(cfg, productImageUC)
+	adminProductImageH.RegisterRoutes(e, cfg, userRepo, apiKeyUC, rbacUC)
+
+	// Categories（カテゴリツリーと商品の分類）
+	categoryUC := usecase.NewCategoryUsecase(categoryRepo)
+
+	categoryH := handler.NewCategoryHandler(categoryUC)
+	categoryH.RegisterRoutes(e)
+
+	adminCategoryH := handler.NewAdminCategoryHandler(categoryUC)
+	adminCategoryH.RegisterRoutes(e, cfg, userRepo, apiKeyUC, rbacUC)
+
+	// Cart（未ログインならゲストカート）
+	cartH := handler.NewCartHandler(cfg, cartUC)
+	cartH.RegisterRoutes(e, userRepo)
 
 	// TxManager
 	txManager := infrarepo.NewTxManagerGorm(gormDB)
@@ -163,9 +341,51 @@ func main() {
 	//AdminOrder一覧
 	adminOrderUC := usecase.NewAdminOrderUsecase(txManager, auditRepo)
 	adminOrderH := handler.NewAdminOrderHandler(adminOrderUC)
-	adminOrderH.RegisterRoutes(e, cfg, userRepo)
+	adminOrderH.RegisterRoutes(e, cfg, userRepo, apiKeyUC, rbacUC)
 
 	// サーバ起動
 	log.Fatal(e.Start(":" + cfg.Port))
 
 }
+
+// 猶予期間が過ぎた退会申請の匿名化（起動時と、その後1時間ごと）
+func runAccountDeletionSweeper(ctx context.Context, accountUC *usecase.AccountUsecase) {
+	ticker := time.NewTicker(accountDeletionSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := accountUC.PurgeDue(ctx, time.Now())
+		if err != nil {
+			log.Printf("account deletion sweep error: %v", err)
+		} else if n > 0 {
+			log.Printf("account deletion sweep: anonymized=%d", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 放置・合算済みのゲストカートの削除（起動時と、その後1時間ごと）
+func runGuestCartSweeper(ctx context.Context, cartUC *usecase.CartUsecase) {
+	ticker := time.NewTicker(guestCartSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := cartUC.PurgeAbandonedGuests(ctx, time.Now())
+		if err != nil {
+			log.Printf("guest cart sweep error: %v", err)
+		} else if n > 0 {
+			log.Printf("guest cart sweep: deleted=%d", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
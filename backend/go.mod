@@ -37,6 +37,6 @@ require (
 	github.com/joho/godotenv v1.5.1
 	github.com/labstack/echo/v4 v4.15.1
 	golang.org/x/crypto v0.48.0
-	golang.org/x/text v0.34.0 // indirect
+	golang.org/x/text v0.34.0
 	gorm.io/driver/postgres v1.6.0
 )
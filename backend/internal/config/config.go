@@ -2,10 +2,66 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
+
+	"app/internal/security"
+)
+
+// メールアドレス未確認ユーザーをどこで止めるか
+const (
+	EmailVerificationPolicyNone  = "none"  // 止めない
+	EmailVerificationPolicyOrder = "order" // 注文確定を止める
+	EmailVerificationPolicyLogin = "login" // ログイン自体を止める
+)
+
+// ログイン失敗回数の保存先
+const (
+	LoginAttemptStoreMemory   = "memory"   // 単一ノード
+	LoginAttemptStorePostgres = "postgres" // 複数ノード
 )
 
+// refresh時にuser_agentが発行時と違ったときの扱い
+const (
+	RefreshUAMismatchRevokeAll    = "revoke_all"    // そのユーザーの全端末を失効
+	RefreshUAMismatchRevokeFamily = "revoke_family" // その端末（refreshの系列）だけ失効
+	RefreshUAMismatchAllow        = "allow"         // 通す（ログだけ残す）
+)
+
+// token_versionキャッシュの破棄をどこまで届けるか
+const (
+	TokenCacheInvalidationLocal    = "local"    // 単一ノード
+	TokenCacheInvalidationPostgres = "postgres" // 複数ノード（LISTEN/NOTIFY）
+)
+
+// token_versionキャッシュのTTLの上限（通知を取りこぼしても、これ以上は古くならない）
+const TokenCacheMaxTTLSeconds = 300
+
+// bcryptのコストの範囲（10未満は弱すぎ、16を超えるとログインが遅すぎる）
+const (
+	PasswordBcryptMinCost = 10
+	PasswordBcryptMaxCost = 16
+)
+
+// Argon2idのメモリ下限（OWASP推奨の最小 19MiB）
+const PasswordArgon2MinMemoryKiB = 19 * 1024
+
+// OpenID Connectのprovider 1つ分（OIDC_<NAME>_* から読む）
+type OidcProviderConfig struct {
+	Name         string   // URLに使う名前（google など）
+	Issuer       string   // issuer（/.well-known/openid-configuration の取得元）
+	ClientID     string   // client_id
+	ClientSecret string   // client_secret（public clientなら空）
+	RedirectURL  string   // 認可後に戻るURL（IdPに登録したもの）
+	Scopes       []string // 要求するscope（openidは必ず含める）
+}
+
+// provider名に使える文字（URLパス・環境変数名に入れる）
+var oidcProviderNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
 // Configはアプリ全体の設定
 type Config struct {
 	Port string // サーバーポート（8080）
@@ -18,9 +74,56 @@ type Config struct {
 
 	JWTSecret string // JWT署名シークレット
 
+	JWTKeysDir           string           // RS256/EdDSAの鍵ディレクトリ（<kid>.pem / <kid>.pub.pem）。空ならJWT_SECRETのHS256
+	JWTSigningKID        string           // 署名に使うkid
+	JWTAcceptLegacyHS256 bool             // 移行用：kid無しのHS256 tokenも受け付ける
+	JWTKeys              *security.KeySet // Loadで読み込んだ鍵（nilならJWT_SECRETのHS256）
+
 	GoEnv     string // dev/prod
 	APIDomain string // APIドメイン（cookieやCORSなどで使う）
 	FEURL     string // フロントURL（CORSなどで使う）
+
+	TrustedProxies []*net.IPNet // X-Forwarded-Forを信頼するプロキシ（空なら接続元のアドレスをそのまま使う）
+
+	MailFrom    string // 送信元メールアドレス
+	MailSinkDir string // ローカル用：メールをファイルに書き出すディレクトリ（空ならログ出力）
+
+	EmailVerificationPolicy string // none/order/login
+
+	MfaIssuer           string // 認証アプリに表示する発行者名
+	MfaEncryptionKey    string // TOTPシークレット暗号化キー（未設定ならJWT_SECRETから導出）
+	MfaRequiredForAdmin bool   // trueならADMINは2FA必須（未設定のADMINは管理APIを使えない）
+
+	LoginAttemptStore        string // memory/postgres
+	LoginMaxFailuresPerEmail int    // メールアドレスごとの連続失敗上限（0なら無効）
+	LoginMaxFailuresPerIP    int    // IPごとの連続失敗上限（0なら無効）
+	LoginLockoutBaseSeconds  int    // 最初のロック秒数（以降、失敗ごとに2倍）
+	LoginLockoutMaxSeconds   int    // ロック秒数の上限（失敗回数はこの期間失敗が無ければリセット）
+
+	OidcProviders []OidcProviderConfig // ソーシャルログイン（OIDC_PROVIDERS が空なら無効）
+
+	RefreshUAMismatchPolicy string // revoke_all/revoke_family/allow
+
+	TokenCacheTTLSeconds   int    // token_versionキャッシュのTTL（0ならキャッシュしない）
+	TokenCacheMaxEntries   int    // キャッシュするユーザー数の上限
+	TokenCacheInvalidation string // local/postgres
+
+	PasswordHashAlgo          string // bcrypt/argon2id（変えると既存ユーザーは次のログインでハッシュし直す）
+	PasswordBcryptCost        int    // bcryptのコスト
+	PasswordArgon2MemoryKiB   int    // Argon2idのメモリ（KiB）
+	PasswordArgon2Iterations  int    // Argon2idの反復回数
+	PasswordArgon2Parallelism int    // Argon2idの並列度
+	BreachedPasswordsPath     string // 流出済みパスワード一覧（SHA-1。ファイルかrangeごとのディレクトリ）。空なら照合しない
+
+	GuestCartSigningKey string // ゲストカートcookieの署名キー（未設定ならJWT_SECRETから導出）
+	GuestCartTTLDays    int    // ゲストカートの保持日数（cookieの寿命・放置カートの削除）
+
+	AccountDeletionCoolingOffDays int // 退会申請から匿名化までの猶予日数（この間はログインして取り消せる）
+
+	UploadDir                 string // 商品画像などの保存先ディレクトリ（ローカルストレージ）
+	UploadBaseURL             string // 保存したファイルの公開URLの先頭（/で始まればこのAPIが配信する）
+	ProductImageMaxBytes      int    // アップロードできる画像1枚の上限バイト数
+	ProductImageMaxPerProduct int    // 1商品あたりの画像の上限枚数
 }
 
 // Loadは環境変数
@@ -44,6 +147,87 @@ func Load() (Config, error) {
 		GoEnv:     os.Getenv("GO_ENV"),
 		APIDomain: os.Getenv("API_DOMAIN"),
 		FEURL:     os.Getenv("FE_URL"),
+
+		MailFrom:    getEnvDefault("MAIL_FROM", "no-reply@localhost"),
+		MailSinkDir: os.Getenv("MAIL_SINK_DIR"),
+
+		EmailVerificationPolicy: getEnvDefault("EMAIL_VERIFICATION_POLICY", EmailVerificationPolicyNone),
+
+		MfaIssuer:        getEnvDefault("MFA_ISSUER", "EC_App"),
+		MfaEncryptionKey: os.Getenv("MFA_ENCRYPTION_KEY"),
+	}
+
+	cfg.JWTKeysDir = os.Getenv("JWT_KEYS_DIR")
+	cfg.JWTSigningKID = os.Getenv("JWT_SIGNING_KID")
+	if cfg.JWTAcceptLegacyHS256, err = getEnvBool("JWT_ACCEPT_LEGACY_HS256", false); err != nil {
+		return Config{}, err
+	}
+
+	mfaRequired, err := getEnvBool("MFA_REQUIRED_FOR_ADMIN", false)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.MfaRequiredForAdmin = mfaRequired
+
+	if cfg.TrustedProxies, err = loadTrustedProxies(); err != nil {
+		return Config{}, err
+	}
+
+	cfg.LoginAttemptStore = getEnvDefault("LOGIN_ATTEMPT_STORE", LoginAttemptStoreMemory)
+	if cfg.LoginMaxFailuresPerEmail, err = getEnvInt("LOGIN_MAX_FAILURES_PER_EMAIL", 5); err != nil {
+		return Config{}, err
+	}
+	if cfg.LoginMaxFailuresPerIP, err = getEnvInt("LOGIN_MAX_FAILURES_PER_IP", 20); err != nil {
+		return Config{}, err
+	}
+	if cfg.LoginLockoutBaseSeconds, err = getEnvInt("LOGIN_LOCKOUT_BASE_SECONDS", 30); err != nil {
+		return Config{}, err
+	}
+	if cfg.LoginLockoutMaxSeconds, err = getEnvInt("LOGIN_LOCKOUT_MAX_SECONDS", 900); err != nil {
+		return Config{}, err
+	}
+
+	cfg.RefreshUAMismatchPolicy = getEnvDefault("REFRESH_UA_MISMATCH_POLICY", RefreshUAMismatchRevokeFamily)
+
+	if cfg.TokenCacheTTLSeconds, err = getEnvInt("TOKEN_CACHE_TTL_SECONDS", 30); err != nil {
+		return Config{}, err
+	}
+	if cfg.TokenCacheMaxEntries, err = getEnvInt("TOKEN_CACHE_MAX_ENTRIES", 100000); err != nil {
+		return Config{}, err
+	}
+	cfg.TokenCacheInvalidation = getEnvDefault("TOKEN_CACHE_INVALIDATION", TokenCacheInvalidationLocal)
+
+	cfg.PasswordHashAlgo = getEnvDefault("PASSWORD_HASH_ALGO", security.PasswordAlgoBcrypt)
+	if cfg.PasswordBcryptCost, err = getEnvInt("PASSWORD_BCRYPT_COST", PasswordBcryptMinCost); err != nil {
+		return Config{}, err
+	}
+	if cfg.PasswordArgon2MemoryKiB, err = getEnvInt("PASSWORD_ARGON2_MEMORY_KIB", 64*1024); err != nil {
+		return Config{}, err
+	}
+	if cfg.PasswordArgon2Iterations, err = getEnvInt("PASSWORD_ARGON2_ITERATIONS", 3); err != nil {
+		return Config{}, err
+	}
+	if cfg.PasswordArgon2Parallelism, err = getEnvInt("PASSWORD_ARGON2_PARALLELISM", 2); err != nil {
+		return Config{}, err
+	}
+	cfg.BreachedPasswordsPath = os.Getenv("BREACHED_PASSWORDS_PATH")
+
+	cfg.GuestCartSigningKey = os.Getenv("GUEST_CART_SIGNING_KEY")
+	if cfg.GuestCartTTLDays, err = getEnvInt("GUEST_CART_TTL_DAYS", 30); err != nil {
+		return Config{}, err
+	}
+
+	if cfg.AccountDeletionCoolingOffDays, err = getEnvInt("ACCOUNT_DELETION_COOLING_OFF_DAYS", 14); err != nil {
+		return Config{}, err
+	}
+
+	cfg.UploadDir = getEnvDefault("UPLOAD_DIR", "./uploads")
+	cfg.UploadBaseURL = getEnvDefault("UPLOAD_BASE_URL", "/uploads")
+	if cfg.ProductImageMaxBytes, err = getEnvInt("PRODUCT_IMAGE_MAX_BYTES", 5<<20); err != nil {
+		return Config{}, err
+	}
+	if cfg.ProductImageMaxPerProduct, err = getEnvInt("PRODUCT_IMAGE_MAX_PER_PRODUCT", 10); err != nil {
+		return Config{}, err
 	}
 
 	//必須チェック
@@ -75,9 +259,189 @@ func Load() (Config, error) {
 		return Config{}, fmt.Errorf("FE_URL is required")
 	}
 
+	switch cfg.EmailVerificationPolicy {
+	case EmailVerificationPolicyNone, EmailVerificationPolicyOrder, EmailVerificationPolicyLogin:
+	default:
+		return Config{}, fmt.Errorf("EMAIL_VERIFICATION_POLICY must be none/order/login")
+	}
+
+	switch cfg.LoginAttemptStore {
+	case LoginAttemptStoreMemory, LoginAttemptStorePostgres:
+	default:
+		return Config{}, fmt.Errorf("LOGIN_ATTEMPT_STORE must be memory/postgres")
+	}
+	if cfg.LoginLockoutBaseSeconds <= 0 || cfg.LoginLockoutMaxSeconds < cfg.LoginLockoutBaseSeconds {
+		return Config{}, fmt.Errorf("LOGIN_LOCKOUT_BASE_SECONDS must be > 0 and <= LOGIN_LOCKOUT_MAX_SECONDS")
+	}
+
+	switch cfg.RefreshUAMismatchPolicy {
+	case RefreshUAMismatchRevokeAll, RefreshUAMismatchRevokeFamily, RefreshUAMismatchAllow:
+	default:
+		return Config{}, fmt.Errorf("REFRESH_UA_MISMATCH_POLICY must be revoke_all/revoke_family/allow")
+	}
+
+	if cfg.TokenCacheTTLSeconds < 0 || cfg.TokenCacheTTLSeconds > TokenCacheMaxTTLSeconds {
+		return Config{}, fmt.Errorf("TOKEN_CACHE_TTL_SECONDS must be 0-%d", TokenCacheMaxTTLSeconds)
+	}
+	if cfg.TokenCacheMaxEntries <= 0 {
+		return Config{}, fmt.Errorf("TOKEN_CACHE_MAX_ENTRIES must be > 0")
+	}
+	switch cfg.TokenCacheInvalidation {
+	case TokenCacheInvalidationLocal, TokenCacheInvalidationPostgres:
+	default:
+		return Config{}, fmt.Errorf("TOKEN_CACHE_INVALIDATION must be local/postgres")
+	}
+
+	switch cfg.PasswordHashAlgo {
+	case security.PasswordAlgoBcrypt, security.PasswordAlgoArgon2id:
+	default:
+		return Config{}, fmt.Errorf("PASSWORD_HASH_ALGO must be bcrypt/argon2id")
+	}
+	if cfg.PasswordBcryptCost < PasswordBcryptMinCost || cfg.PasswordBcryptCost > PasswordBcryptMaxCost {
+		return Config{}, fmt.Errorf("PASSWORD_BCRYPT_COST must be %d-%d", PasswordBcryptMinCost, PasswordBcryptMaxCost)
+	}
+	if cfg.PasswordArgon2MemoryKiB < PasswordArgon2MinMemoryKiB {
+		return Config{}, fmt.Errorf("PASSWORD_ARGON2_MEMORY_KIB must be >= %d", PasswordArgon2MinMemoryKiB)
+	}
+	if cfg.PasswordArgon2Iterations <= 0 {
+		return Config{}, fmt.Errorf("PASSWORD_ARGON2_ITERATIONS must be > 0")
+	}
+	if cfg.PasswordArgon2Parallelism <= 0 || cfg.PasswordArgon2Parallelism > 255 {
+		return Config{}, fmt.Errorf("PASSWORD_ARGON2_PARALLELISM must be 1-255")
+	}
+
+	if cfg.GuestCartTTLDays <= 0 {
+		return Config{}, fmt.Errorf("GUEST_CART_TTL_DAYS must be > 0")
+	}
+	if cfg.AccountDeletionCoolingOffDays <= 0 {
+		return Config{}, fmt.Errorf("ACCOUNT_DELETION_COOLING_OFF_DAYS must be > 0")
+	}
+	if cfg.ProductImageMaxBytes <= 0 {
+		return Config{}, fmt.Errorf("PRODUCT_IMAGE_MAX_BYTES must be > 0")
+	}
+	if cfg.ProductImageMaxPerProduct <= 0 {
+		return Config{}, fmt.Errorf("PRODUCT_IMAGE_MAX_PER_PRODUCT must be > 0")
+	}
+
+	if cfg.OidcProviders, err = loadOidcProviders(); err != nil {
+		return Config{}, err
+	}
+
+	//非対称鍵（設定されていれば）
+	if cfg.JWTKeysDir != "" {
+		ks, err := security.LoadKeySetFromDir(cfg.JWTKeysDir, cfg.JWTSigningKID)
+		if err != nil {
+			return Config{}, err
+		}
+		if cfg.JWTAcceptLegacyHS256 {
+			ks.AcceptLegacyHMAC(cfg.JWTSecret)
+		}
+		cfg.JWTKeys = ks
+	}
+
+	if cfg.MfaEncryptionKey == "" {
+		cfg.MfaEncryptionKey = cfg.JWTSecret
+	}
+	if cfg.GuestCartSigningKey == "" {
+		cfg.GuestCartSigningKey = cfg.JWTSecret
+	}
+
 	return cfg, nil
 }
 
+// access tokenの署名・検証に使う鍵（JWT_KEYS_DIR未設定ならJWT_SECRETのHS256）
+func (c Config) JWTKeySet() *security.KeySet {
+	if c.JWTKeys != nil {
+		return c.JWTKeys
+	}
+	return security.NewHMACKeySet(c.JWTSecret)
+}
+
+// パスワードのハッシュ化・照合（未設定の項目はbcrypt・デフォルトコスト）
+func (c Config) PasswordHasher() *security.PasswordHasher {
+	argon2Params := security.Argon2Params{
+		MemoryKiB:   uint32(c.PasswordArgon2MemoryKiB),
+		Iterations:  uint32(c.PasswordArgon2Iterations),
+		Parallelism: uint8(c.PasswordArgon2Parallelism),
+	}
+	if argon2Params.MemoryKiB == 0 || argon2Params.Iterations == 0 || argon2Params.Parallelism == 0 {
+		argon2Params = security.Argon2Params{MemoryKiB: 64 * 1024, Iterations: 3, Parallelism: 2}
+	}
+	return security.NewPasswordHasher(c.PasswordHashAlgo, c.PasswordBcryptCost, argon2Params)
+}
+
+// OIDC_PROVIDERS=google,keycloak のようにカンマ区切りで指定し、
+// providerごとに OIDC_<NAME>_ISSUER / _CLIENT_ID / _CLIENT_SECRET / _REDIRECT_URL / _SCOPES を読む
+func loadOidcProviders() ([]OidcProviderConfig, error) {
+	raw := os.Getenv("OIDC_PROVIDERS")
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var out []OidcProviderConfig
+	seen := map[string]bool{}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !oidcProviderNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("OIDC_PROVIDERS: invalid provider name %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("OIDC_PROVIDERS: duplicated provider %q", name)
+		}
+		seen[name] = true
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		p := OidcProviderConfig{
+			Name:         name,
+			Issuer:       strings.TrimRight(os.Getenv(prefix+"ISSUER"), "/"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(getEnvDefault(prefix+"SCOPES", "openid email profile")),
+		}
+		for _, key := range []string{"ISSUER", "CLIENT_ID", "REDIRECT_URL"} {
+			if os.Getenv(prefix+key) == "" {
+				return nil, fmt.Errorf("%s%s is required", prefix, key)
+			}
+		}
+
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// TRUSTED_PROXIES=10.0.0.0/8,192.168.1.10 のようにカンマ区切りで指定（CIDRか単一のIP）
+func loadTrustedProxies() ([]*net.IPNet, error) {
+	var out []*net.IPNet
+	for _, v := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("TRUSTED_PROXIES: invalid address %q", v)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			out = append(out, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, r, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES: invalid CIDR %q", v)
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
 func mustAtoi(key string) (int, error) {
 	v := os.Getenv(key)
 	if v == "" {
@@ -89,3 +453,38 @@ func mustAtoi(key string) (int, error) {
 	}
 	return i, nil
 }
+
+// 任意の環境変数（未設定ならデフォルト値）
+func getEnvDefault(key string, def string) string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// 任意の真偽値の環境変数（未設定ならデフォルト値）
+func getEnvBool(key string, def bool) (bool, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true/false: %w", key, err)
+	}
+	return b, nil
+}
+
+// 任意の数値の環境変数（未設定ならデフォルト値）
+func getEnvInt(key string, def int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be number: %w", key, err)
+	}
+	return i, nil
+}
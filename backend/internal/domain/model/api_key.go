@@ -0,0 +1,53 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// APIキーで使えるスコープ
+const (
+	ApiKeyScopeProductsWrite  = "products:write"
+	ApiKeyScopeInventoryWrite = "inventory:write"
+	ApiKeyScopeOrdersRead     = "orders:read"
+	ApiKeyScopeOrdersWrite    = "orders:write"
+)
+
+// 指定できるスコープの一覧
+var ApiKeyScopes = []string{
+	ApiKeyScopeProductsWrite,
+	ApiKeyScopeInventoryWrite,
+	ApiKeyScopeOrdersRead,
+	ApiKeyScopeOrdersWrite,
+}
+
+// サーバー間連携用のAPIキー（管理者が発行）。
+// キー本体は発行時に1回だけ返し、DBにはハッシュだけ保存する
+type ApiKey struct {
+	ID   int64  `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name string `gorm:"type:varchar(100);not null" json:"name"`
+	//キーの先頭部分（一覧で見分ける用。これだけでは認証できない）
+	Prefix  string `gorm:"type:varchar(20);not null;uniqueIndex" json:"prefix"`
+	KeyHash string `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	//スペース区切り（例: "inventory:write orders:read"）
+	Scopes string `gorm:"type:text;not null;default:''" json:"-"`
+	//発行した管理者。キーの操作はこの管理者の権限で行う
+	CreatedByUserID int64      `gorm:"not null;index" json:"created_by_user_id"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+	LastUsedAt      *time.Time `json:"last_used_at"`
+	RevokedAt       *time.Time `json:"revoked_at"`
+	CreatedAt       time.Time  `gorm:"not null" json:"created_at"`
+}
+
+func (k ApiKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+func (k ApiKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -10,6 +10,26 @@ const (
 	AuditActionUpdateStock AuditAction = "UPDATE_STOCK"
 	//注文ステータスを更新した操作。
 	AuditActionUpdateOrderStatus AuditAction = "UPDATE_ORDER_STATUS"
+	//ログインロックを解除した操作。
+	AuditActionClearLoginLockout AuditAction = "CLEAR_LOGIN_LOCKOUT"
+	//ユーザーの有効・無効を切り替えた操作。
+	AuditActionUpdateUserStatus AuditAction = "UPDATE_USER_STATUS"
+	//ユーザーのロールを変更した操作。
+	AuditActionUpdateUserRole AuditAction = "UPDATE_USER_ROLE"
+	//APIキーを発行した操作。
+	AuditActionCreateApiKey AuditAction = "CREATE_API_KEY"
+	//APIキーを失効させた操作。
+	AuditActionRevokeApiKey AuditAction = "REVOKE_API_KEY"
+	//ロールの権限を設定した操作。
+	AuditActionUpdateRolePermissions AuditAction = "UPDATE_ROLE_PERMISSIONS"
+	//ロールを削除した操作。
+	AuditActionDeleteRole AuditAction = "DELETE_ROLE"
+	//ユーザーとしてログインするtokenを発行した操作（なりすまし開始）。
+	AuditActionImpersonateUser AuditAction = "IMPERSONATE_USER"
+	//なりすまし中のリクエスト。ActorUserIDは管理者、ResourceIDは顧客。
+	AuditActionImpersonatedRequest AuditAction = "IMPERSONATED_REQUEST"
+	//猶予期間を待たずにユーザーを退会（匿名化）させた操作。
+	AuditActionDeleteUser AuditAction = "DELETE_USER"
 )
 
 // 何に対する操作か
@@ -19,11 +39,23 @@ const (
 	//商品に対する操作。
 	AuditResourceProduct AuditResourceType = "product"
 
+	//商品のバリエーション（SKU）に対する操作。ResourceIDはバリエーションID。
+	AuditResourceProductVariant AuditResourceType = "product_variant"
+
 	//注文に対する操作。
 	AuditResourceOrder AuditResourceType = "order"
 
 	//ユーザーに対する操作。
 	AuditResourceUser AuditResourceType = "user"
+
+	//ログインロック（email/IP）に対する操作。ResourceIDはユーザーID（IP・未登録なら0）。
+	AuditResourceLoginLockout AuditResourceType = "login_lockout"
+
+	//APIキーに対する操作。
+	AuditResourceApiKey AuditResourceType = "api_key"
+
+	//ロールに対する操作。ResourceIDは0（ロール名はJSONに入れる）。
+	AuditResourceRole AuditResourceType = "role"
 )
 
 // 監査ログ（管理者操作ログ）。
@@ -35,6 +67,9 @@ type AuditLog struct {
 	//操作したユーザー（主に管理者）のID。
 	ActorUserID int64 `gorm:"not null;index" json:"actor_user_id"`
 
+	//APIキー経由の操作ならそのキーのID（ActorUserIDはキーを発行した管理者）。
+	ActorApiKeyID *int64 `gorm:"index" json:"actor_api_key_id"`
+
 	//Actionは操作の種類（UPDATE_STOCK / UPDATE_ORDER_STATUS など）。
 	Action AuditAction `gorm:"type:varchar(50);not null;index" json:"action"`
 
@@ -8,12 +8,14 @@ const (
 	CartStatusActive     CartStatus = "ACTIVE"
 	CartStatusCheckedOut CartStatus = "CHECKED_OUT"
 	CartStatusAbandoned  CartStatus = "ABANDONED"
+	CartStatusMerged     CartStatus = "MERGED" // ゲストカートをログイン後のカートに合算済み
 )
 
-// 1ユーザーにつきACTIVEは1つ
+// 1ユーザーにつきACTIVEは1つ。
+// UserIDがnilならゲストカート（署名付きcookieでカートIDを持つ）
 type Cart struct {
 	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID    int64      `gorm:"not null;index" json:"user_id"`
+	UserID    *int64     `gorm:"index" json:"user_id"`
 	Status    CartStatus `gorm:"type:varchar(20);not null;index" json:"status"`
 	CreatedAt time.Time  `gorm:"not null;autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time  `gorm:"not null;autoUpdateTime" json:"updated_at"`
@@ -5,11 +5,15 @@ import "time"
 // カートの明細
 // 追加時点の価格）を必ず保存。
 type CartItem struct {
-	ID                int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	CartID            int64     `gorm:"not null;index" json:"cart_id"`
-	ProductID         int64     `gorm:"not null;index" json:"product_id"`
-	Quantity          int64     `gorm:"not null" json:"quantity"`
-	UnitPriceSnapshot int64     `gorm:"not null;column:unit_price_snapshot" json:"unit_price_snapshot"`
-	CreatedAt         time.Time `gorm:"not null;autoCreateTime" json:"created_at"`
-	UpdatedAt         time.Time `gorm:"not null;autoUpdateTime" json:"updated_at"`
+	ID        int64 `gorm:"primaryKey;autoIncrement" json:"id"`
+	CartID    int64 `gorm:"not null;index" json:"cart_id"`
+	ProductID int64 `gorm:"not null;index" json:"product_id"`
+	//バリエーションのある商品だけ（無い商品はnil）
+	VariantID         *int64 `gorm:"index" json:"variant_id"`
+	Quantity          int64  `gorm:"not null" json:"quantity"`
+	UnitPriceSnapshot int64  `gorm:"not null;column:unit_price_snapshot" json:"unit_price_snapshot"`
+	//追加時点の軸ラベル（例 "サイズ: M / 色: 白"）
+	OptionLabelsSnapshot string    `gorm:"type:varchar(255);not null;default:''" json:"option_labels_snapshot"`
+	CreatedAt            time.Time `gorm:"not null;autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time `gorm:"not null;autoUpdateTime" json:"updated_at"`
 }
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// 商品カテゴリ（親子の木構造。ParentIDがnilならトップレベル）
+type Category struct {
+	ID       int64  `gorm:"primaryKey;autoIncrement" json:"id"`
+	ParentID *int64 `gorm:"index" json:"parent_id"`
+	Name     string `gorm:"type:varchar(100);not null" json:"name"`
+	//URL用の名前（英小文字・数字・ハイフン、全体で一意）
+	Slug string `gorm:"type:varchar(100);not null;uniqueIndex" json:"slug"`
+	//同じ親の中での並び順（小さい順）
+	SortOrder int       `gorm:"not null;default:0" json:"sort_order"`
+	CreatedAt time.Time `gorm:"not null;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"not null;autoUpdateTime" json:"updated_at"`
+}
+
+// 商品とカテゴリの対応（多対多。1商品が複数カテゴリに入れる）
+type ProductCategory struct {
+	ProductID  int64     `gorm:"primaryKey" json:"product_id"`
+	CategoryID int64     `gorm:"primaryKey;index" json:"category_id"`
+	CreatedAt  time.Time `gorm:"not null" json:"created_at"`
+}
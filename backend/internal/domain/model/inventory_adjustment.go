@@ -5,8 +5,10 @@ import "time"
 //在庫調整の履歴
 
 type InventoryAdjustment struct {
-	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	ProductID   int64     `gorm:"not null;index" json:"product_id"`
+	ID        int64 `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID int64 `gorm:"not null;index" json:"product_id"`
+	//バリエーションの在庫を調整したとき
+	VariantID   *int64    `gorm:"index" json:"variant_id"`
 	AdminUserID int64     `gorm:"not null;index" json:"admin_user_id"`
 	Delta       int64     `gorm:"not null" json:"delta"`
 	Reason      string    `gorm:"type:varchar(255);not null" json:"reason"`
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// ログイン失敗の記録（ブルートフォース対策）。
+// Keyは "email:<メールアドレス>" か "ip:<IPアドレス>"。
+type LoginAttempt struct {
+	Key          string     `gorm:"type:varchar(320);primaryKey" json:"key"`
+	Failures     int        `gorm:"not null;default:0" json:"failures"`
+	LastFailedAt time.Time  `gorm:"not null" json:"last_failed_at"`
+	LockedUntil  *time.Time `gorm:"index" json:"locked_until"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// 2FAのリカバリーコード（1回だけ使える。DBにはhashのみ保存）
+type MfaRecoveryCode struct {
+	ID        string     `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    int64      `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"type:varchar(255);not null;uniqueIndex" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `gorm:"not null" json:"created_at"`
+}
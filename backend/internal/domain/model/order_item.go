@@ -3,11 +3,15 @@ package model
 import "time"
 
 type OrderItem struct {
-	ID                  int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	OrderID             int64     `gorm:"not null;index" json:"order_id"`
-	ProductID           int64     `gorm:"not null;index" json:"product_id"`
-	ProductNameSnapshot string    `gorm:"type:varchar(255);not null" json:"product_name_snapshot"`
-	UnitPriceSnapshot   int64     `gorm:"not null" json:"unit_price_snapshot"`
-	Quantity            int64     `gorm:"not null" json:"quantity"`
-	CreatedAt           time.Time `gorm:"not null;autoCreateTime" json:"created_at"`
+	ID                  int64  `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrderID             int64  `gorm:"not null;index" json:"order_id"`
+	ProductID           int64  `gorm:"not null;index" json:"product_id"`
+	ProductNameSnapshot string `gorm:"type:varchar(255);not null" json:"product_name_snapshot"`
+	//バリエーションのある商品だけ（SKU・軸ラベルは注文時点のもの）
+	VariantID            *int64    `gorm:"index" json:"variant_id"`
+	SKUSnapshot          string    `gorm:"column:sku_snapshot;type:varchar(100);not null;default:''" json:"sku_snapshot"`
+	OptionLabelsSnapshot string    `gorm:"type:varchar(255);not null;default:''" json:"option_labels_snapshot"`
+	UnitPriceSnapshot    int64     `gorm:"not null" json:"unit_price_snapshot"`
+	Quantity             int64     `gorm:"not null" json:"quantity"`
+	CreatedAt            time.Time `gorm:"not null;autoCreateTime" json:"created_at"`
 }
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// パスワード再設定用のワンタイムトークン
+// 平文はメールでのみ渡し、DBにはhashだけ保存する。
+type PasswordResetToken struct {
+	ID        string     `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    int64      `gorm:"not null;index" json:"user_id"`
+	TokenHash string     `gorm:"type:varchar(255);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"index" json:"used_at"`
+	CreatedAt time.Time  `gorm:"not null" json:"created_at"`
+}
@@ -16,4 +16,8 @@ type Product struct {
 	CreatedAt   time.Time      `gorm:"not null;autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time      `gorm:"not null;autoUpdateTime" json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	//検索用（name・descriptionを正規化したもの。保存時にrepositoryが埋める）
+	SearchName        string `gorm:"type:text;not null;default:''" json:"-"`
+	SearchDescription string `gorm:"type:text;not null;default:''" json:"-"`
 }
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// 商品画像。原寸と縮小版はストレージに置き、ここにはキーの元とサイズだけ持つ
+type ProductImage struct {
+	ID        int64 `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID int64 `gorm:"not null;index" json:"product_id"`
+	//ストレージ上の置き場所（<KeyPrefix>/original.<ext>、<KeyPrefix>/<サイズ名>.<ext>）
+	KeyPrefix string    `gorm:"type:varchar(255);not null" json:"-"`
+	Format    string    `gorm:"type:varchar(10);not null" json:"format"`
+	Width     int       `gorm:"not null" json:"width"`
+	Height    int       `gorm:"not null" json:"height"`
+	ByteSize  int64     `gorm:"not null" json:"byte_size"`
+	Position  int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt time.Time `gorm:"not null;autoCreateTime" json:"created_at"`
+}
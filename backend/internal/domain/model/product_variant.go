@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 商品のバリエーション軸（例：サイズ、色）。Positionの小さい順に並べる
+type ProductOption struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID int64     `gorm:"not null;uniqueIndex:idx_product_options_product_name" json:"product_id"`
+	Name      string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_product_options_product_name" json:"name"`
+	Position  int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt time.Time `gorm:"not null;autoCreateTime" json:"created_at"`
+}
+
+// バリエーション（SKU）。価格・在庫・公開はバリエーションごとに持つ
+type ProductVariant struct {
+	ID        int64  `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID int64  `gorm:"not null;index" json:"product_id"`
+	SKU       string `gorm:"type:varchar(100);not null;uniqueIndex" json:"sku"`
+	Price     int64  `gorm:"not null" json:"price"`
+	Stock     int64  `gorm:"not null" json:"stock"`
+	IsActive  bool   `gorm:"not null;default:false" json:"is_active"`
+	//軸ごとの値（サイズ=M、色=白）
+	Values    []ProductVariantValue `gorm:"foreignKey:VariantID" json:"-"`
+	CreatedAt time.Time             `gorm:"not null;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time             `gorm:"not null;autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt        `gorm:"index" json:"-"`
+}
+
+// バリエーションの軸ごとの値
+type ProductVariantValue struct {
+	VariantID int64  `gorm:"primaryKey" json:"variant_id"`
+	OptionID  int64  `gorm:"primaryKey;index" json:"option_id"`
+	Value     string `gorm:"type:varchar(50);not null" json:"value"`
+}
@@ -3,12 +3,16 @@ package model
 import "time"
 
 type RefreshToken struct {
-	ID        string     `gorm:"type:uuid;primaryKey" json:"id"`
-	UserID    int64      `gorm:"not null;index" json:"user_id"`
+	ID     string `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID int64  `gorm:"not null;index" json:"user_id"`
+	//ログインで作られた系列のID（回転しても引き継ぐ。1系列=1端末）。空なら系列導入前のtoken
+	FamilyID  string     `gorm:"type:varchar(36);not null;default:'';index" json:"family_id"`
 	TokenHash string     `gorm:"type:varchar(255);not null;uniqueIndex" json:"token_hash"`
 	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
 	UsedAt    *time.Time `gorm:"index" json:"used_at"`
 	UserAgent string     `gorm:"type:varchar(255);not null" json:"user_agent"`
 	IP        *string    `gorm:"type:varchar(45)" json:"ip"`
 	CreatedAt time.Time  `gorm:"not null" json:"created_at"`
+	//この系列のログインで2FA（TOTP・リカバリーコード）を通ったか。回転しても引き継ぐ
+	MfaVerified bool `gorm:"not null;default:false" json:"mfa_verified"`
 }
@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// スタッフ用のロール（初期データ。DBで追加・変更できる）
+const (
+	RoleInventoryManager Role = "INVENTORY_MANAGER"
+	RoleOrderOperator    Role = "ORDER_OPERATOR"
+	RoleSupportAgent     Role = "SUPPORT_AGENT"
+)
+
+// 管理APIの権限。ルートごとに RequirePermission で確認する
+const (
+	PermProductsWrite       = "products.write"
+	PermInventoryWrite      = "inventory.write"
+	PermOrdersRead          = "orders.read"
+	PermOrdersStatusUpdate  = "orders.status.update"
+	PermUsersRead           = "users.read"
+	PermUsersWrite          = "users.write"
+	PermUsersForceLogout    = "users.force_logout"
+	PermUsersImpersonate    = "users.impersonate"
+	PermUsersAnonymize      = "users.anonymize"
+	PermLoginLockoutsManage = "login_lockouts.manage"
+	PermSecurityEventsRead  = "security_events.read"
+)
+
+// 割り当てできる権限の一覧
+var Permissions = []string{
+	PermProductsWrite,
+	PermInventoryWrite,
+	PermOrdersRead,
+	PermOrdersStatusUpdate,
+	PermUsersRead,
+	PermUsersWrite,
+	PermUsersForceLogout,
+	PermUsersImpersonate,
+	PermUsersAnonymize,
+	PermLoginLockoutsManage,
+	PermSecurityEventsRead,
+}
+
+// role_permissions が空のときに入れる初期データ
+var DefaultRolePermissions = map[Role][]string{
+	RoleInventoryManager: {PermProductsWrite, PermInventoryWrite},
+	RoleOrderOperator:    {PermOrdersRead, PermOrdersStatusUpdate},
+	RoleSupportAgent:     {PermUsersRead, PermUsersForceLogout, PermUsersImpersonate, PermLoginLockoutsManage, PermOrdersRead, PermSecurityEventsRead},
+}
+
+// ロールと権限の対応。ADMIN（全権限）とUSER（権限なし）は固定なのでここには入れない。
+// 1件でも行があるロールが「スタッフのロール」
+type RolePermission struct {
+	Role       Role      `gorm:"type:varchar(20);primaryKey" json:"role"`
+	Permission string    `gorm:"type:varchar(50);primaryKey" json:"permission"`
+	CreatedAt  time.Time `gorm:"not null" json:"created_at"`
+}
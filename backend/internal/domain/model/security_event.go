@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+// 認証まわりの出来事の種類
+type SecurityEventType string
+
+const (
+	//パスワードでのログイン（2FAが有効ならチャレンジ発行まで）。
+	SecurityEventLogin SecurityEventType = "LOGIN"
+	//2FAコードでのログイン完了。
+	SecurityEventLoginMfa SecurityEventType = "LOGIN_MFA"
+	//ソーシャルログイン（OpenID Connect）。
+	SecurityEventLoginOidc SecurityEventType = "LOGIN_OIDC"
+	//メールのログインリンク（パスワードなし）。
+	SecurityEventLoginMagicLink SecurityEventType = "LOGIN_MAGIC_LINK"
+	//ログアウト。
+	SecurityEventLogout SecurityEventType = "LOGOUT"
+	//使用済みrefreshの再送（盗難の疑い）。
+	SecurityEventRefreshReuse SecurityEventType = "REFRESH_REUSE"
+	//発行時と違うuser_agentでのrefresh。
+	SecurityEventRefreshUAMismatch SecurityEventType = "REFRESH_UA_MISMATCH"
+	//管理者による強制ログアウト。
+	SecurityEventForceLogout SecurityEventType = "FORCE_LOGOUT"
+	//退会の申請（猶予期間の後に匿名化）。
+	SecurityEventAccountDeletionRequested SecurityEventType = "ACCOUNT_DELETION_REQUESTED"
+	//退会申請の取り消し。
+	SecurityEventAccountDeletionCanceled SecurityEventType = "ACCOUNT_DELETION_CANCELED"
+)
+
+// 結果
+type SecurityEventOutcome string
+
+const (
+	SecurityOutcomeSuccess SecurityEventOutcome = "SUCCESS"
+	SecurityOutcomeFailure SecurityEventOutcome = "FAILURE"
+)
+
+// 認証まわりのイベントログ（監査ログとは別。本人も /me/security-events で見られる）。
+type SecurityEvent struct {
+	ID int64 `gorm:"primaryKey;autoIncrement" json:"id"`
+
+	//対象のユーザー（存在しないメールアドレスでのログイン失敗ならnil）。
+	UserID *int64 `gorm:"index" json:"user_id"`
+
+	//操作した管理者（強制ログアウトなど。本人の操作ならnil）。
+	ActorUserID *int64 `gorm:"index" json:"actor_user_id"`
+
+	Type    SecurityEventType    `gorm:"type:varchar(30);not null;index" json:"type"`
+	Outcome SecurityEventOutcome `gorm:"type:varchar(10);not null;index" json:"outcome"`
+
+	//理由（invalid_password / locked / refresh_token_reuse など。成功なら空のことが多い）。
+	Reason string `gorm:"type:varchar(50);not null;default:''" json:"reason"`
+
+	//ログインで入力されたメールアドレス（ユーザーが特定できない失敗の調査用）。
+	Email string `gorm:"type:varchar(255);not null;default:''" json:"email"`
+
+	IP        string `gorm:"type:varchar(45);not null;default:'';index" json:"ip"`
+	UserAgent string `gorm:"type:varchar(255);not null;default:''" json:"user_agent"`
+
+	//refreshの系列（どの端末か）。
+	FamilyID string `gorm:"type:varchar(36);not null;default:''" json:"family_id"`
+
+	CreatedAt time.Time `gorm:"not null;index" json:"created_at"`
+}
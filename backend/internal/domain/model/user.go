@@ -17,6 +17,27 @@ type User struct {
 	TokenVersion int    `gorm:"not null;default:0"`
 	IsActive     bool   `gorm:"not null;default:true"`
 	LastLoginAt  *time.Time
+	//メールアドレス確認済みの時刻（未確認ならnil）
+	EmailVerifiedAt *time.Time
+	//TOTPシークレット（AES-GCMで暗号化して保存。登録途中もここに入る）
+	TotpSecretEnc string `gorm:"column:totp_secret_enc;not null;default:''"`
+	//2FAを有効化した時刻（未設定ならnil）
+	TotpEnabledAt *time.Time
+	//最後に受け付けたTOTPのタイムステップ（同じコードの再利用防止）
+	TotpLastStep int64 `gorm:"not null;default:0"`
+	//退会を申請した時刻（取り消したらnil）
+	DeletionRequestedAt *time.Time
+	//この時刻を過ぎたら匿名化する（申請時刻 + 猶予期間）
+	DeletionScheduledAt *time.Time `gorm:"index"`
+	//匿名化した時刻（退会済み。メールアドレス・住所は消してあり、注文だけ残る）
+	AnonymizedAt *time.Time
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
+
+// TokenVersionGuardが見る値だけ（キャッシュする）
+type TokenState struct {
+	UserID       int64
+	TokenVersion int
+	IsActive     bool
+}
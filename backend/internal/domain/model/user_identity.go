@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// 外部IdP（OpenID Connect）のアカウントとユーザーの紐付け
+// provider + subject（IdP側のユーザーID）で一意。1ユーザーにつき同じproviderは1つまで
+type UserIdentity struct {
+	ID       string `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID   int64  `gorm:"not null;index;uniqueIndex:idx_user_identities_user_provider" json:"user_id"`
+	Provider string `gorm:"type:varchar(50);not null;uniqueIndex:idx_user_identities_provider_subject;uniqueIndex:idx_user_identities_user_provider" json:"provider"`
+	Subject  string `gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identities_provider_subject" json:"-"`
+	//IdP側のメールアドレス（表示用。ログインの照合には使わない）
+	Email       string     `gorm:"type:varchar(320);not null;default:''" json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `gorm:"not null" json:"created_at"`
+}
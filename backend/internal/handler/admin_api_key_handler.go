@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"app/internal/config"
+	"app/internal/middleware"
+	"app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// /admin/api-keys（サーバー間連携用のAPIキーの発行・一覧・失効）
+type AdminApiKeyHandler struct {
+	uc *usecase.ApiKeyUsecase
+}
+
+func NewAdminApiKeyHandler(uc *usecase.ApiKeyUsecase) *AdminApiKeyHandler {
+	return &AdminApiKeyHandler{uc: uc}
+}
+
+type CreateApiKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// RFC3339。省略すると無期限
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// キーの管理は管理者のJWTだけ（APIキーでAPIキーは作れない）
+func (h *AdminApiKeyHandler) RegisterRoutes(e *echo.Echo, cfg config.Config, userRepo repository.UserRepository) {
+	admin := e.Group("/admin")
+	admin.Use(middleware.AuthJWT(cfg))
+	admin.Use(middleware.TokenVersionGuard(userRepo))
+	admin.Use(middleware.AdminRoleGuard(cfg))
+
+	admin.GET("/api-keys", h.list)
+	admin.POST("/api-keys", h.create)
+	admin.DELETE("/api-keys/:id", h.revoke)
+}
+
+func (h *AdminApiKeyHandler) list(c echo.Context) error {
+	out, err := h.uc.List(c.Request().Context())
+	if err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+// キー本体（key）はこのレスポンスでしか返さない
+func (h *AdminApiKeyHandler) create(c echo.Context) error {
+	var req CreateApiKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid body"})
+	}
+
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	out, err := h.uc.Create(c.Request().Context(), adminID, usecase.CreateApiKeyInput{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusCreated, out)
+}
+
+func (h *AdminApiKeyHandler) revoke(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	if err := h.uc.Revoke(c.Request().Context(), adminID, id); err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{Message: "revoked"})
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/middleware"
+	"app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CategoryRequest はカテゴリの作成・更新の入力です（parent_id省略でルート）。
+type CategoryRequest struct {
+	ParentID  *int64 `json:"parent_id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	SortOrder int    `json:"sort_order"`
+}
+
+// /admin/categories
+type AdminCategoryHandler struct {
+	uc *usecase.CategoryUsecase
+}
+
+// DI
+func NewAdminCategoryHandler(uc *usecase.CategoryUsecase) *AdminCategoryHandler {
+	return &AdminCategoryHandler{uc: uc}
+}
+
+// 商品と同じ権限（products.write / products:write）で扱う
+func (h *AdminCategoryHandler) RegisterRoutes(e *echo.Echo, cfg config.Config, userRepo repository.UserRepository, apiKeys middleware.ApiKeyAuthenticator, perms middleware.PermissionChecker) {
+	admin := e.Group("/admin")
+	admin.Use(middleware.AdminAuth(cfg, userRepo, apiKeys))
+
+	productsWrite := []echo.MiddlewareFunc{
+		middleware.RequireScope(model.ApiKeyScopeProductsWrite),
+		middleware.RequirePermission(perms, model.PermProductsWrite),
+	}
+	admin.GET("/categories", h.list, productsWrite...)
+	admin.POST("/categories", h.create, productsWrite...)
+	admin.PUT("/categories/:id", h.update, productsWrite...)
+	admin.DELETE("/categories/:id", h.delete, productsWrite...)
+}
+
+func (h *AdminCategoryHandler) list(c echo.Context) error {
+	out, err := h.uc.AdminList(c.Request().Context())
+	if err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+func (h *AdminCategoryHandler) create(c echo.Context) error {
+	var req CategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid body"})
+	}
+
+	out, err := h.uc.AdminCreate(c.Request().Context(), usecase.AdminCategoryInput{
+		ParentID:  req.ParentID,
+		Name:      req.Name,
+		Slug:      req.Slug,
+		SortOrder: req.SortOrder,
+	})
+	if err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusCreated, out)
+}
+
+func (h *AdminCategoryHandler) update(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	var req CategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid body"})
+	}
+
+	out, err := h.uc.AdminUpdate(c.Request().Context(), id, usecase.AdminCategoryInput{
+		ParentID:  req.ParentID,
+		Name:      req.Name,
+		Slug:      req.Slug,
+		SortOrder: req.SortOrder,
+	})
+	if err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+func (h *AdminCategoryHandler) delete(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	if err := h.uc.AdminDelete(c.Request().Context(), id); err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResponse{Message: "deleted"})
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/middleware"
+	"app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// /admin/users/:id/impersonate（サポート用。顧客として読み取り専用でログインする）
+type AdminImpersonationHandler struct {
+	uc *usecase.ImpersonationUsecase
+}
+
+func NewAdminImpersonationHandler(uc *usecase.ImpersonationUsecase) *AdminImpersonationHandler {
+	return &AdminImpersonationHandler{uc: uc}
+}
+
+// POST /admin/users/:id/impersonate（理由は監査ログに残る）
+type AdminImpersonateRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *AdminImpersonationHandler) RegisterRoutes(e *echo.Echo, cfg config.Config, userRepo repository.UserRepository, perms middleware.PermissionChecker) {
+	admin := e.Group("/admin")
+	admin.Use(middleware.AuthJWT(cfg))
+	admin.Use(middleware.TokenVersionGuard(userRepo))
+	admin.Use(middleware.StaffRoleGuard(cfg))
+
+	admin.POST("/users/:id/impersonate", h.impersonate, middleware.RequirePermission(perms, model.PermUsersImpersonate))
+}
+
+func (h *AdminImpersonationHandler) impersonate(c echo.Context) error {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	var req AdminImpersonateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid body"})
+	}
+
+	actorID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	out, err := h.uc.Impersonate(c.Request().Context(), actorID, userID, req.Reason)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, out)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/middleware"
+	"app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// /admin/login-lockouts（ログインロックの確認・解除）
+type AdminLoginLockoutHandler struct {
+	uc *usecase.AdminLoginLockoutUsecase
+}
+
+func NewAdminLoginLockoutHandler(uc *usecase.AdminLoginLockoutUsecase) *AdminLoginLockoutHandler {
+	return &AdminLoginLockoutHandler{uc: uc}
+}
+
+type ClearLoginLockoutRequest struct {
+	// 一覧の key（"email:user1@test.com" / "ip:127.0.0.1"）
+	Key string `json:"key"`
+}
+
+func (h *AdminLoginLockoutHandler) RegisterRoutes(e *echo.Echo, cfg config.Config, userRepo repository.UserRepository, perms middleware.PermissionChecker) {
+	admin := e.Group("/admin")
+	admin.Use(middleware.AuthJWT(cfg))
+	admin.Use(middleware.TokenVersionGuard(userRepo))
+	admin.Use(middleware.StaffRoleGuard(cfg))
+	admin.Use(middleware.RequirePermission(perms, model.PermLoginLockoutsManage))
+
+	admin.GET("/login-lockouts", h.list)
+	admin.POST("/login-lockouts/clear", h.clear)
+}
+
+func (h *AdminLoginLockoutHandler) list(c echo.Context) error {
+	out, err := h.uc.List(c.Request().Context())
+	if err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+func (h *AdminLoginLockoutHandler) clear(c echo.Context) error {
+	var req ClearLoginLockoutRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid body"})
+	}
+
+	//操作した管理者ID（監査ログ用）
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	if err := h.uc.Clear(c.Request().Context(), adminID, usecase.ClearLoginLockoutInput{Key: req.Key}); err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{Message: "cleared"})
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"app/internal/config"
+	"app/internal/domain/model"
 	"app/internal/middleware"
 	"app/internal/repository"
 	"app/internal/usecase"
@@ -25,14 +26,19 @@ type OrderStatusUpdateRequest struct {
 	Status string `json:"status"`
 }
 
-func (h *AdminOrderHandler) RegisterRoutes(e *echo.Echo, cfg config.Config, userRepo repository.UserRepository) {
+// 権限を持つスタッフのJWTか、scope付きのAPIキー（X-API-Key）で呼べる
+func (h *AdminOrderHandler) RegisterRoutes(e *echo.Echo, cfg config.Config, userRepo repository.UserRepository, apiKeys middleware.ApiKeyAuthenticator, perms middleware.PermissionChecker) {
 	admin := e.Group("/admin")
-	admin.Use(middleware.AuthJWT(cfg))
-	admin.Use(middleware.TokenVersionGuard(userRepo))
-	admin.Use(middleware.AdminRoleGuard())
-
-	admin.GET("/orders", h.list)
-	admin.PUT("/orders/:id/status", h.updateStatus)
+	admin.Use(middleware.AdminAuth(cfg, userRepo, apiKeys))
+
+	admin.GET("/orders", h.list,
+		middleware.RequireScope(model.ApiKeyScopeOrdersRead),
+		middleware.RequirePermission(perms, model.PermOrdersRead),
+	)
+	admin.PUT("/orders/:id/status", h.updateStatus,
+		middleware.RequireScope(model.ApiKeyScopeOrdersWrite),
+		middleware.RequirePermission(perms, model.PermOrdersStatusUpdate),
+	)
 }
 
 func (h *AdminOrderHandler) list(c echo.Context) error {
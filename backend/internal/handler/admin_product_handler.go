@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"app/internal/config"
+	"app/internal/domain/model"
 	"app/internal/middleware"
 	"app/internal/repository"
 	"app/internal/usecase"
@@ -24,6 +25,8 @@ type ProductCreateRequest struct {
 	Price       int64  `json:"price"`
 	Stock       int64  `json:"stock"`
 	IsActive    bool   `json:"is_active"`
+	//省略時は変更しない、[]なら全部外す
+	CategoryIDs []int64 `json:"category_ids"`
 }
 
 // InventoryUpdateRequest は在庫更新の入力です。
@@ -43,17 +46,23 @@ func NewAdminProductHandler(uc *usecase.ProductUsecase) *AdminProductHandler {
 }
 
 // adminを登録
-func (h *AdminProductHandler) RegisterRoutes(e *echo.Echo, cfg config.Config, userRepo repository.UserRepository) {
+// 権限を持つスタッフのJWTか、scope付きのAPIキー（X-API-Key）で呼べる
+func (h *AdminProductHandler) RegisterRoutes(e *echo.Echo, cfg config.Config, userRepo repository.UserRepository, apiKeys middleware.ApiKeyAuthenticator, perms middleware.PermissionChecker) {
 	admin := e.Group("/admin")
 
-	admin.Use(middleware.AuthJWT(cfg))
-	admin.Use(middleware.TokenVersionGuard(userRepo))
-	admin.Use(middleware.AdminRoleGuard())
+	admin.Use(middleware.AdminAuth(cfg, userRepo, apiKeys))
 
-	admin.POST("/products", h.createProduct)
-	admin.PUT("/products/:id", h.updateProduct)
-	admin.DELETE("/products/:id", h.deleteProduct)
-	admin.PUT("/inventory/:product_id", h.updateInventory)
+	productsWrite := []echo.MiddlewareFunc{
+		middleware.RequireScope(model.ApiKeyScopeProductsWrite),
+		middleware.RequirePermission(perms, model.PermProductsWrite),
+	}
+	admin.POST("/products", h.createProduct, productsWrite...)
+	admin.PUT("/products/:id", h.updateProduct, productsWrite...)
+	admin.DELETE("/products/:id", h.deleteProduct, productsWrite...)
+	admin.PUT("/inventory/:product_id", h.updateInventory,
+		middleware.RequireScope(model.ApiKeyScopeInventoryWrite),
+		middleware.RequirePermission(perms, model.PermInventoryWrite),
+	)
 }
 
 func (h *AdminProductHandler) createProduct(c echo.Context) error {
@@ -76,6 +85,7 @@ func (h *AdminProductHandler) createProduct(c echo.Context) error {
 			Price:       req.Price,
 			Stock:       req.Stock,
 			IsActive:    req.IsActive,
+			CategoryIDs: req.CategoryIDs,
 		},
 	)
 	if err != nil {
@@ -111,6 +121,7 @@ func (h *AdminProductHandler) updateProduct(c echo.Context) error {
 			Price:       req.Price,
 			Stock:       req.Stock,
 			IsActive:    req.IsActive,
+			CategoryIDs: req.CategoryIDs,
 		},
 	)
 	if err != nil {
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/middleware"
+	"app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	echomw "github.com/labstack/echo/v4/middleware"
+)
+
+// multipartの境界やヘッダの分の余裕
+const multipartOverheadBytes = 64 << 10
+
+// ProductImageOrderRequest は画像の並び順です（先頭がメイン画像）。
+type ProductImageOrderRequest struct {
+	ImageIDs []int64 `json:"image_ids"`
+}
+
+// /admin/products/:id/images
+type AdminProductImageHandler struct {
+	uc       *usecase.ProductImageUsecase
+	maxBytes int64
+}
+
+// DI
+func NewAdminProductImageHandler(cfg config.Config, uc *usecase.ProductImageUsecase) *AdminProductImageHandler {
+	return &AdminProductImageHandler{uc: uc, maxBytes: int64(cfg.ProductImageMaxBytes)}
+}
+
+// 商品と同じく、権限を持つスタッフのJWTか、scope付きのAPIキー（X-API-Key）で呼べる
+func (h *AdminProductImageHandler) RegisterRoutes(e *echo.Echo, cfg config.Config, userRepo repository.UserRepository, apiKeys middleware.ApiKeyAuthenticator, perms middleware.PermissionChecker) {
+	admin := e.Group("/admin")
+	admin.Use(middleware.AdminAuth(cfg, userRepo, apiKeys))
+
+	productsWrite := []echo.MiddlewareFunc{
+		middleware.RequireScope(model.ApiKeyScopeProductsWrite),
+		middleware.RequirePermission(perms, model.PermProductsWrite),
+	}
+	admin.GET("/products/:id/images", h.list, productsWrite...)
+	//上限を超えるボディはmultipartを読む前に413で止める
+	admin.POST("/products/:id/images", h.upload,
+		append(productsWrite, echomw.BodyLimit(fmt.Sprintf("%dB", h.maxBytes+multipartOverheadBytes)))...,
+	)
+	admin.PUT("/products/:id/images/order", h.reorder, productsWrite...)
+	admin.DELETE("/products/:id/images/:image_id", h.delete, productsWrite...)
+}
+
+func (h *AdminProductImageHandler) list(c echo.Context) error {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	out, err := h.uc.AdminList(c.Request().Context(), adminID, productID)
+	if err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+// multipart/form-data の image フィールド
+func (h *AdminProductImageHandler) upload(c echo.Context) error {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	fh, err := c.FormFile("image")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "image required"})
+	}
+	if fh.Size > h.maxBytes {
+		return c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "image too large"})
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid image"})
+	}
+	defer f.Close()
+
+	//申告サイズは信用せず、上限+1バイトまでしか読まない（超えたらusecaseが413にする）
+	data, err := io.ReadAll(io.LimitReader(f, h.maxBytes+1))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid image"})
+	}
+
+	out, err := h.uc.AdminUpload(c.Request().Context(), adminID, productID, data)
+	if err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusCreated, out)
+}
+
+func (h *AdminProductImageHandler) reorder(c echo.Context) error {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	var req ProductImageOrderRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid body"})
+	}
+
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	out, err := h.uc.AdminReorder(c.Request().Context(), adminID, productID, req.ImageIDs)
+	if err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+func (h *AdminProductImageHandler) delete(c echo.Context) error {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+	imageID, err := strconv.ParseInt(c.Param("image_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid image_id"})
+	}
+
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	if err := h.uc.AdminDelete(c.Request().Context(), adminID, productID, imageID); err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResponse{Message: "deleted"})
+}
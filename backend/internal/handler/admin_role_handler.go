@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+
+	"app/internal/config"
+	"app/internal/middleware"
+	"app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// /admin/roles（スタッフのロールと権限の対応。ADMINだけが編集できる）
+type AdminRoleHandler struct {
+	uc *usecase.RbacUsecase
+}
+
+func NewAdminRoleHandler(uc *usecase.RbacUsecase) *AdminRoleHandler {
+	return &AdminRoleHandler{uc: uc}
+}
+
+type PutRoleRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+func (h *AdminRoleHandler) RegisterRoutes(e *echo.Echo, cfg config.Config, userRepo repository.UserRepository) {
+	admin := e.Group("/admin")
+	admin.Use(middleware.AuthJWT(cfg))
+	admin.Use(middleware.TokenVersionGuard(userRepo))
+	admin.Use(middleware.AdminRoleGuard(cfg))
+
+	admin.GET("/permissions", h.permissions)
+	admin.GET("/roles", h.list)
+	admin.PUT("/roles/:role", h.put)
+	admin.DELETE("/roles/:role", h.delete)
+}
+
+func (h *AdminRoleHandler) permissions(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.uc.Permissions())
+}
+
+func (h *AdminRoleHandler) list(c echo.Context) error {
+	out, err := h.uc.ListRoles(c.Request().Context())
+	if err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+// ロールが無ければ作る。権限は丸ごと置き換え
+func (h *AdminRoleHandler) put(c echo.Context) error {
+	var req PutRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid body"})
+	}
+
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	out, err := h.uc.PutRole(c.Request().Context(), adminID, c.Param("role"), req.Permissions)
+	if err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+func (h *AdminRoleHandler) delete(c echo.Context) error {
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	if err := h.uc.DeleteRole(c.Request().Context(), adminID, c.Param("role")); err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResponse{Message: "deleted"})
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/middleware"
+	"app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// /admin/security-events（ログイン失敗・refresh再利用などの調査用）
+type AdminSecurityEventHandler struct {
+	uc *usecase.SecurityEventUsecase
+}
+
+func NewAdminSecurityEventHandler(uc *usecase.SecurityEventUsecase) *AdminSecurityEventHandler {
+	return &AdminSecurityEventHandler{uc: uc}
+}
+
+func (h *AdminSecurityEventHandler) RegisterRoutes(e *echo.Echo, cfg config.Config, userRepo repository.UserRepository, perms middleware.PermissionChecker) {
+	admin := e.Group("/admin")
+	admin.Use(middleware.AuthJWT(cfg))
+	admin.Use(middleware.TokenVersionGuard(userRepo))
+	admin.Use(middleware.StaffRoleGuard(cfg))
+
+	admin.GET("/security-events", h.list, middleware.RequirePermission(perms, model.PermSecurityEventsRead))
+}
+
+// ?user_id=&type=&outcome=&ip=&email=&from=&to=（from/toはRFC3339）&page=&limit=
+func (h *AdminSecurityEventHandler) list(c echo.Context) error {
+	page, limit, errMsg := parsePageLimit(c)
+	if errMsg != "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: errMsg})
+	}
+
+	in := usecase.AdminSecurityEventListInput{
+		Page:    page,
+		Limit:   limit,
+		Type:    strings.ToUpper(c.QueryParam("type")),
+		Outcome: strings.ToUpper(c.QueryParam("outcome")),
+		IP:      c.QueryParam("ip"),
+		Email:   c.QueryParam("email"),
+	}
+
+	if v := c.QueryParam("user_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid user_id"})
+		}
+		in.UserID = &id
+	}
+	if v := c.QueryParam("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid from"})
+		}
+		in.CreatedFrom = &t
+	}
+	if v := c.QueryParam("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid to"})
+		}
+		in.CreatedTo = &t
+	}
+
+	out, err := h.uc.AdminList(c.Request().Context(), in)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, out)
+}
@@ -3,8 +3,10 @@ package handler
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"app/internal/config"
+	"app/internal/domain/model"
 	"app/internal/middleware"
 	"app/internal/repository"
 	"app/internal/usecase"
@@ -16,22 +18,145 @@ type AdminUserHandler struct {
 	cfg      config.Config
 	userRepo repository.UserRepository
 	uc       *usecase.AuthUsecase
+	adminUC  *usecase.AdminUserUsecase
 }
 
-func NewAdminUserHandler(cfg config.Config, userRepo repository.UserRepository, uc *usecase.AuthUsecase) *AdminUserHandler {
-	return &AdminUserHandler{cfg: cfg, userRepo: userRepo, uc: uc}
+func NewAdminUserHandler(cfg config.Config, userRepo repository.UserRepository, uc *usecase.AuthUsecase, adminUC *usecase.AdminUserUsecase) *AdminUserHandler {
+	return &AdminUserHandler{cfg: cfg, userRepo: userRepo, uc: uc, adminUC: adminUC}
 }
 
-func (h *AdminUserHandler) RegisterRoutes(e *echo.Echo) {
-	// ★ /admin 配下は全部「JWT必須 + token_version一致 + ADMIN限定」
+// PATCH /admin/users/:id（送った項目だけ変更）
+type AdminUserUpdateRequest struct {
+	IsActive *bool   `json:"is_active"`
+	Role     *string `json:"role"`
+}
+
+func (h *AdminUserHandler) RegisterRoutes(e *echo.Echo, perms middleware.PermissionChecker) {
+	// ★ /admin 配下は全部「JWT必須 + token_version一致 + スタッフ以上」、ルートごとに権限を確認
 	admin := e.Group(
 		"/admin",
 		middleware.AuthJWT(h.cfg),
 		middleware.TokenVersionGuard(h.userRepo),
-		middleware.AdminRoleGuard(),
+		middleware.StaffRoleGuard(h.cfg),
 	)
 
-	admin.POST("/users/:id/force-logout", h.ForceLogout)
+	admin.GET("/users", h.list, middleware.RequirePermission(perms, model.PermUsersRead))
+	admin.GET("/users/:id", h.get, middleware.RequirePermission(perms, model.PermUsersRead))
+	admin.PATCH("/users/:id", h.update, middleware.RequirePermission(perms, model.PermUsersWrite))
+	admin.POST("/users/:id/force-logout", h.ForceLogout, middleware.RequirePermission(perms, model.PermUsersForceLogout))
+	// 退会の猶予期間を待たずに匿名化する
+	admin.DELETE("/users/:id", h.delete, middleware.RequirePermission(perms, model.PermUsersAnonymize))
+}
+
+func (h *AdminUserHandler) list(c echo.Context) error {
+	page := 1
+	if v := c.QueryParam("page"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid page"})
+		}
+		page = p
+	}
+
+	limit := 50
+	if v := c.QueryParam("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid limit"})
+		}
+		limit = l
+	}
+
+	var rolePtr *model.Role
+	if v := c.QueryParam("role"); v != "" {
+		r := model.Role(strings.ToUpper(v))
+		rolePtr = &r
+	}
+
+	var activePtr *bool
+	if v := c.QueryParam("is_active"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid is_active"})
+		}
+		activePtr = &b
+	}
+
+	out, err := h.adminUC.List(c.Request().Context(), repository.AdminUserListFilter{
+		Page:     page,
+		Limit:    limit,
+		Email:    c.QueryParam("email"),
+		Role:     rolePtr,
+		IsActive: activePtr,
+	})
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, out)
+}
+
+func (h *AdminUserHandler) get(c echo.Context) error {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	out, err := h.adminUC.Get(c.Request().Context(), userID)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, out)
+}
+
+func (h *AdminUserHandler) update(c echo.Context) error {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	var req AdminUserUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid body"})
+	}
+
+	// ★操作した管理者IDを取得（監査ログ用）
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	out, err := h.adminUC.Update(
+		c.Request().Context(),
+		adminID,
+		userID,
+		usecase.AdminUpdateUserInput{IsActive: req.IsActive, Role: req.Role},
+	)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, out)
+}
+
+func (h *AdminUserHandler) delete(c echo.Context) error {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	out, err := h.adminUC.Delete(c.Request().Context(), adminID, userID)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, out)
 }
 
 func (h *AdminUserHandler) ForceLogout(c echo.Context) error {
@@ -41,7 +166,12 @@ func (h *AdminUserHandler) ForceLogout(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, errorJSON("invalid user_id"))
 	}
 
-	res, uerr := h.uc.ForceLogout(c.Request().Context(), userID)
+	actorID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+	}
+
+	res, uerr := h.uc.ForceLogout(c.Request().Context(), actorID, userID, c.Request().UserAgent(), c.RealIP())
 	if uerr != nil {
 		return c.JSON(http.StatusInternalServerError, errorJSON("internal error"))
 	}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/middleware"
+	"app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ProductOptionsRequest は商品の軸（例 ["サイズ","色"]）の設定です。
+type ProductOptionsRequest struct {
+	Options []string `json:"options"`
+}
+
+// VariantCreateRequest はバリエーション（SKU）の作成です。options は軸名→値。
+type VariantCreateRequest struct {
+	SKU      string            `json:"sku"`
+	Price    int64             `json:"price"`
+	Stock    int64             `json:"stock"`
+	IsActive bool              `json:"is_active"`
+	Options  map[string]string `json:"options"`
+}
+
+// VariantUpdateRequest はバリエーションの更新です（在庫は /admin/inventory/variants/:variant_id）。
+type VariantUpdateRequest struct {
+	SKU      string `json:"sku"`
+	Price    int64  `json:"price"`
+	IsActive bool   `json:"is_active"`
+}
+
+// /admin/products/:id/variants と /admin/variants、/admin/inventory/variants
+type AdminVariantHandler struct {
+	uc *usecase.ProductVariantUsecase
+}
+
+// DI
+func NewAdminVariantHandler(uc *usecase.ProductVariantUsecase) *AdminVariantHandler {
+	return &AdminVariantHandler{uc: uc}
+}
+
+// 商品と同じく、権限を持つスタッフのJWTか、scope付きのAPIキー（X-API-Key）で呼べる
+func (h *AdminVariantHandler) RegisterRoutes(e *echo.Echo, cfg config.Config, userRepo repository.UserRepository, apiKeys middleware.ApiKeyAuthenticator, perms middleware.PermissionChecker) {
+	admin := e.Group("/admin")
+	admin.Use(middleware.AdminAuth(cfg, userRepo, apiKeys))
+
+	productsWrite := []echo.MiddlewareFunc{
+		middleware.RequireScope(model.ApiKeyScopeProductsWrite),
+		middleware.RequirePermission(perms, model.PermProductsWrite),
+	}
+	admin.GET("/products/:id/variants", h.matrix, productsWrite...)
+	admin.PUT("/products/:id/options", h.replaceOptions, productsWrite...)
+	admin.POST("/products/:id/variants", h.createVariant, productsWrite...)
+	admin.PUT("/variants/:id", h.updateVariant, productsWrite...)
+	admin.DELETE("/variants/:id", h.deleteVariant, productsWrite...)
+	admin.PUT("/inventory/variants/:variant_id", h.updateVariantStock,
+		middleware.RequireScope(model.ApiKeyScopeInventoryWrite),
+		middleware.RequirePermission(perms, model.PermInventoryWrite),
+	)
+}
+
+func (h *AdminVariantHandler) matrix(c echo.Context) error {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	out, err := h.uc.AdminGetMatrix(c.Request().Context(), adminID, productID)
+	if err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+func (h *AdminVariantHandler) replaceOptions(c echo.Context) error {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	var req ProductOptionsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid body"})
+	}
+
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	out, err := h.uc.AdminReplaceOptions(c.Request().Context(), adminID, productID, req.Options)
+	if err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+func (h *AdminVariantHandler) createVariant(c echo.Context) error {
+	productID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	var req VariantCreateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid body"})
+	}
+
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	out, err := h.uc.AdminCreateVariant(c.Request().Context(), adminID, productID, usecase.AdminCreateVariantInput{
+		SKU:      req.SKU,
+		Price:    req.Price,
+		Stock:    req.Stock,
+		IsActive: req.IsActive,
+		Options:  req.Options,
+	})
+	if err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusCreated, out)
+}
+
+func (h *AdminVariantHandler) updateVariant(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	var req VariantUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid body"})
+	}
+
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	if err := h.uc.AdminUpdateVariant(c.Request().Context(), adminID, id, usecase.AdminUpdateVariantInput{
+		SKU:      req.SKU,
+		Price:    req.Price,
+		IsActive: req.IsActive,
+	}); err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResponse{Message: "updated"})
+}
+
+func (h *AdminVariantHandler) deleteVariant(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+	}
+
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	if err := h.uc.AdminDeleteVariant(c.Request().Context(), adminID, id); err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResponse{Message: "deleted"})
+}
+
+func (h *AdminVariantHandler) updateVariantStock(c echo.Context) error {
+	variantID, err := strconv.ParseInt(c.Param("variant_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid variant_id"})
+	}
+
+	var req InventoryUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid body"})
+	}
+
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	if err := h.uc.AdminUpdateVariantStock(c.Request().Context(), adminID, variantID, req.Stock, req.Reason); err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, SuccessResponse{Message: "stock updated"})
+}
@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"bytes"
 	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"app/internal/config"
@@ -17,20 +21,27 @@ import (
 // Cookie名
 const (
 	cookieRefreshToken = "refresh_token"
-	cookieCsrfToken    = "csrf_token"
-	headerCsrfToken    = "X-CSRF-Token"
+	cookieCsrfToken    = middleware.CSRFCookieName
+	cookieOidcState    = "oidc_state"
 )
 
+// oidc_state cookieの寿命（usecaseのstateトークンと同じ10分）
+const oidcStateCookieMaxAge = 10 * 60
+
 // /authのHTTPハンドラ
 type AuthHandler struct {
 	cfg      config.Config
 	uc       *usecase.AuthUsecase
+	oidc     *usecase.OidcUsecase
+	magic    *usecase.MagicLinkUsecase
+	carts    *usecase.CartUsecase
+	accounts *usecase.AccountUsecase
 	userRepo repository.UserRepository
 }
 
 // DI
-func NewAuthHandler(cfg config.Config, uc *usecase.AuthUsecase, userRepo repository.UserRepository) *AuthHandler {
-	return &AuthHandler{cfg: cfg, uc: uc, userRepo: userRepo}
+func NewAuthHandler(cfg config.Config, uc *usecase.AuthUsecase, oidc *usecase.OidcUsecase, magic *usecase.MagicLinkUsecase, carts *usecase.CartUsecase, accounts *usecase.AccountUsecase, userRepo repository.UserRepository) *AuthHandler {
+	return &AuthHandler{cfg: cfg, uc: uc, oidc: oidc, magic: magic, carts: carts, accounts: accounts, userRepo: userRepo}
 }
 func (h *AuthHandler) Me(c echo.Context) error {
 	raw := c.Get(middleware.CtxUserIDKey)
@@ -53,19 +64,74 @@ func (h *AuthHandler) RegisterRoutes(e *echo.Echo) {
 
 	auth.POST("/register", h.Register)
 	auth.POST("/login", h.Login)
-	auth.POST("/refresh", h.Refresh)
+	auth.POST("/login/mfa", h.LoginMfa)
+	// refresh・logoutはcookieで認証するのでCSRF必須（Double Submit Cookie）
+	auth.POST("/refresh", h.Refresh, middleware.CSRF(middleware.CSRFConfig{}))
+	auth.POST("/password/forgot", h.ForgotPassword)
+	auth.POST("/password/reset", h.ResetPassword)
+	auth.POST("/verify-email", h.VerifyEmail)
+	auth.POST("/verify-email/resend", h.ResendVerification)
+	auth.POST("/magic-link", h.RequestMagicLink)
+	auth.POST("/magic-link/consume", h.ConsumeMagicLink)
 
 	auth.POST(
 		"/logout",
 		h.Logout,
 		middleware.AuthJWT(h.cfg),
 		middleware.TokenVersionGuard(h.userRepo),
+		middleware.CSRF(middleware.CSRFConfig{}),
 	)
 	// ★ /me は bearerAuth + token_version一致
 	e.GET("/me", h.Me,
 		middleware.AuthJWT(h.cfg),
 		middleware.TokenVersionGuard(h.userRepo),
 	)
+
+	// パスワード変更（他の端末はログアウト、この端末には新しいtoken）
+	e.POST("/me/password", h.ChangePassword,
+		middleware.AuthJWT(h.cfg),
+		middleware.TokenVersionGuard(h.userRepo),
+	)
+
+	// 2FA（TOTP）の設定は本人のみ
+	mfa := e.Group(
+		"/me/mfa",
+		middleware.AuthJWT(h.cfg),
+		middleware.TokenVersionGuard(h.userRepo),
+	)
+	mfa.POST("/totp/enroll", h.EnrollTotp)
+	mfa.POST("/totp/confirm", h.ConfirmTotp)
+	mfa.POST("/totp/disable", h.DisableTotp)
+
+	// ソーシャルログイン（OpenID Connect）
+	auth.GET("/oidc/providers", h.OidcProviders)
+	auth.POST("/oidc/:provider/authorize", h.OidcAuthorize)
+	auth.POST("/oidc/:provider/callback", h.OidcCallback)
+
+	// IdPアカウントの紐付けは本人のみ
+	identities := e.Group(
+		"/me/identities",
+		middleware.AuthJWT(h.cfg),
+		middleware.TokenVersionGuard(h.userRepo),
+	)
+	identities.GET("", h.ListIdentities)
+	identities.POST("/:provider/authorize", h.LinkIdentityAuthorize)
+	identities.POST("/:provider/callback", h.LinkIdentityCallback)
+	identities.DELETE("/:id", h.UnlinkIdentity)
+
+	// 個人データのエクスポート・退会は本人のみ
+	e.GET("/me/export", h.ExportAccount,
+		middleware.AuthJWT(h.cfg),
+		middleware.TokenVersionGuard(h.userRepo),
+	)
+	e.DELETE("/me", h.DeleteAccount,
+		middleware.AuthJWT(h.cfg),
+		middleware.TokenVersionGuard(h.userRepo),
+	)
+	e.POST("/me/deletion/cancel", h.CancelAccountDeletion,
+		middleware.AuthJWT(h.cfg),
+		middleware.TokenVersionGuard(h.userRepo),
+	)
 }
 
 // POST /auth/register
@@ -80,6 +146,7 @@ func (h *AuthHandler) Register(c echo.Context) error {
 		return h.handleError(c, err)
 	}
 
+	h.mergeGuestCart(c, res.User.ID)
 	return c.JSON(http.StatusOK, res)
 }
 
@@ -98,21 +165,43 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		return h.handleError(c, err)
 	}
 
+	//2FAが有効ならcookieは出さずにチャレンジだけ返す
+	if result.MfaChallenge != nil {
+		return c.JSON(http.StatusOK, result.MfaChallenge)
+	}
+
 	//refresh_tokenをHttpOnly cookieにセット
 	h.setRefreshCookie(c, result.RefreshTokenPlain)
 
 	//csrf_tokenをcookie にセット
 	h.setCsrfCookie(c, result.CsrfTokenPlain)
+	h.mergeGuestCart(c, result.Body.User.ID)
 	return c.JSON(http.StatusOK, result.Body)
 }
 
-// POST /auth/refresh
-func (h *AuthHandler) Refresh(c echo.Context) error {
-	//CSRF検証
-	if err := h.verifyDoubleSubmitCsrf(c); err != nil {
-		return c.JSON(http.StatusUnauthorized, errorJSON("csrf invalid"))
+// POST /auth/login/mfa
+func (h *AuthHandler) LoginMfa(c echo.Context) error {
+	var req usecase.LoginMfaRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorJSON("invalid json"))
+	}
+
+	ua := c.Request().UserAgent()
+	ip := c.RealIP()
+
+	result, err := h.uc.LoginMfa(c.Request().Context(), req, ua, ip)
+	if err != nil {
+		return h.handleError(c, err)
 	}
 
+	h.setRefreshCookie(c, result.RefreshTokenPlain)
+	h.setCsrfCookie(c, result.CsrfTokenPlain)
+	h.mergeGuestCart(c, result.Body.User.ID)
+	return c.JSON(http.StatusOK, result.Body)
+}
+
+// POST /auth/refresh
+func (h *AuthHandler) Refresh(c echo.Context) error {
 	//refresh_token cookieを取得
 	refreshPlain, err := getCookieValue(c, cookieRefreshToken)
 	if err != nil {
@@ -135,18 +224,16 @@ func (h *AuthHandler) Refresh(c echo.Context) error {
 
 // POST /auth/logout
 func (h *AuthHandler) Logout(c echo.Context) error {
-	// CSRF検証（Double Submit Cookie）
-	if err := h.verifyDoubleSubmitCsrf(c); err != nil {
-		return c.JSON(http.StatusUnauthorized, errorJSON("csrf invalid"))
-	}
-
 	//refresh_tokencookieを取得
 	refreshPlain, err := getCookieValue(c, cookieRefreshToken)
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, errorJSON("refresh missing"))
 	}
 
-	res, uerr := h.uc.Logout(c.Request().Context(), refreshPlain)
+	ua := c.Request().UserAgent()
+	ip := c.RealIP()
+
+	res, uerr := h.uc.Logout(c.Request().Context(), refreshPlain, ua, ip)
 	if uerr != nil {
 		return h.handleError(c, uerr)
 	}
@@ -158,26 +245,399 @@ func (h *AuthHandler) Logout(c echo.Context) error {
 	return c.JSON(http.StatusOK, res)
 }
 
-// helper: CSRF Double Submit Cookie 検証
-func (h *AuthHandler) verifyDoubleSubmitCsrf(c echo.Context) error {
-	header := c.Request().Header.Get(headerCsrfToken)
-	if header == "" {
-		return errors.New("csrf header missing")
+// POST /auth/password/forgot
+func (h *AuthHandler) ForgotPassword(c echo.Context) error {
+	var req usecase.ForgotPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorJSON("invalid json"))
+	}
+
+	res, err := h.uc.ForgotPassword(c.Request().Context(), req)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// POST /auth/password/reset
+func (h *AuthHandler) ResetPassword(c echo.Context) error {
+	var req usecase.ResetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorJSON("invalid json"))
+	}
+
+	res, err := h.uc.ResetPassword(c.Request().Context(), req)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	//このブラウザに残っているcookieも消す
+	h.clearCookie(c, cookieRefreshToken)
+	h.clearCookie(c, cookieCsrfToken)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// POST /auth/verify-email
+func (h *AuthHandler) VerifyEmail(c echo.Context) error {
+	var req usecase.VerifyEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorJSON("invalid json"))
+	}
+
+	res, err := h.uc.VerifyEmail(c.Request().Context(), req)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// POST /auth/verify-email/resend
+func (h *AuthHandler) ResendVerification(c echo.Context) error {
+	var req usecase.ResendVerificationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorJSON("invalid json"))
+	}
+
+	res, err := h.uc.ResendVerification(c.Request().Context(), req)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// POST /me/password
+func (h *AuthHandler) ChangePassword(c echo.Context) error {
+	userID, ok := getUserIDFromContext(c)
+	if !ok || userID <= 0 {
+		return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+	}
+
+	var req usecase.ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorJSON("invalid json"))
+	}
+
+	//この端末のrefreshだけ残す（cookieが無ければ全端末を失効して新しく発行）
+	current, _ := getCookieValue(c, cookieRefreshToken)
+
+	ua := c.Request().UserAgent()
+	ip := c.RealIP()
+
+	result, err := h.uc.ChangePassword(c.Request().Context(), userID, req, current, ua, ip)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	h.setRefreshCookie(c, result.RefreshTokenPlain)
+	h.setCsrfCookie(c, result.CsrfTokenPlain)
+	return c.JSON(http.StatusOK, result.Body)
+}
+
+// GET /me/export（?format=zip でzip、それ以外はJSON）
+func (h *AuthHandler) ExportAccount(c echo.Context) error {
+	userID, ok := getUserIDFromContext(c)
+	if !ok || userID <= 0 {
+		return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+	}
+	//なりすまし中の管理者には個人データをまとめて渡さない
+	if _, impersonating := c.Get(middleware.CtxImpersonatorKey).(int64); impersonating {
+		return c.JSON(http.StatusForbidden, errorJSON("not allowed while impersonating"))
+	}
+
+	format := c.QueryParam("format")
+	if format != "" && format != "json" && format != "zip" {
+		return c.JSON(http.StatusBadRequest, errorJSON("invalid format"))
+	}
+
+	ex, err := h.accounts.Export(c.Request().Context(), userID)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	filename := "account-export-" + strconv.FormatInt(userID, 10)
+	if format == "zip" {
+		var buf bytes.Buffer
+		if err := usecase.WriteAccountExportZip(&buf, ex); err != nil {
+			return c.JSON(http.StatusInternalServerError, errorJSON("internal error"))
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`.zip"`)
+		return c.Blob(http.StatusOK, "application/zip", buf.Bytes())
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`.json"`)
+	return c.JSON(http.StatusOK, ex)
+}
+
+// DELETE /me
+// 猶予期間の後に匿名化される。全端末がログアウトになるので、この端末のcookieも消す
+func (h *AuthHandler) DeleteAccount(c echo.Context) error {
+	userID, ok := getUserIDFromContext(c)
+	if !ok || userID <= 0 {
+		return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+	}
+
+	var req usecase.DeleteAccountRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorJSON("invalid json"))
+	}
+
+	res, err := h.accounts.RequestDeletion(c.Request().Context(), userID, req, c.Request().UserAgent(), c.RealIP())
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	h.clearCookie(c, cookieRefreshToken)
+	h.clearCookie(c, cookieCsrfToken)
+	return c.JSON(http.StatusAccepted, res)
+}
+
+// POST /me/deletion/cancel
+func (h *AuthHandler) CancelAccountDeletion(c echo.Context) error {
+	userID, ok := getUserIDFromContext(c)
+	if !ok || userID <= 0 {
+		return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
 	}
 
-	cookieVal, err := getCookieValue(c, cookieCsrfToken)
+	res, err := h.accounts.CancelDeletion(c.Request().Context(), userID, c.Request().UserAgent(), c.RealIP())
 	if err != nil {
-		return errors.New("csrf cookie missing")
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// POST /me/mfa/totp/enroll
+func (h *AuthHandler) EnrollTotp(c echo.Context) error {
+	userID, ok := getUserIDFromContext(c)
+	if !ok || userID <= 0 {
+		return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
 	}
 
-	if header != cookieVal {
-		return errors.New("csrf mismatch")
+	res, err := h.uc.EnrollTotp(c.Request().Context(), userID)
+	if err != nil {
+		return h.handleError(c, err)
 	}
 
-	return nil
+	return c.JSON(http.StatusOK, res)
 }
 
+// POST /me/mfa/totp/confirm
+func (h *AuthHandler) ConfirmTotp(c echo.Context) error {
+	userID, ok := getUserIDFromContext(c)
+	if !ok || userID <= 0 {
+		return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+	}
+
+	var req usecase.TotpConfirmRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorJSON("invalid json"))
+	}
+
+	//この端末のrefreshだけ残す（他の端末はログアウト）
+	current, _ := getCookieValue(c, cookieRefreshToken)
+
+	res, err := h.uc.ConfirmTotp(c.Request().Context(), userID, req, current)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// POST /me/mfa/totp/disable
+func (h *AuthHandler) DisableTotp(c echo.Context) error {
+	userID, ok := getUserIDFromContext(c)
+	if !ok || userID <= 0 {
+		return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+	}
+
+	var req usecase.TotpDisableRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorJSON("invalid json"))
+	}
+
+	res, err := h.uc.DisableTotp(c.Request().Context(), userID, req)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	//全セッション失効済みなのでcookieも消す
+	h.clearCookie(c, cookieRefreshToken)
+	h.clearCookie(c, cookieCsrfToken)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// GET /auth/oidc/providers
+func (h *AuthHandler) OidcProviders(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.oidc.Providers())
+}
+
+// POST /auth/oidc/:provider/authorize
+// IdPのURLを返す（state・PKCEはoidc_state cookieに入れておく）
+func (h *AuthHandler) OidcAuthorize(c echo.Context) error {
+	res, err := h.oidc.AuthorizeLogin(c.Request().Context(), c.Param("provider"))
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	h.setOidcStateCookie(c, res.StateToken)
+	return c.JSON(http.StatusOK, res.Body)
+}
+
+// POST /auth/oidc/:provider/callback
+// IdPから戻ってきた code / state を受け取り、/auth/login と同じようにtokenを発行する
+func (h *AuthHandler) OidcCallback(c echo.Context) error {
+	var req usecase.OidcCallbackRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorJSON("invalid json"))
+	}
+
+	stateToken, _ := getCookieValue(c, cookieOidcState)
+	//stateは1回きり（成功しても失敗しても消す）
+	h.clearCookie(c, cookieOidcState)
+
+	ua := c.Request().UserAgent()
+	ip := c.RealIP()
+
+	result, err := h.oidc.CallbackLogin(c.Request().Context(), c.Param("provider"), req, stateToken, ua, ip)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	//2FAが有効ならcookieは出さずにチャレンジだけ返す
+	if result.MfaChallenge != nil {
+		return c.JSON(http.StatusOK, result.MfaChallenge)
+	}
+
+	h.setRefreshCookie(c, result.RefreshTokenPlain)
+	h.setCsrfCookie(c, result.CsrfTokenPlain)
+	h.mergeGuestCart(c, result.Body.User.ID)
+	return c.JSON(http.StatusOK, result.Body)
+}
+
+// POST /auth/magic-link
+func (h *AuthHandler) RequestMagicLink(c echo.Context) error {
+	var req usecase.MagicLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorJSON("invalid json"))
+	}
+
+	res, err := h.magic.Request(c.Request().Context(), req)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// POST /auth/magic-link/consume
+func (h *AuthHandler) ConsumeMagicLink(c echo.Context) error {
+	var req usecase.MagicLinkConsumeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorJSON("invalid json"))
+	}
+
+	ua := c.Request().UserAgent()
+	ip := c.RealIP()
+
+	result, err := h.magic.Consume(c.Request().Context(), req, ua, ip)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	//2FAが有効ならcookieは出さずにチャレンジだけ返す
+	if result.MfaChallenge != nil {
+		return c.JSON(http.StatusOK, result.MfaChallenge)
+	}
+
+	h.setRefreshCookie(c, result.RefreshTokenPlain)
+	h.setCsrfCookie(c, result.CsrfTokenPlain)
+	h.mergeGuestCart(c, result.Body.User.ID)
+	return c.JSON(http.StatusOK, result.Body)
+}
+
+// GET /me/identities
+func (h *AuthHandler) ListIdentities(c echo.Context) error {
+	userID, ok := getUserIDFromContext(c)
+	if !ok || userID <= 0 {
+		return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+	}
+
+	res, err := h.oidc.ListIdentities(c.Request().Context(), userID)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// POST /me/identities/:provider/authorize
+func (h *AuthHandler) LinkIdentityAuthorize(c echo.Context) error {
+	userID, ok := getUserIDFromContext(c)
+	if !ok || userID <= 0 {
+		return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+	}
+
+	res, err := h.oidc.AuthorizeLink(c.Request().Context(), userID, c.Param("provider"))
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	h.setOidcStateCookie(c, res.StateToken)
+	return c.JSON(http.StatusOK, res.Body)
+}
+
+// POST /me/identities/:provider/callback
+func (h *AuthHandler) LinkIdentityCallback(c echo.Context) error {
+	userID, ok := getUserIDFromContext(c)
+	if !ok || userID <= 0 {
+		return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+	}
+
+	var req usecase.OidcCallbackRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorJSON("invalid json"))
+	}
+
+	stateToken, _ := getCookieValue(c, cookieOidcState)
+	h.clearCookie(c, cookieOidcState)
+
+	res, err := h.oidc.CallbackLink(c.Request().Context(), userID, c.Param("provider"), req, stateToken)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// DELETE /me/identities/:id
+func (h *AuthHandler) UnlinkIdentity(c echo.Context) error {
+	userID, ok := getUserIDFromContext(c)
+	if !ok || userID <= 0 {
+		return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+	}
+
+	res, err := h.oidc.Unlink(c.Request().Context(), userID, c.Param("id"))
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// helper: usecase・validatorのエラーをHTTPレスポンスに変換
 func (h *AuthHandler) handleError(c echo.Context, err error) error {
+	// パスワードのルール違反は理由（code）と利用者向けの文言も返す
+	var pwErr *validator.PasswordRuleError
+	if errors.As(err, &pwErr) {
+		return c.JSON(http.StatusBadRequest, passwordRuleErrorJSON(c, pwErr.Code))
+	}
+
 	// validator層のエラー
 	switch {
 	case errors.Is(err, validator.ErrInvalidInput):
@@ -188,16 +648,33 @@ func (h *AuthHandler) handleError(c echo.Context, err error) error {
 		return c.JSON(http.StatusUnauthorized, errorJSON(err.Error()))
 	}
 
+	// ロック中は Retry-After（秒）を付けて429
+	var locked *usecase.LoginLockedError
+	if errors.As(err, &locked) {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		return c.JSON(http.StatusTooManyRequests, errorJSON(err.Error()))
+	}
+
 	// usecase層のエラー
 	switch {
+	case errors.Is(err, usecase.ErrOidcProviderNotFound), errors.Is(err, usecase.ErrOidcIdentityNotFound):
+		return c.JSON(http.StatusNotFound, errorJSON(err.Error()))
+	case errors.Is(err, usecase.ErrOidcAccountExists), errors.Is(err, usecase.ErrOidcIdentityInUse), errors.Is(err, usecase.ErrOidcLastLoginMethod):
+		return c.JSON(http.StatusConflict, errorJSON(err.Error()))
 	case errors.Is(err, usecase.ErrValidation):
 		return c.JSON(http.StatusBadRequest, errorJSON(err.Error()))
 	case errors.Is(err, usecase.ErrConflict):
 		return c.JSON(http.StatusConflict, errorJSON(err.Error()))
+	case errors.Is(err, usecase.ErrInvalidToken):
+		return c.JSON(http.StatusBadRequest, errorJSON(err.Error()))
+	case errors.Is(err, usecase.ErrInvalidMfaCode):
+		return c.JSON(http.StatusBadRequest, errorJSON(err.Error()))
 	case errors.Is(err, usecase.ErrUnauthorized):
 		return c.JSON(http.StatusUnauthorized, errorJSON(err.Error()))
 	case errors.Is(err, usecase.ErrForbidden):
 		return c.JSON(http.StatusForbidden, errorJSON(err.Error()))
+	case errors.Is(err, usecase.ErrEmailNotVerified):
+		return c.JSON(http.StatusForbidden, errorJSON(err.Error()))
 	case errors.Is(err, usecase.ErrSecurityIncident):
 		return c.JSON(http.StatusUnauthorized, errorJSON(err.Error()))
 	default:
@@ -206,13 +683,32 @@ func (h *AuthHandler) handleError(c echo.Context, err error) error {
 }
 
 type errorResponse struct {
-	Error string `json:"error"`
+	Error   string `json:"error"`
+	Code    string `json:"code,omitempty"`    // 理由（フロントで出し分ける用）
+	Message string `json:"message,omitempty"` // 利用者向けの文言（Accept-Language）
 }
 
 func errorJSON(msg string) errorResponse {
 	return errorResponse{Error: msg}
 }
 
+// ゲストカートがあればログインしたユーザーのカートに合算してcookieを消す（失敗してもログインは続行）
+func (h *AuthHandler) mergeGuestCart(c echo.Context, userID int64) {
+	if h.carts == nil {
+		return
+	}
+	token, err := getCookieValue(c, cookieGuestCart)
+	if err != nil {
+		return
+	}
+
+	if err := h.carts.MergeGuestCart(c.Request().Context(), userID, token); err != nil {
+		log.Printf("guest cart merge failed: user_id=%d err=%v", userID, err)
+		return
+	}
+	h.clearCookie(c, cookieGuestCart)
+}
+
 // Cookie操作
 func (h *AuthHandler) setRefreshCookie(c echo.Context, value string) {
 	c.SetCookie(&http.Cookie{
@@ -226,26 +722,37 @@ func (h *AuthHandler) setRefreshCookie(c echo.Context, value string) {
 	})
 }
 
+// ログイン・refreshのたびに新しい値にする（CSRFミドルウェアがヘッダと比べる）
 func (h *AuthHandler) setCsrfCookie(c echo.Context, value string) {
+	middleware.SetCSRFCookie(c, value, h.isSecureCookie())
+}
+
+// OIDCのstate・PKCE（JSからは読めない。コールバックまでの10分だけ）
+func (h *AuthHandler) setOidcStateCookie(c echo.Context, value string) {
 	c.SetCookie(&http.Cookie{
-		Name:     cookieCsrfToken,
+		Name:     cookieOidcState,
 		Value:    value,
 		Path:     "/",
-		HttpOnly: false,
+		MaxAge:   oidcStateCookieMaxAge,
+		HttpOnly: true,
 		Secure:   h.isSecureCookie(),
 		SameSite: http.SameSiteLaxMode,
-		Expires:  time.Now().Add(30 * 24 * time.Hour),
 	})
 }
 
 func (h *AuthHandler) clearCookie(c echo.Context, name string) {
+	clearAuthCookie(c, name, h.isSecureCookie())
+}
+
+// refresh/csrf cookieの削除（/me/sessions からも使う）
+func clearAuthCookie(c echo.Context, name string, secure bool) {
 	c.SetCookie(&http.Cookie{
 		Name:     name,
 		Value:    "",
 		Path:     "/",
 		MaxAge:   -1,
-		HttpOnly: name == cookieRefreshToken,
-		Secure:   h.isSecureCookie(),
+		HttpOnly: name != cookieCsrfToken,
+		Secure:   secure,
 		SameSite: http.SameSiteLaxMode,
 		Expires:  time.Unix(0, 0),
 	})
@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"app/internal/config"
 	"app/internal/middleware"
@@ -12,19 +13,25 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+// ゲストカートのcookie名（値は "<カートID>.<署名>"）
+const cookieGuestCart = "guest_cart"
+
 // /cartのHTTP
 type CartHandler struct {
-	uc *usecase.CartUsecase
+	cfg config.Config
+	uc  *usecase.CartUsecase
 }
 
 // DI
-func NewCartHandler(uc *usecase.CartUsecase) *CartHandler {
-	return &CartHandler{uc: uc}
+func NewCartHandler(cfg config.Config, uc *usecase.CartUsecase) *CartHandler {
+	return &CartHandler{cfg: cfg, uc: uc}
 }
 
 type AddCartRequest struct {
 	ProductID int64 `json:"product_id"`
-	Quantity  int64 `json:"quantity"`
+	//バリエーションのある商品では必須
+	VariantID *int64 `json:"variant_id"`
+	Quantity  int64  `json:"quantity"`
 }
 
 type UpdateCartItemRequest struct {
@@ -32,10 +39,10 @@ type UpdateCartItemRequest struct {
 }
 
 // /cart, /cart/{id} を登録
-func (h *CartHandler) RegisterRoutes(e *echo.Echo, cfg config.Config, userRepo repository.UserRepository) {
+// ログイン中はユーザーのカート、Authorizationヘッダが無ければゲストカート（cookie）
+func (h *CartHandler) RegisterRoutes(e *echo.Echo, userRepo repository.UserRepository) {
 	g := e.Group("/cart")
-	g.Use(middleware.AuthJWT(cfg))
-	g.Use(middleware.TokenVersionGuard(userRepo))
+	g.Use(middleware.OptionalAuth(h.cfg, userRepo))
 
 	g.GET("", h.getCart)
 	g.POST("", h.addToCart)
@@ -46,7 +53,8 @@ func (h *CartHandler) RegisterRoutes(e *echo.Echo, cfg config.Config, userRepo r
 func (h *CartHandler) getCart(c echo.Context) error {
 	userID, ok := getUserIDFromContext(c)
 	if !ok {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+		out, err := h.uc.GetGuestCart(c.Request().Context(), h.guestToken(c))
+		return h.guestJSON(c, out, err)
 	}
 
 	out, err := h.uc.GetCart(c.Request().Context(), userID)
@@ -58,20 +66,23 @@ func (h *CartHandler) getCart(c echo.Context) error {
 }
 
 func (h *CartHandler) addToCart(c echo.Context) error {
-	userID, ok := getUserIDFromContext(c)
-	if !ok {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
-	}
-
 	var req AddCartRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid body"})
 	}
-
-	out, err := h.uc.AddToCart(c.Request().Context(), userID, usecase.AddCartInput{
+	in := usecase.AddCartInput{
 		ProductID: req.ProductID,
+		VariantID: req.VariantID,
 		Quantity:  req.Quantity,
-	})
+	}
+
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		out, err := h.uc.AddToGuestCart(c.Request().Context(), h.guestToken(c), in)
+		return h.guestJSON(c, out, err)
+	}
+
+	out, err := h.uc.AddToCart(c.Request().Context(), userID, in)
 	if err != nil {
 		return writeError(c, err)
 	}
@@ -80,11 +91,6 @@ func (h *CartHandler) addToCart(c echo.Context) error {
 }
 
 func (h *CartHandler) patchItem(c echo.Context) error {
-	userID, ok := getUserIDFromContext(c)
-	if !ok {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
-	}
-
 	itemID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
@@ -94,10 +100,17 @@ func (h *CartHandler) patchItem(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid body"})
 	}
-
-	out, err := h.uc.UpdateCartItem(c.Request().Context(), userID, itemID, usecase.UpdateCartItemInput{
+	in := usecase.UpdateCartItemInput{
 		Quantity: req.Quantity,
-	})
+	}
+
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		out, err := h.uc.UpdateGuestCartItem(c.Request().Context(), h.guestToken(c), itemID, in)
+		return h.guestJSON(c, out, err)
+	}
+
+	out, err := h.uc.UpdateCartItem(c.Request().Context(), userID, itemID, in)
 	if err != nil {
 		return writeError(c, err)
 	}
@@ -106,16 +119,17 @@ func (h *CartHandler) patchItem(c echo.Context) error {
 }
 
 func (h *CartHandler) deleteItem(c echo.Context) error {
-	userID, ok := getUserIDFromContext(c)
-	if !ok {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
-	}
-
 	itemID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
 	}
 
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		out, err := h.uc.DeleteGuestCartItem(c.Request().Context(), h.guestToken(c), itemID)
+		return h.guestJSON(c, out, err)
+	}
+
 	out, err := h.uc.DeleteCartItem(c.Request().Context(), userID, itemID)
 	if err != nil {
 		return writeError(c, err)
@@ -123,3 +137,32 @@ func (h *CartHandler) deleteItem(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, out)
 }
+
+func (h *CartHandler) guestToken(c echo.Context) string {
+	v, _ := getCookieValue(c, cookieGuestCart)
+	return v
+}
+
+// ゲストカートのレスポンス（使うたびにcookieの期限を延ばす）
+func (h *CartHandler) guestJSON(c echo.Context, out usecase.GuestCartOutput, err error) error {
+	if err != nil {
+		return writeError(c, err)
+	}
+	if out.GuestToken != "" {
+		setGuestCartCookie(c, out.GuestToken, h.uc.GuestCartTTL(), h.cfg.GoEnv == "prod")
+	}
+	return c.JSON(http.StatusOK, out.Cart)
+}
+
+// JSからは読めない。SameSite=Laxなので他サイトからのPOSTにはcookieが付かない
+func setGuestCartCookie(c echo.Context, value string, ttl time.Duration, secure bool) {
+	c.SetCookie(&http.Cookie{
+		Name:     cookieGuestCart,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
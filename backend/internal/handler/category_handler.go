@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+
+	"app/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// /categories の公開API
+type CategoryHandler struct {
+	uc *usecase.CategoryUsecase
+}
+
+// DI
+func NewCategoryHandler(uc *usecase.CategoryUsecase) *CategoryHandler {
+	return &CategoryHandler{uc: uc}
+}
+
+// 公開カテゴリのルートを登録
+func (h *CategoryHandler) RegisterRoutes(e *echo.Echo) {
+	e.GET("/categories", h.tree)
+}
+
+func (h *CategoryHandler) tree(c echo.Context) error {
+	out, err := h.uc.Tree(c.Request().Context())
+	if err != nil {
+		return writeError(c, err)
+	}
+	return c.JSON(http.StatusOK, out)
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+
+	"app/internal/config"
+	"app/internal/security"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GET /.well-known/jwks.json（他サービスがaccess tokenを検証するための公開鍵）
+type JWKSHandler struct {
+	keys *security.KeySet
+}
+
+// DI
+func NewJWKSHandler(cfg config.Config) *JWKSHandler {
+	return &JWKSHandler{keys: cfg.JWTKeySet()}
+}
+
+func (h *JWKSHandler) RegisterRoutes(e *echo.Echo) {
+	e.GET("/.well-known/jwks.json", h.jwks)
+}
+
+func (h *JWKSHandler) jwks(c echo.Context) error {
+	//ローテーション時に反映されるよう短めにキャッシュさせる
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, h.keys.JWKS())
+}
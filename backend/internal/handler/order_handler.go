@@ -30,7 +30,12 @@ func (h *OrderHandler) RegisterRoutes(e *echo.Echo, cfg config.Config, userRepo
 	g.Use(middleware.AuthJWT(cfg))
 	g.Use(middleware.TokenVersionGuard(userRepo))
 
-	g.POST("", h.create)
+	//設定によってはメール未確認ユーザーの注文確定を止める
+	if cfg.EmailVerificationPolicy != config.EmailVerificationPolicyNone {
+		g.POST("", h.create, middleware.EmailVerifiedGuard(userRepo))
+	} else {
+		g.POST("", h.create)
+	}
 	g.GET("", h.list)
 	g.GET("/:id", h.detail)
 }
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"strings"
+
+	"app/internal/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// パスワードのルール違反の文言（codeごと。jaがデフォルト）
+var passwordRuleMessages = map[string]map[string]string{
+	validator.PasswordTooShort: {
+		"ja": "パスワードは8文字以上にしてください。",
+		"en": "Password must be at least 8 characters.",
+	},
+	validator.PasswordTooLong: {
+		"ja": "パスワードは72バイト以内にしてください。",
+		"en": "Password must be at most 72 bytes.",
+	},
+	validator.PasswordBreached: {
+		"ja": "このパスワードは過去のデータ流出で見つかっているため使用できません。別のパスワードを設定してください。",
+		"en": "This password has appeared in a data breach. Please choose a different password.",
+	},
+}
+
+// {"error":"invalid input","code":"password_breached","message":"..."}
+func passwordRuleErrorJSON(c echo.Context, code string) errorResponse {
+	msg := passwordRuleMessages[code][messageLang(c)]
+	return errorResponse{Error: validator.ErrInvalidInput.Error(), Code: code, Message: msg}
+}
+
+// Accept-Languageの先頭が英語ならen、それ以外はja
+func messageLang(c echo.Context) string {
+	al := c.Request().Header.Get("Accept-Language")
+	first := strings.TrimSpace(strings.SplitN(al, ",", 2)[0])
+	if strings.HasPrefix(strings.ToLower(first), "en") {
+		return "en"
+	}
+	return "ja"
+}
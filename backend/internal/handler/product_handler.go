@@ -64,6 +64,7 @@ func (h *ProductHandler) list(c echo.Context) error {
 
 	q := c.QueryParam("q")
 	sort := c.QueryParam("sort")
+	category := c.QueryParam("category")
 
 	var minPrice *int64
 	if v := c.QueryParam("min_price"); v != "" {
@@ -83,6 +84,16 @@ func (h *ProductHandler) list(c echo.Context) error {
 		maxPrice = &x
 	}
 
+	// facets（default false）
+	facets := false
+	if v := c.QueryParam("facets"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid facets"})
+		}
+		facets = b
+	}
+
 	out, err := h.uc.ListPublicProducts(c.Request().Context(), usecase.ListProductsInput{
 		Page:     page,
 		Limit:    limit,
@@ -90,6 +101,8 @@ func (h *ProductHandler) list(c echo.Context) error {
 		MinPrice: minPrice,
 		MaxPrice: maxPrice,
 		Sort:     sort,
+		Category: category,
+		Facets:   facets,
 	})
 	if err != nil {
 		return writeError(c, err)
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"app/internal/config"
+	"app/internal/middleware"
+	"app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// /me/security-events（自分のログイン・ログアウトなどの履歴）
+type SecurityEventHandler struct {
+	cfg config.Config
+	uc  *usecase.SecurityEventUsecase
+}
+
+// DI
+func NewSecurityEventHandler(cfg config.Config, uc *usecase.SecurityEventUsecase) *SecurityEventHandler {
+	return &SecurityEventHandler{cfg: cfg, uc: uc}
+}
+
+func (h *SecurityEventHandler) RegisterRoutes(e *echo.Echo, userRepo repository.UserRepository) {
+	g := e.Group("/me/security-events")
+	g.Use(middleware.AuthJWT(h.cfg))
+	g.Use(middleware.TokenVersionGuard(userRepo))
+
+	g.GET("", h.list)
+}
+
+func (h *SecurityEventHandler) list(c echo.Context) error {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	page, limit, errMsg := parsePageLimit(c)
+	if errMsg != "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: errMsg})
+	}
+
+	out, err := h.uc.ListMine(c.Request().Context(), userID, page, limit)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, out)
+}
+
+// ?page=&limit=（省略時は0 = usecaseの既定値）。数値でなければエラーメッセージを返す
+func parsePageLimit(c echo.Context) (int, int, string) {
+	page := 0
+	if v := c.QueryParam("page"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, "invalid page"
+		}
+		page = p
+	}
+
+	limit := 0
+	if v := c.QueryParam("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, "invalid limit"
+		}
+		limit = l
+	}
+
+	return page, limit, ""
+}
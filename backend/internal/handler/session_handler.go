@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net/http"
+
+	"app/internal/config"
+	"app/internal/middleware"
+	"app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// /me/sessions のHTTP（ログイン中の端末一覧・失効）
+type SessionHandler struct {
+	cfg config.Config
+	uc  *usecase.SessionUsecase
+}
+
+// DI
+func NewSessionHandler(cfg config.Config, uc *usecase.SessionUsecase) *SessionHandler {
+	return &SessionHandler{cfg: cfg, uc: uc}
+}
+
+func (h *SessionHandler) RegisterRoutes(e *echo.Echo, userRepo repository.UserRepository) {
+	g := e.Group("/me/sessions")
+	g.Use(middleware.AuthJWT(h.cfg))
+	g.Use(middleware.TokenVersionGuard(userRepo))
+
+	g.GET("", h.list)
+	g.DELETE("/:id", h.revoke)
+	g.POST("/revoke-others", h.revokeOthers)
+}
+
+func (h *SessionHandler) list(c echo.Context) error {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	//現在の端末の判定用（無くても一覧は返す）
+	current, _ := getCookieValue(c, cookieRefreshToken)
+
+	out, err := h.uc.List(c.Request().Context(), userID, current)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, out)
+}
+
+func (h *SessionHandler) revoke(c echo.Context) error {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	current, _ := getCookieValue(c, cookieRefreshToken)
+
+	isCurrent, err := h.uc.Revoke(c.Request().Context(), userID, c.Param("id"), current)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	//自分の端末を消した場合はログアウトと同じくcookieも消す
+	if isCurrent {
+		clearAuthCookie(c, cookieRefreshToken, h.cfg.GoEnv == "prod")
+		clearAuthCookie(c, cookieCsrfToken, h.cfg.GoEnv == "prod")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *SessionHandler) revokeOthers(c echo.Context) error {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized"})
+	}
+
+	current, _ := getCookieValue(c, cookieRefreshToken)
+
+	out, err := h.uc.RevokeOthers(c.Request().Context(), userID, current)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, out)
+}
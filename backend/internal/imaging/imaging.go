@@ -0,0 +1,159 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// 受け付ける画像形式（標準ライブラリでデコードできるもの）
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+)
+
+const jpegQuality = 85
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrInvalidImage      = errors.New("invalid image")
+	ErrTooManyPixels     = errors.New("image dimensions too large")
+)
+
+// 中身の先頭バイトから形式を判定する（クライアントのContent-Typeや拡張子は信用しない）
+func Sniff(data []byte) (string, error) {
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		return FormatJPEG, nil
+	case "image/png":
+		return FormatPNG, nil
+	case "image/gif":
+		return FormatGIF, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// 形式ごとのContent-Typeと拡張子
+func ContentType(format string) string {
+	return "image/" + format
+}
+
+func Ext(format string) string {
+	if format == FormatJPEG {
+		return "jpg"
+	}
+	return format
+}
+
+// 縮小版の形式（GIFはアニメーションを捨ててPNGにする）
+func ThumbnailFormat(format string) string {
+	if format == FormatGIF {
+		return FormatPNG
+	}
+	return format
+}
+
+// デコードする。展開後のサイズで落ちないよう、先にヘッダだけ読んで画素数を確かめる
+func Decode(data []byte, maxPixels int) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width > maxPixels/cfg.Height {
+		return nil, ErrTooManyPixels
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	return img, nil
+}
+
+// RGBAに変換する（元画像の形式がYCbCr・パレットなどでも縮小で同じように扱える）。既にRGBAならそのまま返す
+func ToRGBA(src image.Image) *image.RGBA {
+	if rgba, ok := src.(*image.RGBA); ok {
+		return rgba
+	}
+	b := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+	return rgba
+}
+
+// 長辺がmaxSideに収まるよう縮小する（面積平均）。元が小さければ拡大せずそのまま返す
+// 複数のサイズを作るときは大きい順に、前の結果を次のsrcにすると速い
+func Thumbnail(src *image.RGBA, maxSide int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw <= maxSide && sh <= maxSide {
+		return src
+	}
+
+	dw, dh := maxSide, maxSide
+	if sw >= sh {
+		dh = max(1, sh*maxSide/sw)
+	} else {
+		dw = max(1, sw*maxSide/sh)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0 := dy * sh / dh
+		y1 := max(y0+1, (dy+1)*sh/dh)
+		for dx := 0; dx < dw; dx++ {
+			x0 := dx * sw / dw
+			x1 := max(x0+1, (dx+1)*sw/dw)
+
+			var r, g, bl, a, n uint64
+			for y := y0; y < y1; y++ {
+				off := src.PixOffset(b.Min.X+x0, b.Min.Y+y)
+				for x := x0; x < x1; x++ {
+					r += uint64(src.Pix[off])
+					g += uint64(src.Pix[off+1])
+					bl += uint64(src.Pix[off+2])
+					a += uint64(src.Pix[off+3])
+					off += 4
+					n++
+				}
+			}
+
+			o := dst.PixOffset(dx, dy)
+			dst.Pix[o] = uint8(r / n)
+			dst.Pix[o+1] = uint8(g / n)
+			dst.Pix[o+2] = uint8(bl / n)
+			dst.Pix[o+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// 形式に合わせてエンコードする
+func Encode(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case FormatJPEG:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	case FormatPNG:
+		err = png.Encode(&buf, img)
+	case FormatGIF:
+		err = gif.Encode(&buf, img, nil)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -12,14 +12,7 @@ import (
 
 // DB接続
 func NewGorm(cfg config.Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%d sslmode=disable",
-		cfg.PostgresHost,
-		cfg.PostgresUser,
-		cfg.PostgresPassword,
-		cfg.PostgresDB,
-		cfg.PostgresPort,
-	)
+	dsn := DSN(cfg)
 
 	//ログの設定
 	gormLogger := logger.Default
@@ -44,3 +37,15 @@ func NewGorm(cfg config.Config) (*gorm.DB, error) {
 
 	return db, nil
 }
+
+// 接続文字列（LISTEN用のpgx接続でも使う）
+func DSN(cfg config.Config) string {
+	return fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%d sslmode=disable",
+		cfg.PostgresHost,
+		cfg.PostgresUser,
+		cfg.PostgresPassword,
+		cfg.PostgresDB,
+		cfg.PostgresPort,
+	)
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"fmt"
+
+	"app/internal/domain/model"
+	"app/internal/textsearch"
+
+	"gorm.io/gorm"
+)
+
+// 検索用カラムを埋め直すときの1回あたりの件数
+const productSearchBackfillBatch = 500
+
+// 商品検索の準備（AutoMigrateの後に呼ぶ。何度呼んでもよい）
+//   - pg_trgm拡張と、検索用カラムのGINインデックス（LIKE '%語%' をインデックスで引ける）
+//   - 検索用カラムが空の既存商品を埋める（カラム追加前に作られた商品）
+//
+// 日本語をtrigramに分けるにはDBのLC_CTYPEがUTF-8系のロケールである必要がある（Cロケールでも結果は同じだが全件走査になる）
+func MigrateProductSearch(db *gorm.DB) error {
+	stmts := []string{
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		"CREATE INDEX IF NOT EXISTS idx_products_search_name_trgm ON products USING gin (search_name gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_products_search_description_trgm ON products USING gin (search_description gin_trgm_ops)",
+	}
+	for _, s := range stmts {
+		if err := db.Exec(s).Error; err != nil {
+			return fmt.Errorf("product search migration: %w", err)
+		}
+	}
+
+	var lastID int64
+	for {
+		var products []model.Product
+		if err := db.Unscoped().
+			Select("id", "name", "description").
+			Where("id > ? AND search_name = '' AND name <> ''", lastID).
+			Order("id ASC").
+			Limit(productSearchBackfillBatch).
+			Find(&products).Error; err != nil {
+			return fmt.Errorf("product search backfill: %w", err)
+		}
+		if len(products) == 0 {
+			return nil
+		}
+
+		for _, p := range products {
+			if err := db.Unscoped().Model(&model.Product{}).Where("id = ?", p.ID).UpdateColumns(map[string]interface{}{
+				"search_name":        textsearch.Normalize(p.Name),
+				"search_description": textsearch.Normalize(p.Description),
+			}).Error; err != nil {
+				return fmt.Errorf("product search backfill: %w", err)
+			}
+			lastID = p.ID
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"app/internal/usecase"
+)
+
+// ローカル開発用：メールを1通1ファイルで書き出す
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+// DI
+func NewFileMailer(dir string, from string) (usecase.Mailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("mail sink dir: %w", err)
+	}
+	return &fileMailer{dir: dir, from: from}, nil
+}
+
+func (m *fileMailer) Send(ctx context.Context, msg usecase.MailMessage) error {
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), sanitizeFileName(msg.To))
+
+	content := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\n\r\n%s\r\n",
+		m.from,
+		msg.To,
+		msg.Subject,
+		time.Now().Format(time.RFC1123Z),
+		msg.Body,
+	)
+
+	return os.WriteFile(filepath.Join(m.dir, name), []byte(content), 0o600)
+}
+
+// ファイル名に使えない文字を置き換える
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '@', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
@@ -0,0 +1,23 @@
+package mailer
+
+import (
+	"context"
+	"log"
+
+	"app/internal/usecase"
+)
+
+// 送信先が無い環境用：メール内容をログに出すだけ
+type logMailer struct {
+	from string
+}
+
+// DI
+func NewLogMailer(from string) usecase.Mailer {
+	return &logMailer{from: from}
+}
+
+func (m *logMailer) Send(ctx context.Context, msg usecase.MailMessage) error {
+	log.Printf("[mail] from=%s to=%s subject=%q\n%s", m.from, msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,339 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"app/internal/config"
+	"app/internal/usecase"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// JWKSを取り直す最短間隔（知らないkidが来るたびにIdPを叩かない）
+const jwksRefreshInterval = time.Minute
+
+// id_tokenのiat・expの時計ずれの許容
+const clockSkew = time.Minute
+
+// /.well-known/openid-configuration のうち使う項目
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	IDToken string `json:"id_token"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// OpenID Connect（authorization code + PKCE）のprovider
+type provider struct {
+	cfg  config.OidcProviderConfig
+	http *http.Client
+
+	mu            sync.Mutex
+	disc          *discovery
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// DI（httpClientがnilならタイムアウト付きのデフォルト）
+func NewProvider(cfg config.OidcProviderConfig, httpClient *http.Client) usecase.OidcProvider {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &provider{cfg: cfg, http: httpClient}
+}
+
+func (p *provider) Name() string {
+	return p.cfg.Name
+}
+
+// 認可エンドポイントのURL（response_type=code + PKCE S256）
+func (p *provider) AuthCodeURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	d, err := p.discovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(d.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.scopes(), " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// 認可コード → token。id_tokenの署名・iss・aud・期限を検証してclaimsを返す
+func (p *provider) Exchange(ctx context.Context, code string, codeVerifier string) (*usecase.OidcIdentity, error) {
+	d, err := p.discovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	//confidential clientはclient_secret_basic（RFC 6749 2.3.1）
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var tr tokenResponse
+	if err := p.doJSON(req, &tr); err != nil {
+		return nil, fmt.Errorf("token endpoint: %w", err)
+	}
+	if tr.IDToken == "" {
+		return nil, errors.New("token endpoint: id_token missing")
+	}
+
+	return p.verifyIDToken(ctx, d, tr.IDToken)
+}
+
+func (p *provider) verifyIDToken(ctx context.Context, d *discovery, raw string) (*usecase.OidcIdentity, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256", "ES256"}), jwt.WithoutClaimsValidation())
+
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, d, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("id_token: %w", err)
+	}
+
+	now := time.Now()
+	if !claims.VerifyIssuer(d.Issuer, true) {
+		return nil, errors.New("id_token: issuer mismatch")
+	}
+	if !claims.VerifyAudience(p.cfg.ClientID, true) {
+		return nil, errors.New("id_token: audience mismatch")
+	}
+	//audが複数ならazpが自分であること
+	if aud, ok := claims["aud"].([]interface{}); ok && len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.cfg.ClientID {
+			return nil, errors.New("id_token: azp mismatch")
+		}
+	}
+	if !claims.VerifyExpiresAt(now.Add(-clockSkew).Unix(), true) {
+		return nil, errors.New("id_token: expired")
+	}
+	if !claims.VerifyIssuedAt(now.Add(clockSkew).Unix(), true) {
+		return nil, errors.New("id_token: issued in the future")
+	}
+
+	sub, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+	nonce, _ := claims["nonce"].(string)
+
+	return &usecase.OidcIdentity{
+		Subject:       sub,
+		Email:         email,
+		EmailVerified: claimBool(claims["email_verified"]),
+		Nonce:         nonce,
+	}, nil
+}
+
+// discoveryは最初の1回だけ取得してキャッシュ
+func (p *provider) discovery(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.disc != nil {
+		return p.disc, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var d discovery
+	if err := p.doJSON(req, &d); err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+	//issuerの差し替え防止（OpenID Connect Discovery 4.3）
+	if strings.TrimRight(d.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("discovery: issuer mismatch %q", d.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JwksURI == "" {
+		return nil, errors.New("discovery: endpoint missing")
+	}
+
+	p.disc = &d
+	return p.disc, nil
+}
+
+// kidの公開鍵。知らないkidならJWKSを取り直す（IdPの鍵ローテーション対応）
+func (p *provider) key(ctx context.Context, d *discovery, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	}
+	if p.keys != nil && time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+
+	keys, err := p.fetchJWKS(ctx, d.JwksURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown kid %q", kid)
+}
+
+func (p *provider) fetchJWKS(ctx context.Context, jwksURI string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.doJSON(req, &set); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	keys := map[string]interface{}{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			//対応していない鍵は飛ばす
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	return keys, nil
+}
+
+func (p *provider) doJSON(req *http.Request, out interface{}) error {
+	res, err := p.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", res.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// openidは必ず入れる
+func (p *provider) scopes() []string {
+	for _, s := range p.cfg.Scopes {
+		if s == "openid" {
+			return p.cfg.Scopes
+		}
+	}
+	return append([]string{"openid"}, p.cfg.Scopes...)
+}
+
+// RSA / EC(P-256) の公開鍵
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("invalid ec point")
+		}
+		return pub, nil
+	default:
+		return nil, fmt.Errorf("unsupported kty %q", k.Kty)
+	}
+}
+
+// email_verifiedは文字列で返すIdPもある
+func claimBool(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	default:
+		return false
+	}
+}
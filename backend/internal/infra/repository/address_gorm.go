@@ -98,6 +98,22 @@ func (r *addressGormRepository) IsOwnedByUser(ctx context.Context, addressID, us
 	return count == 1, nil
 }
 
+// 個人が分かる項目を空にする（都道府県は売上の集計用に残す）
+func (r *addressGormRepository) AnonymizeByUserID(ctx context.Context, userID int64) error {
+	return r.db.WithContext(ctx).
+		Model(&model.Address{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]any{
+			"postal_code": "",
+			"city":        "",
+			"line1":       "",
+			"line2":       "",
+			"name":        "",
+			"phone":       "",
+			"is_default":  false,
+		}).Error
+}
+
 // デフォルト住所を切り替える
 func (r *addressGormRepository) SetDefault(ctx context.Context, userID, addressID int64) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type apiKeyGormRepository struct {
+	db *gorm.DB
+}
+
+// DI
+func NewApiKeyGormRepository(db *gorm.DB) repo.ApiKeyRepository {
+	return &apiKeyGormRepository{db: db}
+}
+
+func (r *apiKeyGormRepository) Create(ctx context.Context, key *model.ApiKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// key_hashで1件検索
+func (r *apiKeyGormRepository) FindByHash(ctx context.Context, keyHash string) (model.ApiKey, bool, error) {
+	return r.first(ctx, "key_hash = ?", keyHash)
+}
+
+// IDで1件検索
+func (r *apiKeyGormRepository) FindByID(ctx context.Context, id int64) (model.ApiKey, bool, error) {
+	return r.first(ctx, "id = ?", id)
+}
+
+func (r *apiKeyGormRepository) List(ctx context.Context) ([]model.ApiKey, error) {
+	var list []model.ApiKey
+
+	if err := r.db.WithContext(ctx).
+		Order("id DESC").
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// 未失効のものだけ revoked_at を入れる
+func (r *apiKeyGormRepository) Revoke(ctx context.Context, id int64, at time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&model.ApiKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *apiKeyGormRepository) TouchLastUsed(ctx context.Context, id int64, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.ApiKey{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
+
+func (r *apiKeyGormRepository) first(ctx context.Context, query string, arg interface{}) (model.ApiKey, bool, error) {
+	var key model.ApiKey
+
+	err := r.db.WithContext(ctx).Where(query, arg).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.ApiKey{}, false, nil
+		}
+		return model.ApiKey{}, false, err
+	}
+
+	return key, true, nil
+}
@@ -30,6 +30,9 @@ func (r *auditLogGormRepository) List(ctx context.Context, filter repo.AuditLogF
 	if filter.ActorUserID != nil {
 		q = q.Where("actor_user_id = ?", *filter.ActorUserID)
 	}
+	if filter.ActorApiKeyID != nil {
+		q = q.Where("actor_api_key_id = ?", *filter.ActorApiKeyID)
+	}
 	if filter.Action != nil {
 		q = q.Where("action = ?", *filter.Action)
 	}
@@ -44,7 +44,7 @@ func (r *CartGormRepository) GetOrCreateActiveByUserID(ctx context.Context, user
 		// 無ければ作る
 		now := time.Now()
 		newCart := model.Cart{
-			UserID:    userID,
+			UserID:    &userID,
 			Status:    model.CartStatusActive,
 			CreatedAt: now,
 			UpdatedAt: now,
@@ -125,6 +125,91 @@ func (r *CartGormRepository) Clear(ctx context.Context, cartID int64) error {
 	})
 }
 
+// ゲストカートを作成
+func (r *CartGormRepository) CreateGuest(ctx context.Context) (model.Cart, error) {
+	now := time.Now()
+	cart := model.Cart{
+		UserID:    nil,
+		Status:    model.CartStatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := r.db.WithContext(ctx).Create(&cart).Error; err != nil {
+		return model.Cart{}, err
+	}
+	return cart, nil
+}
+
+// ACTIVEのゲストカートを取得
+func (r *CartGormRepository) FindActiveGuestByID(ctx context.Context, cartID int64) (model.Cart, error) {
+	var cart model.Cart
+
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND user_id IS NULL AND status = ?", cartID, model.CartStatusActive).
+		First(&cart).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Cart{}, repo.ErrNotFound
+	}
+	if err != nil {
+		return model.Cart{}, err
+	}
+	return cart, nil
+}
+
+// ACTIVEのときだけMERGEDにする（同時ログインで二重に合算しない）
+func (r *CartGormRepository) MarkGuestMerged(ctx context.Context, cartID int64) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&model.Cart{}).
+		Where("id = ? AND user_id IS NULL AND status = ?", cartID, model.CartStatusActive).
+		Update("status", model.CartStatusMerged)
+
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// 放置されたゲストカートを削除（明細の更新もbefore以前のもの）
+func (r *CartGormRepository) DeleteAbandonedGuests(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		abandoned := tx.
+			Model(&model.Cart{}).
+			Select("id").
+			Where("user_id IS NULL").
+			Where(
+				tx.Where("status <> ?", model.CartStatusActive).
+					Or("updated_at < ? AND NOT EXISTS (SELECT 1 FROM cart_items WHERE cart_items.cart_id = carts.id AND cart_items.updated_at >= ?)", before, before),
+			)
+
+		var ids []int64
+		if err := abandoned.Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := tx.Where("cart_id IN ?", ids).Delete(&model.CartItem{}).Error; err != nil {
+			return err
+		}
+		res := tx.Where("id IN ?", ids).Delete(&model.Cart{})
+		if res.Error != nil {
+			return res.Error
+		}
+		deleted = res.RowsAffected
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
 // カート明細を一覧取得
 func (r *CartGormRepository) ListByCartID(ctx context.Context, cartID int64) ([]model.CartItem, error) {
 	var items []model.CartItem
@@ -139,8 +224,8 @@ func (r *CartGormRepository) ListByCartID(ctx context.Context, cartID int64) ([]
 	return items, nil
 }
 
-// 同一商品は数量加算
-func (r *CartGormRepository) UpsertByCartAndProduct(ctx context.Context, cartID int64, productID int64, addQty int64, unitPriceSnapshot int64) error {
+// 同一商品（同じバリエーション）は数量加算
+func (r *CartGormRepository) UpsertByCartAndProduct(ctx context.Context, cartID int64, productID int64, variantID *int64, addQty int64, unitPriceSnapshot int64, optionLabelsSnapshot string) error {
 
 	if addQty <= 0 {
 		return errors.New("invalid quantity")
@@ -149,10 +234,15 @@ func (r *CartGormRepository) UpsertByCartAndProduct(ctx context.Context, cartID
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var item model.CartItem
 
-		err := tx.
+		q := tx.
 			Clauses(clause.Locking{Strength: "UPDATE"}).
-			Where("cart_id = ? AND product_id = ?", cartID, productID).
-			First(&item).Error
+			Where("cart_id = ? AND product_id = ?", cartID, productID)
+		if variantID != nil {
+			q = q.Where("variant_id = ?", *variantID)
+		} else {
+			q = q.Where("variant_id IS NULL")
+		}
+		err := q.First(&item).Error
 
 		if err == nil {
 			// 既存ありだったら数量を増やす
@@ -178,12 +268,14 @@ func (r *CartGormRepository) UpsertByCartAndProduct(ctx context.Context, cartID
 		//無い場合は新規作成
 		now := time.Now()
 		newItem := model.CartItem{
-			CartID:            cartID,
-			ProductID:         productID,
-			Quantity:          addQty,
-			UnitPriceSnapshot: unitPriceSnapshot,
-			CreatedAt:         now,
-			UpdatedAt:         now,
+			CartID:               cartID,
+			ProductID:            productID,
+			VariantID:            variantID,
+			Quantity:             addQty,
+			UnitPriceSnapshot:    unitPriceSnapshot,
+			OptionLabelsSnapshot: optionLabelsSnapshot,
+			CreatedAt:            now,
+			UpdatedAt:            now,
 		}
 
 		if err := tx.Create(&newItem).Error; err != nil {
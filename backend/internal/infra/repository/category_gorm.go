@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type categoryGormRepository struct {
+	db *gorm.DB
+}
+
+// DI
+func NewCategoryGormRepository(db *gorm.DB) repo.CategoryRepository {
+	return &categoryGormRepository{db: db}
+}
+
+// カテゴリを作成
+func (r *categoryGormRepository) Create(ctx context.Context, c model.Category) (model.Category, error) {
+	if err := r.db.WithContext(ctx).Create(&c).Error; err != nil {
+		return model.Category{}, err
+	}
+	return c, nil
+}
+
+// IDで1件取得
+func (r *categoryGormRepository) FindByID(ctx context.Context, categoryID int64) (model.Category, error) {
+	var c model.Category
+	err := r.db.WithContext(ctx).First(&c, categoryID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Category{}, repo.ErrNotFound
+	}
+	if err != nil {
+		return model.Category{}, err
+	}
+	return c, nil
+}
+
+// slugで1件取得
+func (r *categoryGormRepository) FindBySlug(ctx context.Context, slug string) (model.Category, error) {
+	var c model.Category
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Category{}, repo.ErrNotFound
+	}
+	if err != nil {
+		return model.Category{}, err
+	}
+	return c, nil
+}
+
+// 全カテゴリ（並び順）
+func (r *categoryGormRepository) ListAll(ctx context.Context) ([]model.Category, error) {
+	var list []model.Category
+	if err := r.db.WithContext(ctx).
+		Order("sort_order ASC, id ASC").
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// カテゴリを更新（parent_idをnilに戻せるようにmapで更新）
+func (r *categoryGormRepository) Update(ctx context.Context, c model.Category) error {
+	res := r.db.WithContext(ctx).
+		Model(&model.Category{}).
+		Where("id = ?", c.ID).
+		Updates(map[string]any{
+			"parent_id":  c.ParentID,
+			"name":       c.Name,
+			"slug":       c.Slug,
+			"sort_order": c.SortOrder,
+			"updated_at": time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+// カテゴリと商品との対応を削除
+func (r *categoryGormRepository) Delete(ctx context.Context, categoryID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("category_id = ?", categoryID).Delete(&model.ProductCategory{}).Error; err != nil {
+			return err
+		}
+
+		res := tx.Delete(&model.Category{}, categoryID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return repo.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// 商品のカテゴリを置き換える（消して入れ直す）
+func (r *categoryGormRepository) ReplaceProductCategories(ctx context.Context, productID int64, categoryIDs []int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).Delete(&model.ProductCategory{}).Error; err != nil {
+			return err
+		}
+		if len(categoryIDs) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		rows := make([]model.ProductCategory, 0, len(categoryIDs))
+		for _, id := range categoryIDs {
+			rows = append(rows, model.ProductCategory{ProductID: productID, CategoryID: id, CreatedAt: now})
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+// 商品が入っているカテゴリ
+func (r *categoryGormRepository) ListByProductID(ctx context.Context, productID int64) ([]model.Category, error) {
+	var list []model.Category
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN product_categories pc ON pc.category_id = categories.id").
+		Where("pc.product_id = ?", productID).
+		Order("categories.sort_order ASC, categories.id ASC").
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
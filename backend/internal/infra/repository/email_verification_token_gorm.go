@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type emailVerificationTokenGormRepository struct {
+	db *gorm.DB
+}
+
+// DI
+func NewEmailVerificationTokenGormRepository(db *gorm.DB) repo.EmailVerificationTokenRepository {
+	return &emailVerificationTokenGormRepository{db: db}
+}
+
+// 確認トークンを保存する
+func (r *emailVerificationTokenGormRepository) Create(ctx context.Context, token model.EmailVerificationToken) error {
+	if err := r.db.WithContext(ctx).Create(&token).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// token_hash で1件検索。
+func (r *emailVerificationTokenGormRepository) FindByHash(ctx context.Context, tokenHash string) (model.EmailVerificationToken, bool, error) {
+	var token model.EmailVerificationToken
+
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ?", tokenHash).
+		First(&token).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.EmailVerificationToken{}, false, nil
+		}
+		return model.EmailVerificationToken{}, false, err
+	}
+
+	return token, true, nil
+}
+
+// used_at をセットして使用済み
+func (r *emailVerificationTokenGormRepository) MarkUsed(ctx context.Context, tokenID string) error {
+	now := time.Now()
+
+	result := r.db.WithContext(ctx).
+		Model(&model.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL", tokenID).
+		Update("used_at", &now)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	//更新件数が0なら「存在しない or すでに使用済み」
+	if result.RowsAffected == 0 {
+		return errors.New("email verification token not found or already used")
+	}
+
+	return nil
+}
+
+// 指定ユーザーの確認トークンを全削除。
+func (r *emailVerificationTokenGormRepository) DeleteByUserID(ctx context.Context, userID int64) error {
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&model.EmailVerificationToken{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// 期限切れの確認トークンを削除し、削除件数を返す。
+func (r *emailVerificationTokenGormRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("expires_at < ?", now).
+		Delete(&model.EmailVerificationToken{})
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
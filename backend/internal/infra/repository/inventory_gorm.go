@@ -66,6 +66,55 @@ func (r *InventoryGormRepository) IncreaseStock(ctx context.Context, productID i
 	return nil
 }
 
+// バリエーションの在庫の現在値を設定
+func (r *InventoryGormRepository) SetVariantStock(ctx context.Context, variantID int64, newStock int64) error {
+	res := r.db.WithContext(ctx).
+		Model(&model.ProductVariant{}).
+		Where("id = ?", variantID).
+		Update("stock", newStock)
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+// バリエーションの在庫が足りるときだけ減らす
+func (r *InventoryGormRepository) DecreaseVariantStockIfEnough(ctx context.Context, variantID int64, qty int64) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&model.ProductVariant{}).
+		Where("id = ? AND stock >= ?", variantID, qty).
+		Update("stock", gorm.Expr("stock - ?", qty))
+
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+// バリエーションの在庫戻し（削除済みのバリエーションにも戻す）
+func (r *InventoryGormRepository) IncreaseVariantStock(ctx context.Context, variantID int64, qty int64) error {
+	res := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&model.ProductVariant{}).
+		Where("id = ?", variantID).
+		Update("stock", gorm.Expr("stock + ?", qty))
+
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
 // 調整履歴作成
 func (r *InventoryGormRepository) CreateAdjustment(ctx context.Context, adj model.InventoryAdjustment) error {
 	if err := r.db.WithContext(ctx).Create(&adj).Error; err != nil {
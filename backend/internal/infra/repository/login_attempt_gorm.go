@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// 複数ノード用（Postgresで共有）
+type loginAttemptGormRepository struct {
+	db *gorm.DB
+}
+
+// DI
+func NewLoginAttemptGormRepository(db *gorm.DB) repo.LoginAttemptRepository {
+	return &loginAttemptGormRepository{db: db}
+}
+
+// UPSERTで失敗回数を原子的に+1する（同時に失敗しても数え漏れない）
+func (r *loginAttemptGormRepository) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (model.LoginAttempt, error) {
+	var a model.LoginAttempt
+
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO login_attempts (key, failures, last_failed_at, updated_at)
+		VALUES (?, 1, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_attempts.last_failed_at < ? THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failed_at = EXCLUDED.last_failed_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING key, failures, last_failed_at, locked_until, updated_at
+	`, key, now, now, now.Add(-window)).Scan(&a).Error
+	if err != nil {
+		return model.LoginAttempt{}, err
+	}
+
+	return a, nil
+}
+
+// ロック期限をセット（短くはしない）
+func (r *loginAttemptGormRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.LoginAttempt{}).
+		Where("key = ? AND (locked_until IS NULL OR locked_until < ?)", key, until).
+		Updates(map[string]interface{}{
+			"locked_until": until,
+			"updated_at":   time.Now(),
+		}).Error
+}
+
+// keyで1件検索。
+func (r *loginAttemptGormRepository) Get(ctx context.Context, key string) (model.LoginAttempt, bool, error) {
+	var a model.LoginAttempt
+
+	err := r.db.WithContext(ctx).
+		Where("key = ?", key).
+		First(&a).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.LoginAttempt{}, false, nil
+		}
+		return model.LoginAttempt{}, false, err
+	}
+
+	return a, true, nil
+}
+
+// 記録を削除。
+func (r *loginAttemptGormRepository) Delete(ctx context.Context, key string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("key = ?", key).
+		Delete(&model.LoginAttempt{})
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ロック中の一覧。
+func (r *loginAttemptGormRepository) ListLocked(ctx context.Context, now time.Time) ([]model.LoginAttempt, error) {
+	var list []model.LoginAttempt
+
+	err := r.db.WithContext(ctx).
+		Where("locked_until > ?", now).
+		Order("locked_until ASC").
+		Limit(500).
+		Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
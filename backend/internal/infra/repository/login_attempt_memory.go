@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+)
+
+// メモリ上で保持する件数の目安（超えたら古い記録を掃除する）
+const loginAttemptMemoryPruneSize = 10000
+
+// 単一ノード用（再起動で消える）
+type loginAttemptMemoryRepository struct {
+	mu       sync.Mutex
+	attempts map[string]model.LoginAttempt
+}
+
+// DI
+func NewLoginAttemptMemoryRepository() repo.LoginAttemptRepository {
+	return &loginAttemptMemoryRepository{attempts: map[string]model.LoginAttempt{}}
+}
+
+func (r *loginAttemptMemoryRepository) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (model.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.attempts) > loginAttemptMemoryPruneSize {
+		r.pruneLocked(now, window)
+	}
+
+	a, ok := r.attempts[key]
+	if !ok || a.LastFailedAt.Before(now.Add(-window)) {
+		a = model.LoginAttempt{Key: key}
+	}
+
+	a.Failures++
+	a.LastFailedAt = now
+	a.UpdatedAt = now
+	r.attempts[key] = a
+
+	return a, nil
+}
+
+func (r *loginAttemptMemoryRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, ok := r.attempts[key]
+	if !ok {
+		a = model.LoginAttempt{Key: key, LastFailedAt: time.Now()}
+	}
+	if a.LockedUntil == nil || a.LockedUntil.Before(until) {
+		u := until
+		a.LockedUntil = &u
+	}
+	a.UpdatedAt = time.Now()
+	r.attempts[key] = a
+
+	return nil
+}
+
+func (r *loginAttemptMemoryRepository) Get(ctx context.Context, key string) (model.LoginAttempt, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, ok := r.attempts[key]
+	return a, ok, nil
+}
+
+func (r *loginAttemptMemoryRepository) Delete(ctx context.Context, key string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.attempts[key]
+	delete(r.attempts, key)
+	return ok, nil
+}
+
+func (r *loginAttemptMemoryRepository) ListLocked(ctx context.Context, now time.Time) ([]model.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]model.LoginAttempt, 0)
+	for _, a := range r.attempts {
+		if a.LockedUntil != nil && a.LockedUntil.After(now) {
+			out = append(out, a)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].LockedUntil.Before(*out[j].LockedUntil)
+	})
+	return out, nil
+}
+
+// ロックが切れていて、window以上失敗していない記録を消す（mu取得済みで呼ぶ）
+func (r *loginAttemptMemoryRepository) pruneLocked(now time.Time, window time.Duration) {
+	for k, a := range r.attempts {
+		locked := a.LockedUntil != nil && a.LockedUntil.After(now)
+		if !locked && a.LastFailedAt.Before(now.Add(-window)) {
+			delete(r.attempts, k)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type magicLinkTokenGormRepository struct {
+	db *gorm.DB
+}
+
+// DI
+func NewMagicLinkTokenGormRepository(db *gorm.DB) repo.MagicLinkTokenRepository {
+	return &magicLinkTokenGormRepository{db: db}
+}
+
+// ログインリンクのトークンを保存する
+func (r *magicLinkTokenGormRepository) Create(ctx context.Context, token model.MagicLinkToken) error {
+	if err := r.db.WithContext(ctx).Create(&token).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// token_hash で1件検索。
+func (r *magicLinkTokenGormRepository) FindByHash(ctx context.Context, tokenHash string) (model.MagicLinkToken, bool, error) {
+	var token model.MagicLinkToken
+
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ?", tokenHash).
+		First(&token).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.MagicLinkToken{}, false, nil
+		}
+		return model.MagicLinkToken{}, false, err
+	}
+
+	return token, true, nil
+}
+
+// used_at をセットして使用済み
+func (r *magicLinkTokenGormRepository) MarkUsed(ctx context.Context, tokenID string) error {
+	now := time.Now()
+
+	result := r.db.WithContext(ctx).
+		Model(&model.MagicLinkToken{}).
+		Where("id = ? AND used_at IS NULL", tokenID).
+		Update("used_at", &now)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	//更新件数が0なら「存在しない or すでに使用済み」
+	if result.RowsAffected == 0 {
+		return errors.New("magic link token not found or already used")
+	}
+
+	return nil
+}
+
+// 指定ユーザーのログインリンクのトークンを全削除。
+func (r *magicLinkTokenGormRepository) DeleteByUserID(ctx context.Context, userID int64) error {
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&model.MagicLinkToken{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// 期限切れのログインリンクのトークンを削除し、削除件数を返す。
+func (r *magicLinkTokenGormRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("expires_at < ?", now).
+		Delete(&model.MagicLinkToken{})
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
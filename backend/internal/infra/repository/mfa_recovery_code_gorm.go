@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type mfaRecoveryCodeGormRepository struct {
+	db *gorm.DB
+}
+
+// DI
+func NewMfaRecoveryCodeGormRepository(db *gorm.DB) repo.MfaRecoveryCodeRepository {
+	return &mfaRecoveryCodeGormRepository{db: db}
+}
+
+// 古いコードを消して新しいコードを保存する（Tx）
+func (r *mfaRecoveryCodeGormRepository) ReplaceForUser(ctx context.Context, userID int64, codes []model.MfaRecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.MfaRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// 未使用のコードを使用済みにする（同時に使われても1回しか成功しない）
+func (r *mfaRecoveryCodeGormRepository) Consume(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	now := time.Now()
+
+	result := r.db.WithContext(ctx).
+		Model(&model.MfaRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", &now)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// 指定ユーザーのコードを全削除。
+func (r *mfaRecoveryCodeGormRepository) DeleteByUserID(ctx context.Context, userID int64) error {
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&model.MfaRecoveryCode{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -130,3 +130,11 @@ func (r *OrderGormRepository) ListAdmin(ctx context.Context, f repo.AdminOrderLi
 
 	return items, total, nil
 }
+
+func (r *OrderGormRepository) CountByUserID(ctx context.Context, userID int64) (int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&model.Order{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
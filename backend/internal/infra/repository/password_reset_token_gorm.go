@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type passwordResetTokenGormRepository struct {
+	db *gorm.DB
+}
+
+// DI
+func NewPasswordResetTokenGormRepository(db *gorm.DB) repo.PasswordResetTokenRepository {
+	return &passwordResetTokenGormRepository{db: db}
+}
+
+// 再設定トークンを保存する
+func (r *passwordResetTokenGormRepository) Create(ctx context.Context, token model.PasswordResetToken) error {
+	if err := r.db.WithContext(ctx).Create(&token).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// token_hash で1件検索。
+func (r *passwordResetTokenGormRepository) FindByHash(ctx context.Context, tokenHash string) (model.PasswordResetToken, bool, error) {
+	var token model.PasswordResetToken
+
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ?", tokenHash).
+		First(&token).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.PasswordResetToken{}, false, nil
+		}
+		return model.PasswordResetToken{}, false, err
+	}
+
+	return token, true, nil
+}
+
+// used_at をセットして使用済み
+func (r *passwordResetTokenGormRepository) MarkUsed(ctx context.Context, tokenID string) error {
+	now := time.Now()
+
+	result := r.db.WithContext(ctx).
+		Model(&model.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", tokenID).
+		Update("used_at", &now)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	//更新件数が0なら「存在しない or すでに使用済み」
+	if result.RowsAffected == 0 {
+		return errors.New("password reset token not found or already used")
+	}
+
+	return nil
+}
+
+// 指定ユーザーの再設定トークンを全削除。
+func (r *passwordResetTokenGormRepository) DeleteByUserID(ctx context.Context, userID int64) error {
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&model.PasswordResetToken{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// 期限切れの再設定トークンを削除し、削除件数を返す。
+func (r *passwordResetTokenGormRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("expires_at < ?", now).
+		Delete(&model.PasswordResetToken{})
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -7,8 +7,10 @@ import (
 
 	"app/internal/domain/model"
 	repo "app/internal/repository"
+	"app/internal/textsearch"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductGormRepository struct {
@@ -25,15 +27,45 @@ func (r *ProductGormRepository) ListPublic(ctx context.Context, q repo.ProductLi
 	var products []model.Product
 	var total int64
 
+	tx := r.publicQuery(ctx, q)
+	terms := textsearch.Terms(q.Q)
+
+	//total（件数）
+	if err := tx.Count(&total).Error; err != nil {
+		return []model.Product{}, 0, err
+	}
+
+	//sort
+	switch {
+	case q.Sort == "relevance" && len(terms) > 0:
+		tx = tx.Clauses(clause.OrderBy{Expression: relevanceOrder(terms)})
+	case q.Sort == "price_asc":
+		tx = tx.Order("price asc").Order("id asc")
+	case q.Sort == "price_desc":
+		tx = tx.Order("price desc").Order("id desc")
+	default:
+		tx = tx.Order("created_at desc").Order("id desc")
+	}
+
+	offset := (q.Page - 1) * q.Limit
+	if err := tx.Offset(offset).Limit(q.Limit).Find(&products).Error; err != nil {
+		return []model.Product{}, 0, err
+	}
+
+	return products, total, nil
+}
+
+// 公開商品の絞り込み（一覧とファセットで同じ条件を使う）
+func (r *ProductGormRepository) publicQuery(ctx context.Context, q repo.ProductListQuery) *gorm.DB {
 	tx := r.db.WithContext(ctx).Model(&model.Product{})
 
 	// 公開（is_active=true）かつ、商品削除されていないものだけ
 	tx = tx.Where("is_active = ?", true)
 
-	// q nameを対象
-	if strings.TrimSpace(q.Q) != "" {
-		like := "%" + strings.TrimSpace(q.Q) + "%"
-		tx = tx.Where("name ILIKE ?", like)
+	// q 名前・説明を対象に、語ごとにAND（正規化した検索用カラムを pg_trgm のGINインデックスで引く）
+	for _, t := range textsearch.Terms(q.Q) {
+		like := "%" + textsearch.EscapeLike(t) + "%"
+		tx = tx.Where("(search_name LIKE ? OR search_description LIKE ?)", like, like)
 	}
 
 	//価格帯
@@ -44,27 +76,111 @@ func (r *ProductGormRepository) ListPublic(ctx context.Context, q repo.ProductLi
 		tx = tx.Where("price <= ?", *q.MaxPrice)
 	}
 
-	//total（件数）
-	if err := tx.Count(&total).Error; err != nil {
-		return []model.Product{}, 0, err
+	//カテゴリ（子孫カテゴリのIDはusecaseで展開済み）
+	if len(q.CategoryIDs) > 0 {
+		tx = tx.Where("id IN (?)", r.db.Model(&model.ProductCategory{}).
+			Select("product_id").
+			Where("category_id IN ?", q.CategoryIDs))
 	}
+	return tx
+}
 
-	//sort
-	switch q.Sort {
-	case "price_asc":
-		tx = tx.Order("price asc").Order("id asc")
-	case "price_desc":
-		tx = tx.Order("price desc").Order("id desc")
-	default:
-		tx = tx.Order("created_at desc").Order("id desc")
+// 在庫ありの判定（バリエーションのある商品は公開中のSKUのどれかに在庫があるか）
+const productInStockSQL = `CASE WHEN EXISTS (
+		SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.deleted_at IS NULL
+	) THEN EXISTS (
+		SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.deleted_at IS NULL AND v.is_active AND v.stock > 0
+	) ELSE products.stock > 0 END`
+
+// カテゴリごとの件数。各カテゴリを祖先にも展開し、同じ商品は1カテゴリにつき1回だけ数える
+const productCategoryFacetSQL = `WITH RECURSIVE category_ancestors AS (
+		SELECT id AS category_id, id AS ancestor_id, parent_id FROM categories
+		UNION
+		SELECT a.category_id, c.id, c.parent_id FROM category_ancestors a JOIN categories c ON c.id = a.parent_id
+	)
+	SELECT a.ancestor_id AS category_id, COUNT(DISTINCT pc.product_id) AS count
+	FROM product_categories pc
+	JOIN category_ancestors a ON a.category_id = pc.category_id
+	WHERE pc.product_id IN (?)
+	GROUP BY a.ancestor_id`
+
+// 軸・値ごとの件数（公開中のSKUのみ）
+const productAttributeFacetSQL = `SELECT o.name AS name, vv.value AS value, COUNT(DISTINCT v.product_id) AS count
+	FROM product_variants v
+	JOIN product_variant_values vv ON vv.variant_id = v.id
+	JOIN product_options o ON o.id = vv.option_id
+	WHERE v.deleted_at IS NULL AND v.is_active AND v.product_id IN (?)
+	GROUP BY o.name, vv.value
+	ORDER BY MIN(o.position), o.name, MIN(v.id)`
+
+// 一覧と同じ絞り込みでのファセット件数。価格帯と在庫は1回の走査（FILTER）で数える
+func (r *ProductGormRepository) FacetPublic(ctx context.Context, q repo.ProductListQuery, priceBounds []int64) (repo.ProductFacetCounts, error) {
+	out := repo.ProductFacetCounts{PriceBuckets: make([]int64, len(priceBounds)+1)}
+
+	cols := make([]string, 0, len(priceBounds)+2)
+	var vars []interface{}
+	for i := 0; i <= len(priceBounds); i++ {
+		switch {
+		case len(priceBounds) == 0:
+			cols = append(cols, "COUNT(*)")
+		case i == 0:
+			cols = append(cols, "COUNT(*) FILTER (WHERE price < ?)")
+			vars = append(vars, priceBounds[0])
+		case i == len(priceBounds):
+			cols = append(cols, "COUNT(*) FILTER (WHERE price >= ?)")
+			vars = append(vars, priceBounds[i-1])
+		default:
+			cols = append(cols, "COUNT(*) FILTER (WHERE price >= ? AND price < ?)")
+			vars = append(vars, priceBounds[i-1], priceBounds[i])
+		}
 	}
+	cols = append(cols, "COUNT(*) FILTER (WHERE "+productInStockSQL+")", "COUNT(*)")
 
-	offset := (q.Page - 1) * q.Limit
-	if err := tx.Offset(offset).Limit(q.Limit).Find(&products).Error; err != nil {
-		return []model.Product{}, 0, err
+	var inStock, total int64
+	dest := make([]interface{}, 0, len(out.PriceBuckets)+2)
+	for i := range out.PriceBuckets {
+		dest = append(dest, &out.PriceBuckets[i])
 	}
+	dest = append(dest, &inStock, &total)
 
-	return products, total, nil
+	if err := r.publicQuery(ctx, q).Select(strings.Join(cols, ", "), vars...).Row().Scan(dest...); err != nil {
+		return repo.ProductFacetCounts{}, err
+	}
+	out.InStock = inStock
+	out.OutOfStock = total - inStock
+
+	ids := r.publicQuery(ctx, q).Select("products.id")
+
+	if err := r.db.WithContext(ctx).Raw(productCategoryFacetSQL, ids).Scan(&out.Categories).Error; err != nil {
+		return repo.ProductFacetCounts{}, err
+	}
+	if err := r.db.WithContext(ctx).Raw(productAttributeFacetSQL, ids).Scan(&out.Attributes).Error; err != nil {
+		return repo.ProductFacetCounts{}, err
+	}
+	return out, nil
+}
+
+// 関連度の高い順。語ごとに 名前に含む(+3)・名前が語で始まる(+1)・説明に含む(+1) を足し、
+// 同点は名前と検索語全体の近さ（pg_trgmのsimilarity）、最後に新しい順
+func relevanceOrder(terms []string) clause.Expr {
+	var parts []string
+	var vars []interface{}
+	for _, t := range terms {
+		esc := textsearch.EscapeLike(t)
+		parts = append(parts,
+			"(CASE WHEN search_name LIKE ? THEN 3 ELSE 0 END)",
+			"(CASE WHEN search_name LIKE ? THEN 1 ELSE 0 END)",
+			"(CASE WHEN search_description LIKE ? THEN 1 ELSE 0 END)",
+		)
+		vars = append(vars, "%"+esc+"%", esc+"%", "%"+esc+"%")
+	}
+	vars = append(vars, strings.Join(terms, " "))
+
+	return clause.Expr{
+		SQL:                "(" + strings.Join(parts, " + ") + ") DESC, similarity(search_name, ?) DESC, created_at DESC, id DESC",
+		Vars:               vars,
+		WithoutParentheses: true,
+	}
 }
 
 // IDで商品を取得
@@ -82,6 +198,8 @@ func (r *ProductGormRepository) FindByID(ctx context.Context, id int64) (model.P
 
 // 商品の作成
 func (r *ProductGormRepository) Create(ctx context.Context, p model.Product) (model.Product, error) {
+	p.SearchName = textsearch.Normalize(p.Name)
+	p.SearchDescription = textsearch.Normalize(p.Description)
 	if err := r.db.WithContext(ctx).Create(&p).Error; err != nil {
 		return model.Product{}, err
 	}
@@ -96,6 +214,9 @@ func (r *ProductGormRepository) Update(ctx context.Context, p model.Product) err
 		"price":       p.Price,
 		"stock":       p.Stock,
 		"is_active":   p.IsActive,
+
+		"search_name":        textsearch.Normalize(p.Name),
+		"search_description": textsearch.Normalize(p.Description),
 	})
 	if res.Error != nil {
 		return res.Error
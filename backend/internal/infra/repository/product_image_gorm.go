@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type productImageGormRepository struct {
+	db *gorm.DB
+}
+
+// DI
+func NewProductImageGormRepository(db *gorm.DB) repo.ProductImageRepository {
+	return &productImageGormRepository{db: db}
+}
+
+// 画像を登録
+func (r *productImageGormRepository) Create(ctx context.Context, img model.ProductImage) (model.ProductImage, error) {
+	if err := r.db.WithContext(ctx).Create(&img).Error; err != nil {
+		return model.ProductImage{}, err
+	}
+	return img, nil
+}
+
+// IDで1件取得
+func (r *productImageGormRepository) FindByID(ctx context.Context, imageID int64) (model.ProductImage, error) {
+	var img model.ProductImage
+	err := r.db.WithContext(ctx).First(&img, imageID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.ProductImage{}, repo.ErrNotFound
+	}
+	if err != nil {
+		return model.ProductImage{}, err
+	}
+	return img, nil
+}
+
+// 商品の画像（並び順）
+func (r *productImageGormRepository) ListByProductID(ctx context.Context, productID int64) ([]model.ProductImage, error) {
+	var list []model.ProductImage
+	if err := r.db.WithContext(ctx).
+		Where("product_id = ?", productID).
+		Order("position ASC, id ASC").
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// 複数商品の画像をまとめて取得（N+1にしない）
+func (r *productImageGormRepository) ListByProductIDs(ctx context.Context, productIDs []int64) ([]model.ProductImage, error) {
+	if len(productIDs) == 0 {
+		return nil, nil
+	}
+	var list []model.ProductImage
+	if err := r.db.WithContext(ctx).
+		Where("product_id IN ?", productIDs).
+		Order("product_id ASC, position ASC, id ASC").
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// 並び替え（途中で失敗したら全部戻す）
+func (r *productImageGormRepository) UpdatePositions(ctx context.Context, productID int64, imageIDs []int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, id := range imageIDs {
+			res := tx.Model(&model.ProductImage{}).
+				Where("id = ? AND product_id = ?", id, productID).
+				Update("position", i)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return repo.ErrNotFound
+			}
+		}
+		return nil
+	})
+}
+
+// 画像を削除
+func (r *productImageGormRepository) Delete(ctx context.Context, imageID int64) error {
+	res := r.db.WithContext(ctx).Delete(&model.ProductImage{}, imageID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
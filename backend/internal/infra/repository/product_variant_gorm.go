@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type ProductVariantGormRepository struct {
+	db *gorm.DB
+}
+
+// DI
+func NewProductVariantGormRepository(db *gorm.DB) *ProductVariantGormRepository {
+	return &ProductVariantGormRepository{db: db}
+}
+
+// 商品の軸（並び順）
+func (r *ProductVariantGormRepository) ListOptions(ctx context.Context, productID int64) ([]model.ProductOption, error) {
+	var options []model.ProductOption
+	if err := r.db.WithContext(ctx).
+		Where("product_id = ?", productID).
+		Order("position ASC, id ASC").
+		Find(&options).Error; err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// 軸を入れ替える（消して入れ直す）
+func (r *ProductVariantGormRepository) ReplaceOptions(ctx context.Context, productID int64, names []string) ([]model.ProductOption, error) {
+	options := make([]model.ProductOption, 0, len(names))
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).Delete(&model.ProductOption{}).Error; err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		for i, name := range names {
+			options = append(options, model.ProductOption{ProductID: productID, Name: name, Position: i, CreatedAt: now})
+		}
+		return tx.Create(&options).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// 削除されていないバリエーション
+func (r *ProductVariantGormRepository) ListByProductID(ctx context.Context, productID int64) ([]model.ProductVariant, error) {
+	var variants []model.ProductVariant
+	if err := r.db.WithContext(ctx).
+		Preload("Values").
+		Where("product_id = ?", productID).
+		Order("id ASC").
+		Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+// IDで1件取得
+func (r *ProductVariantGormRepository) FindByID(ctx context.Context, variantID int64) (model.ProductVariant, error) {
+	var v model.ProductVariant
+	err := r.db.WithContext(ctx).Preload("Values").First(&v, variantID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.ProductVariant{}, repo.ErrNotFound
+	}
+	if err != nil {
+		return model.ProductVariant{}, err
+	}
+	return v, nil
+}
+
+// SKUで1件取得（削除済みも含めて一意なのでUnscoped）
+func (r *ProductVariantGormRepository) FindBySKU(ctx context.Context, sku string) (model.ProductVariant, error) {
+	var v model.ProductVariant
+	err := r.db.WithContext(ctx).Unscoped().Where("sku = ?", sku).First(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.ProductVariant{}, repo.ErrNotFound
+	}
+	if err != nil {
+		return model.ProductVariant{}, err
+	}
+	return v, nil
+}
+
+// バリエーションを作成（Valuesも同じトランザクションで作られる）
+func (r *ProductVariantGormRepository) Create(ctx context.Context, v model.ProductVariant) (model.ProductVariant, error) {
+	if err := r.db.WithContext(ctx).Create(&v).Error; err != nil {
+		return model.ProductVariant{}, err
+	}
+	return v, nil
+}
+
+// sku・price・is_activeを更新
+func (r *ProductVariantGormRepository) Update(ctx context.Context, v model.ProductVariant) error {
+	res := r.db.WithContext(ctx).
+		Model(&model.ProductVariant{}).
+		Where("id = ?", v.ID).
+		Updates(map[string]any{
+			"sku":        v.SKU,
+			"price":      v.Price,
+			"is_active":  v.IsActive,
+			"updated_at": time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+// 論理削除（注文明細から参照されるので行は残す）
+func (r *ProductVariantGormRepository) SoftDelete(ctx context.Context, variantID int64) error {
+	res := r.db.WithContext(ctx).Delete(&model.ProductVariant{}, variantID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
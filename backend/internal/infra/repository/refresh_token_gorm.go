@@ -77,6 +77,24 @@ func (r *refreshTokenGormRepository) DeleteByUserID(ctx context.Context, userID
 	return nil
 }
 
+// 指定系列のリフレッシュトークンを全削除。
+func (r *refreshTokenGormRepository) DeleteByFamilyID(ctx context.Context, familyID string) (int64, error) {
+	//空（系列導入前のtoken）で消すと全ユーザー分が消えるので何もしない
+	if familyID == "" {
+		return 0, nil
+	}
+
+	result := r.db.WithContext(ctx).
+		Where("family_id = ?", familyID).
+		Delete(&model.RefreshToken{})
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
 // 指定IDのリフレッシュトークンを削除。
 func (r *refreshTokenGormRepository) DeleteByID(ctx context.Context, tokenID string) error {
 	result := r.db.WithContext(ctx).
@@ -105,3 +123,52 @@ func (r *refreshTokenGormRepository) DeleteExpired(ctx context.Context, now time
 
 	return result.RowsAffected, nil
 }
+
+// IDで1件検索。
+func (r *refreshTokenGormRepository) FindByID(ctx context.Context, tokenID string) (model.RefreshToken, bool, error) {
+	var token model.RefreshToken
+
+	err := r.db.WithContext(ctx).
+		Where("id = ?", tokenID).
+		First(&token).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.RefreshToken{}, false, nil
+		}
+		return model.RefreshToken{}, false, err
+	}
+
+	return token, true, nil
+}
+
+// 未使用・期限内のrefreshを新しい順に取得。
+func (r *refreshTokenGormRepository) ListActiveByUserID(ctx context.Context, userID int64, now time.Time) ([]model.RefreshToken, error) {
+	var tokens []model.RefreshToken
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND used_at IS NULL AND expires_at > ?", userID, now).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// keepID以外のrefreshを削除し、削除件数を返す。
+func (r *refreshTokenGormRepository) DeleteByUserIDExceptFamily(ctx context.Context, userID int64, keepFamilyID string) (int64, error) {
+	q := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if keepFamilyID != "" {
+		//系列導入前のtokenは自分のIDが系列ID
+		q = q.Where("family_id <> ? AND NOT (family_id = '' AND id = ?)", keepFamilyID, keepFamilyID)
+	}
+
+	result := q.Delete(&model.RefreshToken{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type rolePermissionGormRepository struct {
+	db *gorm.DB
+}
+
+// DI
+func NewRolePermissionGormRepository(db *gorm.DB) repo.RolePermissionRepository {
+	return &rolePermissionGormRepository{db: db}
+}
+
+func (r *rolePermissionGormRepository) ListAll(ctx context.Context) ([]model.RolePermission, error) {
+	var list []model.RolePermission
+
+	if err := r.db.WithContext(ctx).
+		Order("role ASC, permission ASC").
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (r *rolePermissionGormRepository) ListByRole(ctx context.Context, role model.Role) ([]string, error) {
+	var perms []string
+
+	if err := r.db.WithContext(ctx).
+		Model(&model.RolePermission{}).
+		Where("role = ?", role).
+		Order("permission ASC").
+		Pluck("permission", &perms).Error; err != nil {
+		return nil, err
+	}
+
+	return perms, nil
+}
+
+func (r *rolePermissionGormRepository) HasPermission(ctx context.Context, role model.Role, permission string) (bool, error) {
+	var count int64
+
+	if err := r.db.WithContext(ctx).
+		Model(&model.RolePermission{}).
+		Where("role = ? AND permission = ?", role, permission).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// 消してから入れ直す
+func (r *rolePermissionGormRepository) ReplaceRole(ctx context.Context, role model.Role, permissions []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role = ?", role).Delete(&model.RolePermission{}).Error; err != nil {
+			return err
+		}
+		if len(permissions) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		rows := make([]model.RolePermission, 0, len(permissions))
+		for _, p := range permissions {
+			rows = append(rows, model.RolePermission{Role: role, Permission: p, CreatedAt: now})
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+func (r *rolePermissionGormRepository) DeleteRole(ctx context.Context, role model.Role) (bool, error) {
+	res := r.db.WithContext(ctx).Where("role = ?", role).Delete(&model.RolePermission{})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type securityEventGormRepository struct {
+	db *gorm.DB
+}
+
+func NewSecurityEventGormRepository(db *gorm.DB) repo.SecurityEventRepository {
+	return &securityEventGormRepository{db: db}
+}
+
+func (r *securityEventGormRepository) Create(ctx context.Context, ev model.SecurityEvent) error {
+	if err := r.db.WithContext(ctx).Create(&ev).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *securityEventGormRepository) AnonymizeByUserID(ctx context.Context, userID int64) error {
+	return r.db.WithContext(ctx).
+		Model(&model.SecurityEvent{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]any{"email": "", "ip": "", "user_agent": ""}).Error
+}
+
+func (r *securityEventGormRepository) List(ctx context.Context, filter repo.SecurityEventFilter) ([]model.SecurityEvent, error) {
+	q := r.db.WithContext(ctx).Model(&model.SecurityEvent{})
+
+	if filter.UserID != nil {
+		q = q.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Type != nil {
+		q = q.Where("type = ?", *filter.Type)
+	}
+	if filter.Outcome != nil {
+		q = q.Where("outcome = ?", *filter.Outcome)
+	}
+	if filter.IP != "" {
+		q = q.Where("ip = ?", filter.IP)
+	}
+	if filter.Email != "" {
+		q = q.Where("email = ?", filter.Email)
+	}
+	if filter.CreatedFrom != nil {
+		q = q.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		q = q.Where("created_at <= ?", *filter.CreatedTo)
+	}
+
+	//新しい順
+	q = q.Order("id DESC")
+
+	// limit/offset
+	limit := filter.Limit
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	q = q.Limit(limit).Offset(filter.Offset)
+
+	var events []model.SecurityEvent
+	if err := q.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
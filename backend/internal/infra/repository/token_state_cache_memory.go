@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+)
+
+type tokenStateEntry struct {
+	state     model.TokenState
+	expiresAt time.Time
+}
+
+// ノードごとのキャッシュ。TTLで必ず切れるので、通知を取りこぼしてもTTL以上は古くならない
+type tokenStateMemoryCache struct {
+	mu         sync.RWMutex
+	ttl        time.Duration
+	maxEntries int
+	gen        uint64
+	entries    map[int64]tokenStateEntry
+}
+
+// DI
+func NewTokenStateMemoryCache(ttl time.Duration, maxEntries int) repo.TokenStateCache {
+	return &tokenStateMemoryCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    map[int64]tokenStateEntry{},
+	}
+}
+
+func (c *tokenStateMemoryCache) Get(ctx context.Context, userID int64) (model.TokenState, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.entries[userID]
+	if !ok || !time.Now().Before(e.expiresAt) {
+		return model.TokenState{}, false
+	}
+	return e.state, true
+}
+
+func (c *tokenStateMemoryCache) Generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gen
+}
+
+func (c *tokenStateMemoryCache) Set(ctx context.Context, st model.TokenState, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	//読んでいる間に破棄があった（古い値の可能性がある）
+	if gen != c.gen {
+		return
+	}
+
+	now := time.Now()
+	if len(c.entries) >= c.maxEntries {
+		c.pruneLocked(now)
+	}
+	//期限内のものだけで埋まっていたら入れない（次のリクエストでDBを読むだけ）
+	if len(c.entries) >= c.maxEntries {
+		return
+	}
+
+	c.entries[st.UserID] = tokenStateEntry{state: st, expiresAt: now.Add(c.ttl)}
+}
+
+func (c *tokenStateMemoryCache) Invalidate(ctx context.Context, userID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	delete(c.entries, userID)
+}
+
+func (c *tokenStateMemoryCache) Clear(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.entries = map[int64]tokenStateEntry{}
+}
+
+// 期限切れを消す（mu取得済みで呼ぶ）
+func (c *tokenStateMemoryCache) pruneLocked(now time.Time) {
+	for id, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, id)
+		}
+	}
+}
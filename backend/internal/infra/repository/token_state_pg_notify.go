@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	repo "app/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// token_versionのキャッシュ破棄を流すチャンネル（payloadはuser_id）
+const TokenStateInvalidationChannel = "token_state_invalidate"
+
+// 再接続までの待ち時間
+const (
+	tokenStateListenRetryMin = time.Second
+	tokenStateListenRetryMax = 30 * time.Second
+)
+
+// 複数ノード用：NOTIFYで他ノードに破棄を知らせる
+type tokenStatePgPublisher struct {
+	db *gorm.DB
+}
+
+// DI
+func NewTokenStatePgPublisher(db *gorm.DB) repo.TokenStateInvalidationPublisher {
+	return &tokenStatePgPublisher{db: db}
+}
+
+func (p *tokenStatePgPublisher) Publish(ctx context.Context, userID int64) error {
+	return p.db.WithContext(ctx).
+		Exec("SELECT pg_notify(?, ?)", TokenStateInvalidationChannel, strconv.FormatInt(userID, 10)).
+		Error
+}
+
+// LISTENして、届いたuser_idをこのノードのキャッシュから消す（ctxが終わるまで戻らない）。
+// 切断中の通知は届かないので、つなぎ直したらキャッシュを全部捨てる
+func ListenTokenStateInvalidations(ctx context.Context, dsn string, cache repo.TokenStateCache) {
+	wait := tokenStateListenRetryMin
+	for {
+		err := listenTokenStateInvalidations(ctx, dsn, cache)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("token state listener disconnected: err=%v (retry in %s)", err, wait)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait *= 2
+		if wait > tokenStateListenRetryMax {
+			wait = tokenStateListenRetryMax
+		}
+	}
+}
+
+func listenTokenStateInvalidations(ctx context.Context, dsn string, cache repo.TokenStateCache) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+TokenStateInvalidationChannel); err != nil {
+		return err
+	}
+	cache.Clear(ctx)
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		userID, err := strconv.ParseInt(n.Payload, 10, 64)
+		if err != nil {
+			continue
+		}
+		cache.Invalidate(ctx, userID)
+	}
+}
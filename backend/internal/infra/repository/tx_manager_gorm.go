@@ -15,14 +15,16 @@ type txReposGorm struct {
 	cartItems  repo.CartItemRepository
 	inventory  repo.InventoryRepository
 	products   repo.ProductRepository
+	variants   repo.ProductVariantRepository
 }
 
-func (r *txReposGorm) Orders() repo.OrderRepository         { return r.orders }
-func (r *txReposGorm) OrderItems() repo.OrderItemRepository { return r.orderItems }
-func (r *txReposGorm) Carts() repo.CartRepository           { return r.carts }
-func (r *txReposGorm) CartItems() repo.CartItemRepository   { return r.cartItems }
-func (r *txReposGorm) Inventory() repo.InventoryRepository  { return r.inventory }
-func (r *txReposGorm) Products() repo.ProductRepository     { return r.products }
+func (r *txReposGorm) Orders() repo.OrderRepository            { return r.orders }
+func (r *txReposGorm) OrderItems() repo.OrderItemRepository    { return r.orderItems }
+func (r *txReposGorm) Carts() repo.CartRepository              { return r.carts }
+func (r *txReposGorm) CartItems() repo.CartItemRepository      { return r.cartItems }
+func (r *txReposGorm) Inventory() repo.InventoryRepository     { return r.inventory }
+func (r *txReposGorm) Products() repo.ProductRepository        { return r.products }
+func (r *txReposGorm) Variants() repo.ProductVariantRepository { return r.variants }
 
 type TxManagerGorm struct {
 	db *gorm.DB
@@ -42,6 +44,7 @@ func (tm *TxManagerGorm) WithinTx(ctx context.Context, fn func(r repo.TxRepos) e
 			cartItems:  NewCartGormRepository(tx),
 			inventory:  NewInventoryGormRepository(tx),
 			products:   NewProductGormRepository(tx),
+			variants:   NewProductVariantGormRepository(tx),
 		}
 		return fn(r)
 	})
@@ -5,6 +5,8 @@ import (
 	domainrepo "app/internal/repository"
 	"context"
 	"errors"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -88,3 +90,78 @@ func (r *userGormRepository) IncrementTokenVersion(ctx context.Context, id int64
 	}
 	return nil
 }
+
+// token_versionとis_activeだけ取得（リクエストごとのtv確認用）
+func (r *userGormRepository) FindTokenState(ctx context.Context, id int64) (*model.TokenState, error) {
+	var st model.TokenState
+
+	res := r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Select("id AS user_id, token_version, is_active").
+		Where("id = ?", id).
+		Limit(1).
+		Scan(&st)
+
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &st, nil
+}
+
+// 管理者用のユーザー一覧
+func (r *userGormRepository) List(ctx context.Context, f domainrepo.AdminUserListFilter) ([]model.User, int64, error) {
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.Limit <= 0 || f.Limit > 100 {
+		f.Limit = 50
+	}
+
+	q := r.db.WithContext(ctx).Model(&model.User{})
+
+	//email 部分一致（%と_はそのまま文字として扱う）
+	if f.Email != "" {
+		q = q.Where("email ILIKE ?", "%"+escapeLike(f.Email)+"%")
+	}
+	if f.Role != nil {
+		q = q.Where("role = ?", *f.Role)
+	}
+	if f.IsActive != nil {
+		q = q.Where("is_active = ?", *f.IsActive)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return []model.User{}, 0, err
+	}
+
+	var items []model.User
+	offset := (f.Page - 1) * f.Limit
+	if err := q.Order("id asc").Limit(f.Limit).Offset(offset).Find(&items).Error; err != nil {
+		return []model.User{}, 0, err
+	}
+
+	return items, total, nil
+}
+
+// 猶予期間が過ぎた退会申請（古い順）
+func (r *userGormRepository) ListDeletionDue(ctx context.Context, now time.Time, limit int) ([]model.User, error) {
+	var items []model.User
+	err := r.db.WithContext(ctx).
+		Where("deletion_scheduled_at <= ? AND anonymized_at IS NULL", now).
+		Order("deletion_scheduled_at asc").
+		Limit(limit).
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// LIKEの特殊文字をエスケープ（postgresのデフォルトのエスケープ文字は\）
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
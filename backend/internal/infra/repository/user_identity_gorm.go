@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type userIdentityGormRepository struct {
+	db *gorm.DB
+}
+
+// DI
+func NewUserIdentityGormRepository(db *gorm.DB) repo.UserIdentityRepository {
+	return &userIdentityGormRepository{db: db}
+}
+
+// 紐付けを保存する（一意制約違反はそのままエラー）
+func (r *userIdentityGormRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+// provider + subject で1件検索。
+func (r *userIdentityGormRepository) FindByProviderSubject(ctx context.Context, provider string, subject string) (model.UserIdentity, bool, error) {
+	var identity model.UserIdentity
+
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.UserIdentity{}, false, nil
+		}
+		return model.UserIdentity{}, false, err
+	}
+
+	return identity, true, nil
+}
+
+// そのユーザーの紐付けを古い順に返す
+func (r *userIdentityGormRepository) ListByUserID(ctx context.Context, userID int64) ([]model.UserIdentity, error) {
+	var list []model.UserIdentity
+
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// last_login_at を更新
+func (r *userIdentityGormRepository) TouchLastLogin(ctx context.Context, id string, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.UserIdentity{}).
+		Where("id = ?", id).
+		Update("last_login_at", &at).Error
+}
+
+// user_idも条件に入れて、他人の紐付けは消せないようにする
+func (r *userIdentityGormRepository) DeleteByIDForUser(ctx context.Context, id string, userID int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&model.UserIdentity{})
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// そのユーザーの紐付けを全部削除
+func (r *userIdentityGormRepository) DeleteByUserID(ctx context.Context, userID int64) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&model.UserIdentity{}).Error
+}
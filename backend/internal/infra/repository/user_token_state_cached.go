@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"app/internal/domain/model"
+	domainrepo "app/internal/repository"
+)
+
+// UserRepositoryにtoken_versionのキャッシュを足す。
+// FindTokenStateだけキャッシュから返し、Update・IncrementTokenVersionのたびに破棄する（他ノードにも通知）
+type tokenStateCachedUserRepository struct {
+	domainrepo.UserRepository
+	cache     domainrepo.TokenStateCache
+	publisher domainrepo.TokenStateInvalidationPublisher
+}
+
+// DI（publisherは単一ノードならnil）
+func NewTokenStateCachedUserRepository(inner domainrepo.UserRepository, cache domainrepo.TokenStateCache, publisher domainrepo.TokenStateInvalidationPublisher) domainrepo.UserRepository {
+	return &tokenStateCachedUserRepository{UserRepository: inner, cache: cache, publisher: publisher}
+}
+
+func (r *tokenStateCachedUserRepository) FindTokenState(ctx context.Context, id int64) (*model.TokenState, error) {
+	if st, ok := r.cache.Get(ctx, id); ok {
+		return &st, nil
+	}
+
+	gen := r.cache.Generation()
+	st, err := r.UserRepository.FindTokenState(ctx, id)
+	if err != nil || st == nil {
+		return st, err
+	}
+	r.cache.Set(ctx, *st, gen)
+	return st, nil
+}
+
+// is_active・roleの変更もあるので破棄する
+func (r *tokenStateCachedUserRepository) Update(ctx context.Context, user *model.User) error {
+	err := r.UserRepository.Update(ctx, user)
+	r.invalidate(ctx, user.ID)
+	return err
+}
+
+func (r *tokenStateCachedUserRepository) IncrementTokenVersion(ctx context.Context, id int64) error {
+	err := r.UserRepository.IncrementTokenVersion(ctx, id)
+	r.invalidate(ctx, id)
+	return err
+}
+
+// 失敗しても書き込みが済んでいる可能性があるので、エラーでも破棄する
+func (r *tokenStateCachedUserRepository) invalidate(ctx context.Context, id int64) {
+	r.cache.Invalidate(ctx, id)
+	if r.publisher == nil {
+		return
+	}
+	//通知できなくても他ノードはTTLで切れる
+	if err := r.publisher.Publish(ctx, id); err != nil {
+		log.Printf("token state invalidation publish failed: user_id=%d err=%v", id, err)
+	}
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"app/internal/usecase"
+)
+
+// ローカルディスクに置く（開発・単一ノード用）。配信は baseURL 配下の静的ファイルとして行う
+type localStorage struct {
+	dir     string
+	baseURL string
+}
+
+// DI
+func NewLocalStorage(dir string, baseURL string) (usecase.FileStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("upload dir: %w", err)
+	}
+	return &localStorage{dir: dir, baseURL: strings.TrimRight(baseURL, "/")}, nil
+}
+
+func (s *localStorage) Put(ctx context.Context, key string, contentType string, data []byte) error {
+	p, err := s.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	//書きかけのファイルを配信しないよう、一時ファイルに書いてから置き換える
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+func (s *localStorage) Delete(ctx context.Context, key string) error {
+	p, err := s.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *localStorage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// keyをdir配下のパスにする（dirの外を指すkeyは受け付けない）
+func (s *localStorage) filePath(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", fmt.Errorf("invalid storage key: %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
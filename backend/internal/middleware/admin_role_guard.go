@@ -3,12 +3,15 @@ package middleware
 import (
 	"net/http"
 
+	"app/internal/config"
+
 	"github.com/labstack/echo/v4"
 )
 
 //contextに入っているroleがADMINかどうかを確認します。
+//MFA_REQUIRED_FOR_ADMIN=true なら2FA未設定のADMINも拒否します。
 
-func AdminRoleGuard() echo.MiddlewareFunc {
+func AdminRoleGuard(cfg config.Config) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			rawRole := c.Get(CtxUserRoleKey)
@@ -22,6 +25,40 @@ func AdminRoleGuard() echo.MiddlewareFunc {
 				return c.JSON(http.StatusForbidden, errorJSON("admin only"))
 			}
 
+			//2FA必須の設定なら、mfa=trueのtokenだけ許可
+			if cfg.MfaRequiredForAdmin {
+				mfa, _ := c.Get(CtxMfaKey).(bool)
+				if !mfa {
+					return c.JSON(http.StatusForbidden, errorJSON("mfa required"))
+				}
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// USER以外（ADMIN・スタッフのロール）を通す。何ができるかはルートごとの RequirePermission で確認する。
+// MFA_REQUIRED_FOR_ADMIN=true ならスタッフも2FA必須。
+func StaffRoleGuard(cfg config.Config) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			role, ok := c.Get(CtxUserRoleKey).(string)
+			if !ok || role == "" {
+				return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+			}
+
+			if role == "USER" {
+				return c.JSON(http.StatusForbidden, errorJSON("staff only"))
+			}
+
+			if cfg.MfaRequiredForAdmin {
+				mfa, _ := c.Get(CtxMfaKey).(bool)
+				if !mfa {
+					return c.JSON(http.StatusForbidden, errorJSON("mfa required"))
+				}
+			}
+
 			return next(c)
 		}
 	}
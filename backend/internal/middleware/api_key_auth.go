@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// サーバー間連携用のAPIキーのヘッダ
+const HeaderApiKey = "X-API-Key"
+
+const (
+	CtxApiKeyIDKey     = "api_key_id"     // int64
+	CtxApiKeyScopesKey = "api_key_scopes" // []string
+)
+
+// APIキーの検証（usecase.ApiKeyUsecase が実装）
+type ApiKeyAuthenticator interface {
+	Authenticate(ctx context.Context, plain string) (*model.ApiKey, error)
+}
+
+// /admin 用の認証。X-API-Keyがあればキーで、無ければ
+// 「JWT必須 + token_version一致 + スタッフ以上」で認証する（権限はルートごとにRequirePermission）。
+// キーの場合、user_idはキーを発行した管理者（監査ログにはキーIDも残る）
+func AdminAuth(cfg config.Config, userRepo repository.UserRepository, apiKeys ApiKeyAuthenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		jwtChain := AuthJWT(cfg)(TokenVersionGuard(userRepo)(StaffRoleGuard(cfg)(next)))
+
+		return func(c echo.Context) error {
+			plain := strings.TrimSpace(c.Request().Header.Get(HeaderApiKey))
+			if plain == "" {
+				return jwtChain(c)
+			}
+
+			key, err := apiKeys.Authenticate(c.Request().Context(), plain)
+			if err != nil || key == nil {
+				return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+			}
+
+			c.Set(CtxUserIDKey, key.CreatedByUserID)
+			c.Set(CtxUserRoleKey, string(model.RoleAdmin))
+			c.Set(CtxApiKeyIDKey, key.ID)
+			c.Set(CtxApiKeyScopesKey, key.ScopeList())
+			c.SetRequest(c.Request().WithContext(usecase.WithApiKeyActor(c.Request().Context(), key.ID)))
+
+			return next(c)
+		}
+	}
+}
+
+// APIキーのリクエストはscopeを持っているときだけ通す（JWTの管理者はそのまま通す）
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Get(CtxApiKeyIDKey) == nil {
+				return next(c)
+			}
+
+			scopes, _ := c.Get(CtxApiKeyScopesKey).([]string)
+			for _, s := range scopes {
+				if s == scope {
+					return next(c)
+				}
+			}
+			return c.JSON(http.StatusForbidden, errorJSON("insufficient scope"))
+		}
+	}
+}
@@ -16,10 +16,14 @@ const (
 	CtxUserIDKey       = "user_id"       // int64
 	CtxUserRoleKey     = "user_role"     // string
 	CtxTokenVersionKey = "token_version" // int
+	CtxMfaKey          = "mfa"           // bool（このセッションのログインで2FAを通ったか）
+	CtxImpersonatorKey = "impersonator"  // int64（なりすまし中なら操作している管理者のID）
 )
 
 // bearerAuth用のJWT検証ミドルウェア。
 func AuthJWT(cfg config.Config) echo.MiddlewareFunc {
+	keys := cfg.JWTKeySet()
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			//Authorizationヘッダを取得
@@ -38,13 +42,8 @@ func AuthJWT(cfg config.Config) echo.MiddlewareFunc {
 				return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
 			}
 
-			//JWTをパースして検証する
-			token, err := jwt.Parse(rawToken, func(t *jwt.Token) (interface{}, error) {
-				if t.Method != jwt.SigningMethodHS256 {
-					return nil, errors.New("unexpected signing method")
-				}
-				return []byte(cfg.JWTSecret), nil
-			})
+			//JWTをパースして検証する（kidで鍵を選び、algも鍵と一致するか確認）
+			token, err := jwt.Parse(rawToken, keys.Keyfunc)
 			if err != nil || token == nil || !token.Valid {
 				return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
 			}
@@ -55,6 +54,11 @@ func AuthJWT(cfg config.Config) echo.MiddlewareFunc {
 				return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
 			}
 
+			//access token以外（MFAチャレンジなど）は受け付けない
+			if _, hasTyp := claims["typ"]; hasTyp {
+				return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+			}
+
 			//user_idを取り出す
 
 			userID, err := parseUserID(claims["sub"])
@@ -74,10 +78,31 @@ func AuthJWT(cfg config.Config) echo.MiddlewareFunc {
 				return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
 			}
 
+			//mfa（古いtokenには無いのでfalse扱い）
+			mfa, _ := claims["mfa"].(bool)
+
+			//act（なりすまし）があれば管理者IDを取り出す
+			var actorID int64
+			if act, hasAct := claims["act"]; hasAct {
+				actorID, err = parseActor(act)
+				if err != nil || actorID <= 0 {
+					return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+				}
+			}
+
 			//contextへ保存
 			c.Set(CtxUserIDKey, userID)
 			c.Set(CtxUserRoleKey, role)
 			c.Set(CtxTokenVersionKey, tv)
+			c.Set(CtxMfaKey, mfa)
+			if actorID > 0 {
+				c.Set(CtxImpersonatorKey, actorID)
+			}
+
+			//なりすまし中は読み取りだけ（注文・住所の変更などはさせない）
+			if actorID > 0 && !isReadOnlyMethod(c.Request().Method) {
+				return c.JSON(http.StatusForbidden, errorJSON("read only while impersonating"))
+			}
 
 			return next(c)
 		}
@@ -104,6 +129,23 @@ func parseUserID(v interface{}) (int64, error) {
 	}
 }
 
+// act claim（{"sub": 管理者ID}）から管理者IDを取り出す
+func parseActor(v interface{}) (int64, error) {
+	act, ok := v.(map[string]interface{})
+	if !ok {
+		return 0, errors.New("invalid act")
+	}
+	return parseUserID(act["sub"])
+}
+
+func isReadOnlyMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
 func parseString(v interface{}) (string, error) {
 	s, ok := v.(string)
 	if !ok {
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net"
+
+	"github.com/labstack/echo/v4"
+)
+
+// c.RealIP()の取り出し方（e.IPExtractorに設定する）。
+// 信頼するプロキシが無ければ接続元のアドレスだけを使い、X-Forwarded-For/X-Real-IPは無視する。
+// あればX-Forwarded-Forを右からたどり、信頼するプロキシ以外で最初のアドレスを使う
+// （private/loopbackも明示しない限り信頼しない）
+func ClientIPExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	opts := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, r := range trustedProxies {
+		opts = append(opts, echo.TrustIPRange(r))
+	}
+	return echo.ExtractIPFromXFFHeader(opts...)
+}
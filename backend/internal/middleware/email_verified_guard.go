@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"app/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// メールアドレス確認済みのユーザーだけ通す（TokenVersionGuardの後ろで使う）
+func EmailVerifiedGuard(userRepo repository.UserRepository) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			rawUserID := c.Get(CtxUserIDKey)
+			userID, ok := rawUserID.(int64)
+			if !ok || userID <= 0 {
+				return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+			}
+
+			user, err := userRepo.FindByID(c.Request().Context(), userID)
+			if err != nil || user == nil {
+				return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+			}
+
+			//未確認は403
+			if user.EmailVerifiedAt == nil {
+				return c.JSON(http.StatusForbidden, errorJSON("email not verified"))
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// なりすまし中のリクエストの記録（usecase.ImpersonationUsecase が実装）
+type ImpersonationRecorder interface {
+	RecordImpersonatedRequest(ctx context.Context, actorUserID int64, userID int64, method string, path string, status int) error
+}
+
+// なりすまし中（AuthJWTがCtxImpersonatorKeyを入れた）のリクエストを、管理者と顧客のIDつきで監査ログに残す。
+// AuthJWTはルートごとに付いているので、e.Use で全体に付けて、処理が終わってから確認する。
+// 拒否されたリクエスト（書き込みなど）もステータスつきで残る
+func ImpersonationAudit(rec ImpersonationRecorder) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+
+			actorID, ok := c.Get(CtxImpersonatorKey).(int64)
+			if !ok || actorID <= 0 {
+				return err
+			}
+			userID, _ := c.Get(CtxUserIDKey).(int64)
+
+			status := responseStatus(c, err)
+			if rerr := rec.RecordImpersonatedRequest(c.Request().Context(), actorID, userID, c.Request().Method, c.Request().URL.RequestURI(), status); rerr != nil {
+				log.Printf("impersonation audit failed: actor=%d user=%d path=%s err=%v", actorID, userID, c.Request().URL.Path, rerr)
+			}
+
+			return err
+		}
+	}
+}
+
+// ハンドラがerrorを返したときは、まだレスポンスが書かれていないのでerrorからステータスを決める
+func responseStatus(c echo.Context, err error) int {
+	if err == nil || c.Response().Committed {
+		return c.Response().Status
+	}
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return he.Code
+	}
+	return http.StatusInternalServerError
+}
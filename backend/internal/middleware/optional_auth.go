@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"app/internal/config"
+	"app/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ゲストも使えるルート用の認証。Authorizationヘッダがあれば
+// 「JWT必須 + token_version一致」で認証し、無ければuser_id無しのまま通す
+// （不正なtokenはゲスト扱いにせず401）
+func OptionalAuth(cfg config.Config, userRepo repository.UserRepository) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		jwtChain := AuthJWT(cfg)(TokenVersionGuard(userRepo)(next))
+
+		return func(c echo.Context) error {
+			if c.Request().Header.Get("Authorization") != "" {
+				return jwtChain(c)
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ロールが権限を持っているか（usecase.RbacUsecase が実装）
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, role string, permission string) (bool, error)
+}
+
+// contextのroleがpermissionを持っているときだけ通す（StaffRoleGuardの後ろで使う）
+func RequirePermission(perms PermissionChecker, permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			role, ok := c.Get(CtxUserRoleKey).(string)
+			if !ok || role == "" {
+				return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
+			}
+
+			allowed, err := perms.HasPermission(c.Request().Context(), role, permission)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, errorJSON("internal error"))
+			}
+			if !allowed {
+				return c.JSON(http.StatusForbidden, errorJSON("permission denied"))
+			}
+
+			return next(c)
+		}
+	}
+}
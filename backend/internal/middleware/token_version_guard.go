@@ -8,7 +8,7 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-// JWTのtvとDBのtoken_versionの一致するか確認。
+// JWTのtvとDBのtoken_versionの一致するか確認（無効化されたユーザーも弾く）。
 func TokenVersionGuard(userRepo repository.UserRepository) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -26,14 +26,14 @@ func TokenVersionGuard(userRepo repository.UserRepository) echo.MiddlewareFunc {
 				return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
 			}
 
-			//DBから最新のuserを取得する
-			user, err := userRepo.FindByID(c.Request().Context(), userID)
-			if err != nil || user == nil {
+			//最新のtoken_version・is_activeを取得する（キャッシュがあればDBは読まない）
+			st, err := userRepo.FindTokenState(c.Request().Context(), userID)
+			if err != nil || st == nil {
 				return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
 			}
 
-			//token_version が一致しなければ強制ログアウト扱い（401）
-			if user.TokenVersion != tv {
+			//token_version が一致しない・無効化されたユーザーは強制ログアウト扱い（401）
+			if st.TokenVersion != tv || !st.IsActive {
 				return c.JSON(http.StatusUnauthorized, errorJSON("unauthorized"))
 			}
 
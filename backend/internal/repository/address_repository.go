@@ -28,4 +28,7 @@ type AddressRepository interface {
 
 	//住所の切り替えを行う。
 	SetDefault(ctx context.Context, userID, addressID int64) error
+
+	//そのユーザーの住所を全部匿名化する（退会時。注文から参照されているので行は消さない）
+	AnonymizeByUserID(ctx context.Context, userID int64) error
 }
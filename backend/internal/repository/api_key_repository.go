@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"app/internal/domain/model"
+)
+
+// APIキーの保存・取得・失効
+type ApiKeyRepository interface {
+	//新規作成（IDが入る）
+	Create(ctx context.Context, key *model.ApiKey) error
+	//key_hashで検索。見つからなければfalse。
+	FindByHash(ctx context.Context, keyHash string) (model.ApiKey, bool, error)
+	//IDで検索。見つからなければfalse。
+	FindByID(ctx context.Context, id int64) (model.ApiKey, bool, error)
+	//全件を新しい順に返す（失効済みも含む）
+	List(ctx context.Context) ([]model.ApiKey, error)
+	//失効させる。未失効のキーが無ければfalse
+	Revoke(ctx context.Context, id int64, at time.Time) (bool, error)
+	//last_used_at を更新
+	TouchLastUsed(ctx context.Context, id int64, at time.Time) error
+}
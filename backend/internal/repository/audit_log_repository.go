@@ -10,14 +10,15 @@ import (
 //監査ログの絞り込み条件。
 
 type AuditLogFilter struct {
-	ActorUserID  *int64
-	Action       *model.AuditAction
-	ResourceType *model.AuditResourceType
-	ResourceID   *int64
-	CreatedFrom  *time.Time
-	CreatedTo    *time.Time
-	Limit        int
-	Offset       int
+	ActorUserID   *int64
+	ActorApiKeyID *int64
+	Action        *model.AuditAction
+	ResourceType  *model.AuditResourceType
+	ResourceID    *int64
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+	Limit         int
+	Offset        int
 }
 
 // 監査ログの保存・一覧取得の約束。
@@ -8,8 +8,8 @@ import (
 
 type CartItemRepository interface {
 	ListByCartID(ctx context.Context, cartID int64) ([]model.CartItem, error)
-	// 同一商品はプラス
-	UpsertByCartAndProduct(ctx context.Context, cartID int64, productID int64, addQty int64, unitPriceSnapshot int64) error
+	// 同一商品（同じバリエーション）はプラス。variantIDはバリエーションの無い商品ならnil
+	UpsertByCartAndProduct(ctx context.Context, cartID int64, productID int64, variantID *int64, addQty int64, unitPriceSnapshot int64, optionLabelsSnapshot string) error
 	UpdateQuantity(ctx context.Context, cartItemID int64, qty int64) error
 	DeleteByID(ctx context.Context, cartItemID int64) error
 	FindByID(ctx context.Context, cartItemID int64) (model.CartItem, error)
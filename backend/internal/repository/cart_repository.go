@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"app/internal/domain/model"
 )
@@ -11,4 +12,12 @@ type CartRepository interface {
 	FindActiveByUserID(ctx context.Context, userID int64) (model.Cart, error)
 	UpdateStatus(ctx context.Context, cartID int64, status model.CartStatus) error
 	Clear(ctx context.Context, cartID int64) error
+
+	// ゲストカート（user_idなし）
+	CreateGuest(ctx context.Context) (model.Cart, error)
+	FindActiveGuestByID(ctx context.Context, cartID int64) (model.Cart, error)
+	// ACTIVEのゲストカートをMERGEDにする（false = 既に合算済み・存在しない）
+	MarkGuestMerged(ctx context.Context, cartID int64) (bool, error)
+	// before以降に更新の無いゲストカートと、合算済みのゲストカートを明細ごと削除
+	DeleteAbandonedGuests(ctx context.Context, before time.Time) (int64, error)
 }
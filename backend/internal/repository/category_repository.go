@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"app/internal/domain/model"
+	"context"
+)
+
+// カテゴリと、商品との対応を保存・取得する約束
+type CategoryRepository interface {
+	//カテゴリを作成（slugが重複したらエラー）
+	Create(ctx context.Context, category model.Category) (model.Category, error)
+
+	//IDで1件取得（無ければErrNotFound）
+	FindByID(ctx context.Context, categoryID int64) (model.Category, error)
+
+	//slugで1件取得（無ければErrNotFound）
+	FindBySlug(ctx context.Context, slug string) (model.Category, error)
+
+	//全カテゴリを並び順（sort_order, id）で返す。木の組み立て・子孫の計算はusecaseで行う
+	ListAll(ctx context.Context) ([]model.Category, error)
+
+	//名前・slug・親・並び順の更新（無ければErrNotFound）
+	Update(ctx context.Context, category model.Category) error
+
+	//カテゴリを削除し、商品との対応も消す（無ければErrNotFound）
+	Delete(ctx context.Context, categoryID int64) error
+
+	//商品のカテゴリを丸ごと置き換える（空なら全部外す）
+	ReplaceProductCategories(ctx context.Context, productID int64, categoryIDs []int64) error
+
+	//商品が入っているカテゴリ（並び順）
+	ListByProductID(ctx context.Context, productID int64) ([]model.Category, error)
+}
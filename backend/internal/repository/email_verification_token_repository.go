@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"app/internal/domain/model"
+	"context"
+	"time"
+)
+
+// メール確認トークンの保存・取得・失効を行う約束。
+type EmailVerificationTokenRepository interface {
+	//新しい確認トークンを保存。
+	Create(ctx context.Context, token model.EmailVerificationToken) error
+
+	//token_hashで検索。見つからなければfalse。
+	FindByHash(ctx context.Context, tokenHash string) (model.EmailVerificationToken, bool, error)
+
+	//未使用のトークンだけused_atをセットする（同時に使われても1回しか成功しない）
+	MarkUsed(ctx context.Context, tokenID string) error
+
+	//そのユーザーの確認トークンを全部消す（再送時・確認完了時）
+	DeleteByUserID(ctx context.Context, userID int64) error
+
+	//期限切れ件数
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
@@ -15,6 +15,11 @@ type InventoryRepository interface {
 	// 在庫戻し（キャンセルなど）
 	IncreaseStock(ctx context.Context, productID int64, qty int64) error
 
+	// バリエーション（SKU）ごとの在庫。商品単位と同じ約束
+	SetVariantStock(ctx context.Context, variantID int64, newStock int64) error
+	DecreaseVariantStockIfEnough(ctx context.Context, variantID int64, qty int64) (bool, error)
+	IncreaseVariantStock(ctx context.Context, variantID int64, qty int64) error
+
 	// 調整履歴作成
 	CreateAdjustment(ctx context.Context, adjustment model.InventoryAdjustment) error
 }
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"app/internal/domain/model"
+	"context"
+	"time"
+)
+
+// ログイン失敗回数・ロック状態の保存を行う約束。
+// 単一ノードはメモリ実装、複数ノードはPostgres実装を使う。
+type LoginAttemptRepository interface {
+	//失敗を1回記録して更新後を返す（最後の失敗からwindow以上空いていたら1から数え直す）
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (model.LoginAttempt, error)
+
+	//ロック期限をセット（既にもっと先までロック中ならそのまま）
+	Lock(ctx context.Context, key string, until time.Time) error
+
+	//keyで検索。見つからなければfalse。
+	Get(ctx context.Context, key string) (model.LoginAttempt, bool, error)
+
+	//記録を消す（ログイン成功・管理者による解除）。消えたらtrue。
+	Delete(ctx context.Context, key string) (bool, error)
+
+	//現在ロック中の一覧（ロック期限が近い順）
+	ListLocked(ctx context.Context, now time.Time) ([]model.LoginAttempt, error)
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"app/internal/domain/model"
+	"context"
+	"time"
+)
+
+// ログインリンクのトークンの保存・取得・失効を行う約束。
+type MagicLinkTokenRepository interface {
+	//新しいトークンを保存。
+	Create(ctx context.Context, token model.MagicLinkToken) error
+
+	//token_hashで検索。見つからなければfalse。
+	FindByHash(ctx context.Context, tokenHash string) (model.MagicLinkToken, bool, error)
+
+	//未使用のトークンだけused_atをセットする（同時に使われても1回しか成功しない）
+	MarkUsed(ctx context.Context, tokenID string) error
+
+	//そのユーザーのトークンを全部消す（再発行時・ログイン完了時）
+	DeleteByUserID(ctx context.Context, userID int64) error
+
+	//期限切れ件数
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
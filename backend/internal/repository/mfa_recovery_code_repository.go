@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"app/internal/domain/model"
+	"context"
+)
+
+// 2FAリカバリーコードの保存・消費を行う約束。
+type MfaRecoveryCodeRepository interface {
+	//そのユーザーのコードを全部入れ替える（再発行時）
+	ReplaceForUser(ctx context.Context, userID int64, codes []model.MfaRecoveryCode) error
+
+	//未使用のコードだけused_atをセットする。使えたらtrue。
+	Consume(ctx context.Context, userID int64, codeHash string) (bool, error)
+
+	//そのユーザーのコードを全部消す（2FA無効化時）
+	DeleteByUserID(ctx context.Context, userID int64) error
+}
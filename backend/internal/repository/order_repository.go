@@ -26,4 +26,6 @@ type OrderRepository interface {
 	FindByIdempotencyKey(ctx context.Context, userID int64, key string) (model.Order, bool, error)
 	//管理者用の注文一覧
 	ListAdmin(ctx context.Context, f AdminOrderListFilter) ([]model.Order, int64, error)
+	//そのユーザーの注文件数
+	CountByUserID(ctx context.Context, userID int64) (int64, error)
 }
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"app/internal/domain/model"
+	"context"
+	"time"
+)
+
+// パスワード再設定トークンの保存・取得・失効を行う約束。
+type PasswordResetTokenRepository interface {
+	//新しい再設定トークンを保存。
+	Create(ctx context.Context, token model.PasswordResetToken) error
+
+	//token_hashで検索。見つからなければfalse。
+	FindByHash(ctx context.Context, tokenHash string) (model.PasswordResetToken, bool, error)
+
+	//未使用のトークンだけused_atをセットする（同時に使われても1回しか成功しない）
+	MarkUsed(ctx context.Context, tokenID string) error
+
+	//そのユーザーの再設定トークンを全部消す（再発行時・再設定完了時）
+	DeleteByUserID(ctx context.Context, userID int64) error
+
+	//期限切れ件数
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
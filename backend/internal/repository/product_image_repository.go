@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"app/internal/domain/model"
+)
+
+// 商品画像のメタデータ（ファイル本体はストレージ側）
+type ProductImageRepository interface {
+	Create(ctx context.Context, image model.ProductImage) (model.ProductImage, error)
+	// 見つからなければErrNotFound
+	FindByID(ctx context.Context, imageID int64) (model.ProductImage, error)
+	// 並び順（position, id）
+	ListByProductID(ctx context.Context, productID int64) ([]model.ProductImage, error)
+	// 一覧ページ用。商品ごとに並び順（product_id, position, id）
+	ListByProductIDs(ctx context.Context, productIDs []int64) ([]model.ProductImage, error)
+	// imageIDsの順にPositionを0から振り直す
+	UpdatePositions(ctx context.Context, productID int64, imageIDs []int64) error
+	Delete(ctx context.Context, imageID int64) error
+}
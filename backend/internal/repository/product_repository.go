@@ -15,7 +15,34 @@ type ProductListQuery struct {
 	Q        string
 	MinPrice *int64
 	MaxPrice *int64
-	Sort     string
+	//new/price_asc/price_desc/relevance（relevanceはQがあるときだけ。無ければnew）
+	Sort string
+	//このどれかのカテゴリに入っている商品だけ（空なら絞り込まない）
+	CategoryIDs []int64
+}
+
+// 一覧の絞り込み結果に対する件数（ファセット）
+type ProductFacetCounts struct {
+	//価格帯ごとの件数。境界が [b0, b1, …] なら [~b0), [b0~b1), …, [bn~) の順で len(境界)+1 個
+	PriceBuckets []int64
+	//在庫あり/なし（バリエーションのある商品は公開中のSKUのどれかに在庫があれば「あり」）
+	InStock    int64
+	OutOfStock int64
+	//カテゴリごとの商品数（子孫カテゴリの商品も親に数える。0件のカテゴリは含まない）
+	Categories []CategoryFacetCount
+	//バリエーションの軸・値ごとの商品数（公開中のSKUのみ。軸の並び順→値の登録順）
+	Attributes []AttributeFacetCount
+}
+
+type CategoryFacetCount struct {
+	CategoryID int64
+	Count      int64
+}
+
+type AttributeFacetCount struct {
+	Name  string
+	Value string
+	Count int64
 }
 
 // 商品の永続化（保存・取得）だけを約束。
@@ -24,6 +51,8 @@ type ProductRepository interface {
 	FindByID(ctx context.Context, productID int64) (model.Product, error)
 	// 公開商品の一覧
 	ListPublic(ctx context.Context, query ProductListQuery) ([]model.Product, int64, error)
+	// 公開商品の一覧と同じ絞り込み（Page・Limit・Sortは使わない）でのファセット件数
+	FacetPublic(ctx context.Context, query ProductListQuery, priceBounds []int64) (ProductFacetCounts, error)
 	Update(ctx context.Context, product model.Product) error
 	SoftDelete(ctx context.Context, productID int64) error
 	//公開切替
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"app/internal/domain/model"
+)
+
+// 商品のバリエーション軸とバリエーション（SKU）
+type ProductVariantRepository interface {
+	// 軸（Positionの順）
+	ListOptions(ctx context.Context, productID int64) ([]model.ProductOption, error)
+	// 軸を入れ替える（namesの順にPositionを振る）
+	ReplaceOptions(ctx context.Context, productID int64, names []string) ([]model.ProductOption, error)
+
+	// 削除されていないバリエーション（Values付き、id順）
+	ListByProductID(ctx context.Context, productID int64) ([]model.ProductVariant, error)
+	// Values付き。見つからなければErrNotFound
+	FindByID(ctx context.Context, variantID int64) (model.ProductVariant, error)
+	FindBySKU(ctx context.Context, sku string) (model.ProductVariant, error)
+	// Valuesも一緒に作る
+	Create(ctx context.Context, variant model.ProductVariant) (model.ProductVariant, error)
+	// sku・price・is_activeを更新（在庫はInventoryRepositoryで）
+	Update(ctx context.Context, variant model.ProductVariant) error
+	SoftDelete(ctx context.Context, variantID int64) error
+}
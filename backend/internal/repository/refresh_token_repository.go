@@ -22,8 +22,17 @@ type RefreshTokenRepository interface {
 
 	// そのユーザーのrefreshを全部消す処理です。再利用検知や強制ログアウトで必要。
 	DeleteByUserID(ctx context.Context, userID int64) error
+	// 同じ系列（1端末）のrefreshを全部消して削除件数を返す。再利用検知で必要。
+	DeleteByFamilyID(ctx context.Context, familyID string) (int64, error)
 	// 1件削除
 	DeleteByID(ctx context.Context, tokenID string) error
 	//期限切れ件数
 	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+
+	//IDで検索。見つからなければfalse。
+	FindByID(ctx context.Context, tokenID string) (model.RefreshToken, bool, error)
+	//そのユーザーの有効な（未使用・期限内）refreshを新しい順に返す（セッション一覧）
+	ListActiveByUserID(ctx context.Context, userID int64, now time.Time) ([]model.RefreshToken, error)
+	//keepFamilyIDの系列以外のそのユーザーのrefreshを全部消して削除件数を返す（keepFamilyIDが空なら全部）
+	DeleteByUserIDExceptFamily(ctx context.Context, userID int64, keepFamilyID string) (int64, error)
 }
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"app/internal/domain/model"
+)
+
+// ロールと権限の対応の保存・取得
+type RolePermissionRepository interface {
+	//全件（role, permission の順）
+	ListAll(ctx context.Context) ([]model.RolePermission, error)
+	//そのロールの権限
+	ListByRole(ctx context.Context, role model.Role) ([]string, error)
+	//そのロールが権限を持っているか
+	HasPermission(ctx context.Context, role model.Role, permission string) (bool, error)
+	//そのロールの権限を丸ごと置き換える（1トランザクション）
+	ReplaceRole(ctx context.Context, role model.Role, permissions []string) error
+	//そのロールの行を全部消す。無ければfalse
+	DeleteRole(ctx context.Context, role model.Role) (bool, error)
+}
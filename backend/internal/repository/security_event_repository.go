@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"app/internal/domain/model"
+)
+
+// セキュリティイベントの絞り込み条件（nil・空は絞り込まない）。
+type SecurityEventFilter struct {
+	UserID      *int64
+	Type        *model.SecurityEventType
+	Outcome     *model.SecurityEventOutcome
+	IP          string
+	Email       string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Limit       int
+	Offset      int
+}
+
+// セキュリティイベントの保存・一覧取得の約束。
+type SecurityEventRepository interface {
+	//1件保存
+	Create(ctx context.Context, ev model.SecurityEvent) error
+
+	//条件で一覧取得（新しい順）。
+	List(ctx context.Context, filter SecurityEventFilter) ([]model.SecurityEvent, error)
+
+	//ユーザーのイベントからメールアドレス・IP・User-Agentを消す（退会時）。イベント自体は残す
+	AnonymizeByUserID(ctx context.Context, userID int64) error
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"app/internal/domain/model"
+	"context"
+)
+
+// TokenVersionGuard用のキャッシュ（user_id => token_version / is_active）
+type TokenStateCache interface {
+	//期限内ならキャッシュを返す
+	Get(ctx context.Context, userID int64) (model.TokenState, bool)
+	//DBを読む前に取る世代。読んでいる間にInvalidateがあればSetは捨てる
+	Generation() uint64
+	Set(ctx context.Context, st model.TokenState, gen uint64)
+	//このノードのキャッシュから消す
+	Invalidate(ctx context.Context, userID int64)
+	//全部消す（他ノードからの通知を取りこぼしたとき）
+	Clear(ctx context.Context)
+}
+
+// 他ノードへキャッシュの破棄を知らせる（単一ノードならnil）
+type TokenStateInvalidationPublisher interface {
+	Publish(ctx context.Context, userID int64) error
+}
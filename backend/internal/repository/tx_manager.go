@@ -10,6 +10,7 @@ type TxRepos interface {
 	CartItems() CartItemRepository
 	Inventory() InventoryRepository
 	Products() ProductRepository
+	Variants() ProductVariantRepository
 }
 
 // UsecaseからTxの開始/commit/rollbackを隠す。
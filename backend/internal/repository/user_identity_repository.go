@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"app/internal/domain/model"
+	"context"
+	"time"
+)
+
+// 外部IdPアカウントの紐付けを保存・取得する約束。
+type UserIdentityRepository interface {
+	//紐付けを保存（provider+subject、user+providerが重複したらエラー）
+	Create(ctx context.Context, identity *model.UserIdentity) error
+
+	//provider + subject で検索。見つからなければfalse。
+	FindByProviderSubject(ctx context.Context, provider string, subject string) (model.UserIdentity, bool, error)
+
+	//そのユーザーの紐付けを古い順に返す
+	ListByUserID(ctx context.Context, userID int64) ([]model.UserIdentity, error)
+
+	//最後にこのIdPでログインした時刻を更新
+	TouchLastLogin(ctx context.Context, id string, at time.Time) error
+
+	//本人の紐付けだけ削除する。消せたらtrue。
+	DeleteByIDForUser(ctx context.Context, id string, userID int64) (bool, error)
+
+	//そのユーザーの紐付けを全部削除（退会時）
+	DeleteByUserID(ctx context.Context, userID int64) error
+}
@@ -4,11 +4,21 @@ import (
 	"app/internal/domain/model"
 	"context"
 	"errors"
+	"time"
 )
 
 // ユーザーが見つかりませんを統一
 var ErrUserNotFound = errors.New("user not found")
 
+// 管理者用のユーザー一覧の条件（nilは絞り込まない）
+type AdminUserListFilter struct {
+	Page     int
+	Limit    int
+	Email    string
+	Role     *model.Role
+	IsActive *bool
+}
+
 // 保存・取得を約束
 type UserRepository interface {
 	//新規ユーザー作成
@@ -21,4 +31,10 @@ type UserRepository interface {
 	Update(ctx context.Context, user *model.User) error
 	//トークンのバージョンを＋１
 	IncrementTokenVersion(ctx context.Context, id int64) error
+	//token_versionとis_activeだけ取得する（無ければnil）
+	FindTokenState(ctx context.Context, id int64) (*model.TokenState, error)
+	//管理者用のユーザー一覧（emailは部分一致）
+	List(ctx context.Context, f AdminUserListFilter) ([]model.User, int64, error)
+	//猶予期間が過ぎた（まだ匿名化していない）退会申請をlimit件まで返す
+	ListDeletionDue(ctx context.Context, now time.Time, limit int) ([]model.User, error)
 }
@@ -0,0 +1,128 @@
+package security
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SHA-1の先頭5文字（k-anonymityのrange）
+const breachedPrefixLen = 5
+
+// 流出済みパスワードの一覧（Have I Been Pwned の Pwned Passwords と同じ形式）。
+//   - ファイル：1行1件で "<SHA-1 40桁>:<件数>"（件数は省略可）
+//   - ディレクトリ：rangeごとのファイル "<先頭5文字>.txt" に "<末尾35文字>:<件数>"（range APIの応答と同じ）
+//
+// rangeごと（SHA-1の先頭5文字）に末尾35文字を持ち、照合もrange単位で行う
+type BreachedPasswords struct {
+	ranges map[string]map[string]struct{}
+	count  int
+}
+
+// 起動時に読み込む
+func LoadBreachedPasswords(path string) (*BreachedPasswords, error) {
+	b := &BreachedPasswords{ranges: map[string]map[string]struct{}{}}
+
+	st, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("breached passwords: %w", err)
+	}
+	if !st.IsDir() {
+		if err := b.loadFile(path, ""); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("breached passwords: %w", err)
+	}
+	for _, e := range entries {
+		prefix := strings.ToUpper(strings.TrimSuffix(e.Name(), ".txt"))
+		if e.IsDir() || !isHex(prefix, breachedPrefixLen) {
+			continue
+		}
+		if err := b.loadFile(filepath.Join(path, e.Name()), prefix); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// prefixが空なら40桁、あれば末尾35桁の行として読む
+func (b *BreachedPasswords) loadFile(path string, prefix string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("breached passwords: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	line := 0
+	for sc.Scan() {
+		line++
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		if i := strings.IndexByte(s, ':'); i >= 0 {
+			s = s[:i]
+		}
+		s = prefix + strings.ToUpper(s)
+		if !isHex(s, sha1.Size*2) {
+			return fmt.Errorf("breached passwords: %s:%d: not a SHA-1 hash", path, line)
+		}
+
+		b.add(s)
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("breached passwords: %w", err)
+	}
+	return nil
+}
+
+func (b *BreachedPasswords) add(hash string) {
+	prefix, suffix := hash[:breachedPrefixLen], hash[breachedPrefixLen:]
+	set, ok := b.ranges[prefix]
+	if !ok {
+		set = map[string]struct{}{}
+		b.ranges[prefix] = set
+	}
+	if _, dup := set[suffix]; !dup {
+		set[suffix] = struct{}{}
+		b.count++
+	}
+}
+
+// n桁の16進数か（rangeの5桁は奇数なのでhex.DecodeStringは使わない）
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'A' || c > 'F') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// 読み込んだ件数
+func (b *BreachedPasswords) Len() int {
+	return b.count
+}
+
+// 流出済みパスワードか
+func (b *BreachedPasswords) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	_, ok := b.ranges[hash[:breachedPrefixLen]][hash[breachedPrefixLen:]]
+	return ok
+}
@@ -0,0 +1,269 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// JWTの署名鍵・検証鍵のセット。
+// 署名は1本（signing）、検証はkidごとに複数持てる（ローテーション中は旧鍵も残す）。
+type KeySet struct {
+	signing *jwtKey
+	keys    map[string]*jwtKey
+}
+
+type jwtKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   interface{} // nilなら検証専用
+	verifyKey interface{}
+}
+
+// JWKS（GET /.well-known/jwks.json）
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// 鍵ファイル名の規則（kidはファイル名から取る）
+const (
+	privateKeySuffix = ".pem"     // <kid>.pem     秘密鍵（署名・検証）
+	publicKeySuffix  = ".pub.pem" // <kid>.pub.pem 公開鍵（検証のみ。ローテーションで退役した鍵など）
+)
+
+// 共有シークレットのHS256（鍵ディレクトリ未設定時の従来動作。kidヘッダは付けない）
+func NewHMACKeySet(secret string) *KeySet {
+	k := &jwtKey{
+		kid:       "",
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+	return &KeySet{signing: k, keys: map[string]*jwtKey{"": k}}
+}
+
+// ディレクトリ内の鍵（RS256 / EdDSA）を読み込む。
+// signingKIDが空なら、秘密鍵が1本だけのときにそれを使う。
+func LoadKeySetFromDir(dir string, signingKID string) (*KeySet, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read jwt keys dir: %w", err)
+	}
+
+	ks := &KeySet{keys: map[string]*jwtKey{}}
+	var privateKIDs []string
+
+	for _, ent := range entries {
+		name := ent.Name()
+		if ent.IsDir() || !strings.HasSuffix(name, privateKeySuffix) {
+			continue
+		}
+
+		isPublic := strings.HasSuffix(name, publicKeySuffix)
+		kid := strings.TrimSuffix(name, privateKeySuffix)
+		if isPublic {
+			kid = strings.TrimSuffix(name, publicKeySuffix)
+		}
+		if kid == "" {
+			return nil, fmt.Errorf("jwt key %s: empty kid", name)
+		}
+
+		raw, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", name, err)
+		}
+
+		var key *jwtKey
+		if isPublic {
+			key, err = parsePublicKeyPEM(kid, raw)
+		} else {
+			key, err = parsePrivateKeyPEM(kid, raw)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", name, err)
+		}
+
+		//同じkidの秘密鍵と公開鍵がある場合は秘密鍵を優先
+		if prev, ok := ks.keys[kid]; ok && prev.signKey != nil {
+			continue
+		}
+		ks.keys[kid] = key
+		if key.signKey != nil {
+			privateKIDs = append(privateKIDs, kid)
+		}
+	}
+
+	if signingKID == "" {
+		if len(privateKIDs) != 1 {
+			return nil, errors.New("JWT_SIGNING_KID is required when the keys dir has zero or multiple private keys")
+		}
+		signingKID = privateKIDs[0]
+	}
+
+	signing, ok := ks.keys[signingKID]
+	if !ok || signing.signKey == nil {
+		return nil, fmt.Errorf("private key for JWT_SIGNING_KID %q not found", signingKID)
+	}
+	ks.signing = signing
+
+	return ks, nil
+}
+
+// 移行用：kid無しのHS256 tokenも検証できるようにする（切替前に発行されたtokenを期限まで通す）
+func (k *KeySet) AcceptLegacyHMAC(secret string) {
+	k.keys[""] = &jwtKey{
+		kid:       "",
+		method:    jwt.SigningMethodHS256,
+		verifyKey: []byte(secret),
+	}
+}
+
+// 署名鍵で署名する（kidがあればヘッダに入れる）
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	t := jwt.NewWithClaims(k.signing.method, claims)
+	if k.signing.kid != "" {
+		t.Header["kid"] = k.signing.kid
+	}
+	return t.SignedString(k.signing.signKey)
+}
+
+// jwt.Parse用：kidで鍵を選び、algが鍵と一致するかも確認する
+func (k *KeySet) Keyfunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown kid")
+	}
+	if t.Method == nil || t.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.verifyKey, nil
+}
+
+// 公開してよい鍵（RSA / Ed25519）だけをJWKにする。HMACは絶対に出さない
+func (k *KeySet) JWKS() JWKSet {
+	kids := make([]string, 0, len(k.keys))
+	for kid := range k.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	out := JWKSet{Keys: []JWK{}}
+	for _, kid := range kids {
+		key := k.keys[kid]
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			out.Keys = append(out.Keys, JWK{
+				Kty: "RSA",
+				Use: "sig",
+				Alg: key.method.Alg(),
+				Kid: kid,
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			out.Keys = append(out.Keys, JWK{
+				Kty: "OKP",
+				Use: "sig",
+				Alg: key.method.Alg(),
+				Kid: kid,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return out
+}
+
+// 署名に使っているkid（HS256なら空）
+func (k *KeySet) SigningKID() string {
+	return k.signing.kid
+}
+
+// PKCS#8（RSA / Ed25519）か PKCS#1（RSA）の秘密鍵
+func parsePrivateKeyPEM(kid string, raw []byte) (*jwtKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("invalid PEM")
+	}
+
+	var priv interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		priv, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		priv, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key")
+	}
+
+	key, err := newAsymmetricKey(kid, signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	key.signKey = priv
+	return key, nil
+}
+
+// PKIXの公開鍵（検証専用）
+func parsePublicKeyPEM(kid string, raw []byte) (*jwtKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("invalid public key PEM")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return newAsymmetricKey(kid, pub)
+}
+
+func newAsymmetricKey(kid string, pub crypto.PublicKey) (*jwtKey, error) {
+	switch p := pub.(type) {
+	case *rsa.PublicKey:
+		if p.N.BitLen() < 2048 {
+			return nil, errors.New("rsa key must be at least 2048 bits")
+		}
+		return &jwtKey{kid: kid, method: jwt.SigningMethodRS256, verifyKey: p}, nil
+	case ed25519.PublicKey:
+		return &jwtKey{kid: kid, method: jwt.SigningMethodEdDSA, verifyKey: p}, nil
+	default:
+		return nil, errors.New("unsupported key type (use RSA or Ed25519)")
+	}
+}
@@ -0,0 +1,143 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// パスワードハッシュのアルゴリズム
+const (
+	PasswordAlgoBcrypt   = "bcrypt"
+	PasswordAlgoArgon2id = "argon2id"
+)
+
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
+
+// Argon2idのパラメータ（OWASP推奨の m=64MiB, t=3, p=2 相当をデフォルトに）
+type Argon2Params struct {
+	MemoryKiB   uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+const (
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// パスワードのハッシュ化・照合。照合は保存済みの形式（bcrypt / argon2id）で行い、
+// 今の設定と違う形式・コストなら NeedsRehash が true を返す（ログイン時に作り直す）
+type PasswordHasher struct {
+	algo       string
+	bcryptCost int
+	argon2     Argon2Params
+}
+
+func NewPasswordHasher(algo string, bcryptCost int, argon2Params Argon2Params) *PasswordHasher {
+	if algo == "" {
+		algo = PasswordAlgoBcrypt
+	}
+	if bcryptCost == 0 {
+		bcryptCost = bcrypt.DefaultCost
+	}
+	return &PasswordHasher{algo: algo, bcryptCost: bcryptCost, argon2: argon2Params}
+}
+
+// 今の設定でハッシュ化
+func (h *PasswordHasher) Hash(plain string) (string, error) {
+	if h.algo == PasswordAlgoArgon2id {
+		return h.hashArgon2id(plain)
+	}
+
+	b, err := bcrypt.GenerateFromPassword([]byte(plain), h.bcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// 保存済みハッシュと照合（一致しなければfalse。形式が読めなければエラー）
+func (h *PasswordHasher) Verify(hash string, plain string) (bool, error) {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false, err
+		}
+		got := argon2.IDKey([]byte(plain), salt, params.Iterations, params.MemoryKiB, params.Parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(got, key) == 1, nil
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(plain))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, ErrUnknownPasswordHash
+	}
+	return true, nil
+}
+
+// 保存済みハッシュが今の設定（アルゴリズム・コスト）と違うか
+func (h *PasswordHasher) NeedsRehash(hash string) bool {
+	if h.algo == PasswordAlgoArgon2id {
+		params, _, _, err := decodeArgon2id(hash)
+		return err != nil || params != h.argon2
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.bcryptCost
+}
+
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>（PHC形式）
+func (h *PasswordHasher) hashArgon2id(plain string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	p := h.argon2
+	key := argon2.IDKey([]byte(plain), salt, p.Iterations, p.MemoryKiB, p.Parallelism, argon2KeyLen)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.MemoryKiB, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func decodeArgon2id(hash string) (Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != PasswordAlgoArgon2id {
+		return Argon2Params{}, nil, nil, ErrUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2Params{}, nil, nil, ErrUnknownPasswordHash
+	}
+
+	var p Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.MemoryKiB, &p.Iterations, &p.Parallelism); err != nil {
+		return Argon2Params{}, nil, nil, ErrUnknownPasswordHash
+	}
+	if p.MemoryKiB == 0 || p.Iterations == 0 || p.Parallelism == 0 {
+		return Argon2Params{}, nil, nil, ErrUnknownPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, ErrUnknownPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2Params{}, nil, nil, ErrUnknownPasswordHash
+	}
+	return p, salt, key, nil
+}
@@ -0,0 +1,60 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// DBに平文で置きたくない値（TOTPシークレットなど）をAES-GCMで暗号化する
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// 任意長の鍵文字列からAES-256の鍵を作る
+func NewSecretBox(key string) (*SecretBox, error) {
+	if key == "" {
+		return nil, errors.New("secret box key is empty")
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+// 暗号化（nonce + ciphertext を base64）
+func (b *SecretBox) Seal(plain string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := b.aead.Seal(nonce, nonce, []byte(plain), nil)
+	return base64.RawStdEncoding.EncodeToString(out), nil
+}
+
+// 復号
+func (b *SecretBox) Open(sealed string) (string, error) {
+	raw, err := base64.RawStdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	ns := b.aead.NonceSize()
+	if len(raw) < ns {
+		return "", errors.New("sealed value too short")
+	}
+	plain, err := b.aead.Open(nil, raw[:ns], raw[ns:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,127 @@
+package textsearch
+
+import (
+	"html"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 1回の検索で使う語の上限（それ以降は無視）
+const MaxTerms = 5
+
+// 検索用の正規化。保存する側（商品名・説明）と検索語の両方に同じものをかける
+//   - NFKC（全角英数→半角、半角カナ→全角、全角スペース→半角）
+//   - 小文字化
+//   - カタカナ→ひらがな（「コーヒー」と「こーひー」を同じに扱う）
+func Normalize(s string) string {
+	s = strings.ToLower(norm.NFKC.String(s))
+	return strings.Map(func(r rune) rune {
+		//ァ(U+30A1)〜ヶ(U+30F6) はひらがなと0x60ずれている
+		if r >= 0x30A1 && r <= 0x30F6 {
+			return r - 0x60
+		}
+		return r
+	}, s)
+}
+
+// 検索語を空白（全角スペースを含む）で分けて正規化する。重複は除く
+func Terms(q string) []string {
+	var terms []string
+	seen := map[string]bool{}
+	for _, t := range strings.Fields(Normalize(q)) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		terms = append(terms, t)
+		if len(terms) == MaxTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// LIKEのワイルドカードをエスケープする（ESCAPE '\' と組み合わせる）
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// textのうちtermsに一致する箇所を <mark>〜</mark> で囲んだHTML断片を返す（それ以外はエスケープ済み）
+// maxRunes > 0 なら最初に一致した箇所のまわりだけを切り出し、省いた側に「…」を付ける
+// 一致が無ければ第2戻り値はfalse（切り出しは先頭から）
+func Highlight(text string, terms []string, maxRunes int) (string, bool) {
+	runes := []rune(text)
+
+	//1文字ずつ正規化して、正規化後の位置→元の文字の位置の対応を作る
+	var normalized []rune
+	var origin []int
+	for i, r := range runes {
+		for _, nr := range Normalize(string(r)) {
+			normalized = append(normalized, nr)
+			origin = append(origin, i)
+		}
+	}
+
+	marked := make([]bool, len(runes))
+	first := -1
+	for _, term := range terms {
+		tr := []rune(term)
+		if len(tr) == 0 {
+			continue
+		}
+		for start := 0; start+len(tr) <= len(normalized); start++ {
+			if !hasRunesAt(normalized, tr, start) {
+				continue
+			}
+			from, to := origin[start], origin[start+len(tr)-1]
+			for i := from; i <= to; i++ {
+				marked[i] = true
+			}
+			if first == -1 || from < first {
+				first = from
+			}
+		}
+	}
+
+	//切り出す範囲（一致箇所の前を1/4ほど残す）
+	begin, end := 0, len(runes)
+	if maxRunes > 0 && len(runes) > maxRunes {
+		if first > 0 {
+			begin = max(0, first-maxRunes/4)
+		}
+		end = min(len(runes), begin+maxRunes)
+		begin = max(0, end-maxRunes)
+	}
+
+	var b strings.Builder
+	if begin > 0 {
+		b.WriteString("…")
+	}
+	for i := begin; i < end; {
+		j := i
+		for j < end && marked[j] == marked[i] {
+			j++
+		}
+		chunk := html.EscapeString(string(runes[i:j]))
+		if marked[i] {
+			b.WriteString("<mark>" + chunk + "</mark>")
+		} else {
+			b.WriteString(chunk)
+		}
+		i = j
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String(), first != -1
+}
+
+func hasRunesAt(s, sub []rune, at int) bool {
+	for k := range sub {
+		if s[at+k] != sub[k] {
+			return false
+		}
+	}
+	return true
+}
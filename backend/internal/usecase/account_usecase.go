@@ -0,0 +1,371 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/repository"
+)
+
+// エクスポートで注文を読むときの1ページの件数
+const accountExportOrderPageSize = 100
+
+// 匿名化の1回の処理件数（残りは次の実行で）
+const accountDeletionBatchSize = 100
+
+type DeleteAccountRequest struct {
+	// パスワードを設定していないユーザー（ソーシャルログインのみ）は空でよい
+	Password string `json:"password"`
+}
+
+type AccountDeletionResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+// GET /me/export の中身（JSONのまま、またはzipにして返す）
+type AccountExport struct {
+	ExportedAt time.Time              `json:"exported_at"`
+	Profile    AccountExportProfile   `json:"profile"`
+	Addresses  []model.Address        `json:"addresses"`
+	Orders     []AccountExportOrder   `json:"orders"`
+	Sessions   []AccountExportSession `json:"sessions"`
+}
+
+// パスワードハッシュやTOTPシークレットは入れない
+type AccountExportProfile struct {
+	ID                  int64      `json:"id"`
+	Email               string     `json:"email"`
+	Role                model.Role `json:"role"`
+	EmailVerifiedAt     *time.Time `json:"email_verified_at"`
+	MfaEnabled          bool       `json:"mfa_enabled"`
+	LastLoginAt         *time.Time `json:"last_login_at"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+type AccountExportOrder struct {
+	model.Order
+	Items []model.OrderItem `json:"items"`
+}
+
+type AccountExportSession struct {
+	ID        string    `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	IP        *string   `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// 個人データのエクスポートと退会（猶予期間つき）。
+// 退会はメールアドレス・住所などを匿名化するだけで、注文・注文明細（スナップショット）は会計のために残す
+type AccountUsecase struct {
+	auth       *AuthUsecase
+	users      repository.UserRepository
+	addresses  repository.AddressRepository
+	orders     repository.OrderRepository
+	orderItems repository.OrderItemRepository
+	identities repository.UserIdentityRepository
+}
+
+// DI
+func NewAccountUsecase(
+	auth *AuthUsecase,
+	users repository.UserRepository,
+	addresses repository.AddressRepository,
+	orders repository.OrderRepository,
+	orderItems repository.OrderItemRepository,
+	identities repository.UserIdentityRepository,
+) *AccountUsecase {
+	return &AccountUsecase{
+		auth:       auth,
+		users:      users,
+		addresses:  addresses,
+		orders:     orders,
+		orderItems: orderItems,
+		identities: identities,
+	}
+}
+
+// GET /me/export
+func (u *AccountUsecase) Export(ctx context.Context, userID int64) (*AccountExport, error) {
+	user, err := u.auth.findActiveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := u.addresses.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	orders, err := u.exportOrders(ctx, user.ID)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	tokens, err := u.auth.rtRepo.ListActiveByUserID(ctx, user.ID, time.Now())
+	if err != nil {
+		return nil, ErrInternal
+	}
+	sessions := make([]AccountExportSession, 0, len(tokens))
+	for _, t := range tokens {
+		sessions = append(sessions, AccountExportSession{
+			ID:        refreshFamilyID(t),
+			UserAgent: t.UserAgent,
+			IP:        t.IP,
+			CreatedAt: t.CreatedAt,
+			ExpiresAt: t.ExpiresAt,
+		})
+	}
+
+	if addresses == nil {
+		addresses = []model.Address{}
+	}
+
+	return &AccountExport{
+		ExportedAt: time.Now(),
+		Profile: AccountExportProfile{
+			ID:                  user.ID,
+			Email:               user.Email,
+			Role:                user.Role,
+			EmailVerifiedAt:     user.EmailVerifiedAt,
+			MfaEnabled:          user.TotpEnabledAt != nil,
+			LastLoginAt:         user.LastLoginAt,
+			DeletionScheduledAt: user.DeletionScheduledAt,
+			CreatedAt:           user.CreatedAt,
+		},
+		Addresses: addresses,
+		Orders:    orders,
+		Sessions:  sessions,
+	}, nil
+}
+
+// 全注文を明細つきで（新しい順）
+func (u *AccountUsecase) exportOrders(ctx context.Context, userID int64) ([]AccountExportOrder, error) {
+	out := []AccountExportOrder{}
+	for page := 1; ; page++ {
+		orders, total, err := u.orders.ListByUserID(ctx, userID, page, accountExportOrderPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range orders {
+			items, err := u.orderItems.ListByOrderID(ctx, o.ID)
+			if err != nil {
+				return nil, err
+			}
+			if items == nil {
+				items = []model.OrderItem{}
+			}
+			out = append(out, AccountExportOrder{Order: o, Items: items})
+		}
+		if len(orders) < accountExportOrderPageSize || int64(len(out)) >= total {
+			return out, nil
+		}
+	}
+}
+
+// エクスポートをzipにする（項目ごとに1ファイル）
+func WriteAccountExportZip(w io.Writer, ex *AccountExport) error {
+	zw := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		v    any
+	}{
+		{"profile.json", ex.Profile},
+		{"addresses.json", ex.Addresses},
+		{"orders.json", ex.Orders},
+		{"sessions.json", ex.Sessions},
+	}
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: ex.ExportedAt})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.v); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// DELETE /me
+// すぐには消さず、猶予期間の後に匿名化する（それまでにログインすれば取り消せる）。
+// 申請した時点でこの端末も含めて全部ログアウトさせる
+func (u *AccountUsecase) RequestDeletion(ctx context.Context, userID int64, req DeleteAccountRequest, userAgent string, ip string) (*AccountDeletionResponse, error) {
+	user, err := u.auth.findActiveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	//スタッフ・ADMINは管理者が /admin/users から退会させる
+	if user.Role != model.RoleUser {
+		return nil, ErrForbidden
+	}
+	if user.DeletionScheduledAt != nil {
+		return nil, ErrConflict
+	}
+
+	//パスワードがあれば確認する（ログインと同じ回数制限）
+	if user.PasswordHash != "" {
+		if req.Password == "" {
+			return nil, ErrValidation
+		}
+		if err := u.auth.checkLoginLock(ctx, user.Email, ip); err != nil {
+			return nil, err
+		}
+		if !u.auth.verifyPassword(user, req.Password) {
+			return nil, u.auth.loginFailed(ctx, user.Email, ip)
+		}
+		u.auth.clearLoginFailures(ctx, user.Email)
+	}
+
+	now := time.Now()
+	scheduledAt := now.Add(u.coolingOff())
+	user.DeletionRequestedAt = &now
+	user.DeletionScheduledAt = &scheduledAt
+
+	//既存のaccess/refreshを全部無効にする
+	if err := u.users.IncrementTokenVersion(ctx, user.ID); err != nil {
+		return nil, ErrInternal
+	}
+	user.TokenVersion++
+	if err := u.users.Update(ctx, user); err != nil {
+		return nil, ErrInternal
+	}
+	if err := u.auth.rtRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return nil, ErrInternal
+	}
+
+	u.auth.recordSecurityEvent(ctx, model.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      model.SecurityEventAccountDeletionRequested,
+		Outcome:   model.SecurityOutcomeSuccess,
+		IP:        ip,
+		UserAgent: userAgent,
+	})
+
+	//メールが送れなくても申請は受け付ける
+	if err := u.auth.mailer.Send(ctx, accountDeletionMail(user.Email, scheduledAt, strings.TrimRight(u.auth.cfg.FEURL, "/")+"/login")); err != nil {
+		log.Printf("account deletion mail failed: user_id=%d err=%v", user.ID, err)
+	}
+
+	return &AccountDeletionResponse{DeletionScheduledAt: scheduledAt}, nil
+}
+
+// POST /me/deletion/cancel
+func (u *AccountUsecase) CancelDeletion(ctx context.Context, userID int64, userAgent string, ip string) (*SuccessResponse, error) {
+	user, err := u.auth.findActiveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletionScheduledAt == nil {
+		return nil, ErrConflict
+	}
+
+	user.DeletionRequestedAt = nil
+	user.DeletionScheduledAt = nil
+	if err := u.users.Update(ctx, user); err != nil {
+		return nil, ErrInternal
+	}
+
+	u.auth.recordSecurityEvent(ctx, model.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      model.SecurityEventAccountDeletionCanceled,
+		Outcome:   model.SecurityOutcomeSuccess,
+		IP:        ip,
+		UserAgent: userAgent,
+	})
+
+	return &SuccessResponse{Message: "account deletion canceled"}, nil
+}
+
+// 猶予期間が過ぎた退会申請を匿名化して件数を返す（定期実行）。
+// 途中で失敗したユーザーは匿名化済みにならないので次の実行でやり直す
+func (u *AccountUsecase) PurgeDue(ctx context.Context, now time.Time) (int, error) {
+	users, err := u.users.ListDeletionDue(ctx, now, accountDeletionBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for i := range users {
+		if err := u.Anonymize(ctx, &users[i], now); err != nil {
+			log.Printf("account anonymize failed: user_id=%d err=%v", users[i].ID, err)
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
+
+// ユーザーを匿名化する（退会の実行。管理者が猶予期間を待たずに行う場合もここ）。
+// メールアドレス・認証情報・住所・IdPの紐付け・ログイン履歴の個人情報を消して、全tokenを無効にする。注文はそのまま
+func (u *AccountUsecase) Anonymize(ctx context.Context, user *model.User, now time.Time) error {
+	if err := u.users.IncrementTokenVersion(ctx, user.ID); err != nil {
+		return err
+	}
+	user.TokenVersion++
+	if err := u.auth.rtRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := u.addresses.AnonymizeByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := u.identities.DeleteByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := u.auth.recoveryRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := u.auth.events.AnonymizeByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	//メールアドレス単位のログイン失敗回数（元のアドレスで引くので上書きより前に消す）
+	if _, err := u.auth.attempts.Delete(ctx, loginAttemptEmailKey(user.Email)); err != nil {
+		return err
+	}
+
+	//最後に匿名化済みにする（ここまで終わらなければ次の実行でやり直す）
+	user.Email = anonymizedEmail(user.ID)
+	user.PasswordHash = ""
+	user.IsActive = false
+	user.LastLoginAt = nil
+	user.EmailVerifiedAt = nil
+	user.TotpSecretEnc = ""
+	user.TotpEnabledAt = nil
+	user.TotpLastStep = 0
+	user.AnonymizedAt = &now
+	return u.users.Update(ctx, user)
+}
+
+func (u *AccountUsecase) coolingOff() time.Duration {
+	return time.Duration(u.auth.cfg.AccountDeletionCoolingOffDays) * 24 * time.Hour
+}
+
+// 退会後のメールアドレス（一意で、届かないドメイン）
+func anonymizedEmail(userID int64) string {
+	return fmt.Sprintf("deleted-%d@deleted.invalid", userID)
+}
+
+func accountDeletionMail(to string, scheduledAt time.Time, loginURL string) MailMessage {
+	return MailMessage{
+		To:      to,
+		Subject: "退会手続きの受付",
+		Body: fmt.Sprintf(
+			"退会の申請を受け付けました。%s にメールアドレス・住所などを削除します（注文の記録は会計のため残ります）。\n\n取り消す場合はそれまでに以下からログインし、退会の取り消しを行ってください。\n\n%s\n\nお心当たりがない場合はすぐにログインしてパスワードを変更してください。",
+			scheduledAt.Format("2006-01-02 15:04 MST"),
+			loginURL,
+		),
+	}
+}
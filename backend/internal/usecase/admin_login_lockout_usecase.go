@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+)
+
+// 管理者向け：ログインロックの一覧・解除
+type AdminLoginLockoutUsecase struct {
+	attempts  repo.LoginAttemptRepository
+	users     repo.UserRepository
+	auditRepo repo.AuditLogRepository
+}
+
+func NewAdminLoginLockoutUsecase(
+	attempts repo.LoginAttemptRepository,
+	users repo.UserRepository,
+	auditRepo repo.AuditLogRepository,
+) *AdminLoginLockoutUsecase {
+	return &AdminLoginLockoutUsecase{attempts: attempts, users: users, auditRepo: auditRepo}
+}
+
+type LoginLockoutOutput struct {
+	Key          string    `json:"key"`
+	Kind         string    `json:"kind"` // email / ip
+	Value        string    `json:"value"`
+	Failures     int       `json:"failures"`
+	LastFailedAt time.Time `json:"last_failed_at"`
+	LockedUntil  time.Time `json:"locked_until"`
+}
+
+type ClearLoginLockoutInput struct {
+	Key string
+}
+
+// 現在ロック中の一覧
+func (u *AdminLoginLockoutUsecase) List(ctx context.Context) ([]LoginLockoutOutput, error) {
+	list, err := u.attempts.ListLocked(ctx, time.Now())
+	if err != nil {
+		return []LoginLockoutOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	outs := make([]LoginLockoutOutput, 0, len(list))
+	for _, a := range list {
+		kind, value := splitLoginAttemptKey(a.Key)
+		out := LoginLockoutOutput{
+			Key:          a.Key,
+			Kind:         kind,
+			Value:        value,
+			Failures:     a.Failures,
+			LastFailedAt: a.LastFailedAt,
+		}
+		if a.LockedUntil != nil {
+			out.LockedUntil = *a.LockedUntil
+		}
+		outs = append(outs, out)
+	}
+	return outs, nil
+}
+
+// ロック解除（失敗回数も消える）。監査ログに残す
+func (u *AdminLoginLockoutUsecase) Clear(ctx context.Context, actorAdminUserID int64, in ClearLoginLockoutInput) error {
+	if actorAdminUserID <= 0 {
+		return NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	key := strings.TrimSpace(in.Key)
+	kind, value := splitLoginAttemptKey(key)
+	if kind == "" || value == "" {
+		return NewHTTPError(http.StatusBadRequest, "invalid key")
+	}
+	//emailは保存時と同じ正規化
+	if kind == "email" {
+		key = loginAttemptEmailKey(value)
+		_, value = splitLoginAttemptKey(key)
+	}
+
+	before, found, err := u.attempts.Get(ctx, key)
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if !found {
+		return NewHTTPError(http.StatusNotFound, "not found")
+	}
+
+	if _, err := u.attempts.Delete(ctx, key); err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	//対象ユーザー（分かる場合だけ）
+	var resourceID int64
+	if kind == "email" {
+		if user, err := u.users.FindByEmail(ctx, value); err == nil && user != nil {
+			resourceID = user.ID
+		}
+	}
+
+	beforeJSON, _ := json.Marshal(before)
+	if err := u.auditRepo.Create(ctx, model.AuditLog{
+		ActorUserID:  actorAdminUserID,
+		Action:       model.AuditActionClearLoginLockout,
+		ResourceType: model.AuditResourceLoginLockout,
+		ResourceID:   resourceID,
+		BeforeJSON:   string(beforeJSON),
+		AfterJSON:    `{"cleared":true}`,
+		CreatedAt:    time.Now(),
+	}); err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	return nil
+}
+
+// "email:xxx" → ("email", "xxx")
+func splitLoginAttemptKey(key string) (string, string) {
+	switch {
+	case strings.HasPrefix(key, loginAttemptKeyEmailPrefix):
+		return "email", strings.TrimPrefix(key, loginAttemptKeyEmailPrefix)
+	case strings.HasPrefix(key, loginAttemptKeyIPPrefix):
+		return "ip", strings.TrimPrefix(key, loginAttemptKeyIPPrefix)
+	default:
+		return "", ""
+	}
+}
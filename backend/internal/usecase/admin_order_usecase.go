@@ -106,6 +106,13 @@ func (u *AdminOrderUsecase) UpdateStatus(ctx context.Context, actorAdminUserID i
 				}
 
 				for _, it := range items {
+					//バリエーションの明細はバリエーションの在庫に戻す
+					if it.VariantID != nil {
+						if err := r.Inventory().IncreaseVariantStock(ctx, *it.VariantID, it.Quantity); err != nil {
+							return NewHTTPError(http.StatusInternalServerError, "db error")
+						}
+						continue
+					}
 					if err := r.Inventory().IncreaseStock(ctx, it.ProductID, it.Quantity); err != nil {
 						return NewHTTPError(http.StatusInternalServerError, "db error")
 					}
@@ -126,13 +133,14 @@ func (u *AdminOrderUsecase) UpdateStatus(ctx context.Context, actorAdminUserID i
 		beforeJSON := `{"status":"` + beforeStatus + `"}`
 		afterJSON := `{"status":"` + newStatus + `"}`
 		if err := u.auditRepo.Create(ctx, model.AuditLog{
-			ActorUserID:  actorAdminUserID,
-			Action:       model.AuditActionUpdateOrderStatus,
-			ResourceType: model.AuditResourceOrder,
-			ResourceID:   orderID,
-			BeforeJSON:   beforeJSON,
-			AfterJSON:    afterJSON,
-			CreatedAt:    time.Now(),
+			ActorUserID:   actorAdminUserID,
+			ActorApiKeyID: apiKeyActorID(ctx),
+			Action:        model.AuditActionUpdateOrderStatus,
+			ResourceType:  model.AuditResourceOrder,
+			ResourceID:    orderID,
+			BeforeJSON:    beforeJSON,
+			AfterJSON:     afterJSON,
+			CreatedAt:     time.Now(),
 		}); err != nil {
 			return NewHTTPError(http.StatusInternalServerError, "db error")
 		}
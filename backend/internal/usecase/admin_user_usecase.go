@@ -0,0 +1,315 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+)
+
+// 管理者向け：ユーザーの一覧・詳細・有効/無効・ロール変更
+type AdminUserUsecase struct {
+	users     repo.UserRepository
+	orders    repo.OrderRepository
+	rtRepo    repo.RefreshTokenRepository
+	roles     repo.RolePermissionRepository
+	auditRepo repo.AuditLogRepository
+	accounts  *AccountUsecase
+}
+
+func NewAdminUserUsecase(
+	users repo.UserRepository,
+	orders repo.OrderRepository,
+	rtRepo repo.RefreshTokenRepository,
+	roles repo.RolePermissionRepository,
+	auditRepo repo.AuditLogRepository,
+	accounts *AccountUsecase,
+) *AdminUserUsecase {
+	return &AdminUserUsecase{users: users, orders: orders, rtRepo: rtRepo, roles: roles, auditRepo: auditRepo, accounts: accounts}
+}
+
+// パスワードハッシュやTOTPシークレットは返さない
+type AdminUserOutput struct {
+	ID              int64      `json:"id"`
+	Email           string     `json:"email"`
+	Role            model.Role `json:"role"`
+	IsActive        bool       `json:"is_active"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	MfaEnabled      bool       `json:"mfa_enabled"`
+	LastLoginAt     *time.Time `json:"last_login_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	//退会申請中なら匿名化される予定の時刻
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+	//退会済み（匿名化した時刻）
+	AnonymizedAt *time.Time `json:"anonymized_at"`
+}
+
+type AdminUserListOutput struct {
+	Items []AdminUserOutput `json:"items"`
+	Total int64             `json:"total"`
+	Page  int               `json:"page"`
+	Limit int               `json:"limit"`
+}
+
+type AdminUserDetailOutput struct {
+	AdminUserOutput
+	OrderCount int64 `json:"order_count"`
+}
+
+// nilの項目は変更しない
+type AdminUpdateUserInput struct {
+	IsActive *bool
+	Role     *string
+}
+
+// ユーザー一覧
+func (u *AdminUserUsecase) List(ctx context.Context, f repo.AdminUserListFilter) (AdminUserListOutput, error) {
+	if f.Page < 1 {
+		return AdminUserListOutput{}, NewHTTPError(http.StatusBadRequest, "invalid page")
+	}
+	if f.Limit < 1 || f.Limit > 100 {
+		return AdminUserListOutput{}, NewHTTPError(http.StatusBadRequest, "invalid limit")
+	}
+	f.Email = strings.TrimSpace(f.Email)
+	if len(f.Email) > 255 {
+		return AdminUserListOutput{}, NewHTTPError(http.StatusBadRequest, "email too long")
+	}
+	if f.Role != nil {
+		ok, err := roleExists(ctx, u.roles, *f.Role)
+		if err != nil {
+			return AdminUserListOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+		if !ok {
+			return AdminUserListOutput{}, NewHTTPError(http.StatusBadRequest, "invalid role")
+		}
+	}
+
+	users, total, err := u.users.List(ctx, f)
+	if err != nil {
+		return AdminUserListOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	items := make([]AdminUserOutput, 0, len(users))
+	for i := range users {
+		items = append(items, toAdminUserOutput(&users[i]))
+	}
+
+	return AdminUserListOutput{
+		Items: items,
+		Total: total,
+		Page:  f.Page,
+		Limit: f.Limit,
+	}, nil
+}
+
+// ユーザー詳細（注文件数つき）
+func (u *AdminUserUsecase) Get(ctx context.Context, userID int64) (AdminUserDetailOutput, error) {
+	user, err := u.findUser(ctx, userID)
+	if err != nil {
+		return AdminUserDetailOutput{}, err
+	}
+
+	count, err := u.orders.CountByUserID(ctx, user.ID)
+	if err != nil {
+		return AdminUserDetailOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	return AdminUserDetailOutput{
+		AdminUserOutput: toAdminUserOutput(user),
+		OrderCount:      count,
+	}, nil
+}
+
+// 有効/無効・ロールの変更。変えた項目ごとに監査ログを残す。
+// 無効化したらログイン中の端末も全部ログアウトさせる。
+// ロールの変更と、USER以外（スタッフ・ADMIN）の変更はADMINだけができる
+func (u *AdminUserUsecase) Update(ctx context.Context, actorAdminUserID int64, userID int64, in AdminUpdateUserInput) (AdminUserDetailOutput, error) {
+	if actorAdminUserID <= 0 {
+		return AdminUserDetailOutput{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	if in.IsActive == nil && in.Role == nil {
+		return AdminUserDetailOutput{}, NewHTTPError(http.StatusBadRequest, "nothing to update")
+	}
+
+	var newRole model.Role
+	if in.Role != nil {
+		newRole = model.Role(strings.ToUpper(strings.TrimSpace(*in.Role)))
+		ok, err := roleExists(ctx, u.roles, newRole)
+		if err != nil {
+			return AdminUserDetailOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+		if !ok {
+			return AdminUserDetailOutput{}, NewHTTPError(http.StatusBadRequest, "invalid role")
+		}
+	}
+
+	//自分自身の無効化・降格はさせない（管理者がいなくなるのを防ぐ）
+	if actorAdminUserID == userID {
+		if (in.IsActive != nil && !*in.IsActive) || (in.Role != nil && newRole != model.RoleAdmin) {
+			return AdminUserDetailOutput{}, NewHTTPError(http.StatusBadRequest, "cannot deactivate or demote yourself")
+		}
+	}
+
+	user, err := u.findUser(ctx, userID)
+	if err != nil {
+		return AdminUserDetailOutput{}, err
+	}
+	//退会済みのユーザーは戻せない
+	if user.AnonymizedAt != nil {
+		return AdminUserDetailOutput{}, NewHTTPError(http.StatusConflict, "already deleted")
+	}
+
+	//users.write を持つスタッフが自分や他のスタッフの権限を上げられないように
+	if in.Role != nil || user.Role != model.RoleUser {
+		actor, err := u.users.FindByID(ctx, actorAdminUserID)
+		if err != nil {
+			return AdminUserDetailOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+		if actor == nil || actor.Role != model.RoleAdmin {
+			return AdminUserDetailOutput{}, NewHTTPError(http.StatusForbidden, "admin only")
+		}
+	}
+
+	var logs []model.AuditLog
+	now := time.Now()
+
+	statusChanged := in.IsActive != nil && *in.IsActive != user.IsActive
+	if statusChanged {
+		logs = append(logs, model.AuditLog{
+			ActorUserID:  actorAdminUserID,
+			Action:       model.AuditActionUpdateUserStatus,
+			ResourceType: model.AuditResourceUser,
+			ResourceID:   user.ID,
+			BeforeJSON:   fmt.Sprintf(`{"is_active":%t}`, user.IsActive),
+			AfterJSON:    fmt.Sprintf(`{"is_active":%t}`, *in.IsActive),
+			CreatedAt:    now,
+		})
+		user.IsActive = *in.IsActive
+	}
+
+	roleChanged := in.Role != nil && newRole != user.Role
+	if roleChanged {
+		logs = append(logs, model.AuditLog{
+			ActorUserID:  actorAdminUserID,
+			Action:       model.AuditActionUpdateUserRole,
+			ResourceType: model.AuditResourceUser,
+			ResourceID:   user.ID,
+			BeforeJSON:   fmt.Sprintf(`{"role":%q}`, user.Role),
+			AfterJSON:    fmt.Sprintf(`{"role":%q}`, newRole),
+			CreatedAt:    now,
+		})
+		user.Role = newRole
+	}
+
+	//何も変わらないなら今の状態を返すだけ
+	if len(logs) == 0 {
+		return u.Get(ctx, user.ID)
+	}
+
+	if err := u.users.Update(ctx, user); err != nil {
+		return AdminUserDetailOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	//roleはaccess tokenに入っているので、発行済みのaccess tokenは使えなくする
+	if err := u.users.IncrementTokenVersion(ctx, user.ID); err != nil {
+		return AdminUserDetailOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	//無効化したらrefreshも全部消す
+	if statusChanged && !user.IsActive {
+		if err := u.rtRepo.DeleteByUserID(ctx, user.ID); err != nil {
+			return AdminUserDetailOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+	}
+
+	for _, l := range logs {
+		if err := u.auditRepo.Create(ctx, l); err != nil {
+			return AdminUserDetailOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+	}
+
+	return u.Get(ctx, user.ID)
+}
+
+// 猶予期間を待たずに退会（匿名化）させる。注文は残る。
+// USER以外（スタッフ・ADMIN）はADMINだけができる
+func (u *AdminUserUsecase) Delete(ctx context.Context, actorAdminUserID int64, userID int64) (AdminUserDetailOutput, error) {
+	if actorAdminUserID <= 0 {
+		return AdminUserDetailOutput{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	if actorAdminUserID == userID {
+		return AdminUserDetailOutput{}, NewHTTPError(http.StatusBadRequest, "cannot delete yourself")
+	}
+
+	user, err := u.findUser(ctx, userID)
+	if err != nil {
+		return AdminUserDetailOutput{}, err
+	}
+	if user.AnonymizedAt != nil {
+		return AdminUserDetailOutput{}, NewHTTPError(http.StatusConflict, "already deleted")
+	}
+
+	if user.Role != model.RoleUser {
+		actor, err := u.users.FindByID(ctx, actorAdminUserID)
+		if err != nil {
+			return AdminUserDetailOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+		if actor == nil || actor.Role != model.RoleAdmin {
+			return AdminUserDetailOutput{}, NewHTTPError(http.StatusForbidden, "admin only")
+		}
+	}
+
+	//メールアドレスは消すので監査ログにも入れない
+	before := fmt.Sprintf(`{"is_active":%t,"deletion_requested":%t}`, user.IsActive, user.DeletionScheduledAt != nil)
+
+	now := time.Now()
+	if err := u.accounts.Anonymize(ctx, user, now); err != nil {
+		return AdminUserDetailOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	if err := u.auditRepo.Create(ctx, model.AuditLog{
+		ActorUserID:  actorAdminUserID,
+		Action:       model.AuditActionDeleteUser,
+		ResourceType: model.AuditResourceUser,
+		ResourceID:   user.ID,
+		BeforeJSON:   before,
+		AfterJSON:    `{"is_active":false,"anonymized":true}`,
+		CreatedAt:    now,
+	}); err != nil {
+		return AdminUserDetailOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	return u.Get(ctx, user.ID)
+}
+
+func (u *AdminUserUsecase) findUser(ctx context.Context, userID int64) (*model.User, error) {
+	if userID <= 0 {
+		return nil, NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	user, err := u.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if user == nil {
+		return nil, NewHTTPError(http.StatusNotFound, "not found")
+	}
+	return user, nil
+}
+
+func toAdminUserOutput(user *model.User) AdminUserOutput {
+	return AdminUserOutput{
+		ID:                  user.ID,
+		Email:               user.Email,
+		Role:                user.Role,
+		IsActive:            user.IsActive,
+		EmailVerifiedAt:     user.EmailVerifiedAt,
+		MfaEnabled:          user.TotpEnabledAt != nil,
+		LastLoginAt:         user.LastLoginAt,
+		CreatedAt:           user.CreatedAt,
+		DeletionScheduledAt: user.DeletionScheduledAt,
+		AnonymizedAt:        user.AnonymizedAt,
+	}
+}
@@ -0,0 +1,289 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+)
+
+// キーの形式: ak_<8桁hex>_<ランダム>。先頭の ak_<8桁hex> がprefix
+const apiKeyPrefix = "ak_"
+
+// last_used_at の更新間隔（リクエストごとに書き込まない）
+const apiKeyTouchInterval = time.Minute
+
+var ErrApiKeyInvalid = errors.New("invalid api key")
+
+// 管理者向け：APIキーの発行・一覧・失効と、リクエストの認証
+type ApiKeyUsecase struct {
+	keys      repo.ApiKeyRepository
+	users     repo.UserRepository
+	auditRepo repo.AuditLogRepository
+}
+
+func NewApiKeyUsecase(keys repo.ApiKeyRepository, users repo.UserRepository, auditRepo repo.AuditLogRepository) *ApiKeyUsecase {
+	return &ApiKeyUsecase{keys: keys, users: users, auditRepo: auditRepo}
+}
+
+type CreateApiKeyInput struct {
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+type ApiKeyOutput struct {
+	ID              int64      `json:"id"`
+	Name            string     `json:"name"`
+	Prefix          string     `json:"prefix"`
+	Scopes          []string   `json:"scopes"`
+	CreatedByUserID int64      `json:"created_by_user_id"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+	LastUsedAt      *time.Time `json:"last_used_at"`
+	RevokedAt       *time.Time `json:"revoked_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// 発行時だけキー本体を返す
+type CreatedApiKeyOutput struct {
+	ApiKeyOutput
+	Key string `json:"key"`
+}
+
+// 発行。監査ログに残す
+func (u *ApiKeyUsecase) Create(ctx context.Context, actorAdminUserID int64, in CreateApiKeyInput) (CreatedApiKeyOutput, error) {
+	if actorAdminUserID <= 0 {
+		return CreatedApiKeyOutput{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return CreatedApiKeyOutput{}, NewHTTPError(http.StatusBadRequest, "name required")
+	}
+	if len(name) > 100 {
+		return CreatedApiKeyOutput{}, NewHTTPError(http.StatusBadRequest, "name too long")
+	}
+
+	scopes, err := normalizeApiKeyScopes(in.Scopes)
+	if err != nil {
+		return CreatedApiKeyOutput{}, err
+	}
+
+	now := time.Now()
+	if in.ExpiresAt != nil && !in.ExpiresAt.After(now) {
+		return CreatedApiKeyOutput{}, NewHTTPError(http.StatusBadRequest, "expires_at must be in the future")
+	}
+
+	plain, prefix, err := newApiKey()
+	if err != nil {
+		return CreatedApiKeyOutput{}, NewHTTPError(http.StatusInternalServerError, "internal error")
+	}
+
+	key := model.ApiKey{
+		Name:            name,
+		Prefix:          prefix,
+		KeyHash:         hashToken(plain),
+		Scopes:          strings.Join(scopes, " "),
+		CreatedByUserID: actorAdminUserID,
+		ExpiresAt:       in.ExpiresAt,
+		CreatedAt:       now,
+	}
+	if err := u.keys.Create(ctx, &key); err != nil {
+		return CreatedApiKeyOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	out := toApiKeyOutput(key)
+	afterJSON, _ := json.Marshal(out)
+	if err := u.auditRepo.Create(ctx, model.AuditLog{
+		ActorUserID:  actorAdminUserID,
+		Action:       model.AuditActionCreateApiKey,
+		ResourceType: model.AuditResourceApiKey,
+		ResourceID:   key.ID,
+		BeforeJSON:   "",
+		AfterJSON:    string(afterJSON),
+		CreatedAt:    now,
+	}); err != nil {
+		return CreatedApiKeyOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	return CreatedApiKeyOutput{ApiKeyOutput: out, Key: plain}, nil
+}
+
+// 一覧（キー本体・ハッシュは返さない）
+func (u *ApiKeyUsecase) List(ctx context.Context) ([]ApiKeyOutput, error) {
+	list, err := u.keys.List(ctx)
+	if err != nil {
+		return []ApiKeyOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	outs := make([]ApiKeyOutput, 0, len(list))
+	for _, k := range list {
+		outs = append(outs, toApiKeyOutput(k))
+	}
+	return outs, nil
+}
+
+// 失効。監査ログに残す
+func (u *ApiKeyUsecase) Revoke(ctx context.Context, actorAdminUserID int64, id int64) error {
+	if actorAdminUserID <= 0 {
+		return NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	if id <= 0 {
+		return NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+
+	before, found, err := u.keys.FindByID(ctx, id)
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if !found {
+		return NewHTTPError(http.StatusNotFound, "not found")
+	}
+
+	now := time.Now()
+	revoked, err := u.keys.Revoke(ctx, id, now)
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	//失効済みなら何もしない
+	if !revoked {
+		return nil
+	}
+
+	if err := u.auditRepo.Create(ctx, model.AuditLog{
+		ActorUserID:  actorAdminUserID,
+		Action:       model.AuditActionRevokeApiKey,
+		ResourceType: model.AuditResourceApiKey,
+		ResourceID:   id,
+		BeforeJSON:   `{"prefix":"` + before.Prefix + `","revoked":false}`,
+		AfterJSON:    `{"prefix":"` + before.Prefix + `","revoked":true}`,
+		CreatedAt:    now,
+	}); err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	return nil
+}
+
+// X-API-Keyの検証。失効・期限切れ・発行した管理者が無効/降格なら ErrApiKeyInvalid
+func (u *ApiKeyUsecase) Authenticate(ctx context.Context, plain string) (*model.ApiKey, error) {
+	if !strings.HasPrefix(plain, apiKeyPrefix) || len(plain) > 128 {
+		return nil, ErrApiKeyInvalid
+	}
+
+	key, found, err := u.keys.FindByHash(ctx, hashToken(plain))
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if !found {
+		return nil, ErrApiKeyInvalid
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil {
+		return nil, ErrApiKeyInvalid
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(now) {
+		return nil, ErrApiKeyInvalid
+	}
+
+	owner, err := u.users.FindByID(ctx, key.CreatedByUserID)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if owner == nil || !owner.IsActive || owner.Role != model.RoleAdmin {
+		return nil, ErrApiKeyInvalid
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		//失敗してもリクエストは通す
+		if err := u.keys.TouchLastUsed(ctx, key.ID, now); err == nil {
+			key.LastUsedAt = &now
+		}
+	}
+
+	return &key, nil
+}
+
+type ctxApiKeyIDKey struct{}
+
+// APIキーで認証したリクエストのctx。監査ログにキーIDが残る
+func WithApiKeyActor(ctx context.Context, apiKeyID int64) context.Context {
+	return context.WithValue(ctx, ctxApiKeyIDKey{}, apiKeyID)
+}
+
+// 監査ログの ActorApiKeyID（APIキー経由でなければnil）
+func apiKeyActorID(ctx context.Context) *int64 {
+	id, ok := ctx.Value(ctxApiKeyIDKey{}).(int64)
+	if !ok || id <= 0 {
+		return nil
+	}
+	return &id
+}
+
+// 重複を除いて、知らないスコープはエラー
+func normalizeApiKeyScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, NewHTTPError(http.StatusBadRequest, "scopes required")
+	}
+
+	seen := map[string]bool{}
+	out := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		s = strings.TrimSpace(s)
+		if !isKnownApiKeyScope(s) {
+			return nil, NewHTTPError(http.StatusBadRequest, "invalid scope: "+s)
+		}
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func isKnownApiKeyScope(scope string) bool {
+	for _, s := range model.ApiKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// キー本体とprefixを作る
+func newApiKey() (plain string, prefix string, err error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	prefix = apiKeyPrefix + hex.EncodeToString(b)
+
+	secret, _, err := newRandomTokenAndHash()
+	if err != nil {
+		return "", "", err
+	}
+	return prefix + "_" + secret, prefix, nil
+}
+
+func toApiKeyOutput(k model.ApiKey) ApiKeyOutput {
+	return ApiKeyOutput{
+		ID:              k.ID,
+		Name:            k.Name,
+		Prefix:          k.Prefix,
+		Scopes:          k.ScopeList(),
+		CreatedByUserID: k.CreatedByUserID,
+		ExpiresAt:       k.ExpiresAt,
+		LastUsedAt:      k.LastUsedAt,
+		RevokedAt:       k.RevokedAt,
+		CreatedAt:       k.CreatedAt,
+	}
+}
@@ -4,17 +4,18 @@ import (
 	"app/internal/config"
 	"app/internal/domain/model"
 	"app/internal/repository"
+	"app/internal/security"
 
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"errors"
+	"log"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var (
@@ -28,6 +29,14 @@ var (
 	ErrSecurityIncident = errors.New("security incident")
 	// 409 競合
 	ErrConflict = errors.New("conflict")
+	// 400 ワンタイムトークンが無効（存在しない・期限切れ・使用済み）
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// 403 メールアドレス未確認
+	ErrEmailNotVerified = errors.New("email not verified")
+	// 429 ログイン失敗が続いてロック中（詳細は LoginLockedError）
+	ErrTooManyAttempts = errors.New("too many login attempts")
+	// 400 2FAコードが違う（登録確認・無効化時）
+	ErrInvalidMfaCode = errors.New("invalid mfa code")
 	// 500
 	ErrInternal = errors.New("internal error")
 )
@@ -45,14 +54,27 @@ type AuthValidator interface {
 	ValidateRefresh(ctx context.Context, refreshToken string, userAgent string) error
 	ValidateLogout(ctx context.Context) error
 	ValidateForceLogout(ctx context.Context, targetUserID int64) error
+	ValidateForgotPassword(ctx context.Context, email string) error
+	ValidateResetPassword(ctx context.Context, token string, newPassword string) error
+	ValidateVerifyEmail(ctx context.Context, token string) error
+	ValidateResendVerification(ctx context.Context, email string) error
+	ValidateLoginMfa(ctx context.Context, mfaToken string, code string, recoveryCode string) error
+	ValidateTotpConfirm(ctx context.Context, code string) error
+	ValidateTotpDisable(ctx context.Context, password string, code string) error
+	ValidateChangePassword(ctx context.Context, currentPassword string, newPassword string) error
+	ValidateMagicLinkRequest(ctx context.Context, email string) error
+	ValidateMagicLinkConsume(ctx context.Context, token string) error
 }
 
 type UserDTO struct {
-	ID           int64  `json:"id"`
-	Email        string `json:"email"`
-	Role         string `json:"role"`
-	TokenVersion int    `json:"token_version"`
-	IsActive     bool   `json:"is_active"`
+	ID            int64  `json:"id"`
+	Email         string `json:"email"`
+	Role          string `json:"role"`
+	TokenVersion  int    `json:"token_version"`
+	IsActive      bool   `json:"is_active"`
+	EmailVerified bool   `json:"email_verified"`
+	// 退会申請中なら匿名化される予定の時刻（ログイン後に取り消しを案内する）
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
 }
 
 type JwtAccessTokenDTO struct {
@@ -78,6 +100,8 @@ type AuthLoginRequest struct {
 type AuthLoginResponse struct {
 	User  UserDTO           `json:"user"`
 	Token JwtAccessTokenDTO `json:"token"`
+	// ADMINに2FA必須なのに未設定の場合true（/me/mfa/totp/enrollで設定する）
+	MfaSetupRequired bool `json:"mfa_setup_required,omitempty"`
 }
 
 type SuccessResponse struct {
@@ -93,6 +117,8 @@ type LoginResult struct {
 	Body              AuthLoginResponse
 	RefreshTokenPlain string
 	CsrfTokenPlain    string
+	// 2FAが有効なユーザーはtokenの代わりにこちらが入る
+	MfaChallenge *MfaChallengeResponse
 }
 
 type RefreshResult struct {
@@ -102,10 +128,18 @@ type RefreshResult struct {
 }
 
 type AuthUsecase struct {
-	cfg       config.Config
-	users     repository.UserRepository
-	rtRepo    repository.RefreshTokenRepository
-	validator AuthValidator
+	cfg          config.Config
+	users        repository.UserRepository
+	rtRepo       repository.RefreshTokenRepository
+	validator    AuthValidator
+	resetRepo    repository.PasswordResetTokenRepository
+	verifyRepo   repository.EmailVerificationTokenRepository
+	recoveryRepo repository.MfaRecoveryCodeRepository
+	attempts     repository.LoginAttemptRepository
+	mailer       Mailer
+	mfaBox       *security.SecretBox
+	passwords    *security.PasswordHasher
+	events       repository.SecurityEventRepository
 }
 
 func NewAuthUsecase(
@@ -113,12 +147,29 @@ func NewAuthUsecase(
 	users repository.UserRepository,
 	rtRepo repository.RefreshTokenRepository,
 	validator AuthValidator,
+	resetRepo repository.PasswordResetTokenRepository,
+	verifyRepo repository.EmailVerificationTokenRepository,
+	recoveryRepo repository.MfaRecoveryCodeRepository,
+	attempts repository.LoginAttemptRepository,
+	mailer Mailer,
+	events repository.SecurityEventRepository,
 ) *AuthUsecase {
+	//TOTPシークレット暗号化用（キーが空ならnilのまま。2FA系APIはErrInternalになる）
+	mfaBox, _ := security.NewSecretBox(cfg.MfaEncryptionKey)
+
 	return &AuthUsecase{
-		cfg:       cfg,
-		users:     users,
-		rtRepo:    rtRepo,
-		validator: validator,
+		cfg:          cfg,
+		users:        users,
+		rtRepo:       rtRepo,
+		validator:    validator,
+		resetRepo:    resetRepo,
+		verifyRepo:   verifyRepo,
+		recoveryRepo: recoveryRepo,
+		attempts:     attempts,
+		mailer:       mailer,
+		mfaBox:       mfaBox,
+		passwords:    cfg.PasswordHasher(),
+		events:       events,
 	}
 }
 
@@ -128,8 +179,8 @@ func (u *AuthUsecase) Register(ctx context.Context, req AuthRegisterRequest) (*A
 		return nil, err
 	}
 
-	//パスワードは必ずハッシュ化して保存
-	pwHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	//パスワードは必ずハッシュ化して保存（PASSWORD_HASH_ALGO）
+	pwHash, err := u.passwords.Hash(req.Password)
 	if err != nil {
 		return nil, ErrInternal
 	}
@@ -137,7 +188,7 @@ func (u *AuthUsecase) Register(ctx context.Context, req AuthRegisterRequest) (*A
 	//ユーザー作成
 	user := &model.User{
 		Email:        req.Email,
-		PasswordHash: string(pwHash),
+		PasswordHash: pwHash,
 		Role:         model.RoleUser,
 		TokenVersion: 0,
 		IsActive:     true,
@@ -148,6 +199,11 @@ func (u *AuthUsecase) Register(ctx context.Context, req AuthRegisterRequest) (*A
 		return nil, ErrConflict
 	}
 
+	//メールアドレス確認のリンクを送る（失敗しても登録は成功。再送APIで送り直せる）
+	if err := u.sendEmailVerification(ctx, user); err != nil {
+		log.Printf("email verification mail failed: user_id=%d err=%v", user.ID, err)
+	}
+
 	//DTOに変換して返す
 	userDTO, err := toUserDTOOpenAPI(user)
 	if err != nil {
@@ -168,29 +224,112 @@ func (u *AuthUsecase) Login(ctx context.Context, req AuthLoginRequest, userAgent
 		return nil, err
 	}
 
-	//ユーザー取得
+	//連続失敗でロック中なら bcrypt まで行かずに429
+	if err := u.checkLoginLock(ctx, req.Email, ip); err != nil {
+		u.recordLoginFailureEvent(ctx, model.SecurityEventLogin, nil, req.Email, "locked", userAgent, ip)
+		return nil, err
+	}
+
+	//ユーザー取得（存在しないメールアドレスも失敗として数える）
 	user, err := u.users.FindByEmail(ctx, req.Email)
 	if err != nil || user == nil {
-		return nil, ErrUnauthorized
+		u.recordLoginFailureEvent(ctx, model.SecurityEventLogin, nil, req.Email, "unknown_email", userAgent, ip)
+		return nil, u.loginFailed(ctx, req.Email, ip)
 	}
 
 	//停止ユーザーはログイン不可
 	if !user.IsActive {
+		u.recordLoginFailureEvent(ctx, model.SecurityEventLogin, user, req.Email, "inactive", userAgent, ip)
 		return nil, ErrForbidden
 	}
 
-	//パスワード照合（bcrypt）
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		return nil, ErrUnauthorized
+	//パスワード照合（保存済みの形式で）
+	if !u.verifyPassword(user, req.Password) {
+		u.recordLoginFailureEvent(ctx, model.SecurityEventLogin, user, req.Email, "invalid_password", userAgent, ip)
+		return nil, u.loginFailed(ctx, req.Email, ip)
+	}
+
+	//古い形式・コストのハッシュは平文が分かる今のうちに作り直す
+	u.rehashPasswordIfNeeded(ctx, user, req.Password)
+
+	//設定によってはメール未確認ユーザーはログイン不可
+	if u.cfg.EmailVerificationPolicy == config.EmailVerificationPolicyLogin && user.EmailVerifiedAt == nil {
+		u.recordLoginFailureEvent(ctx, model.SecurityEventLogin, user, req.Email, "email_not_verified", userAgent, ip)
+		return nil, ErrEmailNotVerified
+	}
+
+	//2FAが有効ならここではtokenを出さず、MFAチャレンジを返す（/auth/login/mfa で完了）
+	if user.TotpEnabledAt != nil {
+		challenge, err := u.issueMfaChallenge(user)
+		if err != nil {
+			return nil, ErrInternal
+		}
+		u.recordSecurityEvent(ctx, model.SecurityEvent{
+			UserID:    &user.ID,
+			Type:      model.SecurityEventLogin,
+			Outcome:   model.SecurityOutcomeSuccess,
+			Reason:    "mfa_required",
+			Email:     user.Email,
+			IP:        ip,
+			UserAgent: userAgent,
+		})
+		return &LoginResult{MfaChallenge: challenge}, nil
+	}
+
+	u.clearLoginFailures(ctx, user.Email)
+	return u.issueSession(ctx, user, model.SecurityEventLogin, false, userAgent, ip)
+}
+
+// パスワード照合（パスワード未設定・読めない形式のハッシュは不一致扱い）
+func (u *AuthUsecase) verifyPassword(user *model.User, plain string) bool {
+	if user.PasswordHash == "" {
+		return false
+	}
+	ok, err := u.passwords.Verify(user.PasswordHash, plain)
+	if err != nil {
+		log.Printf("password verify failed: user_id=%d err=%v", user.ID, err)
+		return false
+	}
+	return ok
+}
+
+// 今の設定（PASSWORD_HASH_ALGO・コスト）と違うハッシュなら作り直して保存（失敗してもログインは続行）
+func (u *AuthUsecase) rehashPasswordIfNeeded(ctx context.Context, user *model.User, plain string) {
+	if !u.passwords.NeedsRehash(user.PasswordHash) {
+		return
+	}
+	pwHash, err := u.passwords.Hash(plain)
+	if err != nil {
+		log.Printf("password rehash failed: user_id=%d err=%v", user.ID, err)
+		return
 	}
 
+	old := user.PasswordHash
+	user.PasswordHash = pwHash
+	if err := u.users.Update(ctx, user); err != nil {
+		user.PasswordHash = old
+		log.Printf("password rehash failed: user_id=%d err=%v", user.ID, err)
+	}
+}
+
+// 失敗を記録して返すエラーを決める（今回でロックされたら429、それ以外は401）
+func (u *AuthUsecase) loginFailed(ctx context.Context, email string, ip string) error {
+	if err := u.recordLoginFailure(ctx, email, ip); err != nil {
+		return err
+	}
+	return ErrUnauthorized
+}
+
+// ログイン完了（last_login更新 + access/refresh/csrf発行）。eventTypeでセキュリティイベントに残す
+// mfaVerifiedはこのログインで2FAを通ったか（refreshの系列に記録し、access tokenのmfaになる）
+func (u *AuthUsecase) issueSession(ctx context.Context, user *model.User, eventType model.SecurityEventType, mfaVerified bool, userAgent string, ip string) (*LoginResult, error) {
 	//last_login更新（失敗してもログインは継続）
 	now := time.Now()
 	user.LastLoginAt = &now
 	_ = u.users.Update(ctx, user)
 
 	//access token発行
-	accessToken, expiresIn, err := u.issueAccessToken(user)
+	accessToken, expiresIn, err := u.issueAccessToken(user, mfaVerified)
 	if err != nil {
 		return nil, ErrInternal
 	}
@@ -208,15 +347,19 @@ func (u *AuthUsecase) Login(ctx context.Context, req AuthLoginRequest, userAgent
 		ipPtr = &ipCopy
 	}
 
+	//ログインごとに新しい系列（1端末）
+	rtID := uuid.NewString()
 	rt := model.RefreshToken{
-		ID:        uuid.NewString(),
-		UserID:    user.ID,
-		TokenHash: refreshHash,
-		UserAgent: userAgent,
-		ExpiresAt: time.Now().Add(refreshTokenTTL),
-		UsedAt:    nil,
-		IP:        ipPtr,
-		CreatedAt: time.Now(),
+		ID:          rtID,
+		UserID:      user.ID,
+		FamilyID:    rtID,
+		TokenHash:   refreshHash,
+		UserAgent:   userAgent,
+		ExpiresAt:   time.Now().Add(refreshTokenTTL),
+		UsedAt:      nil,
+		IP:          ipPtr,
+		CreatedAt:   time.Now(),
+		MfaVerified: mfaVerified,
 	}
 
 	if err := u.rtRepo.Create(ctx, rt); err != nil {
@@ -229,6 +372,16 @@ func (u *AuthUsecase) Login(ctx context.Context, req AuthLoginRequest, userAgent
 		return nil, ErrInternal
 	}
 
+	u.recordSecurityEvent(ctx, model.SecurityEvent{
+		UserID:    &user.ID,
+		Type:      eventType,
+		Outcome:   model.SecurityOutcomeSuccess,
+		Email:     user.Email,
+		IP:        ip,
+		UserAgent: userAgent,
+		FamilyID:  rt.FamilyID,
+	})
+
 	//UserDTO
 	userDTO, err := toUserDTOOpenAPI(user)
 	if err != nil {
@@ -243,6 +396,7 @@ func (u *AuthUsecase) Login(ctx context.Context, req AuthLoginRequest, userAgent
 				ExpiresIn:    expiresIn,
 				TokenVersion: user.TokenVersion,
 			},
+			MfaSetupRequired: u.cfg.MfaRequiredForAdmin && user.Role == model.RoleAdmin && user.TotpEnabledAt == nil,
 		},
 		RefreshTokenPlain: refreshPlain,
 		CsrfTokenPlain:    csrfPlain,
@@ -295,16 +449,25 @@ func (u *AuthUsecase) Refresh(ctx context.Context, refreshTokenPlain string, use
 		return nil, ErrUnauthorized
 	}
 
-	//used済みが来たら replay → 全削除
+	//used済みが来たら replay → その系列（端末）だけ失効
 	if rt.UsedAt != nil {
-		_ = u.rtRepo.DeleteByUserID(ctx, rt.UserID)
+		u.revokeRefreshFamily(ctx, rt, model.SecurityEventRefreshReuse, userAgent, ip)
 		return nil, ErrSecurityIncident
 	}
 
-	//user_agent違い → 再認証扱い（全削除）
+	//user_agent違い → REFRESH_UA_MISMATCH_POLICY に従う
 	if userAgent != "" && rt.UserAgent != "" && userAgent != rt.UserAgent {
-		_ = u.rtRepo.DeleteByUserID(ctx, rt.UserID)
-		return nil, ErrSecurityIncident
+		switch u.cfg.RefreshUAMismatchPolicy {
+		case config.RefreshUAMismatchAllow:
+			u.recordRefreshSecurityEvent(ctx, rt, model.SecurityEventRefreshUAMismatch, model.SecurityOutcomeSuccess, "allowed", userAgent, ip)
+		case config.RefreshUAMismatchRevokeFamily:
+			u.revokeRefreshFamily(ctx, rt, model.SecurityEventRefreshUAMismatch, userAgent, ip)
+			return nil, ErrSecurityIncident
+		default:
+			_ = u.rtRepo.DeleteByUserID(ctx, rt.UserID)
+			u.recordRefreshSecurityEvent(ctx, rt, model.SecurityEventRefreshUAMismatch, model.SecurityOutcomeFailure, "revoke_all", userAgent, ip)
+			return nil, ErrSecurityIncident
+		}
 	}
 
 	//user取得
@@ -316,9 +479,9 @@ func (u *AuthUsecase) Refresh(ctx context.Context, refreshTokenPlain string, use
 		return nil, ErrForbidden
 	}
 
-	//旧tokenをusedにする（ここが失敗したら全削除→incident）
+	//旧tokenをusedにする（ここが失敗したら同時に使われた → 系列を失効してincident）
 	if err := u.rtRepo.MarkUsed(ctx, rt.ID); err != nil {
-		_ = u.rtRepo.DeleteByUserID(ctx, rt.UserID)
+		u.revokeRefreshFamily(ctx, rt, model.SecurityEventRefreshReuse, userAgent, ip)
 		return nil, ErrSecurityIncident
 	}
 
@@ -335,22 +498,24 @@ func (u *AuthUsecase) Refresh(ctx context.Context, refreshTokenPlain string, use
 	}
 
 	newRT := model.RefreshToken{
-		ID:        uuid.NewString(),
-		UserID:    user.ID,
-		TokenHash: newHash,
-		UserAgent: userAgent,
-		ExpiresAt: time.Now().Add(refreshTokenTTL),
-		UsedAt:    nil,
-		IP:        ipPtr,
-		CreatedAt: time.Now(),
+		ID:          uuid.NewString(),
+		UserID:      user.ID,
+		FamilyID:    refreshFamilyID(rt),
+		TokenHash:   newHash,
+		UserAgent:   userAgent,
+		ExpiresAt:   time.Now().Add(refreshTokenTTL),
+		UsedAt:      nil,
+		IP:          ipPtr,
+		CreatedAt:   time.Now(),
+		MfaVerified: rt.MfaVerified,
 	}
 
 	if err := u.rtRepo.Create(ctx, newRT); err != nil {
 		return nil, ErrInternal
 	}
 
-	//access再発行
-	accessToken, expiresIn, err := u.issueAccessToken(user)
+	//access再発行（2FAを通ったかは系列のログイン時のまま。後から2FAを有効にしても古い系列はmfa=falseのまま）
+	accessToken, expiresIn, err := u.issueAccessToken(user, rt.MfaVerified)
 	if err != nil {
 		return nil, ErrInternal
 	}
@@ -372,7 +537,27 @@ func (u *AuthUsecase) Refresh(ctx context.Context, refreshTokenPlain string, use
 	}, nil
 }
 
-func (u *AuthUsecase) Logout(ctx context.Context, refreshTokenPlain string) (*SuccessResponse, error) {
+// refreshの系列ID（系列導入前のtokenは自分のIDを系列IDとして引き継ぐ）
+func refreshFamilyID(rt model.RefreshToken) string {
+	if rt.FamilyID != "" {
+		return rt.FamilyID
+	}
+	return rt.ID
+}
+
+// 盗まれた可能性のある系列（端末）だけ失効する。
+// 系列導入前のtokenは子孫をたどれないので、従来どおりそのユーザーの全端末を失効する
+func (u *AuthUsecase) revokeRefreshFamily(ctx context.Context, rt model.RefreshToken, eventType model.SecurityEventType, userAgent string, ip string) {
+	if rt.FamilyID == "" {
+		_ = u.rtRepo.DeleteByUserID(ctx, rt.UserID)
+		u.recordRefreshSecurityEvent(ctx, rt, eventType, model.SecurityOutcomeFailure, "revoke_all", userAgent, ip)
+		return
+	}
+	_, _ = u.rtRepo.DeleteByFamilyID(ctx, rt.FamilyID)
+	u.recordRefreshSecurityEvent(ctx, rt, eventType, model.SecurityOutcomeFailure, "revoke_family", userAgent, ip)
+}
+
+func (u *AuthUsecase) Logout(ctx context.Context, refreshTokenPlain string, userAgent string, ip string) (*SuccessResponse, error) {
 	if err := u.validator.ValidateLogout(ctx); err != nil {
 		return nil, err
 	}
@@ -396,10 +581,20 @@ func (u *AuthUsecase) Logout(ctx context.Context, refreshTokenPlain string) (*Su
 		return nil, ErrInternal
 	}
 
+	u.recordSecurityEvent(ctx, model.SecurityEvent{
+		UserID:    &rt.UserID,
+		Type:      model.SecurityEventLogout,
+		Outcome:   model.SecurityOutcomeSuccess,
+		IP:        ip,
+		UserAgent: userAgent,
+		FamilyID:  refreshFamilyID(rt),
+	})
+
 	return &SuccessResponse{Message: "logout success"}, nil
 }
 
-func (u *AuthUsecase) ForceLogout(ctx context.Context, targetUserID int64) (*ForceLogoutResponse, error) {
+// actorUserIDは操作した管理者（セキュリティイベントに残す）
+func (u *AuthUsecase) ForceLogout(ctx context.Context, actorUserID int64, targetUserID int64, userAgent string, ip string) (*ForceLogoutResponse, error) {
 	if err := u.validator.ValidateForceLogout(ctx, targetUserID); err != nil {
 		return nil, err
 	}
@@ -416,6 +611,15 @@ func (u *AuthUsecase) ForceLogout(ctx context.Context, targetUserID int64) (*For
 		return nil, ErrInternal
 	}
 
+	u.recordSecurityEvent(ctx, model.SecurityEvent{
+		UserID:      &targetUserID,
+		ActorUserID: &actorUserID,
+		Type:        model.SecurityEventForceLogout,
+		Outcome:     model.SecurityOutcomeSuccess,
+		IP:          ip,
+		UserAgent:   userAgent,
+	})
+
 	//更新後を取得して new_token_version を返す
 	user, err := u.users.FindByID(ctx, targetUserID)
 	if err != nil || user == nil {
@@ -428,8 +632,8 @@ func (u *AuthUsecase) ForceLogout(ctx context.Context, targetUserID int64) (*For
 	}, nil
 }
 
-// jwt発行
-func (u *AuthUsecase) issueAccessToken(user *model.User) (string, int, error) {
+// jwt発行。mfaVerifiedはこのセッション（refreshの系列）のログインで2FAを通ったか
+func (u *AuthUsecase) issueAccessToken(user *model.User, mfaVerified bool) (string, int, error) {
 	now := time.Now()
 	exp := now.Add(accessTokenTTL)
 
@@ -437,13 +641,13 @@ func (u *AuthUsecase) issueAccessToken(user *model.User) (string, int, error) {
 		"sub":  user.ID,
 		"role": string(user.Role),
 		"tv":   user.TokenVersion,
+		"mfa":  mfaVerified && user.TotpEnabledAt != nil,
 		"iat":  now.Unix(),
 		"exp":  exp.Unix(),
 	}
 
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	signed, err := t.SignedString([]byte(u.cfg.JWTSecret))
+	//JWT_KEYS_DIRがあればRS256/EdDSA（kid付き）、無ければHS256
+	signed, err := u.cfg.JWTKeySet().Sign(claims)
 	if err != nil {
 		return "", 0, err
 	}
@@ -474,20 +678,24 @@ func hashToken(plain string) string {
 // model.UserをAPI返却用DTOに変換。
 func toUserDTO(u *model.User) UserDTO {
 	return UserDTO{
-		ID:           u.ID,
-		Email:        u.Email,
-		Role:         string(u.Role),
-		TokenVersion: u.TokenVersion,
-		IsActive:     u.IsActive,
+		ID:                  u.ID,
+		Email:               u.Email,
+		Role:                string(u.Role),
+		TokenVersion:        u.TokenVersion,
+		IsActive:            u.IsActive,
+		EmailVerified:       u.EmailVerifiedAt != nil,
+		DeletionScheduledAt: u.DeletionScheduledAt,
 	}
 }
 
 func toUserDTOOpenAPI(u *model.User) (UserDTO, error) {
 	return UserDTO{
-		ID:           u.ID,
-		Email:        u.Email,
-		Role:         string(u.Role),
-		TokenVersion: u.TokenVersion,
-		IsActive:     u.IsActive,
+		ID:                  u.ID,
+		Email:               u.Email,
+		Role:                string(u.Role),
+		TokenVersion:        u.TokenVersion,
+		IsActive:            u.IsActive,
+		EmailVerified:       u.EmailVerifiedAt != nil,
+		DeletionScheduledAt: u.DeletionScheduledAt,
 	}, nil
 }
@@ -1,39 +1,69 @@
 package usecase
 
 import (
+	"app/internal/config"
+	"app/internal/domain/model"
 	repo "app/internal/repository"
+	"app/internal/security"
 	"context"
+	"log"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // CartUsecase は /cart の業務ロジックです。
 // Repositoryは仕様書どおり、Cart と CartItem を分離して受け取ります。
+// 未ログインのゲストは署名付きcookie（カートID）でカートを持ち、ログイン・登録時に合算します。
 type CartUsecase struct {
 	cartRepo     repo.CartRepository
 	cartItemRepo repo.CartItemRepository
 	productRepo  repo.ProductRepository
+	variantRepo  repo.ProductVariantRepository
+
+	guestSigner *security.Signer
+	guestTTL    time.Duration
 }
 
 func NewCartUsecase(
+	cfg config.Config,
 	cartRepo repo.CartRepository,
 	cartItemRepo repo.CartItemRepository,
 	productRepo repo.ProductRepository,
+	variantRepo repo.ProductVariantRepository,
 ) *CartUsecase {
+	guestKey := cfg.GuestCartSigningKey
+	if guestKey == "" {
+		guestKey = cfg.JWTSecret
+	}
+	signer, _ := security.NewSigner(guestKey, "guest_cart")
+
+	ttlDays := cfg.GuestCartTTLDays
+	if ttlDays <= 0 {
+		ttlDays = 30
+	}
+
 	return &CartUsecase{
 		cartRepo:     cartRepo,
 		cartItemRepo: cartItemRepo,
 		productRepo:  productRepo,
+		variantRepo:  variantRepo,
+		guestSigner:  signer,
+		guestTTL:     time.Duration(ttlDays) * 24 * time.Hour,
 	}
 }
 
 // CartItemResponse は OASの CartItem に合わせます。
 // price は unit_price_snapshot（追加時点の価格）を返します。
+// バリエーションのある商品は variant_id と軸ラベル（追加時点）も返します。
 type CartItemResponse struct {
-	ID        int64  `json:"id"`
-	ProductID int64  `json:"product_id"`
-	Name      string `json:"name"`
-	Price     int64  `json:"price"`
-	Quantity  int64  `json:"quantity"`
+	ID           int64  `json:"id"`
+	ProductID    int64  `json:"product_id"`
+	VariantID    *int64 `json:"variant_id,omitempty"`
+	Name         string `json:"name"`
+	OptionLabels string `json:"option_labels,omitempty"`
+	Price        int64  `json:"price"`
+	Quantity     int64  `json:"quantity"`
 }
 
 // CartResponse は OASの CartResponse に合わせます。
@@ -45,6 +75,7 @@ type CartResponse struct {
 // OAS: AddCartRequest
 type AddCartInput struct {
 	ProductID int64
+	VariantID *int64
 	Quantity  int64
 }
 
@@ -53,6 +84,12 @@ type UpdateCartItemInput struct {
 	Quantity int64
 }
 
+// ゲストカートの結果。GuestToken が空でなければ guest cart cookie にセットする
+type GuestCartOutput struct {
+	Cart       CartResponse
+	GuestToken string
+}
+
 // GetCart はカート取得（無ければACTIVEを作って空を返す）。
 func (u *CartUsecase) GetCart(ctx context.Context, userID int64) (CartResponse, error) {
 	if userID <= 0 {
@@ -85,6 +122,260 @@ func (u *CartUsecase) AddToCart(ctx context.Context, userID int64, in AddCartInp
 		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
 	}
 
+	return u.addItem(ctx, cart.ID, in)
+}
+
+// 数量変更（所有チェック＋在庫チェック）。
+func (u *CartUsecase) UpdateCartItem(ctx context.Context, userID int64, cartItemID int64, in UpdateCartItemInput) (CartResponse, error) {
+	if userID <= 0 {
+		return CartResponse{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	if cartItemID <= 0 {
+		return CartResponse{}, NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	if in.Quantity < 1 {
+		return CartResponse{}, NewHTTPError(http.StatusBadRequest, "invalid quantity")
+	}
+
+	owned, err := u.cartItemRepo.IsOwnedByUser(ctx, cartItemID, userID)
+	if err != nil {
+		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if !owned {
+		return CartResponse{}, NewHTTPError(http.StatusNotFound, "not found")
+	}
+
+	if err := u.updateItemQuantity(ctx, cartItemID, in.Quantity); err != nil {
+		return CartResponse{}, err
+	}
+
+	//ACTIVEカートを取得して返却
+	cart, err := u.cartRepo.FindActiveByUserID(ctx, userID)
+	if err != nil {
+		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return u.buildCartResponse(ctx, cart.ID)
+}
+
+// 明細削除
+func (u *CartUsecase) DeleteCartItem(ctx context.Context, userID int64, cartItemID int64) (CartResponse, error) {
+	if userID <= 0 {
+		return CartResponse{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	if cartItemID <= 0 {
+		return CartResponse{}, NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+
+	owned, err := u.cartItemRepo.IsOwnedByUser(ctx, cartItemID, userID)
+	if err != nil {
+		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if !owned {
+		return CartResponse{}, NewHTTPError(http.StatusNotFound, "not found")
+	}
+
+	if err := u.cartItemRepo.DeleteByID(ctx, cartItemID); err != nil {
+		if err == repo.ErrNotFound {
+			return CartResponse{}, NewHTTPError(http.StatusNotFound, "not found")
+		}
+		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	cart, err := u.cartRepo.FindActiveByUserID(ctx, userID)
+	if err != nil {
+		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return u.buildCartResponse(ctx, cart.ID)
+}
+
+// GetGuestCart はゲストカート取得（cookieが無ければ作らずに空を返す）。
+func (u *CartUsecase) GetGuestCart(ctx context.Context, guestToken string) (GuestCartOutput, error) {
+	cart, found, err := u.findGuestCart(ctx, guestToken)
+	if err != nil {
+		return GuestCartOutput{}, err
+	}
+	if !found {
+		return GuestCartOutput{Cart: CartResponse{Items: []CartItemResponse{}}}, nil
+	}
+
+	resp, err := u.buildCartResponse(ctx, cart.ID)
+	if err != nil {
+		return GuestCartOutput{}, err
+	}
+	return GuestCartOutput{Cart: resp, GuestToken: u.signGuestCart(cart.ID)}, nil
+}
+
+// AddToGuestCart はゲストカートに追加（カートが無ければ作ってcookieを返す）。
+func (u *CartUsecase) AddToGuestCart(ctx context.Context, guestToken string, in AddCartInput) (GuestCartOutput, error) {
+	if in.ProductID <= 0 {
+		return GuestCartOutput{}, NewHTTPError(http.StatusBadRequest, "invalid product_id")
+	}
+	if in.Quantity < 1 {
+		return GuestCartOutput{}, NewHTTPError(http.StatusBadRequest, "invalid quantity")
+	}
+
+	cart, found, err := u.findGuestCart(ctx, guestToken)
+	if err != nil {
+		return GuestCartOutput{}, err
+	}
+	if !found {
+		cart, err = u.cartRepo.CreateGuest(ctx)
+		if err != nil {
+			return GuestCartOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+	}
+
+	resp, err := u.addItem(ctx, cart.ID, in)
+	if err != nil {
+		return GuestCartOutput{}, err
+	}
+	return GuestCartOutput{Cart: resp, GuestToken: u.signGuestCart(cart.ID)}, nil
+}
+
+// ゲストカートの数量変更
+func (u *CartUsecase) UpdateGuestCartItem(ctx context.Context, guestToken string, cartItemID int64, in UpdateCartItemInput) (GuestCartOutput, error) {
+	if cartItemID <= 0 {
+		return GuestCartOutput{}, NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	if in.Quantity < 1 {
+		return GuestCartOutput{}, NewHTTPError(http.StatusBadRequest, "invalid quantity")
+	}
+
+	cart, err := u.findGuestCartItemOwner(ctx, guestToken, cartItemID)
+	if err != nil {
+		return GuestCartOutput{}, err
+	}
+
+	if err := u.updateItemQuantity(ctx, cartItemID, in.Quantity); err != nil {
+		return GuestCartOutput{}, err
+	}
+
+	resp, err := u.buildCartResponse(ctx, cart.ID)
+	if err != nil {
+		return GuestCartOutput{}, err
+	}
+	return GuestCartOutput{Cart: resp, GuestToken: u.signGuestCart(cart.ID)}, nil
+}
+
+// ゲストカートの明細削除
+func (u *CartUsecase) DeleteGuestCartItem(ctx context.Context, guestToken string, cartItemID int64) (GuestCartOutput, error) {
+	if cartItemID <= 0 {
+		return GuestCartOutput{}, NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+
+	cart, err := u.findGuestCartItemOwner(ctx, guestToken, cartItemID)
+	if err != nil {
+		return GuestCartOutput{}, err
+	}
+
+	if err := u.cartItemRepo.DeleteByID(ctx, cartItemID); err != nil {
+		if err == repo.ErrNotFound {
+			return GuestCartOutput{}, NewHTTPError(http.StatusNotFound, "not found")
+		}
+		return GuestCartOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	resp, err := u.buildCartResponse(ctx, cart.ID)
+	if err != nil {
+		return GuestCartOutput{}, err
+	}
+	return GuestCartOutput{Cart: resp, GuestToken: u.signGuestCart(cart.ID)}, nil
+}
+
+// MergeGuestCart はログイン・登録したユーザーのACTIVEカートにゲストカートを合算する。
+// 同一商品（同じバリエーション）は数量を足して在庫で頭打ちにする。単価は追加時点の価格のまま
+// （ユーザーのカートにある商品はその単価、ゲストだけにある商品はゲストで追加した時点の単価）。
+// cookieが無効・合算済みなら何もしない
+func (u *CartUsecase) MergeGuestCart(ctx context.Context, userID int64, guestToken string) error {
+	if userID <= 0 {
+		return NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	guest, found, err := u.findGuestCart(ctx, guestToken)
+	if err != nil || !found {
+		return err
+	}
+
+	//同時ログインで二重に合算しないよう、先にゲストカートを閉じてから明細を読む
+	//（閉じる前の追加・変更は全部引き継ぎ、閉じた後のゲスト側の操作は新しいゲストカートに入る）
+	claimed, err := u.cartRepo.MarkGuestMerged(ctx, guest.ID)
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if !claimed {
+		return nil
+	}
+
+	guestItems, err := u.cartItemRepo.ListByCartID(ctx, guest.ID)
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if len(guestItems) == 0 {
+		return nil
+	}
+
+	cart, err := u.cartRepo.GetOrCreateActiveByUserID(ctx, userID)
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	items, err := u.cartItemRepo.ListByCartID(ctx, cart.ID)
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	existing := make(map[cartLineKey]model.CartItem, len(items))
+	for _, it := range items {
+		existing[newCartLineKey(it.ProductID, it.VariantID)] = it
+	}
+
+	for _, gi := range guestItems {
+		p, err := u.productRepo.FindByID(ctx, gi.ProductID)
+		if err == repo.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+		if !p.IsActive {
+			continue
+		}
+		line, err := u.resolveLine(ctx, p, gi.VariantID)
+		if he, ok := AsHTTPError(err); ok && he.Status == http.StatusBadRequest {
+			//非公開・削除済みのバリエーションは引き継がない
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		//在庫切れは引き継がない
+		if line.stock < 1 {
+			continue
+		}
+
+		if cur, ok := existing[newCartLineKey(gi.ProductID, gi.VariantID)]; ok {
+			newQty := min(cur.Quantity+gi.Quantity, line.stock)
+			if newQty == cur.Quantity {
+				continue
+			}
+			if err := u.cartItemRepo.UpdateQuantity(ctx, cur.ID, newQty); err != nil {
+				return NewHTTPError(http.StatusInternalServerError, "db error")
+			}
+			continue
+		}
+
+		qty := min(gi.Quantity, line.stock)
+		if err := u.cartItemRepo.UpsertByCartAndProduct(ctx, cart.ID, gi.ProductID, gi.VariantID, qty, gi.UnitPriceSnapshot, gi.OptionLabelsSnapshot); err != nil {
+			return NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+	}
+
+	log.Printf("guest cart merged: guest_cart_id=%d user_id=%d cart_id=%d", guest.ID, userID, cart.ID)
+	return nil
+}
+
+// cartIDに商品を追加する（公開商品のみ・既存数量と合わせて在庫まで）。
+// バリエーションのある商品はバリエーションの価格・在庫で判定する。
+func (u *CartUsecase) addItem(ctx context.Context, cartID int64, in AddCartInput) (CartResponse, error) {
 	// 商品チェック（公開のみ）
 	p, err := u.productRepo.FindByID(ctx, in.ProductID)
 	if err == repo.ErrNotFound {
@@ -96,122 +387,195 @@ func (u *CartUsecase) AddToCart(ctx context.Context, userID int64, in AddCartInp
 	if !p.IsActive {
 		return CartResponse{}, NewHTTPError(http.StatusBadRequest, "invalid")
 	}
+	line, err := u.resolveLine(ctx, p, in.VariantID)
+	if err != nil {
+		return CartResponse{}, err
+	}
 
 	// 既存数量を仕様どおり ListByCartID で調べる（FindByCartAndProductは追加しない）
-	items, err := u.cartItemRepo.ListByCartID(ctx, cart.ID)
+	items, err := u.cartItemRepo.ListByCartID(ctx, cartID)
 	if err != nil {
 		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
 	}
 
 	var existingQty int64 = 0
+	key := newCartLineKey(in.ProductID, line.variantID)
 	for _, it := range items {
-		if it.ProductID == in.ProductID {
+		if newCartLineKey(it.ProductID, it.VariantID) == key {
 			existingQty = it.Quantity
 			break
 		}
 	}
 
 	newQty := existingQty + in.Quantity
-	if newQty > p.Stock {
+	if newQty > line.stock {
 		return CartResponse{}, NewHTTPError(http.StatusBadRequest, "stock exceeded")
 	}
 
 	// Upsert（同一商品は加算）
-	// unit_price_snapshot は「追加時点の価格」を渡す
-	if err := u.cartItemRepo.UpsertByCartAndProduct(ctx, cart.ID, in.ProductID, in.Quantity, p.Price); err != nil {
+	// unit_price_snapshot・軸ラベルは「追加時点」のものを渡す
+	if err := u.cartItemRepo.UpsertByCartAndProduct(ctx, cartID, in.ProductID, line.variantID, in.Quantity, line.price, line.labels); err != nil {
 		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
 	}
 
-	return u.buildCartResponse(ctx, cart.ID)
+	return u.buildCartResponse(ctx, cartID)
 }
 
-// 数量変更（所有チェック＋在庫チェック）。
-func (u *CartUsecase) UpdateCartItem(ctx context.Context, userID int64, cartItemID int64, in UpdateCartItemInput) (CartResponse, error) {
-	if userID <= 0 {
-		return CartResponse{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
-	}
-	if cartItemID <= 0 {
-		return CartResponse{}, NewHTTPError(http.StatusBadRequest, "invalid id")
-	}
-	if in.Quantity < 1 {
-		return CartResponse{}, NewHTTPError(http.StatusBadRequest, "invalid quantity")
-	}
-
-	owned, err := u.cartItemRepo.IsOwnedByUser(ctx, cartItemID, userID)
-	if err != nil {
-		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
-	}
-	if !owned {
-		return CartResponse{}, NewHTTPError(http.StatusNotFound, "not found")
-	}
-
+// 明細の数量を変更する（在庫チェック）。所有チェックは呼び出し側で行う。
+func (u *CartUsecase) updateItemQuantity(ctx context.Context, cartItemID int64, qty int64) error {
 	item, err := u.cartItemRepo.FindByID(ctx, cartItemID)
 	if err == repo.ErrNotFound {
-		return CartResponse{}, NewHTTPError(http.StatusNotFound, "not found")
+		return NewHTTPError(http.StatusNotFound, "not found")
 	}
 	if err != nil {
-		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		return NewHTTPError(http.StatusInternalServerError, "db error")
 	}
 
 	//商品の在庫チェック
 	p, err := u.productRepo.FindByID(ctx, item.ProductID)
 	if err == repo.ErrNotFound {
-		return CartResponse{}, NewHTTPError(http.StatusBadRequest, "invalid")
+		return NewHTTPError(http.StatusBadRequest, "invalid")
 	}
 	if err != nil {
-		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		return NewHTTPError(http.StatusInternalServerError, "db error")
 	}
 	if !p.IsActive {
-		return CartResponse{}, NewHTTPError(http.StatusBadRequest, "invalid")
+		return NewHTTPError(http.StatusBadRequest, "invalid")
 	}
-	if in.Quantity > p.Stock {
-		return CartResponse{}, NewHTTPError(http.StatusBadRequest, "stock exceeded")
+	line, err := u.resolveLine(ctx, p, item.VariantID)
+	if err != nil {
+		return err
+	}
+	if qty > line.stock {
+		return NewHTTPError(http.StatusBadRequest, "stock exceeded")
 	}
 
-	if err := u.cartItemRepo.UpdateQuantity(ctx, cartItemID, in.Quantity); err != nil {
+	if err := u.cartItemRepo.UpdateQuantity(ctx, cartItemID, qty); err != nil {
 		if err == repo.ErrNotFound {
-			return CartResponse{}, NewHTTPError(http.StatusNotFound, "not found")
+			return NewHTTPError(http.StatusNotFound, "not found")
 		}
-		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		return NewHTTPError(http.StatusInternalServerError, "db error")
 	}
+	return nil
+}
 
-	//ACTIVEカートを取得して返却
-	cart, err := u.cartRepo.FindActiveByUserID(ctx, userID)
+// カートに入れる単位（商品そのもの、またはバリエーション）の価格・在庫
+type cartLine struct {
+	variantID *int64
+	price     int64
+	stock     int64
+	labels    string
+}
+
+// 明細を同一とみなすキー（バリエーションの無い商品はvariantID=0）
+type cartLineKey struct {
+	productID int64
+	variantID int64
+}
+
+func newCartLineKey(productID int64, variantID *int64) cartLineKey {
+	k := cartLineKey{productID: productID}
+	if variantID != nil {
+		k.variantID = *variantID
+	}
+	return k
+}
+
+// 公開商品とvariant_idから、カートに入れる単位を決める。
+// バリエーションのある商品はvariant_id必須（公開中のもののみ）、無い商品には指定できない
+func (u *CartUsecase) resolveLine(ctx context.Context, p model.Product, variantID *int64) (cartLine, error) {
+	variants, err := u.variantRepo.ListByProductID(ctx, p.ID)
 	if err != nil {
-		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		return cartLine{}, NewHTTPError(http.StatusInternalServerError, "db error")
 	}
-	return u.buildCartResponse(ctx, cart.ID)
+
+	if len(variants) == 0 {
+		if variantID != nil {
+			return cartLine{}, NewHTTPError(http.StatusBadRequest, "invalid variant_id")
+		}
+		return cartLine{price: p.Price, stock: p.Stock}, nil
+	}
+	if variantID == nil {
+		return cartLine{}, NewHTTPError(http.StatusBadRequest, "variant_id required")
+	}
+
+	for _, v := range variants {
+		if v.ID != *variantID || !v.IsActive {
+			continue
+		}
+		options, err := u.variantRepo.ListOptions(ctx, p.ID)
+		if err != nil {
+			return cartLine{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+		id := v.ID
+		return cartLine{variantID: &id, price: v.Price, stock: v.Stock, labels: variantOptionLabels(options, v)}, nil
+	}
+	return cartLine{}, NewHTTPError(http.StatusBadRequest, "invalid variant_id")
 }
 
-// 明細削除
-func (u *CartUsecase) DeleteCartItem(ctx context.Context, userID int64, cartItemID int64) (CartResponse, error) {
-	if userID <= 0 {
-		return CartResponse{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+// cookieの値（"<cartID>.<署名>"）からACTIVEのゲストカートを探す。
+// 署名が不正・カートが無い・合算済みなら found=false
+func (u *CartUsecase) findGuestCart(ctx context.Context, guestToken string) (model.Cart, bool, error) {
+	if guestToken == "" || u.guestSigner == nil {
+		return model.Cart{}, false, nil
 	}
-	if cartItemID <= 0 {
-		return CartResponse{}, NewHTTPError(http.StatusBadRequest, "invalid id")
+	raw, ok := u.guestSigner.Verify(guestToken)
+	if !ok {
+		return model.Cart{}, false, nil
+	}
+	cartID, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || cartID <= 0 {
+		return model.Cart{}, false, nil
 	}
 
-	owned, err := u.cartItemRepo.IsOwnedByUser(ctx, cartItemID, userID)
-	if err != nil {
-		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	cart, err := u.cartRepo.FindActiveGuestByID(ctx, cartID)
+	if err == repo.ErrNotFound {
+		return model.Cart{}, false, nil
 	}
-	if !owned {
-		return CartResponse{}, NewHTTPError(http.StatusNotFound, "not found")
+	if err != nil {
+		return model.Cart{}, false, NewHTTPError(http.StatusInternalServerError, "db error")
 	}
+	return cart, true, nil
+}
 
-	if err := u.cartItemRepo.DeleteByID(ctx, cartItemID); err != nil {
-		if err == repo.ErrNotFound {
-			return CartResponse{}, NewHTTPError(http.StatusNotFound, "not found")
-		}
-		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
+// 明細がcookieのゲストカートに属していればそのカートを返す
+func (u *CartUsecase) findGuestCartItemOwner(ctx context.Context, guestToken string, cartItemID int64) (model.Cart, error) {
+	cart, found, err := u.findGuestCart(ctx, guestToken)
+	if err != nil {
+		return model.Cart{}, err
+	}
+	if !found {
+		return model.Cart{}, NewHTTPError(http.StatusNotFound, "not found")
 	}
 
-	cart, err := u.cartRepo.FindActiveByUserID(ctx, userID)
+	item, err := u.cartItemRepo.FindByID(ctx, cartItemID)
+	if err == repo.ErrNotFound {
+		return model.Cart{}, NewHTTPError(http.StatusNotFound, "not found")
+	}
 	if err != nil {
-		return CartResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		return model.Cart{}, NewHTTPError(http.StatusInternalServerError, "db error")
 	}
-	return u.buildCartResponse(ctx, cart.ID)
+	if item.CartID != cart.ID {
+		return model.Cart{}, NewHTTPError(http.StatusNotFound, "not found")
+	}
+	return cart, nil
+}
+
+func (u *CartUsecase) signGuestCart(cartID int64) string {
+	if u.guestSigner == nil {
+		return ""
+	}
+	return u.guestSigner.Sign(strconv.FormatInt(cartID, 10))
+}
+
+// ゲストカートcookieの寿命
+func (u *CartUsecase) GuestCartTTL() time.Duration {
+	return u.guestTTL
+}
+
+// 放置された（保持日数のあいだ更新の無い）ゲストカートと合算済みのゲストカートを消して件数を返す（定期実行）
+func (u *CartUsecase) PurgeAbandonedGuests(ctx context.Context, now time.Time) (int64, error) {
+	return u.cartRepo.DeleteAbandonedGuests(ctx, now.Add(-u.guestTTL))
 }
 
 // cartIDの明細をまとめてCartResponseを作る。
@@ -232,13 +596,22 @@ func (u *CartUsecase) buildCartResponse(ctx context.Context, cartID int64) (Cart
 		if !p.IsActive {
 			continue
 		}
+		//非公開・削除済みのバリエーションも出さない
+		if it.VariantID != nil {
+			v, err := u.variantRepo.FindByID(ctx, *it.VariantID)
+			if err != nil || !v.IsActive {
+				continue
+			}
+		}
 
 		respItems = append(respItems, CartItemResponse{
-			ID:        it.ID,
-			ProductID: it.ProductID,
-			Name:      p.Name,
-			Price:     it.UnitPriceSnapshot,
-			Quantity:  it.Quantity,
+			ID:           it.ID,
+			ProductID:    it.ProductID,
+			VariantID:    it.VariantID,
+			Name:         p.Name,
+			OptionLabels: it.OptionLabelsSnapshot,
+			Price:        it.UnitPriceSnapshot,
+			Quantity:     it.Quantity,
 		})
 
 		total += it.UnitPriceSnapshot * it.Quantity
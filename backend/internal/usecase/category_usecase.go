@@ -0,0 +1,285 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+)
+
+// slugは小文字英数字とハイフン（先頭・末尾・連続ハイフンは不可）
+var categorySlugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+const (
+	categoryNameMaxLen = 100
+	categorySlugMaxLen = 100
+)
+
+type CategoryUsecase struct {
+	categoryRepo repo.CategoryRepository
+}
+
+// DI
+func NewCategoryUsecase(categoryRepo repo.CategoryRepository) *CategoryUsecase {
+	return &CategoryUsecase{categoryRepo: categoryRepo}
+}
+
+// GET /categories のツリー
+type CategoryTreeNode struct {
+	ID        int64              `json:"id"`
+	Name      string             `json:"name"`
+	Slug      string             `json:"slug"`
+	SortOrder int                `json:"sort_order"`
+	Children  []CategoryTreeNode `json:"children"`
+}
+
+type CategoryTreeOutput struct {
+	Items []CategoryTreeNode `json:"items"`
+}
+
+// 公開用のカテゴリツリー
+func (u *CategoryUsecase) Tree(ctx context.Context) (CategoryTreeOutput, error) {
+	all, err := u.categoryRepo.ListAll(ctx)
+	if err != nil {
+		return CategoryTreeOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return CategoryTreeOutput{Items: buildCategoryTree(all)}, nil
+}
+
+// ListAllの並び（sort_order, id）を保ったまま親子に組み立てる
+func buildCategoryTree(all []model.Category) []CategoryTreeNode {
+	children := map[int64][]model.Category{}
+	exists := map[int64]bool{}
+	for _, c := range all {
+		exists[c.ID] = true
+	}
+
+	var roots []model.Category
+	for _, c := range all {
+		//親が見つからないものはルート扱い
+		if c.ParentID == nil || !exists[*c.ParentID] {
+			roots = append(roots, c)
+			continue
+		}
+		children[*c.ParentID] = append(children[*c.ParentID], c)
+	}
+
+	var build func(list []model.Category) []CategoryTreeNode
+	build = func(list []model.Category) []CategoryTreeNode {
+		nodes := make([]CategoryTreeNode, 0, len(list))
+		for _, c := range list {
+			nodes = append(nodes, CategoryTreeNode{
+				ID:        c.ID,
+				Name:      c.Name,
+				Slug:      c.Slug,
+				SortOrder: c.SortOrder,
+				Children:  build(children[c.ID]),
+			})
+		}
+		return nodes
+	}
+	return build(roots)
+}
+
+// rootIDと、その子孫カテゴリのID（root自身を含む）
+func descendantCategoryIDs(all []model.Category, rootID int64) []int64 {
+	children := map[int64][]int64{}
+	for _, c := range all {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+
+	ids := []int64{rootID}
+	seen := map[int64]bool{rootID: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			ids = append(ids, child)
+		}
+	}
+	return ids
+}
+
+// 管理画面の一覧（フラット）
+type AdminCategoryListOutput struct {
+	Items []model.Category `json:"items"`
+}
+
+func (u *CategoryUsecase) AdminList(ctx context.Context) (AdminCategoryListOutput, error) {
+	all, err := u.categoryRepo.ListAll(ctx)
+	if err != nil {
+		return AdminCategoryListOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if all == nil {
+		all = []model.Category{}
+	}
+	return AdminCategoryListOutput{Items: all}, nil
+}
+
+type AdminCategoryInput struct {
+	ParentID  *int64
+	Name      string
+	Slug      string
+	SortOrder int
+}
+
+func validateCategoryInput(in AdminCategoryInput) (string, string, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return "", "", NewHTTPError(http.StatusBadRequest, "name required")
+	}
+	if len([]rune(name)) > categoryNameMaxLen {
+		return "", "", NewHTTPError(http.StatusBadRequest, "name too long")
+	}
+
+	slug := strings.TrimSpace(in.Slug)
+	if slug == "" {
+		return "", "", NewHTTPError(http.StatusBadRequest, "slug required")
+	}
+	if len(slug) > categorySlugMaxLen || !categorySlugPattern.MatchString(slug) {
+		return "", "", NewHTTPError(http.StatusBadRequest, "invalid slug")
+	}
+	return name, slug, nil
+}
+
+func (u *CategoryUsecase) AdminCreate(ctx context.Context, in AdminCategoryInput) (model.Category, error) {
+	name, slug, err := validateCategoryInput(in)
+	if err != nil {
+		return model.Category{}, err
+	}
+
+	if in.ParentID != nil {
+		if _, err := u.categoryRepo.FindByID(ctx, *in.ParentID); err != nil {
+			if err == repo.ErrNotFound {
+				return model.Category{}, NewHTTPError(http.StatusBadRequest, "parent not found")
+			}
+			return model.Category{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+	}
+	if err := u.ensureSlugAvailable(ctx, slug, 0); err != nil {
+		return model.Category{}, err
+	}
+
+	now := time.Now()
+	c, err := u.categoryRepo.Create(ctx, model.Category{
+		ParentID:  in.ParentID,
+		Name:      name,
+		Slug:      slug,
+		SortOrder: in.SortOrder,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return model.Category{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return c, nil
+}
+
+func (u *CategoryUsecase) AdminUpdate(ctx context.Context, categoryID int64, in AdminCategoryInput) (model.Category, error) {
+	if categoryID <= 0 {
+		return model.Category{}, NewHTTPError(http.StatusBadRequest, "invalid category id")
+	}
+	name, slug, err := validateCategoryInput(in)
+	if err != nil {
+		return model.Category{}, err
+	}
+
+	all, err := u.categoryRepo.ListAll(ctx)
+	if err != nil {
+		return model.Category{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	var current *model.Category
+	for i := range all {
+		if all[i].ID == categoryID {
+			current = &all[i]
+			break
+		}
+	}
+	if current == nil {
+		return model.Category{}, NewHTTPError(http.StatusNotFound, "not found")
+	}
+
+	//親は存在していて、自分自身や自分の子孫でないこと（循環させない）
+	if in.ParentID != nil {
+		found := false
+		for _, c := range all {
+			if c.ID == *in.ParentID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return model.Category{}, NewHTTPError(http.StatusBadRequest, "parent not found")
+		}
+		for _, id := range descendantCategoryIDs(all, categoryID) {
+			if id == *in.ParentID {
+				return model.Category{}, NewHTTPError(http.StatusBadRequest, "parent must not be the category itself or its descendant")
+			}
+		}
+	}
+	if err := u.ensureSlugAvailable(ctx, slug, categoryID); err != nil {
+		return model.Category{}, err
+	}
+
+	updated := *current
+	updated.ParentID = in.ParentID
+	updated.Name = name
+	updated.Slug = slug
+	updated.SortOrder = in.SortOrder
+	updated.UpdatedAt = time.Now()
+
+	if err := u.categoryRepo.Update(ctx, updated); err != nil {
+		if err == repo.ErrNotFound {
+			return model.Category{}, NewHTTPError(http.StatusNotFound, "not found")
+		}
+		return model.Category{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return updated, nil
+}
+
+// 子カテゴリがあるものは消せない（先に子を移動・削除する）
+func (u *CategoryUsecase) AdminDelete(ctx context.Context, categoryID int64) error {
+	if categoryID <= 0 {
+		return NewHTTPError(http.StatusBadRequest, "invalid category id")
+	}
+
+	all, err := u.categoryRepo.ListAll(ctx)
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if len(descendantCategoryIDs(all, categoryID)) > 1 {
+		return NewHTTPError(http.StatusConflict, "category has children")
+	}
+
+	if err := u.categoryRepo.Delete(ctx, categoryID); err != nil {
+		if err == repo.ErrNotFound {
+			return NewHTTPError(http.StatusNotFound, "not found")
+		}
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return nil
+}
+
+// slugの重複を事前に確認（selfIDは更新時の自分自身）
+func (u *CategoryUsecase) ensureSlugAvailable(ctx context.Context, slug string, selfID int64) error {
+	c, err := u.categoryRepo.FindBySlug(ctx, slug)
+	if err == repo.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if c.ID != selfID {
+		return NewHTTPError(http.StatusConflict, "slug already exists")
+	}
+	return nil
+}
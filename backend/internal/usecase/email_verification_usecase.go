@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+
+	"github.com/google/uuid"
+)
+
+// メール確認トークンの有効期限
+const emailVerificationTokenTTL = 24 * time.Hour
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+// メールアドレスが登録済みかどうかは返さない（常に同じレスポンス）
+const resendVerificationMessage = "if the email is registered and not yet verified, a verification link has been sent"
+
+// POST /auth/verify-email
+func (u *AuthUsecase) VerifyEmail(ctx context.Context, req VerifyEmailRequest) (*SuccessResponse, error) {
+	if err := u.validator.ValidateVerifyEmail(ctx, req.Token); err != nil {
+		return nil, err
+	}
+
+	token, found, err := u.verifyRepo.FindByHash(ctx, hashToken(req.Token))
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if !found || token.UsedAt != nil || token.ExpiresAt.Before(time.Now()) {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := u.users.FindByID(ctx, token.UserID)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if user == nil {
+		return nil, ErrInvalidToken
+	}
+
+	//使用済みにできた1リクエストだけが確認できる
+	if err := u.verifyRepo.MarkUsed(ctx, token.ID); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := u.users.Update(ctx, user); err != nil {
+			return nil, ErrInternal
+		}
+	}
+
+	//残っている確認トークンは不要（失敗しても確認自体は完了）
+	_ = u.verifyRepo.DeleteByUserID(ctx, user.ID)
+
+	return &SuccessResponse{Message: "email verified"}, nil
+}
+
+// POST /auth/verify-email/resend
+func (u *AuthUsecase) ResendVerification(ctx context.Context, req ResendVerificationRequest) (*SuccessResponse, error) {
+	email := strings.TrimSpace(req.Email)
+
+	if err := u.validator.ValidateResendVerification(ctx, email); err != nil {
+		return nil, err
+	}
+
+	//期限切れ掃除（失敗しても続行）
+	_, _ = u.verifyRepo.DeleteExpired(ctx, time.Now())
+
+	user, err := u.users.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	//存在しない・停止中・確認済みのユーザーにも同じレスポンスを返す
+	if user == nil || !user.IsActive || user.EmailVerifiedAt != nil {
+		return &SuccessResponse{Message: resendVerificationMessage}, nil
+	}
+
+	//送信失敗はレスポンスに出さない（登録有無・確認状況が分かってしまうため）
+	if err := u.sendEmailVerification(ctx, user); err != nil {
+		log.Printf("email verification mail failed: user_id=%d err=%v", user.ID, err)
+	}
+
+	return &SuccessResponse{Message: resendVerificationMessage}, nil
+}
+
+// 確認トークンを発行してメールで送る（古いトークンは無効にする）
+func (u *AuthUsecase) sendEmailVerification(ctx context.Context, user *model.User) error {
+	if err := u.verifyRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+
+	plain, hash, err := newRandomTokenAndHash()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	token := model.EmailVerificationToken{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(emailVerificationTokenTTL),
+		UsedAt:    nil,
+		CreatedAt: now,
+	}
+	if err := u.verifyRepo.Create(ctx, token); err != nil {
+		return err
+	}
+
+	return u.mailer.Send(ctx, emailVerificationMail(user.Email, u.frontendLink("/verify-email", plain)))
+}
+
+func emailVerificationMail(to string, link string) MailMessage {
+	return MailMessage{
+		To:      to,
+		Subject: "メールアドレスの確認",
+		Body: fmt.Sprintf(
+			"ご登録ありがとうございます。以下のリンクからメールアドレスを確認してください（%d時間有効）。\n\n%s\n\nお心当たりがない場合はこのメールを破棄してください。",
+			int(emailVerificationTokenTTL.Hours()),
+			link,
+		),
+	}
+}
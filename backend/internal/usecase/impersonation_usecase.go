@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// なりすましtokenの有効期限（通常のaccess tokenより短い。refreshは出さない）
+const impersonationTokenTTL = 10 * time.Minute
+
+// サポート用：顧客としてログインした状態を見る（読み取り専用）
+type ImpersonationUsecase struct {
+	cfg       config.Config
+	users     repo.UserRepository
+	auditRepo repo.AuditLogRepository
+}
+
+func NewImpersonationUsecase(cfg config.Config, users repo.UserRepository, auditRepo repo.AuditLogRepository) *ImpersonationUsecase {
+	return &ImpersonationUsecase{cfg: cfg, users: users, auditRepo: auditRepo}
+}
+
+type ImpersonationOutput struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresIn   int       `json:"expires_in"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	UserID      int64     `json:"user_id"`
+	ActorUserID int64     `json:"actor_user_id"`
+	ReadOnly    bool      `json:"read_only"`
+}
+
+// 顧客（USER）のaccess tokenを発行する。tokenには act（操作している管理者）が入り、
+// AuthJWTが書き込み系のリクエストを拒否する。発行は監査ログに残す
+func (u *ImpersonationUsecase) Impersonate(ctx context.Context, actorUserID int64, targetUserID int64, reason string) (ImpersonationOutput, error) {
+	if actorUserID <= 0 {
+		return ImpersonationOutput{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	if targetUserID <= 0 {
+		return ImpersonationOutput{}, NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	if actorUserID == targetUserID {
+		return ImpersonationOutput{}, NewHTTPError(http.StatusBadRequest, "cannot impersonate yourself")
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ImpersonationOutput{}, NewHTTPError(http.StatusBadRequest, "reason required")
+	}
+	if utf8.RuneCountInString(reason) > 500 {
+		return ImpersonationOutput{}, NewHTTPError(http.StatusBadRequest, "reason too long")
+	}
+
+	user, err := u.users.FindByID(ctx, targetUserID)
+	if err != nil {
+		return ImpersonationOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if user == nil {
+		return ImpersonationOutput{}, NewHTTPError(http.StatusNotFound, "not found")
+	}
+	//スタッフ・ADMINになりすますと権限が広がるので顧客だけ
+	if user.Role != model.RoleUser {
+		return ImpersonationOutput{}, NewHTTPError(http.StatusForbidden, "only customers can be impersonated")
+	}
+	if !user.IsActive {
+		return ImpersonationOutput{}, NewHTTPError(http.StatusBadRequest, "user is inactive")
+	}
+
+	now := time.Now()
+	exp := now.Add(impersonationTokenTTL)
+
+	//sub は顧客、act.sub は操作している管理者（RFC 8693）。mfaは付けない
+	claims := jwt.MapClaims{
+		"sub":  user.ID,
+		"role": string(user.Role),
+		"tv":   user.TokenVersion,
+		"mfa":  false,
+		"act":  map[string]interface{}{"sub": actorUserID},
+		"iat":  now.Unix(),
+		"exp":  exp.Unix(),
+	}
+	signed, err := u.cfg.JWTKeySet().Sign(claims)
+	if err != nil {
+		return ImpersonationOutput{}, NewHTTPError(http.StatusInternalServerError, "internal error")
+	}
+
+	after, _ := json.Marshal(map[string]interface{}{
+		"reason":     reason,
+		"expires_at": exp.UTC().Format(time.RFC3339),
+	})
+	if err := u.auditRepo.Create(ctx, model.AuditLog{
+		ActorUserID:  actorUserID,
+		Action:       model.AuditActionImpersonateUser,
+		ResourceType: model.AuditResourceUser,
+		ResourceID:   user.ID,
+		AfterJSON:    string(after),
+		CreatedAt:    now,
+	}); err != nil {
+		return ImpersonationOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	return ImpersonationOutput{
+		AccessToken: signed,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(impersonationTokenTTL.Seconds()),
+		ExpiresAt:   exp,
+		UserID:      user.ID,
+		ActorUserID: actorUserID,
+		ReadOnly:    true,
+	}, nil
+}
+
+// なりすまし中のリクエストを1件ずつ監査ログに残す（middleware.ImpersonationAudit から）
+func (u *ImpersonationUsecase) RecordImpersonatedRequest(ctx context.Context, actorUserID int64, userID int64, method string, path string, status int) error {
+	after, _ := json.Marshal(map[string]interface{}{
+		"method": method,
+		"path":   path,
+		"status": status,
+	})
+
+	return u.auditRepo.Create(ctx, model.AuditLog{
+		ActorUserID:  actorUserID,
+		Action:       model.AuditActionImpersonatedRequest,
+		ResourceType: model.AuditResourceUser,
+		ResourceID:   userID,
+		AfterJSON:    string(after),
+		CreatedAt:    time.Now(),
+	})
+}
@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// 429 ログイン試行回数の上限（ロック中）
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return ErrTooManyAttempts.Error()
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// login_attempts のkey
+const (
+	loginAttemptKeyEmailPrefix = "email:"
+	loginAttemptKeyIPPrefix    = "ip:"
+)
+
+func loginAttemptEmailKey(email string) string {
+	return loginAttemptKeyEmailPrefix + strings.ToLower(strings.TrimSpace(email))
+}
+
+func loginAttemptIPKey(ip string) string {
+	return loginAttemptKeyIPPrefix + ip
+}
+
+// 失敗回数の上限とロック時間の設定（上限0はそのkeyでロックしない）
+type loginThrottlePolicy struct {
+	maxFailuresPerEmail int
+	maxFailuresPerIP    int
+	baseLockout         time.Duration
+	maxLockout          time.Duration
+}
+
+func (u *AuthUsecase) loginThrottlePolicy() loginThrottlePolicy {
+	return loginThrottlePolicy{
+		maxFailuresPerEmail: u.cfg.LoginMaxFailuresPerEmail,
+		maxFailuresPerIP:    u.cfg.LoginMaxFailuresPerIP,
+		baseLockout:         time.Duration(u.cfg.LoginLockoutBaseSeconds) * time.Second,
+		maxLockout:          time.Duration(u.cfg.LoginLockoutMaxSeconds) * time.Second,
+	}
+}
+
+// 上限を超えた回数に応じたロック時間（base, base*2, base*4 ... 上限max）
+func (p loginThrottlePolicy) lockoutFor(failures int, limit int) time.Duration {
+	if limit <= 0 || failures < limit {
+		return 0
+	}
+
+	d := p.baseLockout
+	for i := limit; i < failures && d < p.maxLockout; i++ {
+		d *= 2
+	}
+	if d > p.maxLockout {
+		d = p.maxLockout
+	}
+	return d
+}
+
+// メールアドレス・IPのどちらかがロック中ならLoginLockedError
+// （ストアの障害ではログインを止めない）
+func (u *AuthUsecase) checkLoginLock(ctx context.Context, email string, ip string) error {
+	now := time.Now()
+
+	var retryAfter time.Duration
+	for _, key := range u.loginAttemptKeys(email, ip) {
+		a, found, err := u.attempts.Get(ctx, key)
+		if err != nil {
+			log.Printf("login attempt lookup failed: key=%s err=%v", key, err)
+			continue
+		}
+		if !found || a.LockedUntil == nil || !a.LockedUntil.After(now) {
+			continue
+		}
+		if d := a.LockedUntil.Sub(now); d > retryAfter {
+			retryAfter = d
+		}
+	}
+
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// 失敗を記録する。このせいでロックされたらLoginLockedErrorを返す
+func (u *AuthUsecase) recordLoginFailure(ctx context.Context, email string, ip string) error {
+	p := u.loginThrottlePolicy()
+	now := time.Now()
+
+	var retryAfter time.Duration
+	for _, key := range u.loginAttemptKeys(email, ip) {
+		limit := p.maxFailuresPerEmail
+		if strings.HasPrefix(key, loginAttemptKeyIPPrefix) {
+			limit = p.maxFailuresPerIP
+		}
+
+		a, err := u.attempts.RecordFailure(ctx, key, now, p.maxLockout)
+		if err != nil {
+			log.Printf("login attempt record failed: key=%s err=%v", key, err)
+			continue
+		}
+
+		d := p.lockoutFor(a.Failures, limit)
+		if d <= 0 {
+			continue
+		}
+		if err := u.attempts.Lock(ctx, key, now.Add(d)); err != nil {
+			log.Printf("login lock failed: key=%s err=%v", key, err)
+			continue
+		}
+		log.Printf("login locked: key=%s failures=%d for=%s", key, a.Failures, d)
+
+		if d > retryAfter {
+			retryAfter = d
+		}
+	}
+
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// ログイン成功：そのメールアドレスの失敗回数を消す
+// （IPの記録は残す。1つの正しいアカウントで他アカウントへの試行をリセットさせない）
+func (u *AuthUsecase) clearLoginFailures(ctx context.Context, email string) {
+	if _, err := u.attempts.Delete(ctx, loginAttemptEmailKey(email)); err != nil {
+		log.Printf("login attempt clear failed: err=%v", err)
+	}
+}
+
+func (u *AuthUsecase) loginAttemptKeys(email string, ip string) []string {
+	keys := []string{loginAttemptEmailKey(email)}
+	if ip != "" {
+		keys = append(keys, loginAttemptIPKey(ip))
+	}
+	return keys
+}
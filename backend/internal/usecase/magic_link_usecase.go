@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ログインリンクの有効期限
+const magicLinkTokenTTL = 15 * time.Minute
+
+// メールアドレスが登録済みかどうかは返さない（常に同じレスポンス）
+const magicLinkRequestMessage = "if the email is registered, a login link has been sent"
+
+type MagicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+type MagicLinkConsumeRequest struct {
+	Token string `json:"token"`
+}
+
+// パスワードなしログイン（メールのワンタイムリンク）。
+// リンクを使うと /auth/login と同じ access/refresh/csrf を発行する
+type MagicLinkUsecase struct {
+	auth   *AuthUsecase
+	users  repository.UserRepository
+	tokens repository.MagicLinkTokenRepository
+	mailer Mailer
+}
+
+// DI
+func NewMagicLinkUsecase(auth *AuthUsecase, users repository.UserRepository, tokens repository.MagicLinkTokenRepository, mailer Mailer) *MagicLinkUsecase {
+	return &MagicLinkUsecase{auth: auth, users: users, tokens: tokens, mailer: mailer}
+}
+
+// POST /auth/magic-link
+func (u *MagicLinkUsecase) Request(ctx context.Context, req MagicLinkRequest) (*SuccessResponse, error) {
+	email := strings.TrimSpace(req.Email)
+
+	if err := u.auth.validator.ValidateMagicLinkRequest(ctx, email); err != nil {
+		return nil, err
+	}
+
+	//期限切れ掃除（失敗しても続行）
+	_, _ = u.tokens.DeleteExpired(ctx, time.Now())
+
+	user, err := u.users.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	//存在しない・停止中のユーザーにも同じレスポンスを返す
+	if user == nil || !user.IsActive {
+		return &SuccessResponse{Message: magicLinkRequestMessage}, nil
+	}
+
+	//有効なのは最新の1本だけにする
+	if err := u.tokens.DeleteByUserID(ctx, user.ID); err != nil {
+		return nil, ErrInternal
+	}
+
+	plain, hash, err := newRandomTokenAndHash()
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	now := time.Now()
+	token := model.MagicLinkToken{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(magicLinkTokenTTL),
+		UsedAt:    nil,
+		CreatedAt: now,
+	}
+	if err := u.tokens.Create(ctx, token); err != nil {
+		return nil, ErrInternal
+	}
+
+	//送信失敗はレスポンスに出さない（登録有無が分かってしまうため）
+	if err := u.mailer.Send(ctx, magicLinkMail(user.Email, u.auth.frontendLink("/auth/magic-link", plain))); err != nil {
+		log.Printf("magic link mail failed: user_id=%d err=%v", user.ID, err)
+	}
+
+	return &SuccessResponse{Message: magicLinkRequestMessage}, nil
+}
+
+// POST /auth/magic-link/consume
+// リンクは1回きり（使用済みにできた1リクエストだけがログインできる）。
+// メールを受け取れた＝メールアドレスの確認も済んだ扱いにする。2FAが有効ならMFAチャレンジを返す
+func (u *MagicLinkUsecase) Consume(ctx context.Context, req MagicLinkConsumeRequest, userAgent string, ip string) (*LoginResult, error) {
+	if err := u.auth.validator.ValidateMagicLinkConsume(ctx, req.Token); err != nil {
+		return nil, err
+	}
+
+	token, found, err := u.tokens.FindByHash(ctx, hashToken(req.Token))
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if !found || token.UsedAt != nil || token.ExpiresAt.Before(time.Now()) {
+		u.auth.recordLoginFailureEvent(ctx, model.SecurityEventLoginMagicLink, nil, "", "invalid_token", userAgent, ip)
+		return nil, ErrInvalidToken
+	}
+
+	user, err := u.users.FindByID(ctx, token.UserID)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if user == nil {
+		return nil, ErrInvalidToken
+	}
+	if !user.IsActive {
+		u.auth.recordLoginFailureEvent(ctx, model.SecurityEventLoginMagicLink, user, user.Email, "inactive", userAgent, ip)
+		return nil, ErrForbidden
+	}
+
+	if err := u.tokens.MarkUsed(ctx, token.ID); err != nil {
+		u.auth.recordLoginFailureEvent(ctx, model.SecurityEventLoginMagicLink, user, user.Email, "replayed", userAgent, ip)
+		return nil, ErrInvalidToken
+	}
+
+	//残っているリンクも消す（失敗してもログインは続行）
+	_ = u.tokens.DeleteByUserID(ctx, user.ID)
+
+	//last_loginと一緒にissueSessionで保存される
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	//メールを受け取れても2FAは省略しない
+	if user.TotpEnabledAt != nil {
+		if err := u.users.Update(ctx, user); err != nil {
+			return nil, ErrInternal
+		}
+		challenge, err := u.auth.issueMfaChallenge(user)
+		if err != nil {
+			return nil, ErrInternal
+		}
+		u.auth.recordSecurityEvent(ctx, model.SecurityEvent{
+			UserID:    &user.ID,
+			Type:      model.SecurityEventLoginMagicLink,
+			Outcome:   model.SecurityOutcomeSuccess,
+			Reason:    "mfa_required",
+			Email:     user.Email,
+			IP:        ip,
+			UserAgent: userAgent,
+		})
+		return &LoginResult{MfaChallenge: challenge}, nil
+	}
+
+	u.auth.clearLoginFailures(ctx, user.Email)
+	return u.auth.issueSession(ctx, user, model.SecurityEventLoginMagicLink, false, userAgent, ip)
+}
+
+func magicLinkMail(to string, link string) MailMessage {
+	return MailMessage{
+		To:      to,
+		Subject: "ログインリンクのご案内",
+		Body: fmt.Sprintf(
+			"以下のリンクからログインしてください（%d分間有効・1回限り）。\n\n%s\n\nお心当たりがない場合はこのメールを破棄してください。",
+			int(magicLinkTokenTTL.Minutes()),
+			link,
+		),
+	}
+}
@@ -0,0 +1,15 @@
+package usecase
+
+import "context"
+
+// 送信するメール1通分
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// usecaseがメール送信に依存する約束（SMTP・外部サービス・ローカル用のファイル出力などを差し替えられる）
+type Mailer interface {
+	Send(ctx context.Context, msg MailMessage) error
+}
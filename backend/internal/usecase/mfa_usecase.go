@@ -0,0 +1,403 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+	"app/internal/security"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// MFAチャレンジ（パスワード確認済み・2FA未完了）の有効期限
+const mfaChallengeTTL = 5 * time.Minute
+
+// JWTのtyp（access tokenとして使われないように区別する）
+const mfaChallengeTokenType = "mfa_challenge"
+
+// 確認時に発行するリカバリーコードの本数
+const mfaRecoveryCodeCount = 10
+
+type MfaChallengeResponse struct {
+	MfaRequired bool   `json:"mfa_required"`
+	MfaToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+type LoginMfaRequest struct {
+	MfaToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type TotpEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+type TotpConfirmRequest struct {
+	Code string `json:"code"`
+}
+
+type TotpConfirmResponse struct {
+	// 平文はこの1回だけ返す
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TotpDisableRequest struct {
+	Password string `json:"password"`
+	// TOTPコード or リカバリーコード
+	Code string `json:"code"`
+}
+
+// POST /auth/login/mfa
+func (u *AuthUsecase) LoginMfa(ctx context.Context, req LoginMfaRequest, userAgent string, ip string) (*LoginResult, error) {
+	if err := u.validator.ValidateLoginMfa(ctx, req.MfaToken, req.Code, req.RecoveryCode); err != nil {
+		return nil, err
+	}
+
+	userID, tv, err := u.parseMfaChallenge(req.MfaToken)
+	if err != nil {
+		u.recordLoginFailureEvent(ctx, model.SecurityEventLoginMfa, nil, "", "invalid_challenge", userAgent, ip)
+		return nil, ErrUnauthorized
+	}
+
+	user, err := u.users.FindByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, ErrUnauthorized
+	}
+	if !user.IsActive {
+		u.recordLoginFailureEvent(ctx, model.SecurityEventLoginMfa, user, user.Email, "inactive", userAgent, ip)
+		return nil, ErrForbidden
+	}
+
+	//チャレンジ発行後に強制ログアウト・2FA無効化されていたら無効
+	if user.TokenVersion != tv || user.TotpEnabledAt == nil {
+		u.recordLoginFailureEvent(ctx, model.SecurityEventLoginMfa, user, user.Email, "invalid_challenge", userAgent, ip)
+		return nil, ErrUnauthorized
+	}
+
+	//コードの総当たりもパスワードと同じ回数制限にかける
+	if err := u.checkLoginLock(ctx, user.Email, ip); err != nil {
+		u.recordLoginFailureEvent(ctx, model.SecurityEventLoginMfa, user, user.Email, "locked", userAgent, ip)
+		return nil, err
+	}
+
+	var ok bool
+	if req.Code != "" {
+		ok, err = u.verifyUserTotp(ctx, user, req.Code)
+	} else {
+		ok, err = u.recoveryRepo.Consume(ctx, user.ID, hashRecoveryCode(req.RecoveryCode))
+	}
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if !ok {
+		u.recordLoginFailureEvent(ctx, model.SecurityEventLoginMfa, user, user.Email, "invalid_code", userAgent, ip)
+		return nil, u.loginFailed(ctx, user.Email, ip)
+	}
+
+	u.clearLoginFailures(ctx, user.Email)
+	return u.issueSession(ctx, user, model.SecurityEventLoginMfa, true, userAgent, ip)
+}
+
+// POST /me/mfa/totp/enroll
+// シークレットを発行して保存する（confirmするまで2FAは有効にならない）
+func (u *AuthUsecase) EnrollTotp(ctx context.Context, userID int64) (*TotpEnrollResponse, error) {
+	user, err := u.findActiveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TotpEnabledAt != nil {
+		return nil, ErrConflict
+	}
+	if u.mfaBox == nil {
+		return nil, ErrInternal
+	}
+
+	secret, err := security.NewTotpSecret()
+	if err != nil {
+		return nil, ErrInternal
+	}
+	sealed, err := u.mfaBox.Seal(secret)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	//やり直しの場合は前のシークレットを上書き
+	user.TotpSecretEnc = sealed
+	user.TotpLastStep = 0
+	if err := u.users.Update(ctx, user); err != nil {
+		return nil, ErrInternal
+	}
+
+	return &TotpEnrollResponse{
+		Secret:     secret,
+		OtpauthURI: security.TotpURI(u.cfg.MfaIssuer, user.Email, secret),
+	}, nil
+}
+
+// POST /me/mfa/totp/confirm
+// 認証アプリのコードで登録を確認し、2FAを有効化してリカバリーコードを返す。
+// 他の端末はログアウト（access tokenもtoken_versionで無効）。この端末のセッションは残すが、
+// 2FAを通ったログインではないのでmfa=falseのまま（mfa=trueが必要なら /auth/login/mfa でログインし直す）
+func (u *AuthUsecase) ConfirmTotp(ctx context.Context, userID int64, req TotpConfirmRequest, currentRefreshPlain string) (*TotpConfirmResponse, error) {
+	if err := u.validator.ValidateTotpConfirm(ctx, req.Code); err != nil {
+		return nil, err
+	}
+
+	user, err := u.findActiveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TotpEnabledAt != nil {
+		return nil, ErrConflict
+	}
+	//enroll前
+	if user.TotpSecretEnc == "" {
+		return nil, ErrValidation
+	}
+
+	ok, err := u.verifyUserTotp(ctx, user, req.Code)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if !ok {
+		return nil, ErrInvalidMfaCode
+	}
+
+	plains, codes, err := newRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if err := u.recoveryRepo.ReplaceForUser(ctx, user.ID, codes); err != nil {
+		return nil, ErrInternal
+	}
+
+	now := time.Now()
+	user.TotpEnabledAt = &now
+	if err := u.users.Update(ctx, user); err != nil {
+		return nil, ErrInternal
+	}
+
+	//パスワードだけで作られた他の端末のセッションを残さない
+	if err := u.users.IncrementTokenVersion(ctx, user.ID); err != nil {
+		return nil, ErrInternal
+	}
+	keepFamilyID := ""
+	if currentRefreshPlain != "" {
+		rt, found, err := u.rtRepo.FindByHash(ctx, hashToken(currentRefreshPlain))
+		if err != nil {
+			return nil, ErrInternal
+		}
+		if found && rt.UserID == user.ID && rt.UsedAt == nil && rt.ExpiresAt.After(now) {
+			keepFamilyID = refreshFamilyID(rt)
+		}
+	}
+	if _, err := u.rtRepo.DeleteByUserIDExceptFamily(ctx, user.ID, keepFamilyID); err != nil {
+		return nil, ErrInternal
+	}
+
+	return &TotpConfirmResponse{RecoveryCodes: plains}, nil
+}
+
+// POST /me/mfa/totp/disable
+// パスワード + コードで2FAを無効化する。全セッションは失効（再ログインが必要）
+func (u *AuthUsecase) DisableTotp(ctx context.Context, userID int64, req TotpDisableRequest) (*SuccessResponse, error) {
+	if err := u.validator.ValidateTotpDisable(ctx, req.Password, req.Code); err != nil {
+		return nil, err
+	}
+
+	user, err := u.findActiveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TotpEnabledAt == nil {
+		return nil, ErrValidation
+	}
+
+	if !u.verifyPassword(user, req.Password) {
+		return nil, ErrUnauthorized
+	}
+
+	//6桁ならTOTP、それ以外はリカバリーコードとして扱う
+	var ok bool
+	if isTotpCodeLike(req.Code) {
+		ok, err = u.verifyUserTotp(ctx, user, req.Code)
+	} else {
+		ok, err = u.recoveryRepo.Consume(ctx, user.ID, hashRecoveryCode(req.Code))
+	}
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if !ok {
+		return nil, ErrInvalidMfaCode
+	}
+
+	user.TotpSecretEnc = ""
+	user.TotpEnabledAt = nil
+	user.TotpLastStep = 0
+	if err := u.users.Update(ctx, user); err != nil {
+		return nil, ErrInternal
+	}
+
+	if err := u.recoveryRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		log.Printf("mfa recovery code cleanup failed: user_id=%d err=%v", user.ID, err)
+	}
+
+	//mfa=trueのaccess tokenを残さない
+	if err := u.users.IncrementTokenVersion(ctx, user.ID); err != nil {
+		return nil, ErrInternal
+	}
+	if err := u.rtRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return nil, ErrInternal
+	}
+
+	return &SuccessResponse{Message: "2fa disabled. please login again"}, nil
+}
+
+// 停止ユーザーは403
+func (u *AuthUsecase) findActiveUser(ctx context.Context, userID int64) (*model.User, error) {
+	if userID <= 0 {
+		return nil, ErrUnauthorized
+	}
+
+	user, err := u.users.FindByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, ErrUnauthorized
+	}
+	if !user.IsActive {
+		return nil, ErrForbidden
+	}
+	return user, nil
+}
+
+// TOTPを検証し、成功したらステップを保存する（同じコードは2回通らない）
+func (u *AuthUsecase) verifyUserTotp(ctx context.Context, user *model.User, code string) (bool, error) {
+	if u.mfaBox == nil || user.TotpSecretEnc == "" {
+		return false, errors.New("totp not configured")
+	}
+
+	secret, err := u.mfaBox.Open(user.TotpSecretEnc)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := security.VerifyTotp(secret, code, time.Now(), user.TotpLastStep)
+	if !ok {
+		return false, nil
+	}
+
+	user.TotpLastStep = step
+	if err := u.users.Update(ctx, user); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// パスワード確認済みを表す短命トークン（access tokenと同じ鍵で署名。typでaccess tokenとしては通らない）
+func (u *AuthUsecase) issueMfaChallenge(user *model.User) (*MfaChallengeResponse, error) {
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		"sub": user.ID,
+		"typ": mfaChallengeTokenType,
+		"tv":  user.TokenVersion,
+		"iat": now.Unix(),
+		"exp": now.Add(mfaChallengeTTL).Unix(),
+	}
+
+	signed, err := u.cfg.JWTKeySet().Sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MfaChallengeResponse{
+		MfaRequired: true,
+		MfaToken:    signed,
+		ExpiresIn:   int(mfaChallengeTTL.Seconds()),
+	}, nil
+}
+
+// MFAチャレンジトークンを検証して user_id と tv を返す
+func (u *AuthUsecase) parseMfaChallenge(raw string) (int64, int, error) {
+	//kidで鍵を選び、algも鍵と一致するか確認（鍵のローテーション中は古い鍵のチャレンジも通る）
+	token, err := jwt.Parse(raw, u.cfg.JWTKeySet().Keyfunc)
+	if err != nil || token == nil || !token.Valid {
+		return 0, 0, errors.New("invalid mfa token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != mfaChallengeTokenType {
+		return 0, 0, errors.New("invalid mfa token")
+	}
+
+	sub, ok1 := claims["sub"].(float64)
+	tv, ok2 := claims["tv"].(float64)
+	if !ok1 || !ok2 || sub <= 0 {
+		return 0, 0, errors.New("invalid mfa token")
+	}
+
+	return int64(sub), int(tv), nil
+}
+
+// リカバリーコード（xxxxx-xxxxx）を作る。平文とDB保存用を返す
+func newRecoveryCodes(userID int64) ([]string, []model.MfaRecoveryCode, error) {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz234567" // 32文字（偏りが出ない）
+
+	now := time.Now()
+	plains := make([]string, 0, mfaRecoveryCodeCount)
+	codes := make([]model.MfaRecoveryCode, 0, mfaRecoveryCodeCount)
+
+	for i := 0; i < mfaRecoveryCodeCount; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		for j := range b {
+			b[j] = alphabet[int(b[j])%len(alphabet)]
+		}
+		plain := string(b[:5]) + "-" + string(b[5:])
+
+		plains = append(plains, plain)
+		codes = append(codes, model.MfaRecoveryCode{
+			ID:        uuid.NewString(),
+			UserID:    userID,
+			CodeHash:  hashRecoveryCode(plain),
+			UsedAt:    nil,
+			CreatedAt: now,
+		})
+	}
+
+	return plains, codes, nil
+}
+
+// 入力ゆれ（大文字・ハイフン・空白）を吸収してからhash
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.TrimSpace(code))
+	normalized = strings.ReplaceAll(normalized, "-", "")
+	normalized = strings.ReplaceAll(normalized, " ", "")
+	return hashToken(normalized)
+}
+
+func isTotpCodeLike(code string) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != security.TotpDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
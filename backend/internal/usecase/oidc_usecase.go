@@ -0,0 +1,491 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/repository"
+	"app/internal/security"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+var (
+	// 404 設定されていないprovider
+	ErrOidcProviderNotFound = errors.New("oidc provider not found")
+	// 409 同じメールアドレスのアカウントが既にある（ログインしてから /me/identities で紐付ける）
+	ErrOidcAccountExists = errors.New("an account with this email already exists. login and link the provider")
+	// 409 そのIdPアカウントは別のユーザーに紐付いている
+	ErrOidcIdentityInUse = errors.New("this provider account is linked to another user")
+	// 409 パスワード未設定のユーザーの最後の紐付けは外せない（ログインできなくなる）
+	ErrOidcLastLoginMethod = errors.New("cannot unlink the last login method. set a password first")
+	// 404 紐付けが無い
+	ErrOidcIdentityNotFound = errors.New("identity not found")
+)
+
+// 認可リクエスト〜コールバックまでの有効期限
+const oidcStateTTL = 10 * time.Minute
+
+// JWTのtyp（access tokenとして使われないように区別する）
+const oidcStateTokenType = "oidc_state"
+
+// stateトークンの用途
+const (
+	oidcModeLogin = "login"
+	oidcModeLink  = "link"
+)
+
+// IdPから受け取ったユーザー情報（id_tokenは検証済み）
+type OidcIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Nonce         string
+}
+
+// usecaseが外部IdPに依存する約束（providerごとに差し替えられる）
+type OidcProvider interface {
+	//URLやDBに入れる名前
+	Name() string
+	//IdPの認可エンドポイントのURL（PKCE S256）
+	AuthCodeURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error)
+	//認可コードをtokenに交換し、id_tokenを検証してユーザー情報を返す
+	Exchange(ctx context.Context, code string, codeVerifier string) (*OidcIdentity, error)
+}
+
+type OidcProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+type OidcAuthorizeResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// 認可開始の結果（stateトークンはhandlerがHttpOnly cookieに入れる）
+type OidcAuthorizeResult struct {
+	Body       OidcAuthorizeResponse
+	StateToken string
+}
+
+type OidcCallbackRequest struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+}
+
+type UserIdentityDTO struct {
+	ID          string     `json:"id"`
+	Provider    string     `json:"provider"`
+	Email       string     `json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type UserIdentityListResponse struct {
+	Items []UserIdentityDTO `json:"items"`
+}
+
+// cookieのstateトークンの中身
+type oidcState struct {
+	provider     string
+	state        string
+	nonce        string
+	codeVerifier string
+	mode         string
+	userID       int64
+}
+
+// OpenID Connectのログイン・紐付け
+type OidcUsecase struct {
+	cfg        config.Config
+	auth       *AuthUsecase
+	users      repository.UserRepository
+	identities repository.UserIdentityRepository
+	providers  map[string]OidcProvider
+}
+
+// DI
+func NewOidcUsecase(
+	cfg config.Config,
+	auth *AuthUsecase,
+	users repository.UserRepository,
+	identities repository.UserIdentityRepository,
+	providers []OidcProvider,
+) *OidcUsecase {
+	m := make(map[string]OidcProvider, len(providers))
+	for _, p := range providers {
+		m[p.Name()] = p
+	}
+
+	return &OidcUsecase{
+		cfg:        cfg,
+		auth:       auth,
+		users:      users,
+		identities: identities,
+		providers:  m,
+	}
+}
+
+// GET /auth/oidc/providers
+func (u *OidcUsecase) Providers() *OidcProvidersResponse {
+	names := make([]string, 0, len(u.providers))
+	for name := range u.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return &OidcProvidersResponse{Providers: names}
+}
+
+// POST /auth/oidc/:provider/authorize
+func (u *OidcUsecase) AuthorizeLogin(ctx context.Context, providerName string) (*OidcAuthorizeResult, error) {
+	return u.authorize(ctx, providerName, oidcModeLogin, 0)
+}
+
+// POST /me/identities/:provider/authorize
+func (u *OidcUsecase) AuthorizeLink(ctx context.Context, userID int64, providerName string) (*OidcAuthorizeResult, error) {
+	if _, err := u.auth.findActiveUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return u.authorize(ctx, providerName, oidcModeLink, userID)
+}
+
+// POST /auth/oidc/:provider/callback
+// 紐付け済みならそのユーザーでログイン、未登録ならユーザーを作る。
+// 2FAが有効なユーザーはパスワードログインと同じくMFAチャレンジを返す
+func (u *OidcUsecase) CallbackLogin(ctx context.Context, providerName string, req OidcCallbackRequest, stateToken string, userAgent string, ip string) (*LoginResult, error) {
+	idt, err := u.callback(ctx, providerName, req, stateToken, oidcModeLogin, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, found, err := u.identities.FindByProviderSubject(ctx, providerName, idt.Subject)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	var user *model.User
+	if found {
+		user, err = u.users.FindByID(ctx, identity.UserID)
+		if err != nil || user == nil {
+			return nil, ErrUnauthorized
+		}
+		if !user.IsActive {
+			return nil, ErrForbidden
+		}
+		if err := u.identities.TouchLastLogin(ctx, identity.ID, time.Now()); err != nil {
+			log.Printf("oidc identity touch failed: id=%s err=%v", identity.ID, err)
+		}
+	} else {
+		user, err = u.signUp(ctx, providerName, idt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	//設定によってはメール未確認ユーザーはログイン不可（パスワードログインと同じ）
+	if u.cfg.EmailVerificationPolicy == config.EmailVerificationPolicyLogin && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+
+	//IdPでログインしても2FAは省略しない
+	if user.TotpEnabledAt != nil {
+		challenge, err := u.auth.issueMfaChallenge(user)
+		if err != nil {
+			return nil, ErrInternal
+		}
+		return &LoginResult{MfaChallenge: challenge}, nil
+	}
+
+	return u.auth.issueSession(ctx, user, model.SecurityEventLoginOidc, false, userAgent, ip)
+}
+
+// POST /me/identities/:provider/callback
+func (u *OidcUsecase) CallbackLink(ctx context.Context, userID int64, providerName string, req OidcCallbackRequest, stateToken string) (*UserIdentityDTO, error) {
+	user, err := u.auth.findActiveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	idt, err := u.callback(ctx, providerName, req, stateToken, oidcModeLink, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, found, err := u.identities.FindByProviderSubject(ctx, providerName, idt.Subject)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if found {
+		if existing.UserID != user.ID {
+			return nil, ErrOidcIdentityInUse
+		}
+		//紐付け済み（やり直し）はそのまま返す
+		dto := toUserIdentityDTO(existing)
+		return &dto, nil
+	}
+
+	identity := &model.UserIdentity{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		Provider:  providerName,
+		Subject:   idt.Subject,
+		Email:     idt.Email,
+		CreatedAt: time.Now(),
+	}
+	//同じproviderの別アカウントが既に紐付いている場合も一意制約で弾かれる
+	if err := u.identities.Create(ctx, identity); err != nil {
+		return nil, ErrConflict
+	}
+
+	dto := toUserIdentityDTO(*identity)
+	return &dto, nil
+}
+
+// GET /me/identities
+func (u *OidcUsecase) ListIdentities(ctx context.Context, userID int64) (*UserIdentityListResponse, error) {
+	if _, err := u.auth.findActiveUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	list, err := u.identities.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	items := make([]UserIdentityDTO, 0, len(list))
+	for _, identity := range list {
+		items = append(items, toUserIdentityDTO(identity))
+	}
+	return &UserIdentityListResponse{Items: items}, nil
+}
+
+// DELETE /me/identities/:id
+func (u *OidcUsecase) Unlink(ctx context.Context, userID int64, identityID string) (*SuccessResponse, error) {
+	user, err := u.auth.findActiveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := u.identities.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	var target *model.UserIdentity
+	for i := range list {
+		if list[i].ID == identityID {
+			target = &list[i]
+			break
+		}
+	}
+	if target == nil {
+		return nil, ErrOidcIdentityNotFound
+	}
+
+	//パスワードが無いユーザーは、最後の1つを外すとログインできなくなる
+	if user.PasswordHash == "" && len(list) == 1 {
+		return nil, ErrOidcLastLoginMethod
+	}
+
+	deleted, err := u.identities.DeleteByIDForUser(ctx, target.ID, user.ID)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if !deleted {
+		return nil, ErrOidcIdentityNotFound
+	}
+
+	return &SuccessResponse{Message: "identity unlinked"}, nil
+}
+
+// state / nonce / PKCE を作って、IdPへのURLとstateトークンを返す
+func (u *OidcUsecase) authorize(ctx context.Context, providerName string, mode string, userID int64) (*OidcAuthorizeResult, error) {
+	p, ok := u.providers[providerName]
+	if !ok {
+		return nil, ErrOidcProviderNotFound
+	}
+
+	state, _, err := newRandomTokenAndHash()
+	if err != nil {
+		return nil, ErrInternal
+	}
+	nonce, _, err := newRandomTokenAndHash()
+	if err != nil {
+		return nil, ErrInternal
+	}
+	verifier, err := security.NewPkceVerifier()
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, security.PkceChallengeS256(verifier))
+	if err != nil {
+		log.Printf("oidc authorize url failed: provider=%s err=%v", providerName, err)
+		return nil, ErrInternal
+	}
+
+	stateToken, err := u.issueOidcState(oidcState{
+		provider:     providerName,
+		state:        state,
+		nonce:        nonce,
+		codeVerifier: verifier,
+		mode:         mode,
+		userID:       userID,
+	})
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	return &OidcAuthorizeResult{
+		Body:       OidcAuthorizeResponse{AuthorizationURL: authURL},
+		StateToken: stateToken,
+	}, nil
+}
+
+// stateを照合して認可コードを交換する（nonceもここで確認）
+func (u *OidcUsecase) callback(ctx context.Context, providerName string, req OidcCallbackRequest, stateToken string, mode string, userID int64) (*OidcIdentity, error) {
+	p, ok := u.providers[providerName]
+	if !ok {
+		return nil, ErrOidcProviderNotFound
+	}
+
+	if strings.TrimSpace(req.Code) == "" || strings.TrimSpace(req.State) == "" {
+		return nil, ErrValidation
+	}
+
+	st, err := u.parseOidcState(stateToken)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	//別のブラウザ・別のprovider・別の用途で始めたフローは受け付けない
+	if st.provider != providerName || st.state != req.State || st.mode != mode || st.userID != userID {
+		return nil, ErrUnauthorized
+	}
+
+	idt, err := p.Exchange(ctx, req.Code, st.codeVerifier)
+	if err != nil {
+		log.Printf("oidc exchange failed: provider=%s err=%v", providerName, err)
+		return nil, ErrUnauthorized
+	}
+	if idt.Subject == "" || idt.Nonce != st.nonce {
+		return nil, ErrUnauthorized
+	}
+
+	return idt, nil
+}
+
+// 未登録のIdPアカウントでユーザーを作る（パスワード無し）
+func (u *OidcUsecase) signUp(ctx context.Context, providerName string, idt *OidcIdentity) (*model.User, error) {
+	email := strings.TrimSpace(idt.Email)
+
+	//IdPが確認していないメールアドレスでは作らない（他人のアドレスの乗っ取り防止）
+	if email == "" || !idt.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	//既存アカウントに勝手に紐付けない（ログインしてから /me/identities で紐付ける）
+	existing, err := u.users.FindByEmail(ctx, email)
+	if err == nil && existing != nil {
+		return nil, ErrOidcAccountExists
+	}
+
+	now := time.Now()
+	user := &model.User{
+		Email:           email,
+		PasswordHash:    "",
+		Role:            model.RoleUser,
+		TokenVersion:    0,
+		IsActive:        true,
+		EmailVerifiedAt: &now,
+	}
+	if err := u.users.Create(ctx, user); err != nil {
+		return nil, ErrConflict
+	}
+
+	identity := &model.UserIdentity{
+		ID:          uuid.NewString(),
+		UserID:      user.ID,
+		Provider:    providerName,
+		Subject:     idt.Subject,
+		Email:       email,
+		LastLoginAt: &now,
+		CreatedAt:   now,
+	}
+	if err := u.identities.Create(ctx, identity); err != nil {
+		log.Printf("oidc identity create failed: user_id=%d provider=%s err=%v", user.ID, providerName, err)
+		return nil, ErrInternal
+	}
+
+	return user, nil
+}
+
+// 認可開始〜コールバックの間だけ使う短命トークン（HttpOnly cookieに入れる）
+func (u *OidcUsecase) issueOidcState(st oidcState) (string, error) {
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		"typ":   oidcStateTokenType,
+		"prv":   st.provider,
+		"state": st.state,
+		"nonce": st.nonce,
+		"cv":    st.codeVerifier,
+		"mode":  st.mode,
+		"sub":   st.userID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(oidcStateTTL).Unix(),
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(u.cfg.JWTSecret))
+}
+
+func (u *OidcUsecase) parseOidcState(raw string) (oidcState, error) {
+	if raw == "" {
+		return oidcState{}, errors.New("oidc state missing")
+	}
+
+	token, err := jwt.Parse(raw, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(u.cfg.JWTSecret), nil
+	})
+	if err != nil || token == nil || !token.Valid {
+		return oidcState{}, errors.New("invalid oidc state")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != oidcStateTokenType {
+		return oidcState{}, errors.New("invalid oidc state")
+	}
+
+	st := oidcState{}
+	st.provider, _ = claims["prv"].(string)
+	st.state, _ = claims["state"].(string)
+	st.nonce, _ = claims["nonce"].(string)
+	st.codeVerifier, _ = claims["cv"].(string)
+	st.mode, _ = claims["mode"].(string)
+	sub, _ := claims["sub"].(float64)
+	st.userID = int64(sub)
+
+	if st.state == "" || st.nonce == "" || st.codeVerifier == "" {
+		return oidcState{}, errors.New("invalid oidc state")
+	}
+	return st, nil
+}
+
+func toUserIdentityDTO(identity model.UserIdentity) UserIdentityDTO {
+	return UserIdentityDTO{
+		ID:          identity.ID,
+		Provider:    identity.Provider,
+		Email:       identity.Email,
+		LastLoginAt: identity.LastLoginAt,
+		CreatedAt:   identity.CreatedAt,
+	}
+}
@@ -29,10 +29,13 @@ type PlaceOrderInput struct {
 }
 
 type OrderItemOutput struct {
-	ProductID int64  `json:"product_id"`
-	Name      string `json:"name"`
-	Price     int64  `json:"price"`
-	Quantity  int64  `json:"quantity"`
+	ProductID    int64  `json:"product_id"`
+	VariantID    *int64 `json:"variant_id,omitempty"`
+	Name         string `json:"name"`
+	SKU          string `json:"sku,omitempty"`
+	OptionLabels string `json:"option_labels,omitempty"`
+	Price        int64  `json:"price"`
+	Quantity     int64  `json:"quantity"`
 }
 
 type OrderOutput struct {
@@ -121,24 +124,18 @@ func (u *OrderUsecase) PlaceOrder(ctx context.Context, userID int64, in PlaceOrd
 				return NewHTTPError(http.StatusInternalServerError, "db error")
 			}
 
-			//在庫減算（足りないなら false）
-			ok, err := r.Inventory().DecreaseStockIfEnough(ctx, ci.ProductID, ci.Quantity)
+			//バリエーションのある商品はバリエーションの在庫を減らす
+			item, err := placeOrderLine(ctx, r, ci)
 			if err != nil {
-				return NewHTTPError(http.StatusInternalServerError, "db error")
-			}
-			if !ok {
-				return NewHTTPError(http.StatusBadRequest, "out of stock")
+				return err
 			}
 
 			//スナップショット
-			now := time.Now()
-			orderItems = append(orderItems, model.OrderItem{
-				ProductID:           ci.ProductID,
-				ProductNameSnapshot: p.Name,
-				UnitPriceSnapshot:   ci.UnitPriceSnapshot,
-				Quantity:            ci.Quantity,
-				CreatedAt:           now,
-			})
+			item.ProductNameSnapshot = p.Name
+			item.UnitPriceSnapshot = ci.UnitPriceSnapshot
+			item.Quantity = ci.Quantity
+			item.CreatedAt = time.Now()
+			orderItems = append(orderItems, item)
 
 			total += ci.UnitPriceSnapshot * ci.Quantity
 		}
@@ -199,6 +196,54 @@ func (u *OrderUsecase) PlaceOrder(ctx context.Context, userID int64, in PlaceOrd
 	return out, nil
 }
 
+// 明細1行の在庫を減らし、注文明細のバリエーション部分（VariantID・SKU・軸ラベル）を作る。
+// バリエーションのある商品の明細はバリエーション必須（追加後にバリエーションが作られた明細は注文できない）
+func placeOrderLine(ctx context.Context, r repo.TxRepos, ci model.CartItem) (model.OrderItem, error) {
+	item := model.OrderItem{ProductID: ci.ProductID}
+
+	if ci.VariantID == nil {
+		variants, err := r.Variants().ListByProductID(ctx, ci.ProductID)
+		if err != nil {
+			return model.OrderItem{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+		if len(variants) > 0 {
+			return model.OrderItem{}, NewHTTPError(http.StatusBadRequest, "invalid")
+		}
+
+		//在庫減算（足りないなら false）
+		ok, err := r.Inventory().DecreaseStockIfEnough(ctx, ci.ProductID, ci.Quantity)
+		if err != nil {
+			return model.OrderItem{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+		if !ok {
+			return model.OrderItem{}, NewHTTPError(http.StatusBadRequest, "out of stock")
+		}
+		return item, nil
+	}
+
+	v, err := r.Variants().FindByID(ctx, *ci.VariantID)
+	if err == repo.ErrNotFound || (err == nil && (!v.IsActive || v.ProductID != ci.ProductID)) {
+		return model.OrderItem{}, NewHTTPError(http.StatusBadRequest, "invalid")
+	}
+	if err != nil {
+		return model.OrderItem{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	ok, err := r.Inventory().DecreaseVariantStockIfEnough(ctx, v.ID, ci.Quantity)
+	if err != nil {
+		return model.OrderItem{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if !ok {
+		return model.OrderItem{}, NewHTTPError(http.StatusBadRequest, "out of stock")
+	}
+
+	//軸ラベルはカートに入れた時点のもの
+	item.VariantID = &v.ID
+	item.SKUSnapshot = v.SKU
+	item.OptionLabelsSnapshot = ci.OptionLabelsSnapshot
+	return item, nil
+}
+
 func (u *OrderUsecase) ListMyOrders(ctx context.Context, userID int64) ([]OrderOutput, error) {
 	if userID <= 0 {
 		return []OrderOutput{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
@@ -272,10 +317,13 @@ func toOrderOutput(o model.Order, items []model.OrderItem) OrderOutput {
 	outItems := make([]OrderItemOutput, 0, len(items))
 	for _, it := range items {
 		outItems = append(outItems, OrderItemOutput{
-			ProductID: it.ProductID,
-			Name:      it.ProductNameSnapshot,
-			Price:     it.UnitPriceSnapshot,
-			Quantity:  it.Quantity,
+			ProductID:    it.ProductID,
+			VariantID:    it.VariantID,
+			Name:         it.ProductNameSnapshot,
+			SKU:          it.SKUSnapshot,
+			OptionLabels: it.OptionLabelsSnapshot,
+			Price:        it.UnitPriceSnapshot,
+			Quantity:     it.Quantity,
 		})
 	}
 
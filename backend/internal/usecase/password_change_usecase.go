@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"app/internal/domain/model"
+
+	"github.com/google/uuid"
+)
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// POST /me/password
+// 現在のパスワードを確認して変更する。他の端末は全部ログアウト（access tokenもtoken_versionで無効）、
+// この端末には新しいaccess/refresh/csrfを返してログイン状態を保つ
+func (u *AuthUsecase) ChangePassword(ctx context.Context, userID int64, req ChangePasswordRequest, currentRefreshPlain string, userAgent string, ip string) (*RefreshResult, error) {
+	if err := u.validator.ValidateChangePassword(ctx, req.CurrentPassword, req.NewPassword); err != nil {
+		return nil, err
+	}
+
+	user, err := u.findActiveUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	//盗まれたaccess tokenで現在のパスワードを総当たりさせない（ログインと同じ回数制限）
+	if err := u.checkLoginLock(ctx, user.Email, ip); err != nil {
+		return nil, err
+	}
+	if !u.verifyPassword(user, req.CurrentPassword) {
+		return nil, u.loginFailed(ctx, user.Email, ip)
+	}
+	u.clearLoginFailures(ctx, user.Email)
+
+	//同じパスワードへの変更は意味が無いので弾く
+	if req.CurrentPassword == req.NewPassword {
+		return nil, ErrValidation
+	}
+
+	pwHash, err := u.passwords.Hash(req.NewPassword)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	user.PasswordHash = pwHash
+	if err := u.users.Update(ctx, user); err != nil {
+		return nil, ErrInternal
+	}
+
+	//既存のaccess tokenを全部無効にする
+	if err := u.users.IncrementTokenVersion(ctx, user.ID); err != nil {
+		return nil, ErrInternal
+	}
+	user.TokenVersion++
+
+	//この端末のrefresh（本人の・未使用で期限内のものだけ）を使用済みにして、その系列（セッション）だけ残す
+	keepFamilyID, keepMfaVerified := "", false
+	if currentRefreshPlain != "" {
+		rt, found, err := u.rtRepo.FindByHash(ctx, hashToken(currentRefreshPlain))
+		if err != nil {
+			return nil, ErrInternal
+		}
+		//MarkUsedに失敗したら同時にrefreshされた → この系列も残さない
+		if found && rt.UserID == user.ID && rt.UsedAt == nil && rt.ExpiresAt.After(time.Now()) &&
+			u.rtRepo.MarkUsed(ctx, rt.ID) == nil {
+			keepFamilyID, keepMfaVerified = refreshFamilyID(rt), rt.MfaVerified
+		}
+	}
+	if _, err := u.rtRepo.DeleteByUserIDExceptFamily(ctx, user.ID, keepFamilyID); err != nil {
+		return nil, ErrInternal
+	}
+
+	//この端末のrefreshも同じ系列の中で回転させる（変更前の値は使えなくし、セッションIDは変えない）
+	refreshPlain, err := u.createRefreshToken(ctx, user, keepFamilyID, keepMfaVerified, userAgent, ip)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	accessToken, expiresIn, err := u.issueAccessToken(user, keepMfaVerified)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	csrfPlain, _, err := newRandomTokenAndHash()
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	return &RefreshResult{
+		Body: JwtAccessTokenDTO{
+			AccessToken:  accessToken,
+			ExpiresIn:    expiresIn,
+			TokenVersion: user.TokenVersion,
+		},
+		RefreshTokenPlain: refreshPlain,
+		CsrfTokenPlain:    csrfPlain,
+	}, nil
+}
+
+// refresh tokenを1本発行して保存し、平文を返す（familyIDが空なら新しい系列になる）
+func (u *AuthUsecase) createRefreshToken(ctx context.Context, user *model.User, familyID string, mfaVerified bool, userAgent string, ip string) (string, error) {
+	plain, hash, err := newRandomTokenAndHash()
+	if err != nil {
+		return "", err
+	}
+
+	var ipPtr *string
+	if ip != "" {
+		ipCopy := ip
+		ipPtr = &ipCopy
+	}
+
+	now := time.Now()
+	rtID := uuid.NewString()
+	if familyID == "" {
+		familyID = rtID
+	}
+	rt := model.RefreshToken{
+		ID:          rtID,
+		UserID:      user.ID,
+		FamilyID:    familyID,
+		TokenHash:   hash,
+		UserAgent:   userAgent,
+		ExpiresAt:   now.Add(refreshTokenTTL),
+		UsedAt:      nil,
+		IP:          ipPtr,
+		CreatedAt:   now,
+		MfaVerified: mfaVerified,
+	}
+	if err := u.rtRepo.Create(ctx, rt); err != nil {
+		return "", err
+	}
+	return plain, nil
+}
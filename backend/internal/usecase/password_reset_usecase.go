@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+
+	"github.com/google/uuid"
+)
+
+// パスワード再設定トークンの有効期限
+const passwordResetTokenTTL = 30 * time.Minute
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// メールアドレスが登録済みかどうかは返さない（常に同じレスポンス）
+const forgotPasswordMessage = "if the email is registered, a reset link has been sent"
+
+// POST /auth/password/forgot
+func (u *AuthUsecase) ForgotPassword(ctx context.Context, req ForgotPasswordRequest) (*SuccessResponse, error) {
+	email := strings.TrimSpace(req.Email)
+
+	if err := u.validator.ValidateForgotPassword(ctx, email); err != nil {
+		return nil, err
+	}
+
+	//期限切れ掃除（失敗しても続行）
+	_, _ = u.resetRepo.DeleteExpired(ctx, time.Now())
+
+	user, err := u.users.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	//存在しない・停止中のユーザーにも同じレスポンスを返す
+	if user == nil || !user.IsActive {
+		return &SuccessResponse{Message: forgotPasswordMessage}, nil
+	}
+
+	//有効なのは最新の1本だけにする
+	if err := u.resetRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return nil, ErrInternal
+	}
+
+	plain, hash, err := newRandomTokenAndHash()
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	now := time.Now()
+	token := model.PasswordResetToken{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(passwordResetTokenTTL),
+		UsedAt:    nil,
+		CreatedAt: now,
+	}
+	if err := u.resetRepo.Create(ctx, token); err != nil {
+		return nil, ErrInternal
+	}
+
+	//送信失敗はレスポンスに出さない（登録有無が分かってしまうため）
+	if err := u.mailer.Send(ctx, passwordResetMail(user.Email, u.frontendLink("/password/reset", plain))); err != nil {
+		log.Printf("password reset mail failed: user_id=%d err=%v", user.ID, err)
+	}
+
+	return &SuccessResponse{Message: forgotPasswordMessage}, nil
+}
+
+// POST /auth/password/reset
+func (u *AuthUsecase) ResetPassword(ctx context.Context, req ResetPasswordRequest) (*SuccessResponse, error) {
+	if err := u.validator.ValidateResetPassword(ctx, req.Token, req.NewPassword); err != nil {
+		return nil, err
+	}
+
+	token, found, err := u.resetRepo.FindByHash(ctx, hashToken(req.Token))
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if !found || token.UsedAt != nil || token.ExpiresAt.Before(time.Now()) {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := u.users.FindByID(ctx, token.UserID)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	if user == nil {
+		return nil, ErrInvalidToken
+	}
+	if !user.IsActive {
+		return nil, ErrForbidden
+	}
+
+	//使用済みにできた1リクエストだけが再設定できる
+	if err := u.resetRepo.MarkUsed(ctx, token.ID); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	pwHash, err := u.passwords.Hash(req.NewPassword)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	user.PasswordHash = pwHash
+	if err := u.users.Update(ctx, user); err != nil {
+		return nil, ErrInternal
+	}
+
+	//既存のaccess/refreshを全部無効にする
+	if err := u.users.IncrementTokenVersion(ctx, user.ID); err != nil {
+		return nil, ErrInternal
+	}
+	if err := u.rtRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return nil, ErrInternal
+	}
+
+	//残っている再設定トークンも消す（失敗しても再設定自体は完了）
+	_ = u.resetRepo.DeleteByUserID(ctx, user.ID)
+
+	return &SuccessResponse{Message: "password reset"}, nil
+}
+
+// フロントのURLにワンタイムトークンを付けたリンクを作る
+func (u *AuthUsecase) frontendLink(path string, token string) string {
+	return strings.TrimRight(u.cfg.FEURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+func passwordResetMail(to string, link string) MailMessage {
+	return MailMessage{
+		To:      to,
+		Subject: "パスワード再設定のご案内",
+		Body: fmt.Sprintf(
+			"以下のリンクからパスワードを再設定してください（%d分間有効）。\n\n%s\n\nお心当たりがない場合はこのメールを破棄してください。",
+			int(passwordResetTokenTTL.Minutes()),
+			link,
+		),
+	}
+}
@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+)
+
+// 価格帯ファセットの境界（円）。~1000, 1000~3000, 3000~5000, 5000~10000, 10000~
+var productPriceFacetBounds = []int64{1000, 3000, 5000, 10000}
+
+// GET /products?facets=true のときだけ返す。件数は q・価格帯・カテゴリで絞り込んだ結果（Totalと同じ母数）に対するもの
+type ProductFacets struct {
+	Price      []PriceFacet     `json:"price"`
+	Categories []CategoryFacet  `json:"categories"`
+	Stock      StockFacet       `json:"stock"`
+	Attributes []AttributeFacet `json:"attributes"`
+}
+
+// minは含み、maxは含まない（nilは上限・下限なし）
+type PriceFacet struct {
+	Min   *int64 `json:"min"`
+	Max   *int64 `json:"max"`
+	Count int64  `json:"count"`
+}
+
+// 子孫カテゴリの商品も含めた件数（0件のカテゴリは出さない）。並びはカテゴリツリーと同じ
+type CategoryFacet struct {
+	ID       int64  `json:"id"`
+	ParentID *int64 `json:"parent_id"`
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+	Count    int64  `json:"count"`
+}
+
+type StockFacet struct {
+	InStock    int64 `json:"in_stock"`
+	OutOfStock int64 `json:"out_of_stock"`
+}
+
+// バリエーションの軸（例：サイズ）ごとの値と件数
+type AttributeFacet struct {
+	Name   string                `json:"name"`
+	Values []AttributeValueFacet `json:"values"`
+}
+
+type AttributeValueFacet struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+func (u *ProductUsecase) productFacets(ctx context.Context, q repo.ProductListQuery) (*ProductFacets, error) {
+	counts, err := u.productRepo.FacetPublic(ctx, q, productPriceFacetBounds)
+	if err != nil {
+		return nil, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	var categories []model.Category
+	if len(counts.Categories) > 0 {
+		if categories, err = u.categoryRepo.ListAll(ctx); err != nil {
+			return nil, NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+	}
+	return buildProductFacets(counts, productPriceFacetBounds, categories), nil
+}
+
+func buildProductFacets(counts repo.ProductFacetCounts, bounds []int64, categories []model.Category) *ProductFacets {
+	out := &ProductFacets{
+		Price:      make([]PriceFacet, 0, len(counts.PriceBuckets)),
+		Categories: []CategoryFacet{},
+		Stock:      StockFacet{InStock: counts.InStock, OutOfStock: counts.OutOfStock},
+		Attributes: []AttributeFacet{},
+	}
+
+	for i, n := range counts.PriceBuckets {
+		f := PriceFacet{Count: n}
+		if i > 0 {
+			f.Min = &bounds[i-1]
+		}
+		if i < len(bounds) {
+			f.Max = &bounds[i]
+		}
+		out.Price = append(out.Price, f)
+	}
+
+	//ListAllの並び（sort_order, id）に合わせる
+	byCategory := map[int64]int64{}
+	for _, c := range counts.Categories {
+		byCategory[c.CategoryID] = c.Count
+	}
+	for _, c := range categories {
+		if n := byCategory[c.ID]; n > 0 {
+			out.Categories = append(out.Categories, CategoryFacet{ID: c.ID, ParentID: c.ParentID, Name: c.Name, Slug: c.Slug, Count: n})
+		}
+	}
+
+	//repositoryの並び（軸の並び順→値の登録順）のまま軸ごとにまとめる
+	for _, a := range counts.Attributes {
+		if len(out.Attributes) == 0 || out.Attributes[len(out.Attributes)-1].Name != a.Name {
+			out.Attributes = append(out.Attributes, AttributeFacet{Name: a.Name})
+		}
+		last := &out.Attributes[len(out.Attributes)-1]
+		last.Values = append(last.Values, AttributeValueFacet{Value: a.Value, Count: a.Count})
+	}
+	return out
+}
@@ -0,0 +1,309 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/imaging"
+	repo "app/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// 展開後の画素数の上限（小さなファイルで巨大な画像を作る攻撃を防ぐ）
+const productImageMaxPixels = 40_000_000
+
+// 縮小版のサイズ（長辺のpx）。名前はAPIの thumbnails のキーにもなる
+// 大きい順に並べる（ひとつ前の縮小版から次を作る）
+var productImageThumbnailSizes = []struct {
+	Name    string
+	MaxSide int
+}{
+	{Name: "large", MaxSide: 1024},
+	{Name: "medium", MaxSide: 480},
+	{Name: "small", MaxSide: 160},
+}
+
+type ProductImageUsecase struct {
+	productRepo repo.ProductRepository
+	imageRepo   repo.ProductImageRepository
+	storage     FileStorage
+
+	maxBytes      int
+	maxPerProduct int
+}
+
+// DI
+func NewProductImageUsecase(cfg config.Config, productRepo repo.ProductRepository, imageRepo repo.ProductImageRepository, storage FileStorage) *ProductImageUsecase {
+	return &ProductImageUsecase{
+		productRepo:   productRepo,
+		imageRepo:     imageRepo,
+		storage:       storage,
+		maxBytes:      cfg.ProductImageMaxBytes,
+		maxPerProduct: cfg.ProductImageMaxPerProduct,
+	}
+}
+
+// 商品画像1枚（原寸と縮小版のURL）
+type ProductImageOutput struct {
+	ID         int64             `json:"id"`
+	URL        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails"`
+	Width      int               `json:"width"`
+	Height     int               `json:"height"`
+	Position   int               `json:"position"`
+}
+
+type ProductImageListOutput struct {
+	Items []ProductImageOutput `json:"items"`
+}
+
+func productImageOriginalKey(img model.ProductImage) string {
+	return img.KeyPrefix + "/original." + imaging.Ext(img.Format)
+}
+
+func productImageThumbnailKey(img model.ProductImage, name string) string {
+	return img.KeyPrefix + "/" + name + "." + imaging.Ext(imaging.ThumbnailFormat(img.Format))
+}
+
+// 画像1枚分のストレージ上のkey（原寸＋縮小版）
+func productImageKeys(img model.ProductImage) []string {
+	keys := []string{productImageOriginalKey(img)}
+	for _, s := range productImageThumbnailSizes {
+		keys = append(keys, productImageThumbnailKey(img, s.Name))
+	}
+	return keys
+}
+
+func productImageOutput(storage FileStorage, img model.ProductImage) ProductImageOutput {
+	thumbs := make(map[string]string, len(productImageThumbnailSizes))
+	for _, s := range productImageThumbnailSizes {
+		thumbs[s.Name] = storage.URL(productImageThumbnailKey(img, s.Name))
+	}
+	return ProductImageOutput{
+		ID:         img.ID,
+		URL:        storage.URL(productImageOriginalKey(img)),
+		Thumbnails: thumbs,
+		Width:      img.Width,
+		Height:     img.Height,
+		Position:   img.Position,
+	}
+}
+
+// 並び順のまま出力に変換する（先頭がメイン画像）
+func productImageOutputs(storage FileStorage, images []model.ProductImage) []ProductImageOutput {
+	out := make([]ProductImageOutput, 0, len(images))
+	for _, img := range images {
+		out = append(out, productImageOutput(storage, img))
+	}
+	return out
+}
+
+// 先頭の画像（無ければnil）
+func primaryProductImage(images []ProductImageOutput) *ProductImageOutput {
+	if len(images) == 0 {
+		return nil
+	}
+	p := images[0]
+	return &p
+}
+
+// 管理画面：商品の画像（並び順）
+func (u *ProductImageUsecase) AdminList(ctx context.Context, adminUserID int64, productID int64) (ProductImageListOutput, error) {
+	if adminUserID <= 0 {
+		return ProductImageListOutput{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	if err := u.ensureProduct(ctx, productID); err != nil {
+		return ProductImageListOutput{}, err
+	}
+
+	images, err := u.imageRepo.ListByProductID(ctx, productID)
+	if err != nil {
+		return ProductImageListOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return ProductImageListOutput{Items: productImageOutputs(u.storage, images)}, nil
+}
+
+// 画像を追加する（末尾に並ぶ）。形式は中身で判定し、縮小版もここで作る
+func (u *ProductImageUsecase) AdminUpload(ctx context.Context, adminUserID int64, productID int64, data []byte) (ProductImageOutput, error) {
+	if adminUserID <= 0 {
+		return ProductImageOutput{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	if len(data) == 0 {
+		return ProductImageOutput{}, NewHTTPError(http.StatusBadRequest, "image required")
+	}
+	if len(data) > u.maxBytes {
+		return ProductImageOutput{}, NewHTTPError(http.StatusRequestEntityTooLarge, "image too large")
+	}
+	format, err := imaging.Sniff(data)
+	if err != nil {
+		return ProductImageOutput{}, NewHTTPError(http.StatusUnsupportedMediaType, "unsupported image type (jpeg/png/gif)")
+	}
+
+	if err := u.ensureProduct(ctx, productID); err != nil {
+		return ProductImageOutput{}, err
+	}
+	existing, err := u.imageRepo.ListByProductID(ctx, productID)
+	if err != nil {
+		return ProductImageOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if len(existing) >= u.maxPerProduct {
+		return ProductImageOutput{}, NewHTTPError(http.StatusConflict, fmt.Sprintf("too many images (max %d)", u.maxPerProduct))
+	}
+
+	src, err := imaging.Decode(data, productImageMaxPixels)
+	if err == imaging.ErrTooManyPixels {
+		return ProductImageOutput{}, NewHTTPError(http.StatusBadRequest, "image dimensions too large")
+	}
+	if err != nil {
+		return ProductImageOutput{}, NewHTTPError(http.StatusBadRequest, "invalid image")
+	}
+
+	img := model.ProductImage{
+		ProductID: productID,
+		KeyPrefix: fmt.Sprintf("products/%d/%s", productID, uuid.NewString()),
+		Format:    format,
+		Width:     src.Bounds().Dx(),
+		Height:    src.Bounds().Dy(),
+		ByteSize:  int64(len(data)),
+		Position:  len(existing),
+		CreatedAt: time.Now(),
+	}
+
+	//原寸はアップロードされたまま置く
+	var stored []string
+	if err := u.storage.Put(ctx, productImageOriginalKey(img), imaging.ContentType(format), data); err != nil {
+		return ProductImageOutput{}, NewHTTPError(http.StatusInternalServerError, "storage error")
+	}
+	stored = append(stored, productImageOriginalKey(img))
+
+	//RGBAへの変換は1回だけ。large → medium → small と前の結果を縮小していく
+	thumbFormat := imaging.ThumbnailFormat(format)
+	thumb := imaging.ToRGBA(src)
+	for _, s := range productImageThumbnailSizes {
+		thumb = imaging.Thumbnail(thumb, s.MaxSide)
+		b, err := imaging.Encode(thumb, thumbFormat)
+		if err == nil {
+			err = u.storage.Put(ctx, productImageThumbnailKey(img, s.Name), imaging.ContentType(thumbFormat), b)
+		}
+		if err != nil {
+			u.deleteFiles(ctx, stored)
+			return ProductImageOutput{}, NewHTTPError(http.StatusInternalServerError, "storage error")
+		}
+		stored = append(stored, productImageThumbnailKey(img, s.Name))
+	}
+
+	created, err := u.imageRepo.Create(ctx, img)
+	if err != nil {
+		u.deleteFiles(ctx, stored)
+		return ProductImageOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return productImageOutput(u.storage, created), nil
+}
+
+// 並び替え。imageIDsはその商品の画像IDを過不足なく並べたもの（先頭がメイン画像）
+func (u *ProductImageUsecase) AdminReorder(ctx context.Context, adminUserID int64, productID int64, imageIDs []int64) (ProductImageListOutput, error) {
+	if adminUserID <= 0 {
+		return ProductImageListOutput{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	if err := u.ensureProduct(ctx, productID); err != nil {
+		return ProductImageListOutput{}, err
+	}
+
+	images, err := u.imageRepo.ListByProductID(ctx, productID)
+	if err != nil {
+		return ProductImageListOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	byID := make(map[int64]model.ProductImage, len(images))
+	for _, img := range images {
+		byID[img.ID] = img
+	}
+	if len(imageIDs) != len(images) {
+		return ProductImageListOutput{}, NewHTTPError(http.StatusBadRequest, "image_ids must list every image of the product exactly once")
+	}
+	ordered := make([]model.ProductImage, 0, len(imageIDs))
+	seen := make(map[int64]bool, len(imageIDs))
+	for i, id := range imageIDs {
+		img, ok := byID[id]
+		if !ok || seen[id] {
+			return ProductImageListOutput{}, NewHTTPError(http.StatusBadRequest, "image_ids must list every image of the product exactly once")
+		}
+		seen[id] = true
+		img.Position = i
+		ordered = append(ordered, img)
+	}
+
+	if err := u.imageRepo.UpdatePositions(ctx, productID, imageIDs); err != nil {
+		return ProductImageListOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return ProductImageListOutput{Items: productImageOutputs(u.storage, ordered)}, nil
+}
+
+// 画像を削除する（残りは詰めて並べ直す）
+func (u *ProductImageUsecase) AdminDelete(ctx context.Context, adminUserID int64, productID int64, imageID int64) error {
+	if adminUserID <= 0 {
+		return NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	if productID <= 0 || imageID <= 0 {
+		return NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+
+	img, err := u.imageRepo.FindByID(ctx, imageID)
+	//別の商品の画像は見えないものとして扱う
+	if err == repo.ErrNotFound || (err == nil && img.ProductID != productID) {
+		return NewHTTPError(http.StatusNotFound, "not found")
+	}
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	if err := u.imageRepo.Delete(ctx, imageID); err != nil {
+		if err == repo.ErrNotFound {
+			return NewHTTPError(http.StatusNotFound, "not found")
+		}
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	rest, err := u.imageRepo.ListByProductID(ctx, productID)
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	ids := make([]int64, 0, len(rest))
+	for _, r := range rest {
+		ids = append(ids, r.ID)
+	}
+	if err := u.imageRepo.UpdatePositions(ctx, productID, ids); err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	//ファイルが残っても表示には出ないので、失敗はログだけ
+	u.deleteFiles(ctx, productImageKeys(img))
+	return nil
+}
+
+func (u *ProductImageUsecase) ensureProduct(ctx context.Context, productID int64) error {
+	if productID <= 0 {
+		return NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	if _, err := u.productRepo.FindByID(ctx, productID); err != nil {
+		if err == repo.ErrNotFound {
+			return NewHTTPError(http.StatusNotFound, "not found")
+		}
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return nil
+}
+
+func (u *ProductImageUsecase) deleteFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := u.storage.Delete(ctx, key); err != nil {
+			log.Printf("product image delete error: key=%s err=%v", key, err)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"app/internal/domain/model"
 	repo "app/internal/repository"
+	"app/internal/textsearch"
 )
 
 type HTTPError struct {
@@ -34,10 +35,17 @@ func AsHTTPError(err error) (*HTTPError, bool) {
 	return he, ok
 }
 
+// 検索結果の説明文の抜粋の長さ（文字数）
+const productSnippetRunes = 80
+
 type ProductUsecase struct {
 	productRepo   repo.ProductRepository
 	inventoryRepo repo.InventoryRepository
 	auditRepo     repo.AuditLogRepository
+	categoryRepo  repo.CategoryRepository
+	variantRepo   repo.ProductVariantRepository
+	imageRepo     repo.ProductImageRepository
+	storage       FileStorage
 }
 
 // DI
@@ -45,11 +53,19 @@ func NewProductUsecase(
 	productRepo repo.ProductRepository,
 	inventoryRepo repo.InventoryRepository,
 	auditRepo repo.AuditLogRepository, // ★追加
+	categoryRepo repo.CategoryRepository,
+	variantRepo repo.ProductVariantRepository,
+	imageRepo repo.ProductImageRepository,
+	storage FileStorage, // 画像URLの組み立て
 ) *ProductUsecase {
 	return &ProductUsecase{
 		productRepo:   productRepo,
 		inventoryRepo: inventoryRepo,
 		auditRepo:     auditRepo,
+		categoryRepo:  categoryRepo,
+		variantRepo:   variantRepo,
+		imageRepo:     imageRepo,
+		storage:       storage,
 	}
 }
 
@@ -61,13 +77,35 @@ type ListProductsInput struct {
 	MinPrice *int64
 	MaxPrice *int64
 	Sort     string
+	//カテゴリのslug（子孫カテゴリの商品も含む）
+	Category string
+	//trueならファセット（価格帯・カテゴリ・在庫・バリエーションの値ごとの件数）も返す
+	Facets bool
+}
+
+// 一覧の1件（画像は並び順、primary_imageは先頭の画像。無ければnull）
+type ProductListItem struct {
+	model.Product
+	Images       []ProductImageOutput `json:"images"`
+	PrimaryImage *ProductImageOutput  `json:"primary_image"`
+	//qがあるときだけ
+	Highlight *ProductHighlight `json:"highlight,omitempty"`
+}
+
+// 検索語に一致した箇所を <mark> で囲んだHTML断片（それ以外はエスケープ済み）
+type ProductHighlight struct {
+	Name string `json:"name"`
+	//一致箇所のまわりの抜粋（一致が無ければ先頭から）
+	Description string `json:"description"`
 }
 
 type ProductListOutput struct {
-	Items []model.Product `json:"items"`
-	Total int64           `json:"total"`
-	Page  int             `json:"page"`
-	Limit int             `json:"limit"`
+	Items []ProductListItem `json:"items"`
+	Total int64             `json:"total"`
+	Page  int               `json:"page"`
+	Limit int               `json:"limit"`
+	//Facetsを指定したときだけ
+	Facets *ProductFacets `json:"facets,omitempty"`
 }
 
 func (u *ProductUsecase) ListPublicProducts(ctx context.Context, in ListProductsInput) (ProductListOutput, error) {
@@ -90,48 +128,147 @@ func (u *ProductUsecase) ListPublicProducts(ctx context.Context, in ListProducts
 		return ProductListOutput{}, NewHTTPError(http.StatusBadRequest, "min_price must be <= max_price")
 	}
 	switch in.Sort {
-	case "", "new", "price_asc", "price_desc":
+	case "", "new", "price_asc", "price_desc", "relevance":
 	default:
 		return ProductListOutput{}, NewHTTPError(http.StatusBadRequest, "invalid sort")
 	}
 
-	items, total, err := u.productRepo.ListPublic(ctx, repo.ProductListQuery{
-		Page:     in.Page,
-		Limit:    in.Limit,
-		Q:        strings.TrimSpace(in.Q),
-		MinPrice: in.MinPrice,
-		MaxPrice: in.MaxPrice,
-		Sort:     in.Sort,
-	})
+	var categoryIDs []int64
+	if slug := strings.TrimSpace(in.Category); slug != "" {
+		ids, err := u.resolveCategoryFilter(ctx, slug)
+		if err != nil {
+			return ProductListOutput{}, err
+		}
+		categoryIDs = ids
+	}
+
+	query := repo.ProductListQuery{
+		Page:        in.Page,
+		Limit:       in.Limit,
+		Q:           strings.TrimSpace(in.Q),
+		MinPrice:    in.MinPrice,
+		MaxPrice:    in.MaxPrice,
+		Sort:        in.Sort,
+		CategoryIDs: categoryIDs,
+	}
+	items, total, err := u.productRepo.ListPublic(ctx, query)
+	if err != nil {
+		return ProductListOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	//画像は1回でまとめて取る
+	ids := make([]int64, 0, len(items))
+	for _, p := range items {
+		ids = append(ids, p.ID)
+	}
+	images, err := u.imageRepo.ListByProductIDs(ctx, ids)
 	if err != nil {
 		return ProductListOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
 	}
+	imagesByProduct := map[int64][]model.ProductImage{}
+	for _, img := range images {
+		imagesByProduct[img.ProductID] = append(imagesByProduct[img.ProductID], img)
+	}
+
+	terms := textsearch.Terms(in.Q)
+	listItems := make([]ProductListItem, 0, len(items))
+	for _, p := range items {
+		imgOut := productImageOutputs(u.storage, imagesByProduct[p.ID])
+		item := ProductListItem{Product: p, Images: imgOut, PrimaryImage: primaryProductImage(imgOut)}
+		if len(terms) > 0 {
+			item.Highlight = highlightProduct(p, terms)
+		}
+		listItems = append(listItems, item)
+	}
+
+	var facets *ProductFacets
+	if in.Facets {
+		if facets, err = u.productFacets(ctx, query); err != nil {
+			return ProductListOutput{}, err
+		}
+	}
 
 	return ProductListOutput{
-		Items: items,
-		Total: total,
-		Page:  in.Page,
-		Limit: in.Limit,
+		Items:  listItems,
+		Total:  total,
+		Page:   in.Page,
+		Limit:  in.Limit,
+		Facets: facets,
 	}, nil
 }
 
-func (u *ProductUsecase) GetProductDetail(ctx context.Context, productID int64) (model.Product, error) {
+func highlightProduct(p model.Product, terms []string) *ProductHighlight {
+	name, _ := textsearch.Highlight(p.Name, terms, 0)
+	desc, _ := textsearch.Highlight(p.Description, terms, productSnippetRunes)
+	return &ProductHighlight{Name: name, Description: desc}
+}
+
+// slugのカテゴリと、その子孫カテゴリのID
+func (u *ProductUsecase) resolveCategoryFilter(ctx context.Context, slug string) ([]int64, error) {
+	c, err := u.categoryRepo.FindBySlug(ctx, slug)
+	if err == repo.ErrNotFound {
+		return nil, NewHTTPError(http.StatusNotFound, "category not found")
+	}
+	if err != nil {
+		return nil, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	all, err := u.categoryRepo.ListAll(ctx)
+	if err != nil {
+		return nil, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return descendantCategoryIDs(all, c.ID), nil
+}
+
+// GET /products/:id。バリエーションのある商品は軸と公開中のバリエーション（価格・在庫）を返す
+type ProductDetailOutput struct {
+	model.Product
+	Images       []ProductImageOutput   `json:"images"`
+	PrimaryImage *ProductImageOutput    `json:"primary_image"`
+	Options      []ProductOptionOutput  `json:"options"`
+	Variants     []ProductVariantOutput `json:"variants"`
+}
+
+func (u *ProductUsecase) GetProductDetail(ctx context.Context, productID int64) (ProductDetailOutput, error) {
 	if productID <= 0 {
-		return model.Product{}, NewHTTPError(http.StatusBadRequest, "invalid product id")
+		return ProductDetailOutput{}, NewHTTPError(http.StatusBadRequest, "invalid product id")
 	}
 
 	p, err := u.productRepo.FindByID(ctx, productID)
 	if err == repo.ErrNotFound {
-		return model.Product{}, NewHTTPError(http.StatusNotFound, "not found")
+		return ProductDetailOutput{}, NewHTTPError(http.StatusNotFound, "not found")
 	}
 	if err != nil {
-		return model.Product{}, NewHTTPError(http.StatusInternalServerError, "db error")
+		return ProductDetailOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
 	}
 
 	if !p.IsActive {
-		return model.Product{}, NewHTTPError(http.StatusNotFound, "not found")
+		return ProductDetailOutput{}, NewHTTPError(http.StatusNotFound, "not found")
+	}
+
+	options, err := u.variantRepo.ListOptions(ctx, productID)
+	if err != nil {
+		return ProductDetailOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	variants, err := u.variantRepo.ListByProductID(ctx, productID)
+	if err != nil {
+		return ProductDetailOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
 	}
-	return p, nil
+
+	images, err := u.imageRepo.ListByProductID(ctx, productID)
+	if err != nil {
+		return ProductDetailOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	optOut, varOut := buildVariantMatrix(options, variants, false)
+	imgOut := productImageOutputs(u.storage, images)
+	return ProductDetailOutput{
+		Product:      p,
+		Images:       imgOut,
+		PrimaryImage: primaryProductImage(imgOut),
+		Options:      optOut,
+		Variants:     varOut,
+	}, nil
 }
 
 type AdminCreateProductInput struct {
@@ -140,6 +277,8 @@ type AdminCreateProductInput struct {
 	Price       int64
 	Stock       int64
 	IsActive    bool
+	//nilなら変更しない、空なら全部外す
+	CategoryIDs []int64
 }
 
 func (u *ProductUsecase) AdminCreateProduct(ctx context.Context, adminUserID int64, in AdminCreateProductInput) (int64, error) {
@@ -155,6 +294,10 @@ func (u *ProductUsecase) AdminCreateProduct(ctx context.Context, adminUserID int
 	if in.Stock < 0 {
 		return 0, NewHTTPError(http.StatusBadRequest, "stock must be >= 0")
 	}
+	categoryIDs, err := u.validateCategoryIDs(ctx, in.CategoryIDs)
+	if err != nil {
+		return 0, err
+	}
 
 	now := time.Now()
 	p, err := u.productRepo.Create(ctx, model.Product{
@@ -169,6 +312,12 @@ func (u *ProductUsecase) AdminCreateProduct(ctx context.Context, adminUserID int
 	if err != nil {
 		return 0, NewHTTPError(http.StatusInternalServerError, "db error")
 	}
+
+	if categoryIDs != nil {
+		if err := u.categoryRepo.ReplaceProductCategories(ctx, p.ID, categoryIDs); err != nil {
+			return 0, NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+	}
 	return p.ID, nil
 }
 
@@ -188,8 +337,12 @@ func (u *ProductUsecase) AdminUpdateProduct(ctx context.Context, adminUserID int
 	if in.Stock < 0 {
 		return NewHTTPError(http.StatusBadRequest, "stock must be >= 0")
 	}
+	categoryIDs, err := u.validateCategoryIDs(ctx, in.CategoryIDs)
+	if err != nil {
+		return err
+	}
 
-	err := u.productRepo.Update(ctx, model.Product{
+	err = u.productRepo.Update(ctx, model.Product{
 		ID:          productID,
 		Name:        strings.TrimSpace(in.Name),
 		Description: in.Description,
@@ -204,9 +357,40 @@ func (u *ProductUsecase) AdminUpdateProduct(ctx context.Context, adminUserID int
 	if err != nil {
 		return NewHTTPError(http.StatusInternalServerError, "db error")
 	}
+
+	if categoryIDs != nil {
+		if err := u.categoryRepo.ReplaceProductCategories(ctx, productID, categoryIDs); err != nil {
+			return NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+	}
 	return nil
 }
 
+// 指定されたカテゴリが全部あるか（重複は除く。nilはnilのまま返す）
+func (u *ProductUsecase) validateCategoryIDs(ctx context.Context, ids []int64) ([]int64, error) {
+	if ids == nil {
+		return nil, nil
+	}
+
+	out := make([]int64, 0, len(ids))
+	seen := map[int64]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if _, err := u.categoryRepo.FindByID(ctx, id); err != nil {
+			if err == repo.ErrNotFound {
+				return nil, NewHTTPError(http.StatusBadRequest, "category not found")
+			}
+			return nil, NewHTTPError(http.StatusInternalServerError, "db error")
+		}
+		out = append(out, id)
+	}
+	return out, nil
+}
+
 func (u *ProductUsecase) AdminDeleteProduct(ctx context.Context, adminUserID int64, productID int64) error {
 	if adminUserID <= 0 {
 		return NewHTTPError(http.StatusUnauthorized, "unauthorized")
@@ -274,13 +458,14 @@ func (u *ProductUsecase) AdminUpdateInventory(ctx context.Context, adminUserID i
 	//監査ログを作成（在庫更新）
 	//「誰が」「何を」「どの対象に」「どう変えたか」を残す
 	if err := u.auditRepo.Create(ctx, model.AuditLog{
-		ActorUserID:  adminUserID,
-		Action:       model.AuditActionUpdateStock,
-		ResourceType: model.AuditResourceProduct,
-		ResourceID:   productID,
-		BeforeJSON:   beforeJSON,
-		AfterJSON:    afterJSON,
-		CreatedAt:    time.Now(),
+		ActorUserID:   adminUserID,
+		ActorApiKeyID: apiKeyActorID(ctx),
+		Action:        model.AuditActionUpdateStock,
+		ResourceType:  model.AuditResourceProduct,
+		ResourceID:    productID,
+		BeforeJSON:    beforeJSON,
+		AfterJSON:     afterJSON,
+		CreatedAt:     time.Now(),
 	}); err != nil {
 		return NewHTTPError(http.StatusInternalServerError, "db error")
 	}
@@ -0,0 +1,446 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+)
+
+// SKUは英数字と . _ -（先頭は英数字）
+var variantSKUPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+const (
+	// 軸は3つまで（サイズ×色×素材 など）
+	maxProductOptions    = 3
+	productOptionMaxLen  = 50
+	variantSKUMaxLen     = 100
+	optionLabelSeparator = " / "
+)
+
+// バリエーション（SKU）の管理と在庫
+type ProductVariantUsecase struct {
+	productRepo   repo.ProductRepository
+	variantRepo   repo.ProductVariantRepository
+	inventoryRepo repo.InventoryRepository
+	auditRepo     repo.AuditLogRepository
+}
+
+// DI
+func NewProductVariantUsecase(
+	productRepo repo.ProductRepository,
+	variantRepo repo.ProductVariantRepository,
+	inventoryRepo repo.InventoryRepository,
+	auditRepo repo.AuditLogRepository,
+) *ProductVariantUsecase {
+	return &ProductVariantUsecase{
+		productRepo:   productRepo,
+		variantRepo:   variantRepo,
+		inventoryRepo: inventoryRepo,
+		auditRepo:     auditRepo,
+	}
+}
+
+// 軸と、その軸で使われている値（バリエーションの並び順）
+type ProductOptionOutput struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type ProductVariantOutput struct {
+	ID       int64  `json:"id"`
+	SKU      string `json:"sku"`
+	Price    int64  `json:"price"`
+	Stock    int64  `json:"stock"`
+	IsActive bool   `json:"is_active"`
+	//軸名→値（{"サイズ":"M","色":"白"}）
+	Options      map[string]string `json:"options"`
+	OptionLabels string            `json:"option_labels"`
+}
+
+// 商品ごとのバリエーション一覧（管理画面は非公開のものも含む）
+type VariantMatrixOutput struct {
+	ProductID int64                  `json:"product_id"`
+	Options   []ProductOptionOutput  `json:"options"`
+	Variants  []ProductVariantOutput `json:"variants"`
+}
+
+// 軸の順に "サイズ: M / 色: 白"
+func variantOptionLabels(options []model.ProductOption, v model.ProductVariant) string {
+	values := make(map[int64]string, len(v.Values))
+	for _, pv := range v.Values {
+		values[pv.OptionID] = pv.Value
+	}
+
+	labels := make([]string, 0, len(options))
+	for _, o := range options {
+		if val, ok := values[o.ID]; ok {
+			labels = append(labels, o.Name+": "+val)
+		}
+	}
+	return strings.Join(labels, optionLabelSeparator)
+}
+
+// 軸とバリエーションを表の形にする（includeInactive=falseなら公開中のものだけ）
+func buildVariantMatrix(options []model.ProductOption, variants []model.ProductVariant, includeInactive bool) ([]ProductOptionOutput, []ProductVariantOutput) {
+	optOut := make([]ProductOptionOutput, 0, len(options))
+	index := make(map[int64]int, len(options))
+	seen := make([]map[string]bool, len(options))
+	for i, o := range options {
+		optOut = append(optOut, ProductOptionOutput{Name: o.Name, Values: []string{}})
+		index[o.ID] = i
+		seen[i] = map[string]bool{}
+	}
+
+	varOut := make([]ProductVariantOutput, 0, len(variants))
+	for _, v := range variants {
+		if !v.IsActive && !includeInactive {
+			continue
+		}
+
+		opts := make(map[string]string, len(v.Values))
+		for _, pv := range v.Values {
+			i, ok := index[pv.OptionID]
+			if !ok {
+				continue
+			}
+			opts[options[i].Name] = pv.Value
+			if !seen[i][pv.Value] {
+				seen[i][pv.Value] = true
+				optOut[i].Values = append(optOut[i].Values, pv.Value)
+			}
+		}
+
+		varOut = append(varOut, ProductVariantOutput{
+			ID:           v.ID,
+			SKU:          v.SKU,
+			Price:        v.Price,
+			Stock:        v.Stock,
+			IsActive:     v.IsActive,
+			Options:      opts,
+			OptionLabels: variantOptionLabels(options, v),
+		})
+	}
+	return optOut, varOut
+}
+
+// 商品が存在するか（削除済みは404）
+func (u *ProductVariantUsecase) findProduct(ctx context.Context, productID int64) (model.Product, error) {
+	if productID <= 0 {
+		return model.Product{}, NewHTTPError(http.StatusBadRequest, "invalid product id")
+	}
+	p, err := u.productRepo.FindByID(ctx, productID)
+	if err == repo.ErrNotFound {
+		return model.Product{}, NewHTTPError(http.StatusNotFound, "not found")
+	}
+	if err != nil {
+		return model.Product{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return p, nil
+}
+
+func (u *ProductVariantUsecase) AdminGetMatrix(ctx context.Context, adminUserID int64, productID int64) (VariantMatrixOutput, error) {
+	if adminUserID <= 0 {
+		return VariantMatrixOutput{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	if _, err := u.findProduct(ctx, productID); err != nil {
+		return VariantMatrixOutput{}, err
+	}
+
+	options, err := u.variantRepo.ListOptions(ctx, productID)
+	if err != nil {
+		return VariantMatrixOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	variants, err := u.variantRepo.ListByProductID(ctx, productID)
+	if err != nil {
+		return VariantMatrixOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	optOut, varOut := buildVariantMatrix(options, variants, true)
+	return VariantMatrixOutput{ProductID: productID, Options: optOut, Variants: varOut}, nil
+}
+
+// 軸（例 ["サイズ","色"]）を設定する。バリエーションがある間は変えられない
+func (u *ProductVariantUsecase) AdminReplaceOptions(ctx context.Context, adminUserID int64, productID int64, names []string) (VariantMatrixOutput, error) {
+	if adminUserID <= 0 {
+		return VariantMatrixOutput{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	if len(names) > maxProductOptions {
+		return VariantMatrixOutput{}, NewHTTPError(http.StatusBadRequest, fmt.Sprintf("at most %d options", maxProductOptions))
+	}
+
+	cleaned := make([]string, 0, len(names))
+	seen := map[string]bool{}
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if n == "" || len([]rune(n)) > productOptionMaxLen {
+			return VariantMatrixOutput{}, NewHTTPError(http.StatusBadRequest, "invalid option name")
+		}
+		if seen[n] {
+			return VariantMatrixOutput{}, NewHTTPError(http.StatusBadRequest, "duplicate option name")
+		}
+		seen[n] = true
+		cleaned = append(cleaned, n)
+	}
+
+	if _, err := u.findProduct(ctx, productID); err != nil {
+		return VariantMatrixOutput{}, err
+	}
+	variants, err := u.variantRepo.ListByProductID(ctx, productID)
+	if err != nil {
+		return VariantMatrixOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if len(variants) > 0 {
+		return VariantMatrixOutput{}, NewHTTPError(http.StatusConflict, "delete variants before changing options")
+	}
+
+	if _, err := u.variantRepo.ReplaceOptions(ctx, productID, cleaned); err != nil {
+		return VariantMatrixOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return u.AdminGetMatrix(ctx, adminUserID, productID)
+}
+
+type AdminCreateVariantInput struct {
+	SKU      string
+	Price    int64
+	Stock    int64
+	IsActive bool
+	//軸名→値。商品の軸を全部1つずつ指定する
+	Options map[string]string
+}
+
+func (u *ProductVariantUsecase) AdminCreateVariant(ctx context.Context, adminUserID int64, productID int64, in AdminCreateVariantInput) (ProductVariantOutput, error) {
+	if adminUserID <= 0 {
+		return ProductVariantOutput{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	sku, err := validateVariantFields(in.SKU, in.Price)
+	if err != nil {
+		return ProductVariantOutput{}, err
+	}
+	if in.Stock < 0 {
+		return ProductVariantOutput{}, NewHTTPError(http.StatusBadRequest, "stock must be >= 0")
+	}
+
+	if _, err := u.findProduct(ctx, productID); err != nil {
+		return ProductVariantOutput{}, err
+	}
+	options, err := u.variantRepo.ListOptions(ctx, productID)
+	if err != nil {
+		return ProductVariantOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if len(options) == 0 {
+		return ProductVariantOutput{}, NewHTTPError(http.StatusBadRequest, "set options before adding variants")
+	}
+
+	//軸を全部・過不足なく
+	if len(in.Options) != len(options) {
+		return ProductVariantOutput{}, NewHTTPError(http.StatusBadRequest, "options must match the product options")
+	}
+	values := make([]model.ProductVariantValue, 0, len(options))
+	for _, o := range options {
+		val, ok := in.Options[o.Name]
+		val = strings.TrimSpace(val)
+		if !ok || val == "" || len([]rune(val)) > productOptionMaxLen {
+			return ProductVariantOutput{}, NewHTTPError(http.StatusBadRequest, "options must match the product options")
+		}
+		values = append(values, model.ProductVariantValue{OptionID: o.ID, Value: val})
+	}
+
+	//同じ組み合わせは1つだけ
+	variants, err := u.variantRepo.ListByProductID(ctx, productID)
+	if err != nil {
+		return ProductVariantOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	for _, v := range variants {
+		if sameVariantValues(v.Values, values) {
+			return ProductVariantOutput{}, NewHTTPError(http.StatusConflict, "variant already exists")
+		}
+	}
+	if err := u.ensureSKUAvailable(ctx, sku, 0); err != nil {
+		return ProductVariantOutput{}, err
+	}
+
+	now := time.Now()
+	created, err := u.variantRepo.Create(ctx, model.ProductVariant{
+		ProductID: productID,
+		SKU:       sku,
+		Price:     in.Price,
+		Stock:     in.Stock,
+		IsActive:  in.IsActive,
+		Values:    values,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return ProductVariantOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	_, out := buildVariantMatrix(options, []model.ProductVariant{created}, true)
+	return out[0], nil
+}
+
+type AdminUpdateVariantInput struct {
+	SKU      string
+	Price    int64
+	IsActive bool
+}
+
+// SKU・価格・公開を更新（軸の値は変えられない。在庫は AdminUpdateVariantStock）
+func (u *ProductVariantUsecase) AdminUpdateVariant(ctx context.Context, adminUserID int64, variantID int64, in AdminUpdateVariantInput) error {
+	if adminUserID <= 0 {
+		return NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	if variantID <= 0 {
+		return NewHTTPError(http.StatusBadRequest, "invalid variant id")
+	}
+	sku, err := validateVariantFields(in.SKU, in.Price)
+	if err != nil {
+		return err
+	}
+
+	v, err := u.variantRepo.FindByID(ctx, variantID)
+	if err == repo.ErrNotFound {
+		return NewHTTPError(http.StatusNotFound, "not found")
+	}
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if err := u.ensureSKUAvailable(ctx, sku, variantID); err != nil {
+		return err
+	}
+
+	v.SKU = sku
+	v.Price = in.Price
+	v.IsActive = in.IsActive
+	if err := u.variantRepo.Update(ctx, v); err != nil {
+		if err == repo.ErrNotFound {
+			return NewHTTPError(http.StatusNotFound, "not found")
+		}
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return nil
+}
+
+func (u *ProductVariantUsecase) AdminDeleteVariant(ctx context.Context, adminUserID int64, variantID int64) error {
+	if adminUserID <= 0 {
+		return NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	if variantID <= 0 {
+		return NewHTTPError(http.StatusBadRequest, "invalid variant id")
+	}
+
+	err := u.variantRepo.SoftDelete(ctx, variantID)
+	if err == repo.ErrNotFound {
+		return NewHTTPError(http.StatusNotFound, "not found")
+	}
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return nil
+}
+
+// バリエーションの在庫更新（商品単位の AdminUpdateInventory と同じく履歴と監査ログを残す）
+func (u *ProductVariantUsecase) AdminUpdateVariantStock(ctx context.Context, adminUserID int64, variantID int64, newStock int64, reason string) error {
+	if adminUserID <= 0 {
+		return NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	if variantID <= 0 {
+		return NewHTTPError(http.StatusBadRequest, "invalid variant id")
+	}
+	if newStock < 0 {
+		return NewHTTPError(http.StatusBadRequest, "stock must be >= 0")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return NewHTTPError(http.StatusBadRequest, "reason required")
+	}
+
+	v, err := u.variantRepo.FindByID(ctx, variantID)
+	if err == repo.ErrNotFound {
+		return NewHTTPError(http.StatusNotFound, "not found")
+	}
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	if err := u.inventoryRepo.SetVariantStock(ctx, variantID, newStock); err != nil {
+		if err == repo.ErrNotFound {
+			return NewHTTPError(http.StatusNotFound, "not found")
+		}
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	if err := u.inventoryRepo.CreateAdjustment(ctx, model.InventoryAdjustment{
+		ProductID:   v.ProductID,
+		VariantID:   &v.ID,
+		AdminUserID: adminUserID,
+		Delta:       newStock - v.Stock,
+		Reason:      strings.TrimSpace(reason),
+		CreatedAt:   time.Now(),
+	}); err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	if err := u.auditRepo.Create(ctx, model.AuditLog{
+		ActorUserID:   adminUserID,
+		ActorApiKeyID: apiKeyActorID(ctx),
+		Action:        model.AuditActionUpdateStock,
+		ResourceType:  model.AuditResourceProductVariant,
+		ResourceID:    variantID,
+		BeforeJSON:    fmt.Sprintf(`{"product_id":%d,"stock":%d}`, v.ProductID, v.Stock),
+		AfterJSON:     fmt.Sprintf(`{"product_id":%d,"stock":%d}`, v.ProductID, newStock),
+		CreatedAt:     time.Now(),
+	}); err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	return nil
+}
+
+func validateVariantFields(sku string, price int64) (string, error) {
+	sku = strings.TrimSpace(sku)
+	if sku == "" {
+		return "", NewHTTPError(http.StatusBadRequest, "sku required")
+	}
+	if len(sku) > variantSKUMaxLen || !variantSKUPattern.MatchString(sku) {
+		return "", NewHTTPError(http.StatusBadRequest, "invalid sku")
+	}
+	if price < 0 {
+		return "", NewHTTPError(http.StatusBadRequest, "price must be >= 0")
+	}
+	return sku, nil
+}
+
+// SKUは削除済みのものも含めて一意（selfIDは更新時の自分自身）
+func (u *ProductVariantUsecase) ensureSKUAvailable(ctx context.Context, sku string, selfID int64) error {
+	v, err := u.variantRepo.FindBySKU(ctx, sku)
+	if err == repo.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if v.ID != selfID {
+		return NewHTTPError(http.StatusConflict, "sku already exists")
+	}
+	return nil
+}
+
+func sameVariantValues(a []model.ProductVariantValue, b []model.ProductVariantValue) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	m := make(map[int64]string, len(a))
+	for _, v := range a {
+		m[v.OptionID] = v.Value
+	}
+	for _, v := range b {
+		if val, ok := m[v.OptionID]; !ok || val != v.Value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+)
+
+// ロール名（users.role は varchar(20)）
+var roleNamePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,19}$`)
+
+// スタッフのロールと権限（ADMINだけが編集できる）
+type RbacUsecase struct {
+	roles     repo.RolePermissionRepository
+	users     repo.UserRepository
+	auditRepo repo.AuditLogRepository
+}
+
+func NewRbacUsecase(roles repo.RolePermissionRepository, users repo.UserRepository, auditRepo repo.AuditLogRepository) *RbacUsecase {
+	return &RbacUsecase{roles: roles, users: users, auditRepo: auditRepo}
+}
+
+type RoleOutput struct {
+	Role        model.Role `json:"role"`
+	Permissions []string   `json:"permissions"`
+}
+
+// role_permissions が空なら初期ロールを入れる（起動時に1回）
+func (u *RbacUsecase) SeedDefaults(ctx context.Context) error {
+	list, err := u.roles.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+	if len(list) > 0 {
+		return nil
+	}
+
+	for role, perms := range model.DefaultRolePermissions {
+		if err := u.roles.ReplaceRole(ctx, role, perms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ADMINは全権限、USERは権限なし、それ以外はDBの対応表
+func (u *RbacUsecase) HasPermission(ctx context.Context, role string, permission string) (bool, error) {
+	switch model.Role(role) {
+	case model.RoleAdmin:
+		return true, nil
+	case model.RoleUser, "":
+		return false, nil
+	}
+	return u.roles.HasPermission(ctx, model.Role(role), permission)
+}
+
+// 割り当てできる権限の一覧
+func (u *RbacUsecase) Permissions() []string {
+	return model.Permissions
+}
+
+// スタッフのロール一覧（ADMIN/USERは固定なので含めない）
+func (u *RbacUsecase) ListRoles(ctx context.Context) ([]RoleOutput, error) {
+	list, err := u.roles.ListAll(ctx)
+	if err != nil {
+		return []RoleOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	outs := []RoleOutput{}
+	for _, rp := range list {
+		if len(outs) == 0 || outs[len(outs)-1].Role != rp.Role {
+			outs = append(outs, RoleOutput{Role: rp.Role, Permissions: []string{}})
+		}
+		last := &outs[len(outs)-1]
+		last.Permissions = append(last.Permissions, rp.Permission)
+	}
+	return outs, nil
+}
+
+// ロールの作成・権限の置き換え。監査ログに残す
+func (u *RbacUsecase) PutRole(ctx context.Context, actorAdminUserID int64, role string, permissions []string) (RoleOutput, error) {
+	if actorAdminUserID <= 0 {
+		return RoleOutput{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	r, err := parseStaffRole(role)
+	if err != nil {
+		return RoleOutput{}, err
+	}
+
+	perms, err := normalizePermissions(permissions)
+	if err != nil {
+		return RoleOutput{}, err
+	}
+
+	before, err := u.roles.ListByRole(ctx, r)
+	if err != nil {
+		return RoleOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	if err := u.roles.ReplaceRole(ctx, r, perms); err != nil {
+		return RoleOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	out := RoleOutput{Role: r, Permissions: perms}
+	beforeJSON, _ := json.Marshal(RoleOutput{Role: r, Permissions: before})
+	afterJSON, _ := json.Marshal(out)
+	if err := u.auditRepo.Create(ctx, model.AuditLog{
+		ActorUserID:  actorAdminUserID,
+		Action:       model.AuditActionUpdateRolePermissions,
+		ResourceType: model.AuditResourceRole,
+		ResourceID:   0,
+		BeforeJSON:   string(beforeJSON),
+		AfterJSON:    string(afterJSON),
+		CreatedAt:    time.Now(),
+	}); err != nil {
+		return RoleOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	return out, nil
+}
+
+// ロール削除。そのロールのユーザーが残っていたら消せない
+func (u *RbacUsecase) DeleteRole(ctx context.Context, actorAdminUserID int64, role string) error {
+	if actorAdminUserID <= 0 {
+		return NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	r, err := parseStaffRole(role)
+	if err != nil {
+		return err
+	}
+
+	before, err := u.roles.ListByRole(ctx, r)
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if len(before) == 0 {
+		return NewHTTPError(http.StatusNotFound, "not found")
+	}
+
+	_, assigned, err := u.users.List(ctx, repo.AdminUserListFilter{Page: 1, Limit: 1, Role: &r})
+	if err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+	if assigned > 0 {
+		return NewHTTPError(http.StatusConflict, "role is assigned to users")
+	}
+
+	if _, err := u.roles.DeleteRole(ctx, r); err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	beforeJSON, _ := json.Marshal(RoleOutput{Role: r, Permissions: before})
+	if err := u.auditRepo.Create(ctx, model.AuditLog{
+		ActorUserID:  actorAdminUserID,
+		Action:       model.AuditActionDeleteRole,
+		ResourceType: model.AuditResourceRole,
+		ResourceID:   0,
+		BeforeJSON:   string(beforeJSON),
+		AfterJSON:    "",
+		CreatedAt:    time.Now(),
+	}); err != nil {
+		return NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	return nil
+}
+
+// USER・ADMINは固定なので編集不可
+func parseStaffRole(role string) (model.Role, error) {
+	r := model.Role(strings.ToUpper(strings.TrimSpace(role)))
+	if r == model.RoleUser || r == model.RoleAdmin {
+		return "", NewHTTPError(http.StatusBadRequest, "built-in role cannot be changed")
+	}
+	if !roleNamePattern.MatchString(string(r)) {
+		return "", NewHTTPError(http.StatusBadRequest, "invalid role")
+	}
+	return r, nil
+}
+
+// 重複を除いて並べる。知らない権限・空はエラー（空にしたいときはロール削除）
+func normalizePermissions(permissions []string) ([]string, error) {
+	if len(permissions) == 0 {
+		return nil, NewHTTPError(http.StatusBadRequest, "permissions required")
+	}
+
+	seen := map[string]bool{}
+	out := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		p = strings.TrimSpace(p)
+		if !isKnownPermission(p) {
+			return nil, NewHTTPError(http.StatusBadRequest, "invalid permission: "+p)
+		}
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func isKnownPermission(permission string) bool {
+	for _, p := range model.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// USER・ADMIN・DBにあるスタッフのロールならtrue
+func roleExists(ctx context.Context, roles repo.RolePermissionRepository, role model.Role) (bool, error) {
+	if role == model.RoleUser || role == model.RoleAdmin {
+		return true, nil
+	}
+	if !roleNamePattern.MatchString(string(role)) {
+		return false, nil
+	}
+	perms, err := roles.ListByRole(ctx, role)
+	if err != nil {
+		return false, err
+	}
+	return len(perms) > 0, nil
+}
@@ -0,0 +1,223 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+)
+
+// セキュリティイベントの一覧（管理者は全員分を絞り込み、本人は自分の分だけ）
+type SecurityEventUsecase struct {
+	events repo.SecurityEventRepository
+}
+
+func NewSecurityEventUsecase(events repo.SecurityEventRepository) *SecurityEventUsecase {
+	return &SecurityEventUsecase{events: events}
+}
+
+// 管理者向けの絞り込み（空・nilは絞り込まない）
+type AdminSecurityEventListInput struct {
+	Page        int
+	Limit       int
+	UserID      *int64
+	Type        string
+	Outcome     string
+	IP          string
+	Email       string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+type SecurityEventOutput struct {
+	ID          int64                      `json:"id"`
+	UserID      *int64                     `json:"user_id"`
+	ActorUserID *int64                     `json:"actor_user_id"`
+	Type        model.SecurityEventType    `json:"type"`
+	Outcome     model.SecurityEventOutcome `json:"outcome"`
+	Reason      string                     `json:"reason"`
+	Email       string                     `json:"email,omitempty"`
+	IP          string                     `json:"ip"`
+	UserAgent   string                     `json:"user_agent"`
+	FamilyID    string                     `json:"family_id,omitempty"`
+	CreatedAt   time.Time                  `json:"created_at"`
+}
+
+type SecurityEventListOutput struct {
+	Items []SecurityEventOutput `json:"items"`
+	Page  int                   `json:"page"`
+	Limit int                   `json:"limit"`
+}
+
+// 管理者向けの一覧（新しい順）
+func (u *SecurityEventUsecase) AdminList(ctx context.Context, in AdminSecurityEventListInput) (SecurityEventListOutput, error) {
+	page, limit, err := normalizeSecurityEventPage(in.Page, in.Limit)
+	if err != nil {
+		return SecurityEventListOutput{}, err
+	}
+
+	f := repo.SecurityEventFilter{
+		UserID:      in.UserID,
+		IP:          in.IP,
+		Email:       in.Email,
+		CreatedFrom: in.CreatedFrom,
+		CreatedTo:   in.CreatedTo,
+		Limit:       limit,
+		Offset:      (page - 1) * limit,
+	}
+	if in.Type != "" {
+		t := model.SecurityEventType(in.Type)
+		if !isKnownSecurityEventType(t) {
+			return SecurityEventListOutput{}, NewHTTPError(http.StatusBadRequest, "invalid type")
+		}
+		f.Type = &t
+	}
+	if in.Outcome != "" {
+		o := model.SecurityEventOutcome(in.Outcome)
+		if o != model.SecurityOutcomeSuccess && o != model.SecurityOutcomeFailure {
+			return SecurityEventListOutput{}, NewHTTPError(http.StatusBadRequest, "invalid outcome")
+		}
+		f.Outcome = &o
+	}
+	if in.CreatedFrom != nil && in.CreatedTo != nil && in.CreatedFrom.After(*in.CreatedTo) {
+		return SecurityEventListOutput{}, NewHTTPError(http.StatusBadRequest, "from must be before to")
+	}
+
+	return u.list(ctx, f, page, limit)
+}
+
+// 本人の一覧（新しい順）
+func (u *SecurityEventUsecase) ListMine(ctx context.Context, userID int64, page int, limit int) (SecurityEventListOutput, error) {
+	if userID <= 0 {
+		return SecurityEventListOutput{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	page, limit, err := normalizeSecurityEventPage(page, limit)
+	if err != nil {
+		return SecurityEventListOutput{}, err
+	}
+
+	return u.list(ctx, repo.SecurityEventFilter{
+		UserID: &userID,
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	}, page, limit)
+}
+
+func (u *SecurityEventUsecase) list(ctx context.Context, f repo.SecurityEventFilter, page int, limit int) (SecurityEventListOutput, error) {
+	events, err := u.events.List(ctx, f)
+	if err != nil {
+		return SecurityEventListOutput{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	items := make([]SecurityEventOutput, 0, len(events))
+	for _, ev := range events {
+		items = append(items, toSecurityEventOutput(ev))
+	}
+	return SecurityEventListOutput{Items: items, Page: page, Limit: limit}, nil
+}
+
+// page/limit の既定値と上限
+func normalizeSecurityEventPage(page int, limit int) (int, int, error) {
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = 50
+	}
+	if page < 0 {
+		return 0, 0, NewHTTPError(http.StatusBadRequest, "invalid page")
+	}
+	if limit < 0 || limit > 200 {
+		return 0, 0, NewHTTPError(http.StatusBadRequest, "invalid limit")
+	}
+	return page, limit, nil
+}
+
+func isKnownSecurityEventType(t model.SecurityEventType) bool {
+	switch t {
+	case model.SecurityEventLogin,
+		model.SecurityEventLoginMfa,
+		model.SecurityEventLoginOidc,
+		model.SecurityEventLoginMagicLink,
+		model.SecurityEventLogout,
+		model.SecurityEventRefreshReuse,
+		model.SecurityEventRefreshUAMismatch,
+		model.SecurityEventForceLogout:
+		return true
+	}
+	return false
+}
+
+func toSecurityEventOutput(ev model.SecurityEvent) SecurityEventOutput {
+	return SecurityEventOutput{
+		ID:          ev.ID,
+		UserID:      ev.UserID,
+		ActorUserID: ev.ActorUserID,
+		Type:        ev.Type,
+		Outcome:     ev.Outcome,
+		Reason:      ev.Reason,
+		Email:       ev.Email,
+		IP:          ev.IP,
+		UserAgent:   ev.UserAgent,
+		FamilyID:    ev.FamilyID,
+		CreatedAt:   ev.CreatedAt,
+	}
+}
+
+// セキュリティイベントを1件残す（保存に失敗しても認証は止めない）
+func (u *AuthUsecase) recordSecurityEvent(ctx context.Context, ev model.SecurityEvent) {
+	if u.events == nil {
+		return
+	}
+
+	ev.Email = truncateRunes(ev.Email, 255)
+	ev.UserAgent = truncateRunes(ev.UserAgent, 255)
+	ev.IP = truncateRunes(ev.IP, 45)
+	ev.CreatedAt = time.Now()
+
+	if err := u.events.Create(ctx, ev); err != nil {
+		log.Printf("security event record failed: type=%s reason=%s err=%v", ev.Type, ev.Reason, err)
+	}
+}
+
+// ログイン失敗（userが特定できなければnil）
+func (u *AuthUsecase) recordLoginFailureEvent(ctx context.Context, eventType model.SecurityEventType, user *model.User, email string, reason string, userAgent string, ip string) {
+	ev := model.SecurityEvent{
+		Type:      eventType,
+		Outcome:   model.SecurityOutcomeFailure,
+		Reason:    reason,
+		Email:     email,
+		IP:        ip,
+		UserAgent: userAgent,
+	}
+	if user != nil {
+		ev.UserID = &user.ID
+	}
+	u.recordSecurityEvent(ctx, ev)
+}
+
+// refreshの系列（端末）で起きたこと。どの端末か分かるように系列IDを残す
+func (u *AuthUsecase) recordRefreshSecurityEvent(ctx context.Context, rt model.RefreshToken, eventType model.SecurityEventType, outcome model.SecurityEventOutcome, reason string, userAgent string, ip string) {
+	u.recordSecurityEvent(ctx, model.SecurityEvent{
+		UserID:    &rt.UserID,
+		Type:      eventType,
+		Outcome:   outcome,
+		Reason:    reason,
+		IP:        ip,
+		UserAgent: userAgent,
+		FamilyID:  refreshFamilyID(rt),
+	})
+}
+
+// 文字数で切り詰める（varcharに入りきらない値を保存エラーにしない）
+func truncateRunes(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max])
+}
@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionUsecase は /me/sessions（ログイン中の端末一覧・失効）の業務ロジックです。
+// 1セッション = refresh tokenの系列（1端末）。IDは系列IDなので、refreshで回転しても変わらない。
+type SessionUsecase struct {
+	rtRepo repo.RefreshTokenRepository
+}
+
+func NewSessionUsecase(rtRepo repo.RefreshTokenRepository) *SessionUsecase {
+	return &SessionUsecase{rtRepo: rtRepo}
+}
+
+type SessionResponse struct {
+	ID        string    `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	IP        *string   `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// このリクエストを送ってきた端末ならtrue
+	Current bool `json:"current"`
+}
+
+type SessionListResponse struct {
+	Items []SessionResponse `json:"items"`
+}
+
+type RevokeOtherSessionsResponse struct {
+	Revoked int64 `json:"revoked"`
+}
+
+// List は自分の有効なセッション一覧（新しい順）。
+// currentRefreshPlain は refresh_token cookie（無ければ空で、current は全部false）。
+func (u *SessionUsecase) List(ctx context.Context, userID int64, currentRefreshPlain string) (SessionListResponse, error) {
+	if userID <= 0 {
+		return SessionListResponse{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	tokens, err := u.rtRepo.ListActiveByUserID(ctx, userID, time.Now())
+	if err != nil {
+		return SessionListResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	currentHash := ""
+	if currentRefreshPlain != "" {
+		currentHash = hashToken(currentRefreshPlain)
+	}
+
+	items := make([]SessionResponse, 0, len(tokens))
+	for _, t := range tokens {
+		items = append(items, SessionResponse{
+			ID:        refreshFamilyID(t),
+			UserAgent: t.UserAgent,
+			IP:        t.IP,
+			CreatedAt: t.CreatedAt,
+			ExpiresAt: t.ExpiresAt,
+			Current:   currentHash != "" && t.TokenHash == currentHash,
+		})
+	}
+
+	return SessionListResponse{Items: items}, nil
+}
+
+// Revoke は自分のセッションを1つ失効させる。現在の端末だった場合は true を返す（cookie削除用）。
+func (u *SessionUsecase) Revoke(ctx context.Context, userID int64, sessionID string, currentRefreshPlain string) (bool, error) {
+	if userID <= 0 {
+		return false, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+	//IDはUUID。形式が違うものはDBに問い合わせず存在しない扱い
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return false, NewHTTPError(http.StatusNotFound, "session not found")
+	}
+
+	tokens, err := u.rtRepo.ListActiveByUserID(ctx, userID, time.Now())
+	if err != nil {
+		return false, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	//自分の有効なセッションから探す（他人のセッションは存在しない扱い）
+	var rt *model.RefreshToken
+	for i := range tokens {
+		if refreshFamilyID(tokens[i]) == sessionID {
+			rt = &tokens[i]
+			break
+		}
+	}
+	if rt == nil {
+		return false, NewHTTPError(http.StatusNotFound, "session not found")
+	}
+
+	//系列ごと消す（使用済みのtokenも残さない）。系列導入前のtokenは1本だけ
+	if rt.FamilyID == "" {
+		if err := u.rtRepo.DeleteByID(ctx, rt.ID); err != nil {
+			return false, NewHTTPError(http.StatusNotFound, "session not found")
+		}
+	} else if _, err := u.rtRepo.DeleteByFamilyID(ctx, rt.FamilyID); err != nil {
+		return false, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	isCurrent := currentRefreshPlain != "" && rt.TokenHash == hashToken(currentRefreshPlain)
+	return isCurrent, nil
+}
+
+// RevokeOthers は現在の端末以外のセッションを全部失効させる。
+// 現在の端末が分からない（cookie無し）場合は全セッションが対象。
+func (u *SessionUsecase) RevokeOthers(ctx context.Context, userID int64, currentRefreshPlain string) (RevokeOtherSessionsResponse, error) {
+	if userID <= 0 {
+		return RevokeOtherSessionsResponse{}, NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	tokens, err := u.rtRepo.ListActiveByUserID(ctx, userID, time.Now())
+	if err != nil {
+		return RevokeOtherSessionsResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	keepFamilyID := ""
+	if currentRefreshPlain != "" {
+		currentHash := hashToken(currentRefreshPlain)
+		for _, t := range tokens {
+			if t.TokenHash == currentHash {
+				keepFamilyID = refreshFamilyID(t)
+				break
+			}
+		}
+	}
+
+	if _, err := u.rtRepo.DeleteByUserIDExceptFamily(ctx, userID, keepFamilyID); err != nil {
+		return RevokeOtherSessionsResponse{}, NewHTTPError(http.StatusInternalServerError, "db error")
+	}
+
+	//件数はtokenの行数ではなくセッション（系列）の数
+	var revoked int64
+	for _, t := range tokens {
+		if refreshFamilyID(t) != keepFamilyID {
+			revoked++
+		}
+	}
+
+	return RevokeOtherSessionsResponse{Revoked: revoked}, nil
+}
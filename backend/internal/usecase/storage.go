@@ -0,0 +1,13 @@
+package usecase
+
+import "context"
+
+// usecaseがファイル保存に依存する約束（ローカルディスク・S3互換ストレージなどを差し替えられる）
+// keyは "products/1/xxxx/original.jpg" のような / 区切りの相対パス
+type FileStorage interface {
+	Put(ctx context.Context, key string, contentType string, data []byte) error
+	//無いkeyを消してもエラーにしない
+	Delete(ctx context.Context, key string) error
+	//公開URL
+	URL(key string) string
+}
@@ -21,13 +21,45 @@ var (
 	ErrInvalidRefresh = errors.New("invalid refresh")
 )
 
+// パスワードのルール違反の理由（レスポンスの code。文言はhandlerで言語ごとに出す）
+const (
+	PasswordTooShort = "password_too_short"
+	PasswordTooLong  = "password_too_long"
+	PasswordBreached = "password_breached"
+)
+
+// パスワードの長さ（上限はbcryptが扱える72バイト）
+const (
+	passwordMinLength = 8
+	passwordMaxBytes  = 72
+)
+
+// パスワードのルール違反（errors.Is(err, ErrInvalidInput) も true）
+type PasswordRuleError struct {
+	Code string
+}
+
+func (e *PasswordRuleError) Error() string {
+	return ErrInvalidInput.Error() + ": " + e.Code
+}
+
+func (e *PasswordRuleError) Unwrap() error {
+	return ErrInvalidInput
+}
+
+// 流出済みパスワードの照合（security.BreachedPasswords）
+type BreachedPasswordChecker interface {
+	Contains(password string) bool
+}
+
 type authValidator struct {
-	users repository.UserRepository
+	users    repository.UserRepository
+	breached BreachedPasswordChecker
 }
 
-// Usecaseは interface を依存注入
-func NewAuthValidator(users repository.UserRepository) usecase.AuthValidator {
-	return &authValidator{users: users}
+// Usecaseは interface を依存注入（breachedがnilなら流出済みパスワードは照合しない）
+func NewAuthValidator(users repository.UserRepository, breached BreachedPasswordChecker) usecase.AuthValidator {
+	return &authValidator{users: users, breached: breached}
 }
 
 // サインアップの入力を検証
@@ -44,9 +76,9 @@ func (v *authValidator) ValidateRegister(ctx context.Context, email string, pass
 		return ErrInvalidInput
 	}
 
-	// パスワード最低文字数（MVP: 8）
-	if len(password) < 8 {
-		return ErrInvalidInput
+	// パスワードのルール
+	if err := v.validatePasswordRule(password); err != nil {
+		return err
 	}
 
 	// email重複チェック（DBが必要）
@@ -97,6 +129,118 @@ func (v *authValidator) ValidateForceLogout(ctx context.Context, targetUserID in
 	return nil
 }
 
+// パスワード再設定メール要求の入力を検証
+func (v *authValidator) ValidateForgotPassword(ctx context.Context, email string) error {
+	email = strings.TrimSpace(email)
+
+	if email == "" || !isEmailLike(email) {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+// パスワード再設定の入力を検証
+func (v *authValidator) ValidateResetPassword(ctx context.Context, token string, newPassword string) error {
+	if strings.TrimSpace(token) == "" || newPassword == "" {
+		return ErrInvalidInput
+	}
+	return v.validatePasswordRule(newPassword)
+}
+
+// メール確認の入力を検証
+func (v *authValidator) ValidateVerifyEmail(ctx context.Context, token string) error {
+	if strings.TrimSpace(token) == "" {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+// 確認メール再送の入力を検証
+func (v *authValidator) ValidateResendVerification(ctx context.Context, email string) error {
+	email = strings.TrimSpace(email)
+
+	if email == "" || !isEmailLike(email) {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+// 2FAログインの入力を検証（TOTPコードかリカバリーコードのどちらか1つ）
+func (v *authValidator) ValidateLoginMfa(ctx context.Context, mfaToken string, code string, recoveryCode string) error {
+	if strings.TrimSpace(mfaToken) == "" {
+		return ErrInvalidInput
+	}
+
+	hasCode := strings.TrimSpace(code) != ""
+	hasRecovery := strings.TrimSpace(recoveryCode) != ""
+	if hasCode == hasRecovery {
+		return ErrInvalidInput
+	}
+	if hasCode && !isTotpCode(code) {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+// 2FA登録確認の入力を検証
+func (v *authValidator) ValidateTotpConfirm(ctx context.Context, code string) error {
+	if !isTotpCode(code) {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+// 2FA無効化の入力を検証
+func (v *authValidator) ValidateTotpDisable(ctx context.Context, password string, code string) error {
+	if password == "" || strings.TrimSpace(code) == "" {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+// パスワード変更の入力を検証（新しいパスワードは登録と同じルール）
+func (v *authValidator) ValidateChangePassword(ctx context.Context, currentPassword string, newPassword string) error {
+	if currentPassword == "" || newPassword == "" {
+		return ErrInvalidInput
+	}
+	return v.validatePasswordRule(newPassword)
+}
+
+// ログインリンク要求の入力を検証
+func (v *authValidator) ValidateMagicLinkRequest(ctx context.Context, email string) error {
+	email = strings.TrimSpace(email)
+
+	if email == "" || !isEmailLike(email) {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+// ログインリンクの入力を検証
+func (v *authValidator) ValidateMagicLinkConsume(ctx context.Context, token string) error {
+	if strings.TrimSpace(token) == "" {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+// パスワードのルール（登録・再設定・変更で共通）
+func (v *authValidator) validatePasswordRule(password string) error {
+	// パスワード最低文字数（MVP: 8）
+	if len(password) < passwordMinLength {
+		return &PasswordRuleError{Code: PasswordTooShort}
+	}
+	if len(password) > passwordMaxBytes {
+		return &PasswordRuleError{Code: PasswordTooLong}
+	}
+
+	// 流出済みのパスワードは使わせない
+	if v.breached != nil && v.breached.Contains(password) {
+		return &PasswordRuleError{Code: PasswordBreached}
+	}
+	return nil
+}
+
 // 簡易メール形式をチェック
 func isEmailLike(s string) bool {
 	re := regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+\.[^\s@]+$`)
@@ -105,3 +249,9 @@ func isEmailLike(s string) bool {
 	re2 := regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
 	return re2.MatchString(s)
 }
+
+// TOTPコード（数字6桁）
+func isTotpCode(s string) bool {
+	re := regexp.MustCompile(`^[0-9]{6}$`)
+	return re.MatchString(strings.TrimSpace(s))
+}
@@ -0,0 +1,93 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type ProductFacetList struct {
+	Total  int64 `json:"total"`
+	Facets *struct {
+		Price []struct {
+			Min   *int64 `json:"min"`
+			Max   *int64 `json:"max"`
+			Count int64  `json:"count"`
+		} `json:"price"`
+		Stock struct {
+			InStock    int64 `json:"in_stock"`
+			OutOfStock int64 `json:"out_of_stock"`
+		} `json:"stock"`
+	} `json:"facets"`
+}
+
+func listProductFacets(t *testing.T, c *TestClient, ctx context.Context, query string) ProductFacetList {
+	t.Helper()
+	resp, body := c.doJSON(ctx, t, http.MethodGet, "/products?page=1&limit=20&facets=true&"+query, "", nil)
+	requireStatus(t, resp, http.StatusOK, body)
+
+	var v ProductFacetList
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatalf("json.Unmarshal(ProductFacetList) failed: %v body=%s", err, string(body))
+	}
+	if v.Facets == nil {
+		t.Fatalf("facets missing: body=%s", string(body))
+	}
+	return v
+}
+
+func Test_Product_Facets_RespectFilters(t *testing.T) {
+	c := NewTestClient(t)
+	ctx := context.Background()
+	access := adminLogin(t, c, ctx)
+
+	tag := "fct" + strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	products := []ProductCreateRequest{
+		{Name: "Cheap " + tag, Description: "x", Price: 500, Stock: 3, IsActive: true},
+		{Name: "Middle " + tag, Description: "x", Price: 2000, Stock: 0, IsActive: true},
+		{Name: "Premium " + tag, Description: "x", Price: 12000, Stock: 1, IsActive: true},
+	}
+	for _, p := range products {
+		b, err := json.Marshal(p)
+		if err != nil {
+			t.Fatalf("json.Marshal(ProductCreateRequest) failed: %v", err)
+		}
+		resp, body := c.doJSON(ctx, t, http.MethodPost, "/admin/products", access, b)
+		requireStatus(t, resp, http.StatusOK, body)
+	}
+
+	//q だけ：3件（~1000 / 1000~3000 / 10000~ に1件ずつ、在庫なしは1件）
+	v := listProductFacets(t, c, ctx, "q="+url.QueryEscape(tag))
+	if v.Total != 3 {
+		t.Fatalf("total want 3 got=%d", v.Total)
+	}
+	want := []int64{1, 1, 0, 0, 1}
+	if len(v.Facets.Price) != len(want) {
+		t.Fatalf("price buckets: %+v", v.Facets.Price)
+	}
+	for i, n := range want {
+		if v.Facets.Price[i].Count != n {
+			t.Fatalf("price bucket %d want %d got=%+v", i, n, v.Facets.Price)
+		}
+	}
+	if v.Facets.Stock.InStock != 2 || v.Facets.Stock.OutOfStock != 1 {
+		t.Fatalf("stock facet: %+v", v.Facets.Stock)
+	}
+
+	//価格帯で絞ると、ファセットも絞った結果だけを数える
+	v = listProductFacets(t, c, ctx, "q="+url.QueryEscape(tag)+"&min_price=1000&max_price=20000")
+	if v.Total != 2 {
+		t.Fatalf("total want 2 got=%d", v.Total)
+	}
+	if v.Facets.Price[0].Count != 0 || v.Facets.Price[1].Count != 1 || v.Facets.Price[4].Count != 1 {
+		t.Fatalf("price buckets with filter: %+v", v.Facets.Price)
+	}
+	if v.Facets.Stock.InStock != 1 || v.Facets.Stock.OutOfStock != 1 {
+		t.Fatalf("stock facet with filter: %+v", v.Facets.Stock)
+	}
+}
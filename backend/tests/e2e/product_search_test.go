@@ -0,0 +1,98 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type SearchProduct struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Highlight *struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	} `json:"highlight"`
+}
+
+type SearchProductList struct {
+	Items []SearchProduct `json:"items"`
+	Total int64           `json:"total"`
+}
+
+func searchProducts(t *testing.T, c *TestClient, ctx context.Context, q string) SearchProductList {
+	t.Helper()
+	resp, body := c.doJSON(ctx, t, http.MethodGet, "/products?page=1&limit=20&sort=relevance&q="+url.QueryEscape(q), "", nil)
+	requireStatus(t, resp, http.StatusOK, body)
+
+	var v SearchProductList
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatalf("json.Unmarshal(SearchProductList) failed: %v body=%s", err, string(body))
+	}
+	return v
+}
+
+func Test_Product_Search_JapaneseAndEnglish(t *testing.T) {
+	c := NewTestClient(t)
+	ctx := context.Background()
+	access := adminLogin(t, c, ctx)
+
+	//ほかのテストの商品と混ざらないよう、全商品に同じ目印を入れて一緒に検索する
+	tag := "srch" + strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	products := []ProductCreateRequest{
+		{Name: "深煎りコーヒー豆 " + tag, Description: "エチオピア産のシングルオリジン", Price: 1800, Stock: 5, IsActive: true},
+		{Name: "マグカップ " + tag, Description: "ｺｰﾋｰにも紅茶にも使える", Price: 1200, Stock: 5, IsActive: true},
+		{Name: "Dark Roast COFFEE " + tag, Description: "Whole beans, 200g", Price: 2000, Stock: 5, IsActive: true},
+	}
+	for _, p := range products {
+		b, err := json.Marshal(p)
+		if err != nil {
+			t.Fatalf("json.Marshal(ProductCreateRequest) failed: %v", err)
+		}
+		resp, body := c.doJSON(ctx, t, http.MethodPost, "/admin/products", access, b)
+		requireStatus(t, resp, http.StatusOK, body)
+	}
+
+	//カタカナ：名前に含む商品が、説明（半角カナ）だけに含む商品より先
+	list := searchProducts(t, c, ctx, "コーヒー "+tag)
+	if list.Total != 2 || len(list.Items) != 2 {
+		t.Fatalf("want 2 hits got=%d body=%+v", list.Total, list.Items)
+	}
+	if !strings.HasPrefix(list.Items[0].Name, "深煎りコーヒー豆") {
+		t.Fatalf("name match should rank first: %+v", list.Items)
+	}
+	if list.Items[0].Highlight == nil || !strings.Contains(list.Items[0].Highlight.Name, "<mark>コーヒー</mark>") {
+		t.Fatalf("name highlight missing: %+v", list.Items[0].Highlight)
+	}
+	if list.Items[1].Highlight == nil || !strings.Contains(list.Items[1].Highlight.Description, "<mark>ｺｰﾋｰ</mark>") {
+		t.Fatalf("description highlight missing: %+v", list.Items[1].Highlight)
+	}
+
+	//ひらがなでも同じ結果
+	if got := searchProducts(t, c, ctx, "こーひー "+tag); got.Total != 2 {
+		t.Fatalf("hiragana query want 2 hits got=%d", got.Total)
+	}
+
+	//英語：大文字小文字を区別せず、説明も対象
+	list = searchProducts(t, c, ctx, "coffee "+tag)
+	if list.Total != 1 || !strings.HasPrefix(list.Items[0].Name, "Dark Roast") {
+		t.Fatalf("english query: %+v", list.Items)
+	}
+	if list.Items[0].Highlight == nil || !strings.Contains(list.Items[0].Highlight.Name, "<mark>COFFEE</mark>") {
+		t.Fatalf("english highlight missing: %+v", list.Items[0].Highlight)
+	}
+	if got := searchProducts(t, c, ctx, "BEANS "+tag); got.Total != 1 {
+		t.Fatalf("description query want 1 hit got=%d", got.Total)
+	}
+
+	//どの語も全部含むものだけ
+	if got := searchProducts(t, c, ctx, "紅茶 coffee "+tag); got.Total != 0 {
+		t.Fatalf("AND query want 0 hits got=%d", got.Total)
+	}
+}
@@ -0,0 +1,367 @@
+package unit
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	infrarepo "app/internal/infra/repository"
+	repo "app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =====================
+// Fake: 住所・注文（使うメソッドだけ）
+// =====================
+
+type fakeAccountAddressRepo struct {
+	repo.AddressRepository
+	addresses  []model.Address
+	anonymized []int64
+}
+
+func (r *fakeAccountAddressRepo) ListByUserID(ctx context.Context, userID int64) ([]model.Address, error) {
+	var out []model.Address
+	for _, a := range r.addresses {
+		if a.UserID == userID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeAccountAddressRepo) AnonymizeByUserID(ctx context.Context, userID int64) error {
+	r.anonymized = append(r.anonymized, userID)
+	return nil
+}
+
+type fakeAccountOrderRepo struct {
+	repo.OrderRepository
+	orders []model.Order
+}
+
+func (r *fakeAccountOrderRepo) ListByUserID(ctx context.Context, userID int64, page int, limit int) ([]model.Order, int64, error) {
+	var mine []model.Order
+	for _, o := range r.orders {
+		if o.UserID == userID {
+			mine = append(mine, o)
+		}
+	}
+	start := (page - 1) * limit
+	if start >= len(mine) {
+		return []model.Order{}, int64(len(mine)), nil
+	}
+	end := min(start+limit, len(mine))
+	return mine[start:end], int64(len(mine)), nil
+}
+
+type fakeAccountOrderItemRepo struct {
+	repo.OrderItemRepository
+	items map[int64][]model.OrderItem
+}
+
+func (r *fakeAccountOrderItemRepo) ListByOrderID(ctx context.Context, orderID int64) ([]model.OrderItem, error) {
+	return r.items[orderID], nil
+}
+
+// =====================
+// Helper
+// =====================
+
+type accountMocks struct {
+	users      *MockUserRepository
+	rt         *MockRefreshTokenRepository
+	recovery   *MockMfaRecoveryCodeRepository
+	identities *MockUserIdentityRepository
+	mailer     *MockMailer
+	addresses  *fakeAccountAddressRepo
+	orders     *fakeAccountOrderRepo
+	items      *fakeAccountOrderItemRepo
+	events     *fakeSecurityEventRepository
+	attempts   repo.LoginAttemptRepository
+}
+
+func newAccountUC() (*usecase.AccountUsecase, accountMocks) {
+	m := accountMocks{
+		users:      new(MockUserRepository),
+		rt:         new(MockRefreshTokenRepository),
+		recovery:   new(MockMfaRecoveryCodeRepository),
+		identities: new(MockUserIdentityRepository),
+		mailer:     new(MockMailer),
+		addresses:  &fakeAccountAddressRepo{},
+		orders:     &fakeAccountOrderRepo{},
+		items:      &fakeAccountOrderItemRepo{items: map[int64][]model.OrderItem{}},
+		events:     new(fakeSecurityEventRepository),
+		attempts:   infrarepo.NewLoginAttemptMemoryRepository(),
+	}
+	cfg := config.Config{JWTSecret: "test-secret", FEURL: "http://localhost:3000", AccountDeletionCoolingOffDays: 14}
+	auth := usecase.NewAuthUsecase(cfg, m.users, m.rt, new(MockAuthValidator), new(MockPasswordResetTokenRepository), new(MockEmailVerificationTokenRepository), m.recovery, m.attempts, m.mailer, m.events)
+	return usecase.NewAccountUsecase(auth, m.users, m.addresses, m.orders, m.items, m.identities), m
+}
+
+// =====================
+// Export
+// =====================
+
+// プロフィール・住所・注文（明細つき、全ページ）・セッションをまとめる。秘密情報は入れない
+func TestAccountUsecase_Export(t *testing.T) {
+	uc, m := newAccountUC()
+	ctx := context.Background()
+
+	user := &model.User{ID: 7, Email: "user@test.com", Role: model.RoleUser, IsActive: true, PasswordHash: "secret-hash", TotpSecretEnc: "enc"}
+	m.users.On("FindByID", mock.Anything, int64(7)).Return(user, nil)
+	m.rt.On("ListActiveByUserID", mock.Anything, int64(7), mock.Anything).Return([]model.RefreshToken{
+		{ID: "rt-2", FamilyID: "rt-1", UserID: 7, UserAgent: "UA", TokenHash: "h"},
+	}, nil)
+
+	m.addresses.addresses = []model.Address{{ID: 1, UserID: 7, Name: "山田"}, {ID: 2, UserID: 8, Name: "他人"}}
+	for i := int64(1); i <= 150; i++ {
+		m.orders.orders = append(m.orders.orders, model.Order{ID: i, UserID: 7, TotalPrice: 100})
+	}
+	m.items.items[1] = []model.OrderItem{{ID: 10, OrderID: 1, ProductNameSnapshot: "Tシャツ", UnitPriceSnapshot: 100, Quantity: 1}}
+
+	ex, err := uc.Export(ctx, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, "user@test.com", ex.Profile.Email)
+	assert.Len(t, ex.Addresses, 1)
+	assert.Len(t, ex.Orders, 150)
+	assert.Equal(t, "Tシャツ", ex.Orders[0].Items[0].ProductNameSnapshot)
+	assert.NotNil(t, ex.Orders[1].Items)
+	if assert.Len(t, ex.Sessions, 1) {
+		//セッションIDは系列ID（/me/sessions と同じ）
+		assert.Equal(t, "rt-1", ex.Sessions[0].ID)
+	}
+
+	b, _ := json.Marshal(ex)
+	assert.NotContains(t, string(b), "secret-hash")
+	assert.NotContains(t, string(b), `"token_hash"`)
+
+	var buf bytes.Buffer
+	assert.NoError(t, usecase.WriteAccountExportZip(&buf, ex))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"profile.json", "addresses.json", "orders.json", "sessions.json"}, names)
+}
+
+// =====================
+// RequestDeletion / CancelDeletion
+// =====================
+
+// 申請：猶予期間後に予約し、全tokenを無効にしてメールで知らせる
+func TestAccountUsecase_RequestDeletion_Success(t *testing.T) {
+	uc, m := newAccountUC()
+	ctx := context.Background()
+
+	user := &model.User{ID: 7, Email: "user@test.com", Role: model.RoleUser, IsActive: true, PasswordHash: mustHash(t, "CorrectPW123!"), TokenVersion: 3}
+	m.users.On("FindByID", mock.Anything, int64(7)).Return(user, nil)
+	m.users.On("IncrementTokenVersion", mock.Anything, int64(7)).Return(nil).Once()
+	m.users.On("Update", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+		return u.DeletionScheduledAt != nil && u.TokenVersion == 4 && u.IsActive
+	})).Return(nil).Once()
+	m.rt.On("DeleteByUserID", mock.Anything, int64(7)).Return(nil).Once()
+	m.mailer.On("Send", mock.Anything, mock.MatchedBy(func(msg usecase.MailMessage) bool {
+		return msg.To == "user@test.com"
+	})).Return(nil).Once()
+
+	before := time.Now()
+	res, err := uc.RequestDeletion(ctx, 7, usecase.DeleteAccountRequest{Password: "CorrectPW123!"}, "UA", "1.2.3.4")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, before.Add(14*24*time.Hour), res.DeletionScheduledAt, time.Minute)
+
+	m.users.AssertExpectations(t)
+	m.rt.AssertExpectations(t)
+	m.mailer.AssertExpectations(t)
+}
+
+func TestAccountUsecase_RequestDeletion_WrongPassword(t *testing.T) {
+	uc, m := newAccountUC()
+
+	user := &model.User{ID: 7, Email: "user@test.com", Role: model.RoleUser, IsActive: true, PasswordHash: mustHash(t, "CorrectPW123!")}
+	m.users.On("FindByID", mock.Anything, int64(7)).Return(user, nil)
+
+	_, err := uc.RequestDeletion(context.Background(), 7, usecase.DeleteAccountRequest{Password: "wrong"}, "UA", "1.2.3.4")
+	assert.ErrorIs(t, err, usecase.ErrUnauthorized)
+
+	_, err = uc.RequestDeletion(context.Background(), 7, usecase.DeleteAccountRequest{}, "UA", "1.2.3.4")
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+
+	m.users.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	m.rt.AssertNotCalled(t, "DeleteByUserID", mock.Anything, mock.Anything)
+}
+
+// 申請済み・スタッフは受け付けない
+func TestAccountUsecase_RequestDeletion_Rejects(t *testing.T) {
+	uc, m := newAccountUC()
+
+	scheduled := time.Now().Add(time.Hour)
+	m.users.On("FindByID", mock.Anything, int64(7)).Return(&model.User{ID: 7, Role: model.RoleUser, IsActive: true, DeletionScheduledAt: &scheduled}, nil)
+	m.users.On("FindByID", mock.Anything, int64(8)).Return(&model.User{ID: 8, Role: model.RoleAdmin, IsActive: true}, nil)
+
+	_, err := uc.RequestDeletion(context.Background(), 7, usecase.DeleteAccountRequest{}, "UA", "")
+	assert.ErrorIs(t, err, usecase.ErrConflict)
+
+	_, err = uc.RequestDeletion(context.Background(), 8, usecase.DeleteAccountRequest{}, "UA", "")
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+}
+
+func TestAccountUsecase_CancelDeletion(t *testing.T) {
+	uc, m := newAccountUC()
+
+	now := time.Now()
+	scheduled := now.Add(time.Hour)
+	m.users.On("FindByID", mock.Anything, int64(7)).Return(&model.User{ID: 7, Role: model.RoleUser, IsActive: true, DeletionRequestedAt: &now, DeletionScheduledAt: &scheduled}, nil).Once()
+	m.users.On("Update", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+		return u.DeletionRequestedAt == nil && u.DeletionScheduledAt == nil
+	})).Return(nil).Once()
+
+	_, err := uc.CancelDeletion(context.Background(), 7, "UA", "")
+	assert.NoError(t, err)
+
+	//申請していなければ409
+	m.users.On("FindByID", mock.Anything, int64(7)).Return(&model.User{ID: 7, Role: model.RoleUser, IsActive: true}, nil).Once()
+	_, err = uc.CancelDeletion(context.Background(), 7, "UA", "")
+	assert.ErrorIs(t, err, usecase.ErrConflict)
+
+	m.users.AssertExpectations(t)
+}
+
+// =====================
+// PurgeDue / Anonymize
+// =====================
+
+// 猶予期間が過ぎたら匿名化：メール・認証情報・住所・IdPの紐付けを消し、全tokenを無効に。注文には触らない
+func TestAccountUsecase_PurgeDue_Anonymizes(t *testing.T) {
+	uc, m := newAccountUC()
+	ctx := context.Background()
+	now := time.Now()
+
+	enabled := now.Add(-time.Hour)
+	due := []model.User{{ID: 7, Email: "user@test.com", Role: model.RoleUser, IsActive: true, PasswordHash: "hash", TotpSecretEnc: "enc", TotpEnabledAt: &enabled, TokenVersion: 1}}
+	m.users.On("ListDeletionDue", mock.Anything, now, mock.Anything).Return(due, nil)
+	m.users.On("IncrementTokenVersion", mock.Anything, int64(7)).Return(nil)
+	m.rt.On("DeleteByUserID", mock.Anything, int64(7)).Return(nil)
+	m.identities.On("DeleteByUserID", mock.Anything, int64(7)).Return(nil)
+	m.recovery.On("DeleteByUserID", mock.Anything, int64(7)).Return(nil)
+
+	var saved *model.User
+	m.users.On("Update", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(1).(*model.User)
+	}).Return(nil)
+
+	n, err := uc.PurgeDue(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	assert.Equal(t, "deleted-7@deleted.invalid", saved.Email)
+	assert.Empty(t, saved.PasswordHash)
+	assert.Empty(t, saved.TotpSecretEnc)
+	assert.Nil(t, saved.TotpEnabledAt)
+	assert.False(t, saved.IsActive)
+	assert.Equal(t, 2, saved.TokenVersion)
+	assert.NotNil(t, saved.AnonymizedAt)
+	assert.Equal(t, []int64{7}, m.addresses.anonymized)
+
+	m.rt.AssertExpectations(t)
+	m.identities.AssertExpectations(t)
+	m.recovery.AssertExpectations(t)
+}
+
+// ログイン履歴のメールアドレス・IP・UAと、メールアドレス単位のログイン失敗回数も消す（他人のものは残す）
+func TestAccountUsecase_Anonymize_ScrubsSecurityEventsAndLoginAttempts(t *testing.T) {
+	uc, m := newAccountUC()
+	ctx := context.Background()
+	now := time.Now()
+
+	mine, other := int64(7), int64(8)
+	_ = m.events.Create(ctx, model.SecurityEvent{UserID: &mine, Type: model.SecurityEventLogin, Email: "user@test.com", IP: "203.0.113.1", UserAgent: "UA"})
+	_ = m.events.Create(ctx, model.SecurityEvent{UserID: &other, Type: model.SecurityEventLogin, Email: "other@test.com", IP: "203.0.113.2", UserAgent: "UA"})
+	_, _ = m.attempts.RecordFailure(ctx, "email:user@test.com", now, time.Hour)
+	_, _ = m.attempts.RecordFailure(ctx, "email:other@test.com", now, time.Hour)
+
+	m.users.On("IncrementTokenVersion", mock.Anything, mine).Return(nil)
+	m.users.On("Update", mock.Anything, mock.Anything).Return(nil)
+	m.rt.On("DeleteByUserID", mock.Anything, mine).Return(nil)
+	m.identities.On("DeleteByUserID", mock.Anything, mine).Return(nil)
+	m.recovery.On("DeleteByUserID", mock.Anything, mine).Return(nil)
+
+	err := uc.Anonymize(ctx, &model.User{ID: mine, Email: "User@Test.com", IsActive: true}, now)
+	assert.NoError(t, err)
+
+	assert.Empty(t, m.events.events[0].Email)
+	assert.Empty(t, m.events.events[0].IP)
+	assert.Empty(t, m.events.events[0].UserAgent)
+	assert.Equal(t, model.SecurityEventLogin, m.events.events[0].Type)
+	assert.Equal(t, "other@test.com", m.events.events[1].Email)
+	assert.Equal(t, "203.0.113.2", m.events.events[1].IP)
+
+	_, found, _ := m.attempts.Get(ctx, "email:user@test.com")
+	assert.False(t, found)
+	_, found, _ = m.attempts.Get(ctx, "email:other@test.com")
+	assert.True(t, found)
+}
+
+// 途中で失敗したら匿名化済みにしない（次の実行でやり直す）
+func TestAccountUsecase_PurgeDue_RetriesOnFailure(t *testing.T) {
+	uc, m := newAccountUC()
+	now := time.Now()
+
+	m.users.On("ListDeletionDue", mock.Anything, now, mock.Anything).Return([]model.User{{ID: 7, Email: "user@test.com", IsActive: true}}, nil)
+	m.users.On("IncrementTokenVersion", mock.Anything, int64(7)).Return(nil)
+	m.rt.On("DeleteByUserID", mock.Anything, int64(7)).Return(assert.AnError)
+
+	n, err := uc.PurgeDue(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	m.users.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// =====================
+// AdminUserUsecase.Delete（猶予期間なしで退会させる）
+// =====================
+
+func TestAdminUserUsecase_Delete(t *testing.T) {
+	accountUC, am := newAccountUC()
+	audit := new(AdminAuditRepoMock)
+	orders := new(AdminOrderRepoMock)
+	uc := usecase.NewAdminUserUsecase(am.users, orders, am.rt, new(MockRolePermissionRepository), audit, accountUC)
+	ctx := context.Background()
+
+	_, err := uc.Delete(ctx, 1, 1)
+	assertErrContains(t, err, "cannot delete yourself")
+
+	user := &model.User{ID: 7, Email: "user@test.com", Role: model.RoleUser, IsActive: true}
+	am.users.On("FindByID", mock.Anything, int64(7)).Return(user, nil)
+	am.users.On("IncrementTokenVersion", mock.Anything, int64(7)).Return(nil)
+	am.users.On("Update", mock.Anything, mock.Anything).Return(nil)
+	am.rt.On("DeleteByUserID", mock.Anything, int64(7)).Return(nil)
+	am.identities.On("DeleteByUserID", mock.Anything, int64(7)).Return(nil)
+	am.recovery.On("DeleteByUserID", mock.Anything, int64(7)).Return(nil)
+	orders.On("CountByUserID", mock.Anything, int64(7)).Return(int64(3), nil)
+	audit.On("Create", mock.Anything, mock.MatchedBy(func(l model.AuditLog) bool {
+		return l.Action == model.AuditActionDeleteUser && l.ResourceID == 7 && l.ActorUserID == 1
+	})).Return(nil).Once()
+
+	out, err := uc.Delete(ctx, 1, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, "deleted-7@deleted.invalid", out.Email)
+	assert.False(t, out.IsActive)
+	assert.NotNil(t, out.AnonymizedAt)
+	assert.Equal(t, int64(3), out.OrderCount)
+	audit.AssertExpectations(t)
+
+	//2回目は409
+	_, err = uc.Delete(ctx, 1, 7)
+	assertErrContains(t, err, "already deleted")
+}
@@ -38,14 +38,16 @@ type AdminTxReposMock struct {
 	carts     repo.CartRepository
 	cartItems repo.CartItemRepository
 	products  repo.ProductRepository
+	variants  repo.ProductVariantRepository
 }
 
-func (r *AdminTxReposMock) Orders() repo.OrderRepository         { return r.orders }
-func (r *AdminTxReposMock) OrderItems() repo.OrderItemRepository { return r.orderItems }
-func (r *AdminTxReposMock) Inventory() repo.InventoryRepository  { return r.inventory }
-func (r *AdminTxReposMock) Carts() repo.CartRepository           { return r.carts }
-func (r *AdminTxReposMock) CartItems() repo.CartItemRepository   { return r.cartItems }
-func (r *AdminTxReposMock) Products() repo.ProductRepository     { return r.products }
+func (r *AdminTxReposMock) Orders() repo.OrderRepository            { return r.orders }
+func (r *AdminTxReposMock) OrderItems() repo.OrderItemRepository    { return r.orderItems }
+func (r *AdminTxReposMock) Inventory() repo.InventoryRepository     { return r.inventory }
+func (r *AdminTxReposMock) Carts() repo.CartRepository              { return r.carts }
+func (r *AdminTxReposMock) CartItems() repo.CartItemRepository      { return r.cartItems }
+func (r *AdminTxReposMock) Products() repo.ProductRepository        { return r.products }
+func (r *AdminTxReposMock) Variants() repo.ProductVariantRepository { return r.variants }
 
 // =====================
 // Repository mocks (Admin向け：衝突回避)
@@ -82,6 +84,11 @@ func (m *AdminOrderRepoMock) ListAdmin(ctx context.Context, f repo.AdminOrderLis
 	return orders, args.Get(1).(int64), args.Error(2)
 }
 
+func (m *AdminOrderRepoMock) CountByUserID(ctx context.Context, userID int64) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 type AdminOrderItemRepoMock struct{ mock.Mock }
 
 func (m *AdminOrderItemRepoMock) CreateBulk(ctx context.Context, orderID int64, items []model.OrderItem) error {
@@ -109,6 +116,19 @@ func (m *AdminInventoryRepoMock) IncreaseStock(ctx context.Context, productID in
 	return args.Error(0)
 }
 
+func (m *AdminInventoryRepoMock) SetVariantStock(ctx context.Context, variantID int64, newStock int64) error {
+	panic("not used in AdminOrderUsecase tests")
+}
+
+func (m *AdminInventoryRepoMock) DecreaseVariantStockIfEnough(ctx context.Context, variantID int64, qty int64) (bool, error) {
+	panic("not used in AdminOrderUsecase tests")
+}
+
+func (m *AdminInventoryRepoMock) IncreaseVariantStock(ctx context.Context, variantID int64, qty int64) error {
+	args := m.Called(ctx, variantID, qty)
+	return args.Error(0)
+}
+
 func (m *AdminInventoryRepoMock) CreateAdjustment(ctx context.Context, adjustment model.InventoryAdjustment) error {
 	panic("not used in AdminOrderUsecase tests")
 }
@@ -396,6 +416,50 @@ func TestAdminOrderUsecase_UpdateStatus_Cancel_RestoresStock_And_Audits(t *testi
 	audit.AssertExpectations(t)
 }
 
+// cancel: バリエーションの明細はバリエーションの在庫に戻す
+func TestAdminOrderUsecase_UpdateStatus_Cancel_RestoresVariantStock(t *testing.T) {
+	ctx := context.Background()
+
+	tx := new(AdminTxManagerMock)
+	audit := new(AdminAuditRepoMock)
+
+	ordersRepo := new(AdminOrderRepoMock)
+	itemsRepo := new(AdminOrderItemRepoMock)
+	invRepo := new(AdminInventoryRepoMock)
+
+	tx.Repos = &AdminTxReposMock{
+		orders:     ordersRepo,
+		orderItems: itemsRepo,
+		inventory:  invRepo,
+	}
+	tx.On("WithinTx", mock.Anything).Return(nil)
+
+	orderID := int64(51)
+	variantID := int64(7)
+
+	ordersRepo.On("FindByID", mock.Anything, orderID).Return(model.Order{
+		ID:     orderID,
+		Status: model.OrderStatusPending,
+	}, nil)
+	itemsRepo.On("ListByOrderID", mock.Anything, orderID).Return([]model.OrderItem{
+		{OrderID: orderID, ProductID: 100, VariantID: &variantID, Quantity: 3},
+		{OrderID: orderID, ProductID: 101, Quantity: 1},
+	}, nil)
+
+	invRepo.On("IncreaseVariantStock", mock.Anything, variantID, int64(3)).Return(nil)
+	invRepo.On("IncreaseStock", mock.Anything, int64(101), int64(1)).Return(nil)
+	ordersRepo.On("UpdateStatus", mock.Anything, orderID, model.OrderStatus("CANCELED")).Return(nil)
+	audit.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	uc := usecase.NewAdminOrderUsecase(tx, audit)
+
+	err := uc.UpdateStatus(ctx, 999, orderID, usecase.AdminUpdateOrderStatusInput{Status: "CANCELED"})
+	assert.NoError(t, err)
+
+	invRepo.AssertExpectations(t)
+	invRepo.AssertNotCalled(t, "IncreaseStock", mock.Anything, int64(100), mock.Anything)
+}
+
 // shipped: PENDING -> SHIPPED は在庫戻しなし + audit
 func TestAdminOrderUsecase_UpdateStatus_Shipped_Audits_NoInventory(t *testing.T) {
 	ctx := context.Background()
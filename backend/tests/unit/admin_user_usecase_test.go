@@ -0,0 +1,230 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type adminUserMocks struct {
+	users  *MockUserRepository
+	orders *AdminOrderRepoMock
+	rt     *MockRefreshTokenRepository
+	roles  *MockRolePermissionRepository
+	audit  *AdminAuditRepoMock
+}
+
+func newAdminUserUC() (*usecase.AdminUserUsecase, adminUserMocks) {
+	m := adminUserMocks{
+		users:  new(MockUserRepository),
+		orders: new(AdminOrderRepoMock),
+		rt:     new(MockRefreshTokenRepository),
+		roles:  new(MockRolePermissionRepository),
+		audit:  new(AdminAuditRepoMock),
+	}
+	return usecase.NewAdminUserUsecase(m.users, m.orders, m.rt, m.roles, m.audit, nil), m
+}
+
+func boolPtr(b bool) *bool             { return &b }
+func strPtr(s string) *string          { return &s }
+func rolePtr(r model.Role) *model.Role { return &r }
+
+// =====================
+// List
+// =====================
+
+func TestAdminUserUsecase_List_InvalidLimit(t *testing.T) {
+	uc, m := newAdminUserUC()
+
+	_, err := uc.List(context.Background(), repo.AdminUserListFilter{Page: 1, Limit: 101})
+	assertErrContains(t, err, "invalid limit")
+	m.users.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
+
+func TestAdminUserUsecase_List_InvalidRole(t *testing.T) {
+	uc, m := newAdminUserUC()
+	m.roles.On("ListByRole", mock.Anything, model.Role("OWNER")).Return([]string{}, nil)
+
+	_, err := uc.List(context.Background(), repo.AdminUserListFilter{Page: 1, Limit: 50, Role: rolePtr("OWNER")})
+	assertErrContains(t, err, "invalid role")
+}
+
+// 出力にパスワードハッシュ等は含めない・フィルタはそのまま渡す
+func TestAdminUserUsecase_List_Success(t *testing.T) {
+	uc, m := newAdminUserUC()
+
+	now := time.Now()
+	f := repo.AdminUserListFilter{Page: 2, Limit: 10, Email: "example.com", Role: rolePtr(model.RoleUser), IsActive: boolPtr(true)}
+	m.users.On("List", mock.Anything, f).Return([]model.User{
+		{ID: 11, Email: "a@example.com", PasswordHash: "hash", Role: model.RoleUser, IsActive: true, LastLoginAt: &now, TotpEnabledAt: &now},
+	}, int64(11), nil)
+
+	out, err := uc.List(context.Background(), repo.AdminUserListFilter{Page: 2, Limit: 10, Email: "  example.com ", Role: rolePtr(model.RoleUser), IsActive: boolPtr(true)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), out.Total)
+	if assert.Len(t, out.Items, 1) {
+		assert.Equal(t, "a@example.com", out.Items[0].Email)
+		assert.True(t, out.Items[0].MfaEnabled)
+		assert.Equal(t, &now, out.Items[0].LastLoginAt)
+	}
+	m.users.AssertExpectations(t)
+}
+
+// =====================
+// Get
+// =====================
+
+func TestAdminUserUsecase_Get_NotFound(t *testing.T) {
+	uc, m := newAdminUserUC()
+	m.users.On("FindByID", mock.Anything, int64(5)).Return(nil, nil)
+
+	_, err := uc.Get(context.Background(), 5)
+	assertErrContains(t, err, "not found")
+}
+
+func TestAdminUserUsecase_Get_IncludesOrderCount(t *testing.T) {
+	uc, m := newAdminUserUC()
+	m.users.On("FindByID", mock.Anything, int64(5)).Return(&model.User{ID: 5, Email: "u@test.com", Role: model.RoleUser, IsActive: true}, nil)
+	m.orders.On("CountByUserID", mock.Anything, int64(5)).Return(int64(3), nil)
+
+	out, err := uc.Get(context.Background(), 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), out.ID)
+	assert.Equal(t, int64(3), out.OrderCount)
+}
+
+// =====================
+// Update
+// =====================
+
+// 無効化 => 保存 + tv++ + refresh全削除 + 監査ログ
+func TestAdminUserUsecase_Update_Deactivate_RevokesSessions(t *testing.T) {
+	uc, m := newAdminUserUC()
+	user := &model.User{ID: 5, Email: "u@test.com", Role: model.RoleUser, IsActive: true}
+
+	m.users.On("FindByID", mock.Anything, int64(5)).Return(user, nil)
+	m.users.On("Update", mock.Anything, user).Return(nil)
+	m.users.On("IncrementTokenVersion", mock.Anything, int64(5)).Return(nil)
+	m.rt.On("DeleteByUserID", mock.Anything, int64(5)).Return(nil)
+	m.audit.On("Create", mock.Anything, mock.MatchedBy(func(l model.AuditLog) bool {
+		return l.ActorUserID == 1 &&
+			l.Action == model.AuditActionUpdateUserStatus &&
+			l.ResourceType == model.AuditResourceUser &&
+			l.ResourceID == 5 &&
+			l.BeforeJSON == `{"is_active":true}` &&
+			l.AfterJSON == `{"is_active":false}`
+	})).Return(nil).Once()
+	m.orders.On("CountByUserID", mock.Anything, int64(5)).Return(int64(0), nil)
+
+	out, err := uc.Update(context.Background(), 1, 5, usecase.AdminUpdateUserInput{IsActive: boolPtr(false)})
+	assert.NoError(t, err)
+	assert.False(t, out.IsActive)
+
+	m.users.AssertExpectations(t)
+	m.rt.AssertExpectations(t)
+	m.audit.AssertExpectations(t)
+}
+
+// ロール変更 => tv++（古いroleのaccess tokenを無効）、refreshは残す
+func TestAdminUserUsecase_Update_ChangeRole(t *testing.T) {
+	uc, m := newAdminUserUC()
+	user := &model.User{ID: 5, Email: "u@test.com", Role: model.RoleUser, IsActive: true}
+
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(&model.User{ID: 1, Role: model.RoleAdmin, IsActive: true}, nil)
+	m.users.On("FindByID", mock.Anything, int64(5)).Return(user, nil)
+	m.users.On("Update", mock.Anything, user).Return(nil)
+	m.users.On("IncrementTokenVersion", mock.Anything, int64(5)).Return(nil)
+	m.audit.On("Create", mock.Anything, mock.MatchedBy(func(l model.AuditLog) bool {
+		return l.Action == model.AuditActionUpdateUserRole &&
+			l.BeforeJSON == `{"role":"USER"}` &&
+			l.AfterJSON == `{"role":"ADMIN"}`
+	})).Return(nil).Once()
+	m.orders.On("CountByUserID", mock.Anything, int64(5)).Return(int64(0), nil)
+
+	out, err := uc.Update(context.Background(), 1, 5, usecase.AdminUpdateUserInput{Role: strPtr("admin")})
+	assert.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, out.Role)
+
+	m.rt.AssertNotCalled(t, "DeleteByUserID", mock.Anything, mock.Anything)
+	m.audit.AssertExpectations(t)
+}
+
+// 変化なし => 保存も監査ログもしない
+func TestAdminUserUsecase_Update_NoChange_NoOp(t *testing.T) {
+	uc, m := newAdminUserUC()
+	user := &model.User{ID: 5, Email: "u@test.com", Role: model.RoleUser, IsActive: true}
+
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(&model.User{ID: 1, Role: model.RoleAdmin, IsActive: true}, nil)
+	m.users.On("FindByID", mock.Anything, int64(5)).Return(user, nil)
+	m.orders.On("CountByUserID", mock.Anything, int64(5)).Return(int64(0), nil)
+
+	_, err := uc.Update(context.Background(), 1, 5, usecase.AdminUpdateUserInput{IsActive: boolPtr(true), Role: strPtr("USER")})
+	assert.NoError(t, err)
+
+	m.users.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	m.users.AssertNotCalled(t, "IncrementTokenVersion", mock.Anything, mock.Anything)
+	m.audit.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// 自分自身の無効化・降格はできない
+func TestAdminUserUsecase_Update_CannotDemoteSelf(t *testing.T) {
+	uc, m := newAdminUserUC()
+
+	_, err := uc.Update(context.Background(), 1, 1, usecase.AdminUpdateUserInput{Role: strPtr("USER")})
+	assertErrContains(t, err, "yourself")
+
+	_, err = uc.Update(context.Background(), 1, 1, usecase.AdminUpdateUserInput{IsActive: boolPtr(false)})
+	assertErrContains(t, err, "yourself")
+
+	m.users.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+}
+
+// スタッフのロールに変更できる（DBにあるロール）
+func TestAdminUserUsecase_Update_ChangeRole_ToStaffRole(t *testing.T) {
+	uc, m := newAdminUserUC()
+	user := &model.User{ID: 5, Email: "u@test.com", Role: model.RoleUser, IsActive: true}
+
+	m.roles.On("ListByRole", mock.Anything, model.RoleInventoryManager).Return([]string{model.PermInventoryWrite}, nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(&model.User{ID: 1, Role: model.RoleAdmin, IsActive: true}, nil)
+	m.users.On("FindByID", mock.Anything, int64(5)).Return(user, nil)
+	m.users.On("Update", mock.Anything, user).Return(nil)
+	m.users.On("IncrementTokenVersion", mock.Anything, int64(5)).Return(nil)
+	m.audit.On("Create", mock.Anything, mock.Anything).Return(nil)
+	m.orders.On("CountByUserID", mock.Anything, int64(5)).Return(int64(0), nil)
+
+	out, err := uc.Update(context.Background(), 1, 5, usecase.AdminUpdateUserInput{Role: strPtr("inventory_manager")})
+	assert.NoError(t, err)
+	assert.Equal(t, model.RoleInventoryManager, out.Role)
+}
+
+// users.write を持つスタッフでも、ロール変更とUSER以外の変更はできない
+func TestAdminUserUsecase_Update_StaffActor_Restricted(t *testing.T) {
+	uc, m := newAdminUserUC()
+
+	m.users.On("FindByID", mock.Anything, int64(2)).Return(&model.User{ID: 2, Role: model.RoleSupportAgent, IsActive: true}, nil)
+	m.users.On("FindByID", mock.Anything, int64(5)).Return(&model.User{ID: 5, Role: model.RoleUser, IsActive: true}, nil)
+	m.users.On("FindByID", mock.Anything, int64(6)).Return(&model.User{ID: 6, Role: model.RoleAdmin, IsActive: true}, nil)
+
+	_, err := uc.Update(context.Background(), 2, 5, usecase.AdminUpdateUserInput{Role: strPtr("ADMIN")})
+	assertErrContains(t, err, "admin only")
+
+	_, err = uc.Update(context.Background(), 2, 6, usecase.AdminUpdateUserInput{IsActive: boolPtr(false)})
+	assertErrContains(t, err, "admin only")
+
+	m.users.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestAdminUserUsecase_Update_InvalidRole(t *testing.T) {
+	uc, m := newAdminUserUC()
+	m.roles.On("ListByRole", mock.Anything, model.Role("OWNER")).Return([]string{}, nil)
+
+	_, err := uc.Update(context.Background(), 1, 5, usecase.AdminUpdateUserInput{Role: strPtr("OWNER")})
+	assertErrContains(t, err, "invalid role")
+}
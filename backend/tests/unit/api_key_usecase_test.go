@@ -0,0 +1,292 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/middleware"
+	"app/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =====================
+// Mock: ApiKeyRepository
+// =====================
+
+type MockApiKeyRepository struct{ mock.Mock }
+
+func (m *MockApiKeyRepository) Create(ctx context.Context, key *model.ApiKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockApiKeyRepository) FindByHash(ctx context.Context, keyHash string) (model.ApiKey, bool, error) {
+	args := m.Called(ctx, keyHash)
+	return args.Get(0).(model.ApiKey), args.Bool(1), args.Error(2)
+}
+
+func (m *MockApiKeyRepository) FindByID(ctx context.Context, id int64) (model.ApiKey, bool, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.ApiKey), args.Bool(1), args.Error(2)
+}
+
+func (m *MockApiKeyRepository) List(ctx context.Context) ([]model.ApiKey, error) {
+	args := m.Called(ctx)
+	list, _ := args.Get(0).([]model.ApiKey)
+	return list, args.Error(1)
+}
+
+func (m *MockApiKeyRepository) Revoke(ctx context.Context, id int64, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockApiKeyRepository) TouchLastUsed(ctx context.Context, id int64, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+type apiKeyMocks struct {
+	keys  *MockApiKeyRepository
+	users *MockUserRepository
+	audit *AdminAuditRepoMock
+}
+
+func newApiKeyUC() (*usecase.ApiKeyUsecase, apiKeyMocks) {
+	m := apiKeyMocks{
+		keys:  new(MockApiKeyRepository),
+		users: new(MockUserRepository),
+		audit: new(AdminAuditRepoMock),
+	}
+	return usecase.NewApiKeyUsecase(m.keys, m.users, m.audit), m
+}
+
+// =====================
+// Create
+// =====================
+
+// キー本体は1回だけ返し、DBにはハッシュだけ・監査ログに残す
+func TestApiKeyUsecase_Create_Success(t *testing.T) {
+	uc, m := newApiKeyUC()
+
+	var saved *model.ApiKey
+	m.keys.On("Create", mock.Anything, mock.AnythingOfType("*model.ApiKey")).Run(func(args mock.Arguments) {
+		saved = args.Get(1).(*model.ApiKey)
+		saved.ID = 7
+	}).Return(nil)
+	m.audit.On("Create", mock.Anything, mock.MatchedBy(func(l model.AuditLog) bool {
+		return l.ActorUserID == 1 &&
+			l.Action == model.AuditActionCreateApiKey &&
+			l.ResourceType == model.AuditResourceApiKey &&
+			l.ResourceID == 7 &&
+			!strings.Contains(l.AfterJSON, `"key"`)
+	})).Return(nil)
+
+	out, err := uc.Create(context.Background(), 1, usecase.CreateApiKeyInput{
+		Name:   " warehouse ",
+		Scopes: []string{"inventory:write", "orders:read", "inventory:write"},
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(out.Key, out.Prefix+"_"))
+	assert.True(t, strings.HasPrefix(out.Prefix, "ak_"))
+	assert.Equal(t, "warehouse", out.Name)
+	assert.Equal(t, []string{"inventory:write", "orders:read"}, out.Scopes)
+
+	if assert.NotNil(t, saved) {
+		assert.Equal(t, sessionHash(out.Key), saved.KeyHash)
+		assert.Equal(t, int64(1), saved.CreatedByUserID)
+	}
+	m.audit.AssertExpectations(t)
+}
+
+func TestApiKeyUsecase_Create_InvalidScope(t *testing.T) {
+	uc, m := newApiKeyUC()
+
+	_, err := uc.Create(context.Background(), 1, usecase.CreateApiKeyInput{Name: "x", Scopes: []string{"users:write"}})
+	assertErrContains(t, err, "invalid scope")
+	m.keys.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestApiKeyUsecase_Create_ExpiresInPast(t *testing.T) {
+	uc, _ := newApiKeyUC()
+
+	past := time.Now().Add(-time.Hour)
+	_, err := uc.Create(context.Background(), 1, usecase.CreateApiKeyInput{Name: "x", Scopes: []string{"orders:read"}, ExpiresAt: &past})
+	assertErrContains(t, err, "expires_at")
+}
+
+// =====================
+// Authenticate
+// =====================
+
+func TestApiKeyUsecase_Authenticate_Success_TouchesLastUsed(t *testing.T) {
+	uc, m := newApiKeyUC()
+
+	plain := "ak_0011aabb_secret"
+	m.keys.On("FindByHash", mock.Anything, sessionHash(plain)).Return(model.ApiKey{ID: 7, CreatedByUserID: 1, Scopes: "orders:read"}, true, nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(&model.User{ID: 1, Role: model.RoleAdmin, IsActive: true}, nil)
+	m.keys.On("TouchLastUsed", mock.Anything, int64(7), mock.AnythingOfType("time.Time")).Return(nil)
+
+	key, err := uc.Authenticate(context.Background(), plain)
+	assert.NoError(t, err)
+	if assert.NotNil(t, key) {
+		assert.Equal(t, int64(7), key.ID)
+		assert.NotNil(t, key.LastUsedAt)
+	}
+	m.keys.AssertExpectations(t)
+}
+
+// 直近に使ったばかりなら last_used_at は書かない
+func TestApiKeyUsecase_Authenticate_RecentlyUsed_NoTouch(t *testing.T) {
+	uc, m := newApiKeyUC()
+
+	recent := time.Now().Add(-10 * time.Second)
+	m.keys.On("FindByHash", mock.Anything, mock.Anything).Return(model.ApiKey{ID: 7, CreatedByUserID: 1, LastUsedAt: &recent}, true, nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(&model.User{ID: 1, Role: model.RoleAdmin, IsActive: true}, nil)
+
+	_, err := uc.Authenticate(context.Background(), "ak_0011aabb_secret")
+	assert.NoError(t, err)
+	m.keys.AssertNotCalled(t, "TouchLastUsed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestApiKeyUsecase_Authenticate_Rejects(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	admin := &model.User{ID: 1, Role: model.RoleAdmin, IsActive: true}
+
+	cases := []struct {
+		name  string
+		key   model.ApiKey
+		owner *model.User
+	}{
+		{"revoked", model.ApiKey{ID: 7, CreatedByUserID: 1, RevokedAt: &past}, admin},
+		{"expired", model.ApiKey{ID: 7, CreatedByUserID: 1, ExpiresAt: &past}, admin},
+		{"owner demoted", model.ApiKey{ID: 7, CreatedByUserID: 1}, &model.User{ID: 1, Role: model.RoleUser, IsActive: true}},
+		{"owner deactivated", model.ApiKey{ID: 7, CreatedByUserID: 1}, &model.User{ID: 1, Role: model.RoleAdmin, IsActive: false}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, m := newApiKeyUC()
+			m.keys.On("FindByHash", mock.Anything, mock.Anything).Return(tc.key, true, nil)
+			m.users.On("FindByID", mock.Anything, int64(1)).Return(tc.owner, nil)
+
+			_, err := uc.Authenticate(context.Background(), "ak_0011aabb_secret")
+			assert.ErrorIs(t, err, usecase.ErrApiKeyInvalid)
+		})
+	}
+}
+
+func TestApiKeyUsecase_Authenticate_UnknownKey(t *testing.T) {
+	uc, m := newApiKeyUC()
+	m.keys.On("FindByHash", mock.Anything, mock.Anything).Return(model.ApiKey{}, false, nil)
+
+	_, err := uc.Authenticate(context.Background(), "ak_0011aabb_secret")
+	assert.ErrorIs(t, err, usecase.ErrApiKeyInvalid)
+
+	//形式違いはDBを見ない
+	_, err = uc.Authenticate(context.Background(), "not-an-api-key")
+	assert.ErrorIs(t, err, usecase.ErrApiKeyInvalid)
+	m.keys.AssertNumberOfCalls(t, "FindByHash", 1)
+}
+
+// =====================
+// 監査ログ（キー経由の操作はキーIDが残る）
+// =====================
+
+func TestProductUsecase_AdminUpdateInventory_ViaApiKey_RecordsKey(t *testing.T) {
+	pRepo := new(ProdProductRepoMock)
+	iRepo := new(ProdInventoryRepoMock)
+	aRepo := new(ProdAuditRepoMock)
+	uc := usecase.NewProductUsecase(pRepo, iRepo, aRepo, nil, nil, nil, nil)
+
+	pRepo.On("FindByID", mock.Anything, int64(10)).Return(model.Product{ID: 10, Stock: 5, IsActive: true}, nil)
+	iRepo.On("SetStock", mock.Anything, int64(10), int64(12)).Return(nil)
+	iRepo.On("CreateAdjustment", mock.Anything, mock.Anything).Return(nil)
+	aRepo.On("Create", mock.Anything, mock.MatchedBy(func(l model.AuditLog) bool {
+		return l.ActorUserID == 1 && l.ActorApiKeyID != nil && *l.ActorApiKeyID == 7
+	})).Return(nil)
+
+	ctx := usecase.WithApiKeyActor(context.Background(), 7)
+	err := uc.AdminUpdateInventory(ctx, 1, 10, 12, "sync from warehouse")
+	assert.NoError(t, err)
+	aRepo.AssertExpectations(t)
+}
+
+// =====================
+// middleware.AdminAuth / RequireScope
+// =====================
+
+type fakeApiKeyAuthenticator struct {
+	key *model.ApiKey
+}
+
+func (f fakeApiKeyAuthenticator) Authenticate(ctx context.Context, plain string) (*model.ApiKey, error) {
+	if f.key == nil || plain != "ak_valid" {
+		return nil, usecase.ErrApiKeyInvalid
+	}
+	return f.key, nil
+}
+
+func newApiKeyEcho(auth middleware.ApiKeyAuthenticator) *echo.Echo {
+	e := echo.New()
+	cfg := config.Config{JWTSecret: "test-secret"}
+	admin := e.Group("/admin", middleware.AdminAuth(cfg, new(MockUserRepoForMiddleware), auth))
+
+	ok := func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"user_id": c.Get(middleware.CtxUserIDKey),
+		})
+	}
+	admin.GET("/orders", ok, middleware.RequireScope(model.ApiKeyScopeOrdersRead))
+	admin.PUT("/inventory/1", ok, middleware.RequireScope(model.ApiKeyScopeInventoryWrite))
+	return e
+}
+
+func runApiKeyRequest(e *echo.Echo, method string, path string, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if key != "" {
+		req.Header.Set(middleware.HeaderApiKey, key)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddleware_AdminAuth_ApiKey_Scopes(t *testing.T) {
+	e := newApiKeyEcho(fakeApiKeyAuthenticator{key: &model.ApiKey{ID: 7, CreatedByUserID: 1, Scopes: "orders:read"}})
+
+	//scopeあり => 通る（user_idは発行した管理者）
+	rec := runApiKeyRequest(e, http.MethodGet, "/admin/orders", "ak_valid")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"user_id":1`)
+
+	//scopeなし => 403
+	rec = runApiKeyRequest(e, http.MethodPut, "/admin/inventory/1", "ak_valid")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "insufficient scope", decodeMWError(t, rec).Error)
+}
+
+func TestMiddleware_AdminAuth_InvalidApiKey_401(t *testing.T) {
+	e := newApiKeyEcho(fakeApiKeyAuthenticator{})
+
+	rec := runApiKeyRequest(e, http.MethodGet, "/admin/orders", "ak_wrong")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+// X-API-Keyが無ければ今まで通りJWTが必要
+func TestMiddleware_AdminAuth_NoApiKey_FallsBackToJWT(t *testing.T) {
+	e := newApiKeyEcho(fakeApiKeyAuthenticator{key: &model.ApiKey{ID: 7, CreatedByUserID: 1, Scopes: "orders:read"}})
+
+	rec := runApiKeyRequest(e, http.MethodGet, "/admin/orders", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -3,6 +3,8 @@ package unit
 import (
 	"app/internal/config"
 	"app/internal/domain/model"
+	infrarepo "app/internal/infra/repository"
+	repo "app/internal/repository"
 	"app/internal/usecase"
 	"context"
 	"testing"
@@ -48,6 +50,24 @@ func (m *MockUserRepository) IncrementTokenVersion(ctx context.Context, id int64
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) FindTokenState(ctx context.Context, id int64) (*model.TokenState, error) {
+	args := m.Called(ctx, id)
+	st, _ := args.Get(0).(*model.TokenState)
+	return st, args.Error(1)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, f repo.AdminUserListFilter) ([]model.User, int64, error) {
+	args := m.Called(ctx, f)
+	users, _ := args.Get(0).([]model.User)
+	return users, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) ListDeletionDue(ctx context.Context, now time.Time, limit int) ([]model.User, error) {
+	args := m.Called(ctx, now, limit)
+	users, _ := args.Get(0).([]model.User)
+	return users, args.Error(1)
+}
+
 // =====================
 // Mock: AuthValidator
 // =====================
@@ -81,6 +101,56 @@ func (m *MockAuthValidator) ValidateForceLogout(ctx context.Context, targetUserI
 	return args.Error(0)
 }
 
+func (m *MockAuthValidator) ValidateForgotPassword(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockAuthValidator) ValidateResetPassword(ctx context.Context, token string, newPassword string) error {
+	args := m.Called(ctx, token, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockAuthValidator) ValidateVerifyEmail(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockAuthValidator) ValidateResendVerification(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockAuthValidator) ValidateLoginMfa(ctx context.Context, mfaToken string, code string, recoveryCode string) error {
+	args := m.Called(ctx, mfaToken, code, recoveryCode)
+	return args.Error(0)
+}
+
+func (m *MockAuthValidator) ValidateTotpConfirm(ctx context.Context, code string) error {
+	args := m.Called(ctx, code)
+	return args.Error(0)
+}
+
+func (m *MockAuthValidator) ValidateTotpDisable(ctx context.Context, password string, code string) error {
+	args := m.Called(ctx, password, code)
+	return args.Error(0)
+}
+
+func (m *MockAuthValidator) ValidateChangePassword(ctx context.Context, currentPassword string, newPassword string) error {
+	args := m.Called(ctx, currentPassword, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockAuthValidator) ValidateMagicLinkRequest(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockAuthValidator) ValidateMagicLinkConsume(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
 // =====================
 // Mock: RefreshTokenRepository
 // =====================
@@ -110,6 +180,11 @@ func (m *MockRefreshTokenRepository) DeleteByUserID(ctx context.Context, userID
 	return args.Error(0)
 }
 
+func (m *MockRefreshTokenRepository) DeleteByFamilyID(ctx context.Context, familyID string) (int64, error) {
+	args := m.Called(ctx, familyID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockRefreshTokenRepository) DeleteByID(ctx context.Context, tokenID string) error {
 	args := m.Called(ctx, tokenID)
 	return args.Error(0)
@@ -121,6 +196,23 @@ func (m *MockRefreshTokenRepository) DeleteExpired(ctx context.Context, now time
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *MockRefreshTokenRepository) FindByID(ctx context.Context, tokenID string) (model.RefreshToken, bool, error) {
+	args := m.Called(ctx, tokenID)
+	rt, _ := args.Get(0).(model.RefreshToken)
+	return rt, args.Bool(1), args.Error(2)
+}
+
+func (m *MockRefreshTokenRepository) ListActiveByUserID(ctx context.Context, userID int64, now time.Time) ([]model.RefreshToken, error) {
+	args := m.Called(ctx, userID, now)
+	list, _ := args.Get(0).([]model.RefreshToken)
+	return list, args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) DeleteByUserIDExceptFamily(ctx context.Context, userID int64, keepFamilyID string) (int64, error) {
+	args := m.Called(ctx, userID, keepFamilyID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 // =====================
 // Helper
 // =====================
@@ -137,7 +229,14 @@ func mustHash(t *testing.T, plain string) string {
 func newAuthUC(userRepo *MockUserRepository, rtRepo *MockRefreshTokenRepository, v *MockAuthValidator) *usecase.AuthUsecase {
 	// JWTSecret は Login/Refresh で必須
 	cfg := config.Config{JWTSecret: "test-secret"}
-	return usecase.NewAuthUsecase(cfg, userRepo, rtRepo, v)
+	// 登録時の確認メールは結果に影響しないので、呼ばれても呼ばれなくてもOK
+	verifyRepo := new(MockEmailVerificationTokenRepository)
+	verifyRepo.On("DeleteByUserID", mock.Anything, mock.Anything).Return(nil).Maybe()
+	verifyRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mailer := new(MockMailer)
+	mailer.On("Send", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	return usecase.NewAuthUsecase(cfg, userRepo, rtRepo, v, new(MockPasswordResetTokenRepository), verifyRepo, new(MockMfaRecoveryCodeRepository), infrarepo.NewLoginAttemptMemoryRepository(), mailer, new(fakeSecurityEventRepository))
 }
 
 // =====================
@@ -414,7 +513,7 @@ func TestAuthUsecase_Refresh_Expired_R2(t *testing.T) {
 	v.AssertExpectations(t)
 }
 
-// 方針② R4: 再利用（used_atあり）=> DeleteByUserID + incident
+// 方針② R4: 再利用（used_atあり・系列導入前のtoken）=> DeleteByUserID + incident
 func TestAuthUsecase_Refresh_Replay_R4(t *testing.T) {
 	ctx := context.Background()
 
@@ -485,6 +584,116 @@ func TestAuthUsecase_Refresh_UserAgentMismatch_R5(t *testing.T) {
 	v.AssertExpectations(t)
 }
 
+// 正常な回転では系列IDを引き継ぐ
+func TestAuthUsecase_Refresh_CarriesFamilyID(t *testing.T) {
+	ctx := context.Background()
+
+	userRepo := new(MockUserRepository)
+	rtRepo := new(MockRefreshTokenRepository)
+	v := new(MockAuthValidator)
+
+	rtRepo.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	v.On("ValidateRefresh", mock.Anything, "refresh-plain", "UA").Return(nil)
+	rtRepo.On("FindByHash", mock.Anything, mock.AnythingOfType("string")).Return(model.RefreshToken{
+		ID:        "rt-2",
+		UserID:    1,
+		FamilyID:  "fam-1",
+		UserAgent: "UA",
+		ExpiresAt: time.Now().Add(10 * time.Minute),
+	}, true, nil)
+	userRepo.On("FindByID", mock.Anything, int64(1)).Return(&model.User{ID: 1, Role: model.RoleUser, IsActive: true}, nil)
+	rtRepo.On("MarkUsed", mock.Anything, "rt-2").Return(nil)
+	rtRepo.On("Create", mock.Anything, mock.MatchedBy(func(rt model.RefreshToken) bool {
+		return rt.FamilyID == "fam-1" && rt.ID != "rt-2"
+	})).Return(nil)
+
+	u := newAuthUC(userRepo, rtRepo, v)
+
+	_, err := u.Refresh(ctx, "refresh-plain", "UA", "")
+	assert.NoError(t, err)
+	rtRepo.AssertExpectations(t)
+}
+
+// 系列のある再利用 => その系列だけ失効（他の端末は残す）+ incident
+func TestAuthUsecase_Refresh_Replay_RevokesFamilyOnly(t *testing.T) {
+	ctx := context.Background()
+
+	userRepo := new(MockUserRepository)
+	rtRepo := new(MockRefreshTokenRepository)
+	v := new(MockAuthValidator)
+
+	usedAt := time.Now().Add(-1 * time.Minute)
+
+	rtRepo.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	v.On("ValidateRefresh", mock.Anything, "used", "UA").Return(nil)
+	rtRepo.On("FindByHash", mock.Anything, mock.AnythingOfType("string")).Return(model.RefreshToken{
+		ID:        "rt-used",
+		UserID:    1,
+		FamilyID:  "fam-1",
+		UserAgent: "UA",
+		ExpiresAt: time.Now().Add(10 * time.Minute),
+		UsedAt:    &usedAt,
+	}, true, nil)
+	rtRepo.On("DeleteByFamilyID", mock.Anything, "fam-1").Return(int64(2), nil)
+
+	u := newAuthUC(userRepo, rtRepo, v)
+
+	res, err := u.Refresh(ctx, "used", "UA", "")
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, usecase.ErrSecurityIncident)
+
+	rtRepo.AssertExpectations(t)
+	rtRepo.AssertNotCalled(t, "DeleteByUserID", mock.Anything, mock.Anything)
+}
+
+// user_agent違いの扱いは REFRESH_UA_MISMATCH_POLICY で切り替える
+func TestAuthUsecase_Refresh_UserAgentMismatch_Policy(t *testing.T) {
+	cases := []struct {
+		policy  string
+		wantErr error
+	}{
+		{config.RefreshUAMismatchRevokeFamily, usecase.ErrSecurityIncident},
+		{config.RefreshUAMismatchAllow, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.policy, func(t *testing.T) {
+			ctx := context.Background()
+
+			userRepo := new(MockUserRepository)
+			rtRepo := new(MockRefreshTokenRepository)
+			v := new(MockAuthValidator)
+
+			rtRepo.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+			v.On("ValidateRefresh", mock.Anything, "ua-mismatch", "UA-NEW").Return(nil)
+			rtRepo.On("FindByHash", mock.Anything, mock.AnythingOfType("string")).Return(model.RefreshToken{
+				ID:        "rt-ua",
+				UserID:    1,
+				FamilyID:  "fam-1",
+				UserAgent: "UA-OLD",
+				ExpiresAt: time.Now().Add(10 * time.Minute),
+			}, true, nil)
+			rtRepo.On("DeleteByFamilyID", mock.Anything, "fam-1").Return(int64(1), nil).Maybe()
+			userRepo.On("FindByID", mock.Anything, int64(1)).Return(&model.User{ID: 1, Role: model.RoleUser, IsActive: true}, nil).Maybe()
+			rtRepo.On("MarkUsed", mock.Anything, "rt-ua").Return(nil).Maybe()
+			rtRepo.On("Create", mock.Anything, mock.AnythingOfType("model.RefreshToken")).Return(nil).Maybe()
+
+			cfg := config.Config{JWTSecret: "test-secret", RefreshUAMismatchPolicy: tc.policy}
+			u := usecase.NewAuthUsecase(cfg, userRepo, rtRepo, v, new(MockPasswordResetTokenRepository), new(MockEmailVerificationTokenRepository), new(MockMfaRecoveryCodeRepository), infrarepo.NewLoginAttemptMemoryRepository(), new(MockMailer), new(fakeSecurityEventRepository))
+
+			_, err := u.Refresh(ctx, "ua-mismatch", "UA-NEW", "")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				rtRepo.AssertCalled(t, "DeleteByFamilyID", mock.Anything, "fam-1")
+			} else {
+				assert.NoError(t, err)
+				rtRepo.AssertNotCalled(t, "DeleteByFamilyID", mock.Anything, mock.Anything)
+			}
+			rtRepo.AssertNotCalled(t, "DeleteByUserID", mock.Anything, mock.Anything)
+		})
+	}
+}
+
 // =====================
 // Logout（方針② logout: L1/L3）
 // =====================
@@ -510,7 +719,7 @@ func TestAuthUsecase_Logout_Success_L1(t *testing.T) {
 
 	u := newAuthUC(userRepo, rtRepo, v)
 
-	res, err := u.Logout(ctx, refreshPlain)
+	res, err := u.Logout(ctx, refreshPlain, "UA", "127.0.0.1")
 	assert.NoError(t, err)
 	assert.NotNil(t, res)
 	assert.Equal(t, "logout success", res.Message)
@@ -531,7 +740,7 @@ func TestAuthUsecase_Logout_EmptyToken_L3(t *testing.T) {
 
 	u := newAuthUC(userRepo, rtRepo, v)
 
-	res, err := u.Logout(ctx, "")
+	res, err := u.Logout(ctx, "", "UA", "127.0.0.1")
 	assert.Nil(t, res)
 	assert.ErrorIs(t, err, usecase.ErrUnauthorized)
 
@@ -567,7 +776,7 @@ func TestAuthUsecase_ForceLogout_Success_F1F2(t *testing.T) {
 
 	u := newAuthUC(userRepo, rtRepo, v)
 
-	res, err := u.ForceLogout(ctx, targetUserID)
+	res, err := u.ForceLogout(ctx, 1, targetUserID, "UA", "127.0.0.1")
 	assert.NoError(t, err)
 	assert.NotNil(t, res)
 	assert.Equal(t, targetUserID, res.UserID)
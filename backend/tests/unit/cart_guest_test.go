@@ -0,0 +1,417 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// CartRepository / CartItemRepository のインメモリ実装
+type fakeCartStore struct {
+	mu     sync.Mutex
+	nextID int64
+	carts  map[int64]model.Cart
+	items  map[int64]model.CartItem
+}
+
+func newFakeCartStore() *fakeCartStore {
+	return &fakeCartStore{carts: map[int64]model.Cart{}, items: map[int64]model.CartItem{}}
+}
+
+func (s *fakeCartStore) id() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *fakeCartStore) GetOrCreateActiveByUserID(ctx context.Context, userID int64) (model.Cart, error) {
+	if c, err := s.FindActiveByUserID(ctx, userID); err == nil {
+		return c, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := model.Cart{ID: s.id(), UserID: &userID, Status: model.CartStatusActive}
+	s.carts[c.ID] = c
+	return c, nil
+}
+
+func (s *fakeCartStore) FindActiveByUserID(ctx context.Context, userID int64) (model.Cart, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.carts {
+		if c.UserID != nil && *c.UserID == userID && c.Status == model.CartStatusActive {
+			return c, nil
+		}
+	}
+	return model.Cart{}, repo.ErrNotFound
+}
+
+func (s *fakeCartStore) UpdateStatus(ctx context.Context, cartID int64, status model.CartStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.carts[cartID]
+	if !ok {
+		return repo.ErrNotFound
+	}
+	c.Status = status
+	s.carts[cartID] = c
+	return nil
+}
+
+func (s *fakeCartStore) Clear(ctx context.Context, cartID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, it := range s.items {
+		if it.CartID == cartID {
+			delete(s.items, id)
+		}
+	}
+	return nil
+}
+
+func (s *fakeCartStore) CreateGuest(ctx context.Context) (model.Cart, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := model.Cart{ID: s.id(), Status: model.CartStatusActive, UpdatedAt: time.Now()}
+	s.carts[c.ID] = c
+	return c, nil
+}
+
+func (s *fakeCartStore) FindActiveGuestByID(ctx context.Context, cartID int64) (model.Cart, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.carts[cartID]
+	if !ok || c.UserID != nil || c.Status != model.CartStatusActive {
+		return model.Cart{}, repo.ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *fakeCartStore) MarkGuestMerged(ctx context.Context, cartID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.carts[cartID]
+	if !ok || c.UserID != nil || c.Status != model.CartStatusActive {
+		return false, nil
+	}
+	c.Status = model.CartStatusMerged
+	s.carts[cartID] = c
+	return true, nil
+}
+
+func (s *fakeCartStore) DeleteAbandonedGuests(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for id, c := range s.carts {
+		if c.UserID == nil && (c.Status != model.CartStatusActive || c.UpdatedAt.Before(before)) {
+			delete(s.carts, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *fakeCartStore) ListByCartID(ctx context.Context, cartID int64) ([]model.CartItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []model.CartItem{}
+	for _, it := range s.items {
+		if it.CartID == cartID {
+			out = append(out, it)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (s *fakeCartStore) UpsertByCartAndProduct(ctx context.Context, cartID int64, productID int64, variantID *int64, addQty int64, unitPriceSnapshot int64, optionLabelsSnapshot string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, it := range s.items {
+		if it.CartID == cartID && it.ProductID == productID && sameVariantID(it.VariantID, variantID) {
+			it.Quantity += addQty
+			s.items[id] = it
+			return nil
+		}
+	}
+	it := model.CartItem{ID: s.id(), CartID: cartID, ProductID: productID, VariantID: variantID, Quantity: addQty, UnitPriceSnapshot: unitPriceSnapshot, OptionLabelsSnapshot: optionLabelsSnapshot}
+	s.items[it.ID] = it
+	return nil
+}
+
+func (s *fakeCartStore) UpdateQuantity(ctx context.Context, cartItemID int64, qty int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[cartItemID]
+	if !ok {
+		return repo.ErrNotFound
+	}
+	it.Quantity = qty
+	s.items[cartItemID] = it
+	return nil
+}
+
+func (s *fakeCartStore) DeleteByID(ctx context.Context, cartItemID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.items[cartItemID]; !ok {
+		return repo.ErrNotFound
+	}
+	delete(s.items, cartItemID)
+	return nil
+}
+
+func (s *fakeCartStore) FindByID(ctx context.Context, cartItemID int64) (model.CartItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[cartItemID]
+	if !ok {
+		return model.CartItem{}, repo.ErrNotFound
+	}
+	return it, nil
+}
+
+func (s *fakeCartStore) IsOwnedByUser(ctx context.Context, cartItemID int64, userID int64) (bool, error) {
+	panic("not used in guest cart tests")
+}
+
+func newGuestCartUC(products map[int64]model.Product) (*usecase.CartUsecase, *fakeCartStore) {
+	store := newFakeCartStore()
+	productRepo := new(ProdProductRepoMock)
+	for id, p := range products {
+		productRepo.On("FindByID", mock.Anything, id).Return(p, nil)
+	}
+	productRepo.On("FindByID", mock.Anything, mock.Anything).Return(model.Product{}, repo.ErrNotFound)
+
+	uc := usecase.NewCartUsecase(config.Config{JWTSecret: "test-secret"}, store, store, productRepo, newFakeVariantRepo())
+	return uc, store
+}
+
+// =====================
+// ゲストカート
+// =====================
+
+// 初回の追加でカートを作り、署名付きの値を返す。同じ値なら同じカートに入る
+func TestCartUsecase_GuestCart_AddAndReuse(t *testing.T) {
+	uc, store := newGuestCartUC(map[int64]model.Product{
+		1: {ID: 1, Name: "A", Price: 100, Stock: 10, IsActive: true},
+	})
+	ctx := context.Background()
+
+	out, err := uc.AddToGuestCart(ctx, "", usecase.AddCartInput{ProductID: 1, Quantity: 2})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, out.GuestToken)
+	assert.Equal(t, int64(200), out.Cart.Total)
+
+	out2, err := uc.AddToGuestCart(ctx, out.GuestToken, usecase.AddCartInput{ProductID: 1, Quantity: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, out.GuestToken, out2.GuestToken)
+	if assert.Len(t, out2.Cart.Items, 1) {
+		assert.Equal(t, int64(3), out2.Cart.Items[0].Quantity)
+	}
+	assert.Len(t, store.carts, 1)
+
+	got, err := uc.GetGuestCart(ctx, out.GuestToken)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(300), got.Cart.Total)
+}
+
+// cookieが無い・改ざんされている場合、GETはカートを作らずに空を返す
+func TestCartUsecase_GuestCart_InvalidToken(t *testing.T) {
+	uc, store := newGuestCartUC(map[int64]model.Product{
+		1: {ID: 1, Name: "A", Price: 100, Stock: 10, IsActive: true},
+	})
+	ctx := context.Background()
+
+	out, err := uc.AddToGuestCart(ctx, "", usecase.AddCartInput{ProductID: 1, Quantity: 1})
+	assert.NoError(t, err)
+
+	//別のカートIDに書き換えても署名が合わない
+	forged := "2" + out.GuestToken[strings.Index(out.GuestToken, "."):]
+	for _, token := range []string{"", "1", "1.bad", forged} {
+		got, err := uc.GetGuestCart(ctx, token)
+		assert.NoError(t, err)
+		assert.Empty(t, got.GuestToken)
+		assert.Empty(t, got.Cart.Items)
+	}
+	assert.Len(t, store.carts, 1)
+}
+
+// 他のカートの明細は404
+func TestCartUsecase_GuestCart_ItemOwnership(t *testing.T) {
+	uc, _ := newGuestCartUC(map[int64]model.Product{
+		1: {ID: 1, Name: "A", Price: 100, Stock: 10, IsActive: true},
+	})
+	ctx := context.Background()
+
+	a, _ := uc.AddToGuestCart(ctx, "", usecase.AddCartInput{ProductID: 1, Quantity: 1})
+	b, _ := uc.AddToGuestCart(ctx, "", usecase.AddCartInput{ProductID: 1, Quantity: 1})
+	itemOfA := a.Cart.Items[0].ID
+
+	_, err := uc.UpdateGuestCartItem(ctx, b.GuestToken, itemOfA, usecase.UpdateCartItemInput{Quantity: 2})
+	assertHTTPStatus(t, err, http.StatusNotFound)
+
+	_, err = uc.DeleteGuestCartItem(ctx, b.GuestToken, itemOfA)
+	assertHTTPStatus(t, err, http.StatusNotFound)
+
+	out, err := uc.UpdateGuestCartItem(ctx, a.GuestToken, itemOfA, usecase.UpdateCartItemInput{Quantity: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(500), out.Cart.Total)
+}
+
+// =====================
+// MergeGuestCart
+// =====================
+
+// 同一商品は数量を足して在庫で頭打ち。単価は追加時点のまま
+func TestCartUsecase_MergeGuestCart(t *testing.T) {
+	products := map[int64]model.Product{
+		1: {ID: 1, Name: "A", Price: 150, Stock: 4, IsActive: true},
+		2: {ID: 2, Name: "B", Price: 250, Stock: 3, IsActive: true},
+		3: {ID: 3, Name: "C", Price: 300, Stock: 10, IsActive: false},
+	}
+	uc, store := newGuestCartUC(products)
+	ctx := context.Background()
+
+	userCart, _ := store.GetOrCreateActiveByUserID(ctx, 7)
+	_ = store.UpsertByCartAndProduct(ctx, userCart.ID, 1, nil, 2, 100, "")
+
+	guest, err := uc.AddToGuestCart(ctx, "", usecase.AddCartInput{ProductID: 1, Quantity: 3})
+	assert.NoError(t, err)
+	guestItem, _ := store.FindByID(ctx, guest.Cart.Items[0].ID)
+	_ = store.UpsertByCartAndProduct(ctx, guestItem.CartID, 2, nil, 5, 200, "")
+	_ = store.UpsertByCartAndProduct(ctx, guestItem.CartID, 3, nil, 1, 300, "")
+
+	assert.NoError(t, uc.MergeGuestCart(ctx, 7, guest.GuestToken))
+
+	items, _ := store.ListByCartID(ctx, userCart.ID)
+	byProduct := map[int64]model.CartItem{}
+	for _, it := range items {
+		byProduct[it.ProductID] = it
+	}
+	assert.Len(t, byProduct, 2)
+	assert.Equal(t, int64(4), byProduct[1].Quantity)
+	assert.Equal(t, int64(100), byProduct[1].UnitPriceSnapshot)
+	assert.Equal(t, int64(3), byProduct[2].Quantity)
+	assert.Equal(t, int64(200), byProduct[2].UnitPriceSnapshot)
+	assert.Equal(t, model.CartStatusMerged, store.carts[guestItem.CartID].Status)
+
+	//2回目（別タブのログインなど）は何もしない
+	assert.NoError(t, uc.MergeGuestCart(ctx, 7, guest.GuestToken))
+	items, _ = store.ListByCartID(ctx, userCart.ID)
+	assert.Len(t, items, 2)
+	got, _ := uc.GetGuestCart(ctx, guest.GuestToken)
+	assert.Empty(t, got.Cart.Items)
+}
+
+// 明細を読む前にゲストカートを閉じる（閉じられなければ明細も読まない）
+type orderRecordingCartStore struct {
+	*fakeCartStore
+	guestID int64
+	//別のログインが先に閉じた状況
+	claimLost bool
+	calls     []string
+}
+
+func (s *orderRecordingCartStore) MarkGuestMerged(ctx context.Context, cartID int64) (bool, error) {
+	s.calls = append(s.calls, "MarkGuestMerged")
+	if s.claimLost {
+		return false, nil
+	}
+	return s.fakeCartStore.MarkGuestMerged(ctx, cartID)
+}
+
+func (s *orderRecordingCartStore) ListByCartID(ctx context.Context, cartID int64) ([]model.CartItem, error) {
+	if cartID == s.guestID {
+		s.calls = append(s.calls, "ListByCartID(guest)")
+	}
+	return s.fakeCartStore.ListByCartID(ctx, cartID)
+}
+
+func TestCartUsecase_MergeGuestCart_ClaimsBeforeReading(t *testing.T) {
+	seed, store := newGuestCartUC(map[int64]model.Product{
+		1: {ID: 1, Name: "A", Price: 100, Stock: 10, IsActive: true},
+	})
+	ctx := context.Background()
+
+	guest, _ := seed.AddToGuestCart(ctx, "", usecase.AddCartInput{ProductID: 1, Quantity: 2})
+	guestItem, _ := store.FindByID(ctx, guest.Cart.Items[0].ID)
+
+	productRepo := new(ProdProductRepoMock)
+	productRepo.On("FindByID", mock.Anything, int64(1)).Return(model.Product{ID: 1, Name: "A", Price: 100, Stock: 10, IsActive: true}, nil)
+	rec := &orderRecordingCartStore{fakeCartStore: store, guestID: guestItem.CartID}
+	uc := usecase.NewCartUsecase(config.Config{JWTSecret: "test-secret"}, rec, rec, productRepo, newFakeVariantRepo())
+
+	assert.NoError(t, uc.MergeGuestCart(ctx, 7, guest.GuestToken))
+	assert.Equal(t, []string{"MarkGuestMerged", "ListByCartID(guest)"}, rec.calls)
+
+	//先に閉じられたら明細は読まない
+	guest2, _ := seed.AddToGuestCart(ctx, "", usecase.AddCartInput{ProductID: 1, Quantity: 1})
+	guestItem2, _ := store.FindByID(ctx, guest2.Cart.Items[0].ID)
+	rec.guestID, rec.claimLost, rec.calls = guestItem2.CartID, true, nil
+	assert.NoError(t, uc.MergeGuestCart(ctx, 7, guest2.GuestToken))
+	assert.Equal(t, []string{"MarkGuestMerged"}, rec.calls)
+}
+
+// ユーザーにカートが無ければ作って移す
+func TestCartUsecase_MergeGuestCart_NewUserCart(t *testing.T) {
+	uc, store := newGuestCartUC(map[int64]model.Product{
+		1: {ID: 1, Name: "A", Price: 100, Stock: 10, IsActive: true},
+	})
+	ctx := context.Background()
+
+	guest, _ := uc.AddToGuestCart(ctx, "", usecase.AddCartInput{ProductID: 1, Quantity: 2})
+	assert.NoError(t, uc.MergeGuestCart(ctx, 9, guest.GuestToken))
+
+	out, err := uc.GetCart(ctx, 9)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(200), out.Total)
+	_, err = store.FindActiveByUserID(ctx, 9)
+	assert.NoError(t, err)
+}
+
+// 合算済み・放置されたゲストカートは定期の掃除で消える（カート追加のついでには消さない）
+func TestCartUsecase_GuestCart_PurgeAbandoned(t *testing.T) {
+	uc, store := newGuestCartUC(map[int64]model.Product{
+		1: {ID: 1, Name: "A", Price: 100, Stock: 10, IsActive: true},
+	})
+	ctx := context.Background()
+
+	merged, _ := uc.AddToGuestCart(ctx, "", usecase.AddCartInput{ProductID: 1, Quantity: 1})
+	assert.NoError(t, uc.MergeGuestCart(ctx, 7, merged.GuestToken))
+
+	stale, _ := store.CreateGuest(ctx)
+	stale.UpdatedAt = time.Now().Add(-31 * 24 * time.Hour)
+	store.carts[stale.ID] = stale
+
+	_, err := uc.AddToGuestCart(ctx, "", usecase.AddCartInput{ProductID: 1, Quantity: 1})
+	assert.NoError(t, err)
+
+	countGuests := func() int {
+		guests := 0
+		for _, c := range store.carts {
+			if c.UserID == nil {
+				guests++
+			}
+		}
+		return guests
+	}
+	assert.Equal(t, 3, countGuests())
+
+	n, err := uc.PurgeAbandonedGuests(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, 1, countGuests())
+}
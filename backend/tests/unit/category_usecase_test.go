@@ -0,0 +1,246 @@
+package unit
+
+import (
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+	"app/internal/usecase"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =====================
+// Fake（メモリ上のカテゴリ）
+// =====================
+
+type fakeCategoryRepo struct {
+	items    []model.Category
+	nextID   int64
+	replaced map[int64][]int64
+	deleted  []int64
+}
+
+func newFakeCategoryRepo(items ...model.Category) *fakeCategoryRepo {
+	return &fakeCategoryRepo{items: items, nextID: 100, replaced: map[int64][]int64{}}
+}
+
+func (r *fakeCategoryRepo) Create(ctx context.Context, c model.Category) (model.Category, error) {
+	r.nextID++
+	c.ID = r.nextID
+	r.items = append(r.items, c)
+	return c, nil
+}
+
+func (r *fakeCategoryRepo) FindByID(ctx context.Context, categoryID int64) (model.Category, error) {
+	for _, c := range r.items {
+		if c.ID == categoryID {
+			return c, nil
+		}
+	}
+	return model.Category{}, repo.ErrNotFound
+}
+
+func (r *fakeCategoryRepo) FindBySlug(ctx context.Context, slug string) (model.Category, error) {
+	for _, c := range r.items {
+		if c.Slug == slug {
+			return c, nil
+		}
+	}
+	return model.Category{}, repo.ErrNotFound
+}
+
+func (r *fakeCategoryRepo) ListAll(ctx context.Context) ([]model.Category, error) {
+	return r.items, nil
+}
+
+func (r *fakeCategoryRepo) Update(ctx context.Context, c model.Category) error {
+	for i := range r.items {
+		if r.items[i].ID == c.ID {
+			r.items[i] = c
+			return nil
+		}
+	}
+	return repo.ErrNotFound
+}
+
+func (r *fakeCategoryRepo) Delete(ctx context.Context, categoryID int64) error {
+	r.deleted = append(r.deleted, categoryID)
+	return nil
+}
+
+func (r *fakeCategoryRepo) ReplaceProductCategories(ctx context.Context, productID int64, categoryIDs []int64) error {
+	r.replaced[productID] = categoryIDs
+	return nil
+}
+
+func (r *fakeCategoryRepo) ListByProductID(ctx context.Context, productID int64) ([]model.Category, error) {
+	panic("not used in category tests")
+}
+
+func catPtr(v int64) *int64 { return &v }
+
+// 食品 > 飲料 > コーヒー、食品 > 菓子、雑貨
+func sampleCategories() []model.Category {
+	return []model.Category{
+		{ID: 1, Name: "食品", Slug: "food", SortOrder: 1},
+		{ID: 2, ParentID: catPtr(1), Name: "飲料", Slug: "drinks", SortOrder: 2},
+		{ID: 3, ParentID: catPtr(2), Name: "コーヒー", Slug: "coffee", SortOrder: 1},
+		{ID: 4, ParentID: catPtr(1), Name: "菓子", Slug: "sweets", SortOrder: 1},
+		{ID: 5, Name: "雑貨", Slug: "goods", SortOrder: 2},
+	}
+}
+
+// =====================
+// Tree
+// =====================
+
+func TestCategoryUsecase_Tree_BuildsNestedChildren(t *testing.T) {
+	uc := usecase.NewCategoryUsecase(newFakeCategoryRepo(sampleCategories()...))
+
+	out, err := uc.Tree(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, out.Items, 2)
+
+	food := out.Items[0]
+	assert.Equal(t, "food", food.Slug)
+	assert.Equal(t, "goods", out.Items[1].Slug)
+
+	//子はListAllの並び順のまま
+	assert.Len(t, food.Children, 2)
+	assert.Equal(t, "drinks", food.Children[0].Slug)
+	assert.Equal(t, "sweets", food.Children[1].Slug)
+	assert.Equal(t, "coffee", food.Children[0].Children[0].Slug)
+	assert.NotNil(t, out.Items[1].Children)
+}
+
+// =====================
+// Create / Update / Delete
+// =====================
+
+func TestCategoryUsecase_AdminCreate_InvalidSlug(t *testing.T) {
+	uc := usecase.NewCategoryUsecase(newFakeCategoryRepo())
+
+	for _, slug := range []string{"", "Coffee", "cof fee", "-coffee", "coffee-", "cof--fee"} {
+		_, err := uc.AdminCreate(context.Background(), usecase.AdminCategoryInput{Name: "x", Slug: slug})
+		he, ok := usecase.AsHTTPError(err)
+		if assert.True(t, ok, slug) {
+			assert.Equal(t, 400, he.Status, slug)
+		}
+	}
+}
+
+func TestCategoryUsecase_AdminCreate_DuplicateSlug(t *testing.T) {
+	uc := usecase.NewCategoryUsecase(newFakeCategoryRepo(sampleCategories()...))
+
+	_, err := uc.AdminCreate(context.Background(), usecase.AdminCategoryInput{Name: "Coffee", Slug: "coffee"})
+	assertErrContains(t, err, "slug already exists")
+}
+
+func TestCategoryUsecase_AdminCreate_ParentNotFound(t *testing.T) {
+	uc := usecase.NewCategoryUsecase(newFakeCategoryRepo(sampleCategories()...))
+
+	_, err := uc.AdminCreate(context.Background(), usecase.AdminCategoryInput{ParentID: catPtr(99), Name: "Tea", Slug: "tea"})
+	assertErrContains(t, err, "parent not found")
+}
+
+func TestCategoryUsecase_AdminCreate_Success(t *testing.T) {
+	r := newFakeCategoryRepo(sampleCategories()...)
+	uc := usecase.NewCategoryUsecase(r)
+
+	c, err := uc.AdminCreate(context.Background(), usecase.AdminCategoryInput{ParentID: catPtr(2), Name: " Tea ", Slug: "tea", SortOrder: 3})
+	assert.NoError(t, err)
+	assert.NotZero(t, c.ID)
+	assert.Equal(t, "Tea", c.Name)
+	assert.Equal(t, int64(2), *c.ParentID)
+}
+
+func TestCategoryUsecase_AdminUpdate_RejectsCycle(t *testing.T) {
+	uc := usecase.NewCategoryUsecase(newFakeCategoryRepo(sampleCategories()...))
+	ctx := context.Background()
+
+	//自分自身
+	_, err := uc.AdminUpdate(ctx, 1, usecase.AdminCategoryInput{ParentID: catPtr(1), Name: "食品", Slug: "food"})
+	assertErrContains(t, err, "descendant")
+
+	//孫（コーヒー）の下には動かせない
+	_, err = uc.AdminUpdate(ctx, 1, usecase.AdminCategoryInput{ParentID: catPtr(3), Name: "食品", Slug: "food"})
+	assertErrContains(t, err, "descendant")
+}
+
+func TestCategoryUsecase_AdminUpdate_MoveToRootAndKeepOwnSlug(t *testing.T) {
+	r := newFakeCategoryRepo(sampleCategories()...)
+	uc := usecase.NewCategoryUsecase(r)
+
+	c, err := uc.AdminUpdate(context.Background(), 3, usecase.AdminCategoryInput{Name: "珈琲", Slug: "coffee"})
+	assert.NoError(t, err)
+	assert.Nil(t, c.ParentID)
+	assert.Equal(t, "珈琲", c.Name)
+}
+
+func TestCategoryUsecase_AdminUpdate_NotFound(t *testing.T) {
+	uc := usecase.NewCategoryUsecase(newFakeCategoryRepo(sampleCategories()...))
+
+	_, err := uc.AdminUpdate(context.Background(), 99, usecase.AdminCategoryInput{Name: "x", Slug: "x"})
+	assertErrContains(t, err, "not found")
+}
+
+func TestCategoryUsecase_AdminDelete_ConflictWhenHasChildren(t *testing.T) {
+	r := newFakeCategoryRepo(sampleCategories()...)
+	uc := usecase.NewCategoryUsecase(r)
+
+	err := uc.AdminDelete(context.Background(), 2)
+	he, ok := usecase.AsHTTPError(err)
+	if assert.True(t, ok) {
+		assert.Equal(t, 409, he.Status)
+	}
+	assert.Empty(t, r.deleted)
+
+	assert.NoError(t, uc.AdminDelete(context.Background(), 3))
+	assert.Equal(t, []int64{3}, r.deleted)
+}
+
+// =====================
+// 商品一覧のカテゴリ絞り込み
+// =====================
+
+func TestProductUsecase_ListPublicProducts_CategoryIncludesDescendants(t *testing.T) {
+	pRepo := new(ProdProductRepoMock)
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), newFakeCategoryRepo(sampleCategories()...), nil, newFakeImageRepo(), newFakeFileStorage())
+
+	q := repo.ProductListQuery{Page: 1, Limit: 20, CategoryIDs: []int64{1, 2, 4, 3}}
+	pRepo.On("ListPublic", mock.Anything, q).Return([]model.Product{}, int64(0), nil)
+
+	_, err := uc.ListPublicProducts(context.Background(), usecase.ListProductsInput{Page: 1, Limit: 20, Category: "food"})
+	assert.NoError(t, err)
+	pRepo.AssertExpectations(t)
+}
+
+func TestProductUsecase_ListPublicProducts_UnknownCategory(t *testing.T) {
+	uc := usecase.NewProductUsecase(new(ProdProductRepoMock), new(ProdInventoryRepoMock), new(ProdAuditRepoMock), newFakeCategoryRepo(), nil, nil, nil)
+
+	_, err := uc.ListPublicProducts(context.Background(), usecase.ListProductsInput{Page: 1, Limit: 20, Category: "nope"})
+	assertErrContains(t, err, "category not found")
+}
+
+func TestProductUsecase_AdminCreateProduct_SetsCategories(t *testing.T) {
+	pRepo := new(ProdProductRepoMock)
+	cRepo := newFakeCategoryRepo(sampleCategories()...)
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), cRepo, nil, nil, nil)
+
+	pRepo.On("Create", mock.Anything, mock.Anything).Return(model.Product{ID: 10}, nil)
+
+	_, err := uc.AdminCreateProduct(context.Background(), 1, usecase.AdminCreateProductInput{Name: "A", CategoryIDs: []int64{3, 3, 5}})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 5}, cRepo.replaced[10])
+}
+
+func TestProductUsecase_AdminUpdateProduct_UnknownCategory(t *testing.T) {
+	pRepo := new(ProdProductRepoMock)
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), newFakeCategoryRepo(sampleCategories()...), nil, nil, nil)
+
+	err := uc.AdminUpdateProduct(context.Background(), 1, 10, usecase.AdminCreateProductInput{Name: "A", CategoryIDs: []int64{99}})
+	assertErrContains(t, err, "category not found")
+	pRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
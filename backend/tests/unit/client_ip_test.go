@@ -0,0 +1,101 @@
+package unit
+
+import (
+	"app/internal/config"
+	"app/internal/handler"
+	"app/internal/middleware"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =====================
+// IPExtractor
+// =====================
+
+func TestClientIPExtractor(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+
+	cases := []struct {
+		name     string
+		trusted  []*net.IPNet
+		remote   string
+		xff      string
+		expected string
+	}{
+		{"no proxies: XFFは無視", nil, "203.0.113.7:5000", "1.2.3.4", "203.0.113.7"},
+		{"no proxies: privateからでも無視", nil, "10.0.0.5:5000", "1.2.3.4", "10.0.0.5"},
+		{"trusted proxy: 右から最初の信頼しないアドレス", []*net.IPNet{proxies}, "10.0.0.5:5000", "9.9.9.9, 1.2.3.4", "1.2.3.4"},
+		{"trusted proxy: 多段のプロキシも飛ばす", []*net.IPNet{proxies}, "10.0.0.5:5000", "1.2.3.4, 10.0.0.6", "1.2.3.4"},
+		{"untrusted remote: XFFは無視", []*net.IPNet{proxies}, "203.0.113.7:5000", "1.2.3.4", "203.0.113.7"},
+		{"明示しないprivateは信頼しない", []*net.IPNet{proxies}, "192.168.0.5:5000", "1.2.3.4", "192.168.0.5"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			e.IPExtractor = middleware.ClientIPExtractor(tc.trusted)
+			e.GET("/ip", func(c echo.Context) error { return c.String(http.StatusOK, c.RealIP()) })
+
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = tc.remote
+			req.Header.Set(echo.HeaderXForwardedFor, tc.xff)
+			req.Header.Set(echo.HeaderXRealIP, "8.8.8.8")
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expected, rec.Body.String())
+		})
+	}
+}
+
+// X-Forwarded-Forを毎回変えても、IPごとのログイン制限は接続元のアドレスで数える
+func TestAuthHandler_Login_SpoofedForwardedForKeepsThrottleKey(t *testing.T) {
+	uc, m := newThrottleUC()
+
+	m.rt.On("DeleteExpired", mock.Anything, mock.Anything).Return(int64(0), nil)
+	m.v.On("ValidateLogin", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	m.users.On("FindByEmail", mock.Anything, mock.Anything).Return(nil, errors.New("not found"))
+
+	e := echo.New()
+	e.IPExtractor = middleware.ClientIPExtractor(nil)
+	handler.NewAuthHandler(config.Config{JWTSecret: "test-secret"}, uc, nil, nil, nil, nil, m.users).RegisterRoutes(e)
+
+	login := func(i int) int {
+		// メールアドレスも毎回変える（IPのキーだけで止まることを見る）
+		body := fmt.Sprintf(`{"email":"user%d@test.com","password":"WrongPW"}`, i)
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.RemoteAddr = "192.0.2.10:5000"
+		req.Header.Set(echo.HeaderXForwardedFor, fmt.Sprintf("198.51.100.%d", i))
+		req.Header.Set(echo.HeaderXRealIP, fmt.Sprintf("198.51.100.%d", i))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// IPごとの上限は10回
+	for i := 1; i < 10; i++ {
+		assert.Equal(t, http.StatusUnauthorized, login(i))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, login(10))
+	assert.Equal(t, http.StatusTooManyRequests, login(11))
+
+	ctx := context.Background()
+	a, found, err := m.attempts.Get(ctx, "ip:192.0.2.10")
+	assert.NoError(t, err)
+	if assert.True(t, found) {
+		assert.NotNil(t, a.LockedUntil)
+	}
+	_, found, _ = m.attempts.Get(ctx, "ip:198.51.100.1")
+	assert.False(t, found)
+}
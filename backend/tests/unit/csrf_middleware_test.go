@@ -0,0 +1,149 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/handler"
+	"app/internal/middleware"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newCSRFEcho(conf middleware.CSRFConfig) *echo.Echo {
+	e := echo.New()
+	g := e.Group("/store", middleware.CSRF(conf))
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusNoContent) }
+	g.GET("/cart", ok)
+	g.POST("/cart", ok)
+	g.POST("/login", ok)
+	g.POST("/webhooks/:provider", ok)
+	return e
+}
+
+func runCSRFRequest(e *echo.Echo, method string, path string, header string, cookie string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if header != "" {
+		req.Header.Set(middleware.CSRFHeaderName, header)
+	}
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: middleware.CSRFCookieName, Value: cookie})
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+// ヘッダとcookieが同じ値のときだけ通す
+func TestCSRF_DoubleSubmit(t *testing.T) {
+	e := newCSRFEcho(middleware.CSRFConfig{})
+
+	cases := []struct {
+		name   string
+		header string
+		cookie string
+		want   int
+	}{
+		{"match", "tok", "tok", http.StatusNoContent},
+		{"header missing", "", "tok", http.StatusUnauthorized},
+		{"cookie missing", "tok", "", http.StatusUnauthorized},
+		{"mismatch", "tok", "other", http.StatusUnauthorized},
+		{"prefix only", "to", "tok", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := runCSRFRequest(e, http.MethodPost, "/store/cart", tc.header, tc.cookie)
+			assert.Equal(t, tc.want, rec.Code)
+			if tc.want == http.StatusUnauthorized {
+				assert.Equal(t, "csrf invalid", decodeMWError(t, rec).Error)
+			}
+		})
+	}
+}
+
+// GETは確認しない
+func TestCSRF_SafeMethodPasses(t *testing.T) {
+	e := newCSRFEcho(middleware.CSRFConfig{})
+
+	rec := runCSRFRequest(e, http.MethodGet, "/store/cart", "", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+// 除外パス（完全一致・末尾*の前方一致・ルート定義）
+func TestCSRF_ExemptPaths(t *testing.T) {
+	e := newCSRFEcho(middleware.CSRFConfig{ExemptPaths: []string{"/store/login", "/store/webhooks/*"}})
+
+	assert.Equal(t, http.StatusNoContent, runCSRFRequest(e, http.MethodPost, "/store/login", "", "").Code)
+	assert.Equal(t, http.StatusNoContent, runCSRFRequest(e, http.MethodPost, "/store/webhooks/stripe", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, runCSRFRequest(e, http.MethodPost, "/store/cart", "", "").Code)
+
+	e2 := newCSRFEcho(middleware.CSRFConfig{ExemptPaths: []string{"/store/webhooks/:provider"}})
+	assert.Equal(t, http.StatusNoContent, runCSRFRequest(e2, http.MethodPost, "/store/webhooks/stripe", "", "").Code)
+}
+
+// JSから読める（HttpOnlyでない）cookieとしてセットする
+func TestCSRF_SetCookie(t *testing.T) {
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+
+	middleware.SetCSRFCookie(c, "new-token", true)
+
+	ck := findCookie(rec.Result().Cookies(), middleware.CSRFCookieName)
+	if assert.NotNil(t, ck) {
+		assert.Equal(t, "new-token", ck.Value)
+		assert.False(t, ck.HttpOnly)
+		assert.True(t, ck.Secure)
+		assert.Equal(t, "/", ck.Path)
+	}
+}
+
+// /auth/refresh：CSRFが無ければ401、通れば csrf cookie が新しい値に入れ替わる
+func TestAuthHandler_Refresh_CSRFAndRotation(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	rtRepo := new(MockRefreshTokenRepository)
+	v := new(MockAuthValidator)
+
+	rtRepo.On("DeleteExpired", mock.Anything, mock.Anything).Return(int64(0), nil)
+	v.On("ValidateRefresh", mock.Anything, "refresh-plain", "UA").Return(nil)
+	rtRepo.On("FindByHash", mock.Anything, mock.Anything).Return(model.RefreshToken{
+		ID: "rt-old", UserID: 1, FamilyID: "rt-old", UserAgent: "UA", ExpiresAt: time.Now().Add(time.Hour),
+	}, true, nil)
+	userRepo.On("FindByID", mock.Anything, int64(1)).Return(&model.User{ID: 1, Role: model.RoleUser, IsActive: true}, nil)
+	rtRepo.On("MarkUsed", mock.Anything, "rt-old").Return(nil)
+	rtRepo.On("Create", mock.Anything, mock.AnythingOfType("model.RefreshToken")).Return(nil)
+
+	e := echo.New()
+	handler.NewAuthHandler(config.Config{JWTSecret: "test-secret"}, newAuthUC(userRepo, rtRepo, v), nil, nil, nil, nil, userRepo).RegisterRoutes(e)
+
+	send := func(csrfHeader string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/auth/refresh", nil)
+		req.Header.Set("User-Agent", "UA")
+		if csrfHeader != "" {
+			req.Header.Set(middleware.CSRFHeaderName, csrfHeader)
+		}
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh-plain"})
+		req.AddCookie(&http.Cookie{Name: middleware.CSRFCookieName, Value: "old-csrf"})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rtRepo.AssertNotCalled(t, "FindByHash", mock.Anything, mock.Anything)
+
+	rec = send("old-csrf")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	ck := findCookie(rec.Result().Cookies(), middleware.CSRFCookieName)
+	if assert.NotNil(t, ck) {
+		assert.NotEmpty(t, ck.Value)
+		assert.NotEqual(t, "old-csrf", ck.Value)
+	}
+}
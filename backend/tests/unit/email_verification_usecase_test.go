@@ -0,0 +1,201 @@
+package unit
+
+import (
+	"app/internal/config"
+	"app/internal/domain/model"
+	infrarepo "app/internal/infra/repository"
+	"app/internal/usecase"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =====================
+// Mock: EmailVerificationTokenRepository
+// =====================
+
+type MockEmailVerificationTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationTokenRepository) Create(ctx context.Context, token model.EmailVerificationToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationTokenRepository) FindByHash(ctx context.Context, tokenHash string) (model.EmailVerificationToken, bool, error) {
+	args := m.Called(ctx, tokenHash)
+	t, _ := args.Get(0).(model.EmailVerificationToken)
+	return t, args.Bool(1), args.Error(2)
+}
+
+func (m *MockEmailVerificationTokenRepository) MarkUsed(ctx context.Context, tokenID string) error {
+	args := m.Called(ctx, tokenID)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationTokenRepository) DeleteByUserID(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationTokenRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// =====================
+// Helper
+// =====================
+
+type verifyMocks struct {
+	users   *MockUserRepository
+	rt      *MockRefreshTokenRepository
+	v       *MockAuthValidator
+	verifys *MockEmailVerificationTokenRepository
+	mailer  *MockMailer
+}
+
+func newVerifyUC(policy string) (*usecase.AuthUsecase, verifyMocks) {
+	m := verifyMocks{
+		users:   new(MockUserRepository),
+		rt:      new(MockRefreshTokenRepository),
+		v:       new(MockAuthValidator),
+		verifys: new(MockEmailVerificationTokenRepository),
+		mailer:  new(MockMailer),
+	}
+	cfg := config.Config{
+		JWTSecret:               "test-secret",
+		FEURL:                   "http://localhost:3000",
+		EmailVerificationPolicy: policy,
+	}
+	return usecase.NewAuthUsecase(cfg, m.users, m.rt, m.v, new(MockPasswordResetTokenRepository), m.verifys, new(MockMfaRecoveryCodeRepository), infrarepo.NewLoginAttemptMemoryRepository(), m.mailer, new(fakeSecurityEventRepository)), m
+}
+
+// =====================
+// VerifyEmail
+// =====================
+
+// 正常 => email_verified_at がセットされる
+func TestAuthUsecase_VerifyEmail_Success(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newVerifyUC(config.EmailVerificationPolicyNone)
+
+	m.v.On("ValidateVerifyEmail", mock.Anything, "verify-plain").Return(nil)
+	m.verifys.On("FindByHash", mock.Anything, mock.AnythingOfType("string")).Return(model.EmailVerificationToken{
+		ID:        "evt-1",
+		UserID:    1,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, true, nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(&model.User{ID: 1, Email: "user@test.com", IsActive: true}, nil)
+	m.verifys.On("MarkUsed", mock.Anything, "evt-1").Return(nil)
+	m.users.On("Update", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+		return u.EmailVerifiedAt != nil
+	})).Return(nil)
+	m.verifys.On("DeleteByUserID", mock.Anything, int64(1)).Return(nil)
+
+	res, err := uc.VerifyEmail(ctx, usecase.VerifyEmailRequest{Token: "verify-plain"})
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+
+	m.users.AssertExpectations(t)
+	m.verifys.AssertExpectations(t)
+}
+
+// 存在しないトークン => ErrInvalidToken
+func TestAuthUsecase_VerifyEmail_UnknownToken(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newVerifyUC(config.EmailVerificationPolicyNone)
+
+	m.v.On("ValidateVerifyEmail", mock.Anything, "unknown").Return(nil)
+	m.verifys.On("FindByHash", mock.Anything, mock.AnythingOfType("string")).Return(model.EmailVerificationToken{}, false, nil)
+
+	res, err := uc.VerifyEmail(ctx, usecase.VerifyEmailRequest{Token: "unknown"})
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, usecase.ErrInvalidToken)
+
+	m.users.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// =====================
+// ResendVerification
+// =====================
+
+// 確認済み => 同じレスポンス、メールは送らない
+func TestAuthUsecase_ResendVerification_AlreadyVerified_NoMail(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newVerifyUC(config.EmailVerificationPolicyNone)
+
+	email := "user@test.com"
+	verifiedAt := time.Now().Add(-time.Hour)
+
+	m.v.On("ValidateResendVerification", mock.Anything, email).Return(nil)
+	m.verifys.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	m.users.On("FindByEmail", mock.Anything, email).Return(&model.User{
+		ID:              1,
+		Email:           email,
+		IsActive:        true,
+		EmailVerifiedAt: &verifiedAt,
+	}, nil)
+
+	res, err := uc.ResendVerification(ctx, usecase.ResendVerificationRequest{Email: email})
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+
+	m.verifys.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	m.mailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+// 送信に失敗しても同じレスポンス（登録有無が分からないように）
+func TestAuthUsecase_ResendVerification_MailFailure_SameResponse(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newVerifyUC(config.EmailVerificationPolicyNone)
+
+	email := "user@test.com"
+
+	m.v.On("ValidateResendVerification", mock.Anything, email).Return(nil)
+	m.verifys.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	m.verifys.On("DeleteByUserID", mock.Anything, int64(1)).Return(nil)
+	m.verifys.On("Create", mock.Anything, mock.Anything).Return(nil)
+	m.users.On("FindByEmail", mock.Anything, email).Return(&model.User{ID: 1, Email: email, IsActive: true}, nil)
+	m.mailer.On("Send", mock.Anything, mock.Anything).Return(assert.AnError)
+
+	res, err := uc.ResendVerification(ctx, usecase.ResendVerificationRequest{Email: email})
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) {
+		assert.Equal(t, "if the email is registered and not yet verified, a verification link has been sent", res.Message)
+	}
+	m.mailer.AssertExpectations(t)
+}
+
+// =====================
+// Login（policy=login）
+// =====================
+
+// 未確認ユーザー => ErrEmailNotVerified / refreshは作られない
+func TestAuthUsecase_Login_PolicyLogin_UnverifiedRejected(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newVerifyUC(config.EmailVerificationPolicyLogin)
+
+	email := "user@test.com"
+	pass := "CorrectPW"
+
+	m.rt.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	m.v.On("ValidateLogin", mock.Anything, email, pass).Return(nil)
+	m.users.On("FindByEmail", mock.Anything, email).Return(&model.User{
+		ID:           1,
+		Email:        email,
+		PasswordHash: mustHash(t, pass),
+		Role:         model.RoleUser,
+		IsActive:     true,
+	}, nil)
+
+	res, err := uc.Login(ctx, usecase.AuthLoginRequest{Email: email, Password: pass}, "UA", "")
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, usecase.ErrEmailNotVerified)
+
+	m.rt.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
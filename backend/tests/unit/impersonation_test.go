@@ -0,0 +1,180 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/middleware"
+	"app/internal/usecase"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newImpersonationUC() (*usecase.ImpersonationUsecase, *MockUserRepository, *AdminAuditRepoMock) {
+	users := new(MockUserRepository)
+	audit := new(AdminAuditRepoMock)
+	return usecase.NewImpersonationUsecase(config.Config{JWTSecret: "test-secret"}, users, audit), users, audit
+}
+
+// =====================
+// Impersonate
+// =====================
+
+// 顧客のtokenに act（管理者）が入り、発行は監査ログに残る
+func TestImpersonationUsecase_Impersonate_Success(t *testing.T) {
+	uc, users, audit := newImpersonationUC()
+	users.On("FindByID", mock.Anything, int64(20)).Return(&model.User{ID: 20, Role: model.RoleUser, IsActive: true, TokenVersion: 3}, nil)
+	audit.On("Create", mock.Anything, mock.MatchedBy(func(l model.AuditLog) bool {
+		return l.ActorUserID == 1 &&
+			l.Action == model.AuditActionImpersonateUser &&
+			l.ResourceType == model.AuditResourceUser &&
+			l.ResourceID == 20 &&
+			strings.Contains(l.AfterJSON, `"reason":"cart question"`)
+	})).Return(nil).Once()
+
+	out, err := uc.Impersonate(context.Background(), 1, 20, " cart question ")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), out.UserID)
+	assert.Equal(t, int64(1), out.ActorUserID)
+	assert.True(t, out.ReadOnly)
+	assert.Equal(t, 600, out.ExpiresIn)
+
+	token, err := jwt.Parse(out.AccessToken, func(t *jwt.Token) (interface{}, error) { return []byte("test-secret"), nil })
+	assert.NoError(t, err)
+	claims := token.Claims.(jwt.MapClaims)
+	assert.Equal(t, float64(20), claims["sub"])
+	assert.Equal(t, float64(3), claims["tv"])
+	assert.Equal(t, false, claims["mfa"])
+	act, ok := claims["act"].(map[string]interface{})
+	if assert.True(t, ok) {
+		assert.Equal(t, float64(1), act["sub"])
+	}
+	audit.AssertExpectations(t)
+}
+
+func TestImpersonationUsecase_Impersonate_Rejects(t *testing.T) {
+	cases := []struct {
+		name    string
+		target  *model.User
+		actor   int64
+		reason  string
+		wantErr string
+	}{
+		{"yourself", &model.User{ID: 20, Role: model.RoleUser, IsActive: true}, 20, "r", "cannot impersonate yourself"},
+		{"no reason", &model.User{ID: 20, Role: model.RoleUser, IsActive: true}, 1, "  ", "reason required"},
+		{"not found", nil, 1, "r", "not found"},
+		{"staff", &model.User{ID: 20, Role: model.RoleSupportAgent, IsActive: true}, 1, "r", "only customers can be impersonated"},
+		{"admin", &model.User{ID: 20, Role: model.RoleAdmin, IsActive: true}, 1, "r", "only customers can be impersonated"},
+		{"inactive", &model.User{ID: 20, Role: model.RoleUser, IsActive: false}, 1, "r", "user is inactive"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, users, audit := newImpersonationUC()
+			users.On("FindByID", mock.Anything, int64(20)).Return(tc.target, nil)
+
+			_, err := uc.Impersonate(context.Background(), tc.actor, 20, tc.reason)
+			assertErrContains(t, err, tc.wantErr)
+			audit.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// =====================
+// AuthJWT + ImpersonationAudit
+// =====================
+
+type fakeImpersonationRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *fakeImpersonationRecorder) RecordImpersonatedRequest(ctx context.Context, actorUserID int64, userID int64, method string, path string, status int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, _ := json.Marshal(map[string]interface{}{"actor": actorUserID, "user": userID, "method": method, "path": path, "status": status})
+	r.calls = append(r.calls, string(b))
+	return nil
+}
+
+func mustMakeImpersonationJWT(t *testing.T, secret string, sub int64, actor interface{}) string {
+	t.Helper()
+
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":  sub,
+		"role": "USER",
+		"tv":   0,
+		"act":  actor,
+		"iat":  1,
+		"exp":  9999999999,
+	}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("SignedString failed: %v", err)
+	}
+	return s
+}
+
+func newImpersonationEcho(rec middleware.ImpersonationRecorder) *echo.Echo {
+	cfg := config.Config{JWTSecret: "test-secret"}
+
+	e := echo.New()
+	e.Use(middleware.ImpersonationAudit(rec))
+	g := e.Group("", middleware.AuthJWT(cfg))
+	g.GET("/orders", func(c echo.Context) error {
+		actor, _ := c.Get(middleware.CtxImpersonatorKey).(int64)
+		return c.JSON(http.StatusOK, map[string]int64{"impersonator": actor})
+	})
+	g.POST("/orders", func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	})
+	return e
+}
+
+// なりすまし中は読み取りだけ。GETもPOST（拒否）も監査ログに残る
+func TestImpersonation_ReadOnlyAndAudited(t *testing.T) {
+	recorder := new(fakeImpersonationRecorder)
+	e := newImpersonationEcho(recorder)
+	token := mustMakeImpersonationJWT(t, "test-secret", 20, map[string]interface{}{"sub": 1})
+
+	res := runRequest(t, e, http.MethodGet, "/orders?page=2", "Bearer "+token)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Contains(t, res.Body.String(), `"impersonator":1`)
+
+	res = runRequest(t, e, http.MethodPost, "/orders", "Bearer "+token)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	assert.Equal(t, "read only while impersonating", decodeMWError(t, res).Error)
+
+	if assert.Len(t, recorder.calls, 2) {
+		assert.JSONEq(t, `{"actor":1,"user":20,"method":"GET","path":"/orders?page=2","status":200}`, recorder.calls[0])
+		assert.JSONEq(t, `{"actor":1,"user":20,"method":"POST","path":"/orders","status":403}`, recorder.calls[1])
+	}
+}
+
+// 通常のtokenは書き込めて、監査ログにも残らない
+func TestImpersonation_NormalTokenNotAudited(t *testing.T) {
+	recorder := new(fakeImpersonationRecorder)
+	e := newImpersonationEcho(recorder)
+	token := mustMakeJWT(t, "test-secret", 20, "USER", 0, jwt.SigningMethodHS256)
+
+	res := runRequest(t, e, http.MethodPost, "/orders", "Bearer "+token)
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Empty(t, recorder.calls)
+}
+
+// actの形が不正なら401
+func TestImpersonation_InvalidActClaim(t *testing.T) {
+	e := newImpersonationEcho(new(fakeImpersonationRecorder))
+	token := mustMakeImpersonationJWT(t, "test-secret", 20, "admin")
+
+	res := runRequest(t, e, http.MethodGet, "/orders", "Bearer "+token)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+}
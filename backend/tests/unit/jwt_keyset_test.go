@@ -0,0 +1,211 @@
+package unit
+
+import (
+	"app/internal/config"
+	"app/internal/middleware"
+	"app/internal/security"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// =====================
+// Helper
+// =====================
+
+func writeRSAKey(t *testing.T, dir string, kid string) *rsa.PrivateKey {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey failed: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey failed: %v", err)
+	}
+	writePEM(t, filepath.Join(dir, kid+".pem"), "PRIVATE KEY", der)
+	return priv
+}
+
+func writeEd25519Key(t *testing.T, dir string, kid string) ed25519.PrivateKey {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey failed: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey failed: %v", err)
+	}
+	writePEM(t, filepath.Join(dir, kid+".pem"), "PRIVATE KEY", der)
+	return priv
+}
+
+func writePublicKey(t *testing.T, dir string, kid string, pub interface{}) {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey failed: %v", err)
+	}
+	writePEM(t, filepath.Join(dir, kid+".pub.pem"), "PUBLIC KEY", der)
+}
+
+func writePEM(t *testing.T, path string, typ string, der []byte) {
+	t.Helper()
+	b := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func accessClaims(sub int64) jwt.MapClaims {
+	return jwt.MapClaims{"sub": sub, "role": "USER", "tv": 0, "iat": 1, "exp": 9999999999}
+}
+
+func protectedEcho(cfg config.Config) *echo.Echo {
+	e := echo.New()
+	e.GET("/protected", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, middleware.AuthJWT(cfg))
+	return e
+}
+
+// =====================
+// KeySet
+// =====================
+
+// RS256：kidヘッダ付きで署名 → middlewareで検証OK
+func TestKeySet_RS256_SignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "rsa-2025")
+
+	ks, err := security.LoadKeySetFromDir(dir, "")
+	assert.NoError(t, err)
+
+	raw, err := ks.Sign(accessClaims(1))
+	assert.NoError(t, err)
+
+	parsed, _ := jwt.Parse(raw, ks.Keyfunc)
+	if assert.NotNil(t, parsed) {
+		assert.True(t, parsed.Valid)
+		assert.Equal(t, "rsa-2025", parsed.Header["kid"])
+		assert.Equal(t, "RS256", parsed.Method.Alg())
+	}
+
+	rec := runRequest(t, protectedEcho(config.Config{JWTSecret: "test-secret", JWTKeys: ks}), http.MethodGet, "/protected", "Bearer "+raw)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+// ローテーション：新しい鍵で署名中も、旧鍵（公開鍵のみ）で署名済みのtokenは通る
+func TestKeySet_Rotation_OldKeyStillVerifies(t *testing.T) {
+	oldDir := t.TempDir()
+	oldPriv := writeEd25519Key(t, oldDir, "ed-old")
+	oldKS, err := security.LoadKeySetFromDir(oldDir, "")
+	assert.NoError(t, err)
+	oldToken, err := oldKS.Sign(accessClaims(1))
+	assert.NoError(t, err)
+
+	newDir := t.TempDir()
+	writeEd25519Key(t, newDir, "ed-new")
+	writePublicKey(t, newDir, "ed-old", oldPriv.Public())
+
+	newKS, err := security.LoadKeySetFromDir(newDir, "ed-new")
+	assert.NoError(t, err)
+	assert.Equal(t, "ed-new", newKS.SigningKID())
+
+	e := protectedEcho(config.Config{JWTSecret: "test-secret", JWTKeys: newKS})
+
+	rec := runRequest(t, e, http.MethodGet, "/protected", "Bearer "+oldToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	newToken, err := newKS.Sign(accessClaims(1))
+	assert.NoError(t, err)
+	rec = runRequest(t, e, http.MethodGet, "/protected", "Bearer "+newToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+// 非対称鍵に切り替えたら、共有シークレットのHS256 tokenは（移行設定が無ければ）通らない
+func TestKeySet_Asymmetric_RejectsHS256(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "rsa-1")
+	ks, err := security.LoadKeySetFromDir(dir, "")
+	assert.NoError(t, err)
+
+	cfg := config.Config{JWTSecret: "test-secret", JWTKeys: ks}
+	hsToken := mustMakeJWT(t, cfg.JWTSecret, 1, "USER", 0, jwt.SigningMethodHS256)
+
+	rec := runRequest(t, protectedEcho(cfg), http.MethodGet, "/protected", "Bearer "+hsToken)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// 移行用設定ならOK
+	ks.AcceptLegacyHMAC(cfg.JWTSecret)
+	rec = runRequest(t, protectedEcho(cfg), http.MethodGet, "/protected", "Bearer "+hsToken)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+// 公開鍵をHMACの鍵として使う alg 差し替え攻撃は通らない
+func TestKeySet_RejectsAlgConfusion(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "rsa-1")
+	ks, err := security.LoadKeySetFromDir(dir, "")
+	assert.NoError(t, err)
+
+	tok := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims(1))
+	tok.Header["kid"] = "rsa-1"
+	raw, err := tok.SignedString([]byte("whatever"))
+	assert.NoError(t, err)
+
+	rec := runRequest(t, protectedEcho(config.Config{JWTSecret: "test-secret", JWTKeys: ks}), http.MethodGet, "/protected", "Bearer "+raw)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+// 秘密鍵が複数なのにkid未指定 => エラー
+func TestKeySet_MultiplePrivateKeys_RequiresKID(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "a")
+	writeEd25519Key(t, dir, "b")
+
+	_, err := security.LoadKeySetFromDir(dir, "")
+	assert.Error(t, err)
+
+	ks, err := security.LoadKeySetFromDir(dir, "b")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", ks.SigningKID())
+}
+
+// JWKS：RSA/Ed25519の公開鍵だけ、HMACは出さない
+func TestKeySet_JWKS(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "rsa-1")
+	writeEd25519Key(t, dir, "ed-1")
+	ks, err := security.LoadKeySetFromDir(dir, "rsa-1")
+	assert.NoError(t, err)
+	ks.AcceptLegacyHMAC("test-secret")
+
+	jwks := ks.JWKS()
+	if assert.Len(t, jwks.Keys, 2) {
+		assert.Equal(t, "ed-1", jwks.Keys[0].Kid)
+		assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+		assert.Equal(t, "Ed25519", jwks.Keys[0].Crv)
+		assert.Equal(t, "EdDSA", jwks.Keys[0].Alg)
+		assert.NotEmpty(t, jwks.Keys[0].X)
+
+		assert.Equal(t, "rsa-1", jwks.Keys[1].Kid)
+		assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+		assert.Equal(t, "RS256", jwks.Keys[1].Alg)
+		assert.Equal(t, "AQAB", jwks.Keys[1].E)
+	}
+
+	// HS256だけなら空
+	assert.Len(t, security.NewHMACKeySet("test-secret").JWKS().Keys, 0)
+}
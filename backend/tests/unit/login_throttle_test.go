@@ -0,0 +1,201 @@
+package unit
+
+import (
+	"app/internal/config"
+	"app/internal/domain/model"
+	infrarepo "app/internal/infra/repository"
+	"app/internal/repository"
+	"app/internal/usecase"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =====================
+// Helper
+// =====================
+
+type throttleMocks struct {
+	users    *MockUserRepository
+	rt       *MockRefreshTokenRepository
+	v        *MockAuthValidator
+	attempts repository.LoginAttemptRepository
+}
+
+// メールアドレスごとに3回失敗でロック（30秒 → 60秒 → ... 上限5分）
+func newThrottleUC() (*usecase.AuthUsecase, throttleMocks) {
+	m := throttleMocks{
+		users:    new(MockUserRepository),
+		rt:       new(MockRefreshTokenRepository),
+		v:        new(MockAuthValidator),
+		attempts: infrarepo.NewLoginAttemptMemoryRepository(),
+	}
+	cfg := config.Config{
+		JWTSecret:                "test-secret",
+		LoginMaxFailuresPerEmail: 3,
+		LoginMaxFailuresPerIP:    10,
+		LoginLockoutBaseSeconds:  30,
+		LoginLockoutMaxSeconds:   300,
+	}
+	uc := usecase.NewAuthUsecase(cfg, m.users, m.rt, m.v,
+		new(MockPasswordResetTokenRepository), new(MockEmailVerificationTokenRepository),
+		new(MockMfaRecoveryCodeRepository), m.attempts, new(MockMailer), new(fakeSecurityEventRepository))
+	return uc, m
+}
+
+// =====================
+// Login
+// =====================
+
+// 3回目の失敗でロック(429) → 4回目は bcrypt まで行かずに429
+func TestAuthUsecase_Login_LocksAfterRepeatedFailures(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newThrottleUC()
+
+	email := "user@test.com"
+	user := &model.User{ID: 1, Email: email, PasswordHash: mustHash(t, "CorrectPW"), Role: model.RoleUser, IsActive: true}
+
+	m.rt.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	m.v.On("ValidateLogin", mock.Anything, email, mock.Anything).Return(nil)
+	m.users.On("FindByEmail", mock.Anything, email).Return(user, nil).Times(3)
+
+	for i := 0; i < 2; i++ {
+		_, err := uc.Login(ctx, usecase.AuthLoginRequest{Email: email, Password: "WrongPW"}, "UA", "10.0.0.1")
+		assert.ErrorIs(t, err, usecase.ErrUnauthorized)
+	}
+
+	_, err := uc.Login(ctx, usecase.AuthLoginRequest{Email: email, Password: "WrongPW"}, "UA", "10.0.0.1")
+	var locked *usecase.LoginLockedError
+	if assert.True(t, errors.As(err, &locked)) {
+		assert.Equal(t, 30*time.Second, locked.RetryAfter)
+	}
+	assert.ErrorIs(t, err, usecase.ErrTooManyAttempts)
+
+	// 正しいパスワードでもロック中は429（FindByEmailは呼ばれない）
+	_, err = uc.Login(ctx, usecase.AuthLoginRequest{Email: email, Password: "CorrectPW"}, "UA", "10.0.0.1")
+	assert.ErrorIs(t, err, usecase.ErrTooManyAttempts)
+
+	m.users.AssertNumberOfCalls(t, "FindByEmail", 3)
+}
+
+// ロック後も失敗が続くとロック時間が倍になる
+func TestAuthUsecase_Login_LockoutBacksOffExponentially(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newThrottleUC()
+
+	email := "ghost@test.com"
+	m.rt.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	m.v.On("ValidateLogin", mock.Anything, email, mock.Anything).Return(nil)
+	m.users.On("FindByEmail", mock.Anything, email).Return(nil, nil)
+
+	for i := 0; i < 3; i++ {
+		_, _ = uc.Login(ctx, usecase.AuthLoginRequest{Email: email, Password: "x"}, "UA", "")
+	}
+
+	// ロックを手動で期限切れにして、もう1回失敗させる
+	past := time.Now().Add(-time.Second)
+	assert.NoError(t, forceLockedUntil(ctx, m.attempts, "email:"+email, past))
+
+	_, err := uc.Login(ctx, usecase.AuthLoginRequest{Email: email, Password: "x"}, "UA", "")
+	var locked *usecase.LoginLockedError
+	if assert.True(t, errors.As(err, &locked)) {
+		assert.Equal(t, 60*time.Second, locked.RetryAfter)
+	}
+}
+
+// 成功したら失敗回数はリセット
+func TestAuthUsecase_Login_SuccessClearsFailures(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newThrottleUC()
+
+	email := "user@test.com"
+	user := &model.User{ID: 1, Email: email, PasswordHash: mustHash(t, "CorrectPW"), Role: model.RoleUser, IsActive: true}
+
+	m.rt.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	m.v.On("ValidateLogin", mock.Anything, email, mock.Anything).Return(nil)
+	m.users.On("FindByEmail", mock.Anything, email).Return(user, nil)
+	m.users.On("Update", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
+	m.rt.On("Create", mock.Anything, mock.AnythingOfType("model.RefreshToken")).Return(nil)
+
+	for i := 0; i < 2; i++ {
+		_, _ = uc.Login(ctx, usecase.AuthLoginRequest{Email: email, Password: "WrongPW"}, "UA", "")
+	}
+
+	_, err := uc.Login(ctx, usecase.AuthLoginRequest{Email: email, Password: "CorrectPW"}, "UA", "")
+	assert.NoError(t, err)
+
+	_, found, err := m.attempts.Get(ctx, "email:"+email)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+// =====================
+// Admin: 一覧・解除
+// =====================
+
+func TestAdminLoginLockoutUsecase_ListAndClear(t *testing.T) {
+	ctx := context.Background()
+	attempts := infrarepo.NewLoginAttemptMemoryRepository()
+	users := new(MockUserRepository)
+	audit := new(ProdAuditRepoMock)
+	uc := usecase.NewAdminLoginLockoutUsecase(attempts, users, audit)
+
+	now := time.Now()
+	_, _ = attempts.RecordFailure(ctx, "email:user@test.com", now, time.Minute)
+	assert.NoError(t, attempts.Lock(ctx, "email:user@test.com", now.Add(time.Minute)))
+	_, _ = attempts.RecordFailure(ctx, "ip:10.0.0.1", now, time.Minute)
+
+	list, err := uc.List(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "email", list[0].Kind)
+		assert.Equal(t, "user@test.com", list[0].Value)
+	}
+
+	users.On("FindByEmail", mock.Anything, "user@test.com").Return(&model.User{ID: 7}, nil)
+	audit.On("Create", mock.Anything, mock.MatchedBy(func(l model.AuditLog) bool {
+		return l.ActorUserID == 99 &&
+			l.Action == model.AuditActionClearLoginLockout &&
+			l.ResourceType == model.AuditResourceLoginLockout &&
+			l.ResourceID == 7
+	})).Return(nil)
+
+	err = uc.Clear(ctx, 99, usecase.ClearLoginLockoutInput{Key: "email:User@Test.com"})
+	assert.NoError(t, err)
+
+	list, err = uc.List(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, list, 0)
+
+	audit.AssertExpectations(t)
+}
+
+func TestAdminLoginLockoutUsecase_Clear_NotFound(t *testing.T) {
+	ctx := context.Background()
+	uc := usecase.NewAdminLoginLockoutUsecase(infrarepo.NewLoginAttemptMemoryRepository(), new(MockUserRepository), new(ProdAuditRepoMock))
+
+	err := uc.Clear(ctx, 99, usecase.ClearLoginLockoutInput{Key: "ip:10.0.0.9"})
+	assertHTTPStatus(t, err, http.StatusNotFound)
+}
+
+// テスト用：ロック期限を書き換える（メモリ実装は Lock で短くできないので作り直す）
+func forceLockedUntil(ctx context.Context, attempts repository.LoginAttemptRepository, key string, until time.Time) error {
+	a, _, err := attempts.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if _, err := attempts.Delete(ctx, key); err != nil {
+		return err
+	}
+	for i := 0; i < a.Failures; i++ {
+		if _, err := attempts.RecordFailure(ctx, key, a.LastFailedAt, time.Hour); err != nil {
+			return err
+		}
+	}
+	return attempts.Lock(ctx, key, until)
+}
@@ -0,0 +1,260 @@
+package unit
+
+import (
+	"app/internal/config"
+	"app/internal/domain/model"
+	infrarepo "app/internal/infra/repository"
+	"app/internal/usecase"
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =====================
+// Mock: MagicLinkTokenRepository
+// =====================
+
+type MockMagicLinkTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockMagicLinkTokenRepository) Create(ctx context.Context, token model.MagicLinkToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockMagicLinkTokenRepository) FindByHash(ctx context.Context, tokenHash string) (model.MagicLinkToken, bool, error) {
+	args := m.Called(ctx, tokenHash)
+	t, _ := args.Get(0).(model.MagicLinkToken)
+	return t, args.Bool(1), args.Error(2)
+}
+
+func (m *MockMagicLinkTokenRepository) MarkUsed(ctx context.Context, tokenID string) error {
+	args := m.Called(ctx, tokenID)
+	return args.Error(0)
+}
+
+func (m *MockMagicLinkTokenRepository) DeleteByUserID(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockMagicLinkTokenRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// =====================
+// Helper
+// =====================
+
+type magicLinkMocks struct {
+	users  *MockUserRepository
+	rt     *MockRefreshTokenRepository
+	v      *MockAuthValidator
+	tokens *MockMagicLinkTokenRepository
+	mailer *MockMailer
+	events *fakeSecurityEventRepository
+}
+
+func newMagicLinkUC() (*usecase.MagicLinkUsecase, magicLinkMocks) {
+	m := magicLinkMocks{
+		users:  new(MockUserRepository),
+		rt:     new(MockRefreshTokenRepository),
+		v:      new(MockAuthValidator),
+		tokens: new(MockMagicLinkTokenRepository),
+		mailer: new(MockMailer),
+		events: new(fakeSecurityEventRepository),
+	}
+	cfg := config.Config{JWTSecret: "test-secret", FEURL: "http://localhost:3000"}
+	authUC := usecase.NewAuthUsecase(cfg, m.users, m.rt, m.v, new(MockPasswordResetTokenRepository), new(MockEmailVerificationTokenRepository), new(MockMfaRecoveryCodeRepository), infrarepo.NewLoginAttemptMemoryRepository(), m.mailer, m.events)
+	return usecase.NewMagicLinkUsecase(authUC, m.users, m.tokens, m.mailer), m
+}
+
+// =====================
+// Request
+// =====================
+
+// 登録済み => hashだけ保存してリンクをメールで送る
+func TestMagicLinkUsecase_Request_SendsMail(t *testing.T) {
+	uc, m := newMagicLinkUC()
+	email := "user@test.com"
+
+	m.v.On("ValidateMagicLinkRequest", mock.Anything, email).Return(nil)
+	m.tokens.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	m.users.On("FindByEmail", mock.Anything, email).Return(&model.User{ID: 1, Email: email, IsActive: true}, nil)
+	m.tokens.On("DeleteByUserID", mock.Anything, int64(1)).Return(nil)
+
+	var saved model.MagicLinkToken
+	m.tokens.On("Create", mock.Anything, mock.AnythingOfType("model.MagicLinkToken")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(model.MagicLinkToken) }).
+		Return(nil)
+
+	var sent usecase.MailMessage
+	m.mailer.On("Send", mock.Anything, mock.AnythingOfType("usecase.MailMessage")).
+		Run(func(args mock.Arguments) { sent = args.Get(1).(usecase.MailMessage) }).
+		Return(nil)
+
+	res, err := uc.Request(context.Background(), usecase.MagicLinkRequest{Email: " " + email + " "})
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+
+	// 期限は短く（15分）、保存するのはhashだけ
+	assert.Equal(t, int64(1), saved.UserID)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), saved.ExpiresAt, 5*time.Second)
+	assert.Equal(t, email, sent.To)
+	assert.Contains(t, sent.Body, "http://localhost:3000/auth/magic-link?token=")
+	assert.NotContains(t, sent.Body, saved.TokenHash)
+}
+
+// 未登録・停止中 => 同じレスポンス、メールは送らない
+func TestMagicLinkUsecase_Request_UnknownOrInactive_SameResponse(t *testing.T) {
+	for _, user := range []*model.User{nil, {ID: 2, Email: "user@test.com", IsActive: false}} {
+		uc, m := newMagicLinkUC()
+
+		m.v.On("ValidateMagicLinkRequest", mock.Anything, "user@test.com").Return(nil)
+		m.tokens.On("DeleteExpired", mock.Anything, mock.Anything).Return(int64(0), nil)
+		m.users.On("FindByEmail", mock.Anything, "user@test.com").Return(user, nil)
+
+		res, err := uc.Request(context.Background(), usecase.MagicLinkRequest{Email: "user@test.com"})
+		assert.NoError(t, err)
+		assert.Equal(t, "if the email is registered, a login link has been sent", res.Message)
+		m.tokens.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		m.mailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	}
+}
+
+// =====================
+// Consume
+// =====================
+
+// メールのリンクからトークンを取り出してConsumeまで通す
+func TestMagicLinkUsecase_Consume_IssuesSession(t *testing.T) {
+	uc, m := newMagicLinkUC()
+	email := "user@test.com"
+	user := &model.User{ID: 1, Email: email, Role: model.RoleUser, IsActive: true}
+
+	m.v.On("ValidateMagicLinkRequest", mock.Anything, email).Return(nil)
+	m.tokens.On("DeleteExpired", mock.Anything, mock.Anything).Return(int64(0), nil)
+	m.users.On("FindByEmail", mock.Anything, email).Return(user, nil)
+	m.tokens.On("DeleteByUserID", mock.Anything, int64(1)).Return(nil)
+
+	var saved model.MagicLinkToken
+	m.tokens.On("Create", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(model.MagicLinkToken) }).
+		Return(nil)
+	var sent usecase.MailMessage
+	m.mailer.On("Send", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { sent = args.Get(1).(usecase.MailMessage) }).
+		Return(nil)
+
+	_, err := uc.Request(context.Background(), usecase.MagicLinkRequest{Email: email})
+	assert.NoError(t, err)
+	plain := tokenFromMailLink(t, sent.Body)
+
+	m.v.On("ValidateMagicLinkConsume", mock.Anything, plain).Return(nil)
+	m.tokens.On("FindByHash", mock.Anything, saved.TokenHash).Return(saved, true, nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(user, nil)
+	m.tokens.On("MarkUsed", mock.Anything, saved.ID).Return(nil)
+
+	var updated model.User
+	m.users.On("Update", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { updated = *args.Get(1).(*model.User) }).
+		Return(nil)
+	m.rt.On("Create", mock.Anything, mock.AnythingOfType("model.RefreshToken")).Return(nil)
+
+	res, err := uc.Consume(context.Background(), usecase.MagicLinkConsumeRequest{Token: plain}, "UA", "203.0.113.1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, res.Body.Token.AccessToken)
+	assert.NotEmpty(t, res.RefreshTokenPlain)
+	assert.NotEmpty(t, res.CsrfTokenPlain)
+
+	// last_login とメール確認済みが保存される
+	assert.NotNil(t, updated.LastLoginAt)
+	assert.NotNil(t, updated.EmailVerifiedAt)
+
+	ev := m.events.only(t)
+	assert.Equal(t, model.SecurityEventLoginMagicLink, ev.Type)
+	assert.Equal(t, model.SecurityOutcomeSuccess, ev.Outcome)
+}
+
+// 使用済み・期限切れ・存在しない => 400、セッションは出さない
+func TestMagicLinkUsecase_Consume_InvalidToken(t *testing.T) {
+	usedAt := time.Now().Add(-time.Minute)
+	cases := []struct {
+		name  string
+		token model.MagicLinkToken
+		found bool
+	}{
+		{"not found", model.MagicLinkToken{}, false},
+		{"used", model.MagicLinkToken{ID: "t1", UserID: 1, ExpiresAt: time.Now().Add(time.Minute), UsedAt: &usedAt}, true},
+		{"expired", model.MagicLinkToken{ID: "t1", UserID: 1, ExpiresAt: time.Now().Add(-time.Second)}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc, m := newMagicLinkUC()
+			m.v.On("ValidateMagicLinkConsume", mock.Anything, "plain").Return(nil)
+			m.tokens.On("FindByHash", mock.Anything, mock.Anything).Return(tc.token, tc.found, nil)
+
+			_, err := uc.Consume(context.Background(), usecase.MagicLinkConsumeRequest{Token: "plain"}, "UA", "203.0.113.1")
+			assert.ErrorIs(t, err, usecase.ErrInvalidToken)
+			m.rt.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			assert.Equal(t, "invalid_token", m.events.only(t).Reason)
+		})
+	}
+}
+
+// 同時に2回使われた（MarkUsedで負けた） => 400
+func TestMagicLinkUsecase_Consume_Replay(t *testing.T) {
+	uc, m := newMagicLinkUC()
+	token := model.MagicLinkToken{ID: "t1", UserID: 1, ExpiresAt: time.Now().Add(time.Minute)}
+
+	m.v.On("ValidateMagicLinkConsume", mock.Anything, "plain").Return(nil)
+	m.tokens.On("FindByHash", mock.Anything, mock.Anything).Return(token, true, nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(&model.User{ID: 1, Email: "user@test.com", IsActive: true}, nil)
+	m.tokens.On("MarkUsed", mock.Anything, "t1").Return(errors.New("already used"))
+
+	_, err := uc.Consume(context.Background(), usecase.MagicLinkConsumeRequest{Token: "plain"}, "UA", "203.0.113.1")
+	assert.ErrorIs(t, err, usecase.ErrInvalidToken)
+	m.rt.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// 2FAが有効 => セッションではなくMFAチャレンジ
+func TestMagicLinkUsecase_Consume_MfaEnabled(t *testing.T) {
+	uc, m := newMagicLinkUC()
+	now := time.Now()
+	token := model.MagicLinkToken{ID: "t1", UserID: 1, ExpiresAt: now.Add(time.Minute)}
+
+	m.v.On("ValidateMagicLinkConsume", mock.Anything, "plain").Return(nil)
+	m.tokens.On("FindByHash", mock.Anything, mock.Anything).Return(token, true, nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(&model.User{ID: 1, Email: "user@test.com", IsActive: true, EmailVerifiedAt: &now, TotpEnabledAt: &now}, nil)
+	m.tokens.On("MarkUsed", mock.Anything, "t1").Return(nil)
+	m.tokens.On("DeleteByUserID", mock.Anything, int64(1)).Return(nil)
+	m.users.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+	res, err := uc.Consume(context.Background(), usecase.MagicLinkConsumeRequest{Token: "plain"}, "UA", "203.0.113.1")
+	assert.NoError(t, err)
+	assert.NotNil(t, res.MfaChallenge)
+	m.rt.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func tokenFromMailLink(t *testing.T, body string) string {
+	t.Helper()
+	i := strings.Index(body, "?token=")
+	if i < 0 {
+		t.Fatalf("link not found in mail: %s", body)
+	}
+	raw := strings.Fields(body[i+len("?token="):])[0]
+	plain, err := url.QueryUnescape(raw)
+	if err != nil {
+		t.Fatalf("unescape failed: %v", err)
+	}
+	return plain
+}
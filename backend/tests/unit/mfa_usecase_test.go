@@ -0,0 +1,519 @@
+package unit
+
+import (
+	"app/internal/config"
+	"app/internal/domain/model"
+	infrarepo "app/internal/infra/repository"
+	"app/internal/middleware"
+	"app/internal/security"
+	"app/internal/usecase"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =====================
+// Mock: MfaRecoveryCodeRepository
+// =====================
+
+type MockMfaRecoveryCodeRepository struct {
+	mock.Mock
+}
+
+func (m *MockMfaRecoveryCodeRepository) ReplaceForUser(ctx context.Context, userID int64, codes []model.MfaRecoveryCode) error {
+	args := m.Called(ctx, userID, codes)
+	return args.Error(0)
+}
+
+func (m *MockMfaRecoveryCodeRepository) Consume(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMfaRecoveryCodeRepository) DeleteByUserID(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// =====================
+// Helper
+// =====================
+
+type mfaMocks struct {
+	users      *MockUserRepository
+	rt         *MockRefreshTokenRepository
+	v          *MockAuthValidator
+	recoveries *MockMfaRecoveryCodeRepository
+}
+
+func newMfaUC() (*usecase.AuthUsecase, mfaMocks, config.Config) {
+	m := mfaMocks{
+		users:      new(MockUserRepository),
+		rt:         new(MockRefreshTokenRepository),
+		v:          new(MockAuthValidator),
+		recoveries: new(MockMfaRecoveryCodeRepository),
+	}
+	cfg := config.Config{
+		JWTSecret:        "test-secret",
+		MfaIssuer:        "EC_App",
+		MfaEncryptionKey: "test-mfa-key",
+	}
+	uc := usecase.NewAuthUsecase(cfg, m.users, m.rt, m.v,
+		new(MockPasswordResetTokenRepository), new(MockEmailVerificationTokenRepository), m.recoveries, infrarepo.NewLoginAttemptMemoryRepository(), new(MockMailer), new(fakeSecurityEventRepository))
+	return uc, m, cfg
+}
+
+// 2FA有効ユーザー（シークレットは暗号化して保存済み）
+func mustTotpUser(t *testing.T, cfg config.Config, secret string, pass string) *model.User {
+	t.Helper()
+
+	box, err := security.NewSecretBox(cfg.MfaEncryptionKey)
+	if err != nil {
+		t.Fatalf("NewSecretBox failed: %v", err)
+	}
+	sealed, err := box.Seal(secret)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	enabledAt := time.Now().Add(-time.Hour)
+	return &model.User{
+		ID:            1,
+		Email:         "admin@test.com",
+		PasswordHash:  mustHash(t, pass),
+		Role:          model.RoleAdmin,
+		IsActive:      true,
+		TotpSecretEnc: sealed,
+		TotpEnabledAt: &enabledAt,
+	}
+}
+
+func mustTotpCode(t *testing.T, secret string, now time.Time) string {
+	t.Helper()
+	code, err := security.TotpCode(secret, security.TotpStep(now))
+	if err != nil {
+		t.Fatalf("TotpCode failed: %v", err)
+	}
+	return code
+}
+
+// =====================
+// TOTP（RFC 6238 Appendix B のテストベクタ、6桁）
+// =====================
+
+func TestTotp_RFC6238Vectors(t *testing.T) {
+	// "12345678901234567890" の base32
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		got, err := security.TotpCode(secret, security.TotpStep(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, "unix=%d", unix)
+	}
+}
+
+// 使用済みステップ以前のコードは通らない
+func TestTotp_Verify_RejectsReplay(t *testing.T) {
+	secret, err := security.NewTotpSecret()
+	assert.NoError(t, err)
+
+	now := time.Now()
+	code := mustTotpCode(t, secret, now)
+
+	step, ok := security.VerifyTotp(secret, code, now, 0)
+	assert.True(t, ok)
+
+	_, ok = security.VerifyTotp(secret, code, now, step)
+	assert.False(t, ok)
+}
+
+// =====================
+// Login（2FA有効）
+// =====================
+
+// パスワードOK => tokenではなくMFAチャレンジ / refreshは作られない
+func TestAuthUsecase_Login_TotpEnabled_ReturnsChallenge(t *testing.T) {
+	ctx := context.Background()
+	uc, m, cfg := newMfaUC()
+
+	pass := "CorrectPW"
+	secret, _ := security.NewTotpSecret()
+	user := mustTotpUser(t, cfg, secret, pass)
+
+	m.rt.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	m.v.On("ValidateLogin", mock.Anything, user.Email, pass).Return(nil)
+	m.users.On("FindByEmail", mock.Anything, user.Email).Return(user, nil)
+
+	res, err := uc.Login(ctx, usecase.AuthLoginRequest{Email: user.Email, Password: pass}, "UA", "")
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) && assert.NotNil(t, res.MfaChallenge) {
+		assert.True(t, res.MfaChallenge.MfaRequired)
+		assert.NotEmpty(t, res.MfaChallenge.MfaToken)
+	}
+	assert.Empty(t, res.RefreshTokenPlain)
+	assert.Empty(t, res.Body.Token.AccessToken)
+
+	m.rt.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// =====================
+// LoginMfa
+// =====================
+
+// JWT_KEYS_DIR（非対称鍵）だけの構成でも、チャレンジはkid付きで署名・検証される（共有シークレット不要）
+func TestAuthUsecase_LoginMfa_AsymmetricKeySet(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "ed-1")
+	ks, err := security.LoadKeySetFromDir(dir, "")
+	assert.NoError(t, err)
+
+	m := mfaMocks{
+		users:      new(MockUserRepository),
+		rt:         new(MockRefreshTokenRepository),
+		v:          new(MockAuthValidator),
+		recoveries: new(MockMfaRecoveryCodeRepository),
+	}
+	cfg := config.Config{JWTKeys: ks, MfaIssuer: "EC_App", MfaEncryptionKey: "test-mfa-key"}
+	uc := usecase.NewAuthUsecase(cfg, m.users, m.rt, m.v,
+		new(MockPasswordResetTokenRepository), new(MockEmailVerificationTokenRepository), m.recoveries, infrarepo.NewLoginAttemptMemoryRepository(), new(MockMailer), new(fakeSecurityEventRepository))
+
+	pass := "CorrectPW"
+	secret, _ := security.NewTotpSecret()
+	user := mustTotpUser(t, cfg, secret, pass)
+
+	m.rt.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	m.v.On("ValidateLogin", mock.Anything, user.Email, pass).Return(nil)
+	m.users.On("FindByEmail", mock.Anything, user.Email).Return(user, nil)
+
+	first, err := uc.Login(ctx, usecase.AuthLoginRequest{Email: user.Email, Password: pass}, "UA", "")
+	assert.NoError(t, err)
+	if !assert.NotNil(t, first.MfaChallenge) {
+		return
+	}
+	challenge, _ := jwt.Parse(first.MfaChallenge.MfaToken, ks.Keyfunc)
+	if assert.NotNil(t, challenge) {
+		assert.True(t, challenge.Valid)
+		assert.Equal(t, "ed-1", challenge.Header["kid"])
+		assert.Equal(t, "EdDSA", challenge.Method.Alg())
+	}
+
+	//空の共有シークレットでHS256署名したチャレンジは通らない
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": user.ID, "typ": "mfa_challenge", "tv": user.TokenVersion, "exp": time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte(""))
+	assert.NoError(t, err)
+	code := mustTotpCode(t, secret, time.Now())
+	m.v.On("ValidateLoginMfa", mock.Anything, mock.Anything, code, "").Return(nil)
+	_, err = uc.LoginMfa(ctx, usecase.LoginMfaRequest{MfaToken: forged, Code: code}, "UA", "")
+	assert.ErrorIs(t, err, usecase.ErrUnauthorized)
+
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(user, nil)
+	m.users.On("Update", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
+	m.rt.On("Create", mock.Anything, mock.AnythingOfType("model.RefreshToken")).Return(nil)
+
+	res, err := uc.LoginMfa(ctx, usecase.LoginMfaRequest{MfaToken: first.MfaChallenge.MfaToken, Code: code}, "UA", "")
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) {
+		assert.NotEmpty(t, res.Body.Token.AccessToken)
+	}
+}
+
+// 正しいTOTP => access(mfa=true) + refresh 発行
+func TestAuthUsecase_LoginMfa_Success(t *testing.T) {
+	ctx := context.Background()
+	uc, m, cfg := newMfaUC()
+
+	pass := "CorrectPW"
+	secret, _ := security.NewTotpSecret()
+	user := mustTotpUser(t, cfg, secret, pass)
+
+	m.rt.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	m.v.On("ValidateLogin", mock.Anything, user.Email, pass).Return(nil)
+	m.users.On("FindByEmail", mock.Anything, user.Email).Return(user, nil)
+
+	first, err := uc.Login(ctx, usecase.AuthLoginRequest{Email: user.Email, Password: pass}, "UA", "")
+	assert.NoError(t, err)
+
+	code := mustTotpCode(t, secret, time.Now())
+	req := usecase.LoginMfaRequest{MfaToken: first.MfaChallenge.MfaToken, Code: code}
+
+	m.v.On("ValidateLoginMfa", mock.Anything, req.MfaToken, code, "").Return(nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(user, nil)
+	m.users.On("Update", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
+	//2FAを通ったことを系列に記録する（refreshしてもmfa=trueのまま）
+	m.rt.On("Create", mock.Anything, mock.MatchedBy(func(rt model.RefreshToken) bool {
+		return rt.MfaVerified
+	})).Return(nil)
+
+	res, err := uc.LoginMfa(ctx, req, "UA", "")
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) {
+		assert.Nil(t, res.MfaChallenge)
+		assert.NotEmpty(t, res.Body.Token.AccessToken)
+		assert.NotEmpty(t, res.RefreshTokenPlain)
+
+		claims := jwt.MapClaims{}
+		_, perr := jwt.ParseWithClaims(res.Body.Token.AccessToken, claims, func(t *jwt.Token) (interface{}, error) {
+			return []byte(cfg.JWTSecret), nil
+		})
+		assert.NoError(t, perr)
+		assert.Equal(t, true, claims["mfa"])
+	}
+	assert.NotZero(t, user.TotpLastStep)
+
+	m.rt.AssertExpectations(t)
+}
+
+// 同じコードの再利用 => 401
+func TestAuthUsecase_LoginMfa_ReusedCode_Unauthorized(t *testing.T) {
+	ctx := context.Background()
+	uc, m, cfg := newMfaUC()
+
+	pass := "CorrectPW"
+	secret, _ := security.NewTotpSecret()
+	user := mustTotpUser(t, cfg, secret, pass)
+
+	m.rt.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	m.v.On("ValidateLogin", mock.Anything, user.Email, pass).Return(nil)
+	m.users.On("FindByEmail", mock.Anything, user.Email).Return(user, nil)
+
+	first, err := uc.Login(ctx, usecase.AuthLoginRequest{Email: user.Email, Password: pass}, "UA", "")
+	assert.NoError(t, err)
+
+	now := time.Now()
+	code := mustTotpCode(t, secret, now)
+	user.TotpLastStep = security.TotpStep(now)
+
+	req := usecase.LoginMfaRequest{MfaToken: first.MfaChallenge.MfaToken, Code: code}
+	m.v.On("ValidateLoginMfa", mock.Anything, req.MfaToken, code, "").Return(nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(user, nil)
+
+	res, err := uc.LoginMfa(ctx, req, "UA", "")
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, usecase.ErrUnauthorized)
+
+	m.rt.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// リカバリーコード（ハイフン・大文字ゆれOK）=> 1回消費してログイン
+func TestAuthUsecase_LoginMfa_RecoveryCode_Success(t *testing.T) {
+	ctx := context.Background()
+	uc, m, cfg := newMfaUC()
+
+	pass := "CorrectPW"
+	secret, _ := security.NewTotpSecret()
+	user := mustTotpUser(t, cfg, secret, pass)
+
+	m.rt.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	m.v.On("ValidateLogin", mock.Anything, user.Email, pass).Return(nil)
+	m.users.On("FindByEmail", mock.Anything, user.Email).Return(user, nil)
+
+	first, err := uc.Login(ctx, usecase.AuthLoginRequest{Email: user.Email, Password: pass}, "UA", "")
+	assert.NoError(t, err)
+
+	req := usecase.LoginMfaRequest{MfaToken: first.MfaChallenge.MfaToken, RecoveryCode: "ABCDE-FGHIJ"}
+	m.v.On("ValidateLoginMfa", mock.Anything, req.MfaToken, "", req.RecoveryCode).Return(nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(user, nil)
+	m.recoveries.On("Consume", mock.Anything, int64(1), mock.AnythingOfType("string")).Return(true, nil)
+	m.users.On("Update", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
+	m.rt.On("Create", mock.Anything, mock.AnythingOfType("model.RefreshToken")).Return(nil)
+
+	res, err := uc.LoginMfa(ctx, req, "UA", "")
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+
+	m.recoveries.AssertExpectations(t)
+}
+
+// =====================
+// Enroll / Confirm
+// =====================
+
+// enroll → confirm で有効化 + リカバリーコード10本
+func TestAuthUsecase_EnrollAndConfirmTotp_Success(t *testing.T) {
+	ctx := context.Background()
+	uc, m, _ := newMfaUC()
+
+	user := &model.User{ID: 1, Email: "admin@test.com", Role: model.RoleAdmin, IsActive: true}
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(user, nil)
+	m.users.On("Update", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
+
+	enrolled, err := uc.EnrollTotp(ctx, 1)
+	assert.NoError(t, err)
+	if assert.NotNil(t, enrolled) {
+		assert.Contains(t, enrolled.OtpauthURI, "otpauth://totp/EC_App:admin@test.com?")
+		assert.Contains(t, enrolled.OtpauthURI, "secret="+enrolled.Secret)
+	}
+	assert.NotEmpty(t, user.TotpSecretEnc)
+	assert.NotEqual(t, enrolled.Secret, user.TotpSecretEnc)
+	assert.Nil(t, user.TotpEnabledAt)
+
+	code := mustTotpCode(t, enrolled.Secret, time.Now())
+	m.v.On("ValidateTotpConfirm", mock.Anything, code).Return(nil)
+	m.recoveries.On("ReplaceForUser", mock.Anything, int64(1), mock.MatchedBy(func(codes []model.MfaRecoveryCode) bool {
+		return len(codes) == 10
+	})).Return(nil)
+
+	//他の端末はログアウト、この端末の系列だけ残す
+	m.users.On("IncrementTokenVersion", mock.Anything, int64(1)).Return(nil)
+	m.rt.On("FindByHash", mock.Anything, sessionHash("current-refresh")).Return(model.RefreshToken{
+		ID: "rt-current", FamilyID: "fam-current", UserID: 1, ExpiresAt: time.Now().Add(time.Hour),
+	}, true, nil)
+	m.rt.On("DeleteByUserIDExceptFamily", mock.Anything, int64(1), "fam-current").Return(int64(2), nil)
+
+	confirmed, err := uc.ConfirmTotp(ctx, 1, usecase.TotpConfirmRequest{Code: code}, "current-refresh")
+	assert.NoError(t, err)
+	if assert.NotNil(t, confirmed) {
+		assert.Len(t, confirmed.RecoveryCodes, 10)
+	}
+	assert.NotNil(t, user.TotpEnabledAt)
+
+	m.recoveries.AssertExpectations(t)
+	m.users.AssertExpectations(t)
+	m.rt.AssertExpectations(t)
+}
+
+// 2FAを有効にした後でも、パスワードだけで作られた系列のrefreshはmfa=falseのまま。
+// 2FAを通った系列は回転してもmfa=trueを引き継ぐ
+func TestAuthUsecase_Refresh_MfaClaimFollowsFamily(t *testing.T) {
+	for _, verified := range []bool{false, true} {
+		ctx := context.Background()
+		uc, m, cfg := newMfaUC()
+
+		secret, _ := security.NewTotpSecret()
+		user := mustTotpUser(t, cfg, secret, "CorrectPW")
+		old := model.RefreshToken{
+			ID: "rt-old", FamilyID: "fam-old", UserID: 1, TokenHash: sessionHash("old-refresh"), UserAgent: "UA",
+			ExpiresAt: time.Now().Add(time.Hour), MfaVerified: verified,
+		}
+
+		m.rt.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+		m.v.On("ValidateRefresh", mock.Anything, "old-refresh", "UA").Return(nil)
+		m.rt.On("FindByHash", mock.Anything, old.TokenHash).Return(old, true, nil)
+		m.users.On("FindByID", mock.Anything, int64(1)).Return(user, nil)
+		m.rt.On("MarkUsed", mock.Anything, "rt-old").Return(nil)
+		m.rt.On("Create", mock.Anything, mock.MatchedBy(func(rt model.RefreshToken) bool {
+			return rt.FamilyID == "fam-old" && rt.MfaVerified == verified
+		})).Return(nil)
+
+		res, err := uc.Refresh(ctx, "old-refresh", "UA", "")
+		assert.NoError(t, err)
+		if assert.NotNil(t, res) {
+			claims := jwt.MapClaims{}
+			_, perr := jwt.ParseWithClaims(res.Body.AccessToken, claims, func(t *jwt.Token) (interface{}, error) {
+				return []byte(cfg.JWTSecret), nil
+			})
+			assert.NoError(t, perr)
+			assert.Equal(t, verified, claims["mfa"])
+		}
+		m.rt.AssertExpectations(t)
+	}
+}
+
+// 違うコード => ErrInvalidMfaCode / 有効化されない
+func TestAuthUsecase_ConfirmTotp_WrongCode(t *testing.T) {
+	ctx := context.Background()
+	uc, m, cfg := newMfaUC()
+
+	secret, _ := security.NewTotpSecret()
+	user := mustTotpUser(t, cfg, secret, "CorrectPW")
+	user.TotpEnabledAt = nil
+
+	m.v.On("ValidateTotpConfirm", mock.Anything, "000000").Return(nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(user, nil)
+
+	res, err := uc.ConfirmTotp(ctx, 1, usecase.TotpConfirmRequest{Code: "000000"}, "")
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, usecase.ErrInvalidMfaCode)
+	assert.Nil(t, user.TotpEnabledAt)
+
+	m.recoveries.AssertNotCalled(t, "ReplaceForUser", mock.Anything, mock.Anything, mock.Anything)
+	m.rt.AssertNotCalled(t, "DeleteByUserIDExceptFamily", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// =====================
+// AdminRoleGuard（MFA_REQUIRED_FOR_ADMIN）
+// =====================
+
+func mustMakeJWTWithMfa(t *testing.T, secret string, sub int64, role string, mfa bool) string {
+	t.Helper()
+
+	claims := jwt.MapClaims{
+		"sub":  sub,
+		"role": role,
+		"tv":   0,
+		"mfa":  mfa,
+		"iat":  1,
+		"exp":  9999999999,
+	}
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("SignedString failed: %v", err)
+	}
+	return s
+}
+
+// 2FA必須: mfa=false のADMIN => 403
+func TestMiddleware_AdminRoleGuard_MfaRequired_Forbidden(t *testing.T) {
+	e := echo.New()
+	cfg := config.Config{JWTSecret: "test-secret", MfaRequiredForAdmin: true}
+
+	e.GET("/admin/x", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, middleware.AuthJWT(cfg), middleware.AdminRoleGuard(cfg))
+
+	raw := mustMakeJWTWithMfa(t, cfg.JWTSecret, 1, "ADMIN", false)
+	rec := runRequest(t, e, http.MethodGet, "/admin/x", "Bearer "+raw)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "mfa required", decodeMWError(t, rec).Error)
+}
+
+// 2FA必須: mfa=true のADMIN => 200
+func TestMiddleware_AdminRoleGuard_MfaRequired_OK(t *testing.T) {
+	e := echo.New()
+	cfg := config.Config{JWTSecret: "test-secret", MfaRequiredForAdmin: true}
+
+	e.GET("/admin/x", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, middleware.AuthJWT(cfg), middleware.AdminRoleGuard(cfg))
+
+	raw := mustMakeJWTWithMfa(t, cfg.JWTSecret, 1, "ADMIN", true)
+	rec := runRequest(t, e, http.MethodGet, "/admin/x", "Bearer "+raw)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+// MFAチャレンジトークンはaccess tokenとして使えない
+func TestMiddleware_AuthJWT_RejectsMfaChallengeToken(t *testing.T) {
+	e := echo.New()
+	cfg := config.Config{JWTSecret: "test-secret"}
+
+	e.GET("/protected", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, middleware.AuthJWT(cfg))
+
+	claims := jwt.MapClaims{
+		"sub":  1,
+		"role": "ADMIN",
+		"tv":   0,
+		"typ":  "mfa_challenge",
+		"exp":  9999999999,
+	}
+	raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.JWTSecret))
+	assert.NoError(t, err)
+
+	rec := runRequest(t, e, http.MethodGet, "/protected", "Bearer "+raw)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/labstack/echo/v4"
@@ -66,6 +67,20 @@ func (m *MockUserRepoForMiddleware) IncrementTokenVersion(ctx context.Context, i
 	return args.Error(0)
 }
 
+func (m *MockUserRepoForMiddleware) FindTokenState(ctx context.Context, id int64) (*model.TokenState, error) {
+	args := m.Called(ctx, id)
+	st, _ := args.Get(0).(*model.TokenState)
+	return st, args.Error(1)
+}
+
+func (m *MockUserRepoForMiddleware) List(ctx context.Context, f repository.AdminUserListFilter) ([]model.User, int64, error) {
+	panic("not used in middleware tests")
+}
+
+func (m *MockUserRepoForMiddleware) ListDeletionDue(ctx context.Context, now time.Time, limit int) ([]model.User, error) {
+	panic("not used in middleware tests")
+}
+
 var _ repository.UserRepository = (*MockUserRepoForMiddleware)(nil)
 
 // =====================
@@ -247,10 +262,8 @@ func TestMiddleware_TokenVersionGuard_Unauthorized_TokenVersionMismatch(t *testi
 
 	raw := mustMakeJWT(t, cfg.JWTSecret, 1, "USER", 0, jwt.SigningMethodHS256)
 
-	userRepo.On("FindByID", mock.Anything, int64(1)).Return(&model.User{
-		ID:           1,
-		Email:        "user@test.com",
-		Role:         model.RoleUser,
+	userRepo.On("FindTokenState", mock.Anything, int64(1)).Return(&model.TokenState{
+		UserID:       1,
 		TokenVersion: 1, // 不一致
 		IsActive:     true,
 	}, nil)
@@ -277,10 +290,8 @@ func TestMiddleware_TokenVersionGuard_Success(t *testing.T) {
 
 	raw := mustMakeJWT(t, cfg.JWTSecret, 1, "USER", 5, jwt.SigningMethodHS256)
 
-	userRepo.On("FindByID", mock.Anything, int64(1)).Return(&model.User{
-		ID:           1,
-		Email:        "user@test.com",
-		Role:         model.RoleUser,
+	userRepo.On("FindTokenState", mock.Anything, int64(1)).Return(&model.TokenState{
+		UserID:       1,
 		TokenVersion: 5, // 一致
 		IsActive:     true,
 	}, nil)
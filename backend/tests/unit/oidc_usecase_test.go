@@ -0,0 +1,594 @@
+package unit
+
+import (
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/handler"
+	"app/internal/infra/oidc"
+	"app/internal/security"
+	"app/internal/usecase"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =====================
+// Fake IdP（httptestで立てるOpenID Provider）
+// =====================
+
+const (
+	fakeIdpClientID     = "test-client"
+	fakeIdpClientSecret = "test-client-secret"
+	fakeIdpRedirectURL  = "http://localhost:3000/auth/callback/fake"
+)
+
+type fakeIdpUser struct {
+	sub           string
+	email         string
+	emailVerified bool
+}
+
+// /authorize で「ログインして同意した」状態（codeごと）
+type fakeIdpGrant struct {
+	user          fakeIdpUser
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+}
+
+type fakeIdp struct {
+	srv *httptest.Server
+	key *rsa.PrivateKey
+	kid string
+
+	mu     sync.Mutex
+	grants map[string]fakeIdpGrant
+	// テスト用：id_tokenのnonceを書き換える
+	overrideNonce string
+}
+
+func newFakeIdp(t *testing.T) *fakeIdp {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey failed: %v", err)
+	}
+
+	idp := &fakeIdp{key: key, kid: "idp-key-1", grants: map[string]fakeIdpGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.srv.URL,
+			"authorization_endpoint": idp.srv.URL + "/authorize",
+			"token_endpoint":         idp.srv.URL + "/token",
+			"jwks_uri":               idp.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"use": "sig",
+				"alg": "RS256",
+				"kid": idp.kid,
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", idp.handleToken)
+
+	idp.srv = httptest.NewServer(mux)
+	t.Cleanup(idp.srv.Close)
+	return idp
+}
+
+// code → id_token（client認証・redirect_uri・PKCEを確認する）
+func (idp *fakeIdp) handleToken(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != fakeIdpClientID || secret != fakeIdpClientSecret {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+	_ = r.ParseForm()
+
+	idp.mu.Lock()
+	g, found := idp.grants[r.PostForm.Get("code")]
+	delete(idp.grants, r.PostForm.Get("code"))
+	nonce := g.nonce
+	if idp.overrideNonce != "" {
+		nonce = idp.overrideNonce
+	}
+	idp.mu.Unlock()
+
+	if !found ||
+		r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("redirect_uri") != g.redirectURI ||
+		security.PkceChallengeS256(r.PostForm.Get("code_verifier")) != g.codeChallenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            idp.srv.URL,
+		"aud":            fakeIdpClientID,
+		"sub":            g.user.sub,
+		"email":          g.user.email,
+		"email_verified": g.user.emailVerified,
+		"nonce":          nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	})
+	tok.Header["kid"] = idp.kid
+	idToken, _ := tok.SignedString(idp.key)
+
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "idp-access",
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+// ブラウザがIdPでログイン → redirect_uri に code / state が返ってくる、を再現する
+func (idp *fakeIdp) approve(t *testing.T, authURL string, user fakeIdpUser) usecase.OidcCallbackRequest {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse authorization url failed: %v", err)
+	}
+	q := u.Query()
+	assert.Equal(t, idp.srv.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, fakeIdpClientID, q.Get("client_id"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	assert.Contains(t, strings.Fields(q.Get("scope")), "openid")
+
+	code := "code-" + user.sub + "-" + q.Get("state")[:8]
+	idp.mu.Lock()
+	idp.grants[code] = fakeIdpGrant{
+		user:          user,
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+		redirectURI:   q.Get("redirect_uri"),
+	}
+	idp.mu.Unlock()
+
+	return usecase.OidcCallbackRequest{Code: code, State: q.Get("state")}
+}
+
+func (idp *fakeIdp) providerConfig() config.OidcProviderConfig {
+	return config.OidcProviderConfig{
+		Name:         "fake",
+		Issuer:       idp.srv.URL,
+		ClientID:     fakeIdpClientID,
+		ClientSecret: fakeIdpClientSecret,
+		RedirectURL:  fakeIdpRedirectURL,
+		Scopes:       []string{"email", "profile"},
+	}
+}
+
+// =====================
+// Mock: UserIdentityRepository
+// =====================
+
+type MockUserIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserIdentityRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockUserIdentityRepository) FindByProviderSubject(ctx context.Context, provider string, subject string) (model.UserIdentity, bool, error) {
+	args := m.Called(ctx, provider, subject)
+	identity, _ := args.Get(0).(model.UserIdentity)
+	return identity, args.Bool(1), args.Error(2)
+}
+
+func (m *MockUserIdentityRepository) ListByUserID(ctx context.Context, userID int64) ([]model.UserIdentity, error) {
+	args := m.Called(ctx, userID)
+	list, _ := args.Get(0).([]model.UserIdentity)
+	return list, args.Error(1)
+}
+
+func (m *MockUserIdentityRepository) TouchLastLogin(ctx context.Context, id string, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockUserIdentityRepository) DeleteByIDForUser(ctx context.Context, id string, userID int64) (bool, error) {
+	args := m.Called(ctx, id, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserIdentityRepository) DeleteByUserID(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// =====================
+// Helper
+// =====================
+
+type oidcMocks struct {
+	users      *MockUserRepository
+	rt         *MockRefreshTokenRepository
+	identities *MockUserIdentityRepository
+}
+
+func newOidcUC(t *testing.T, idp *fakeIdp) (*usecase.OidcUsecase, *usecase.AuthUsecase, oidcMocks) {
+	t.Helper()
+	m := oidcMocks{
+		users:      new(MockUserRepository),
+		rt:         new(MockRefreshTokenRepository),
+		identities: new(MockUserIdentityRepository),
+	}
+	authUC := newAuthUC(m.users, m.rt, new(MockAuthValidator))
+	cfg := config.Config{JWTSecret: "test-secret"}
+	uc := usecase.NewOidcUsecase(cfg, authUC, m.users, m.identities,
+		[]usecase.OidcProvider{oidc.NewProvider(idp.providerConfig(), idp.srv.Client())})
+	return uc, authUC, m
+}
+
+// =====================
+// Login
+// =====================
+
+// 未登録のIdPアカウント => ユーザー作成 + 紐付け + token発行
+func TestOidcUsecase_CallbackLogin_SignUp(t *testing.T) {
+	ctx := context.Background()
+	idp := newFakeIdp(t)
+	uc, _, m := newOidcUC(t, idp)
+
+	start, err := uc.AuthorizeLogin(ctx, "fake")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, start.StateToken)
+
+	req := idp.approve(t, start.Body.AuthorizationURL, fakeIdpUser{sub: "idp-123", email: "new@test.com", emailVerified: true})
+
+	m.identities.On("FindByProviderSubject", mock.Anything, "fake", "idp-123").Return(model.UserIdentity{}, false, nil)
+	m.users.On("FindByEmail", mock.Anything, "new@test.com").Return(nil, nil)
+	m.users.On("Create", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+		return u.Email == "new@test.com" && u.PasswordHash == "" && u.EmailVerifiedAt != nil
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*model.User).ID = 10
+	}).Return(nil)
+	m.identities.On("Create", mock.Anything, mock.MatchedBy(func(i *model.UserIdentity) bool {
+		return i.UserID == 10 && i.Provider == "fake" && i.Subject == "idp-123"
+	})).Return(nil)
+	m.users.On("Update", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
+	m.rt.On("Create", mock.Anything, mock.AnythingOfType("model.RefreshToken")).Return(nil)
+
+	res, err := uc.CallbackLogin(ctx, "fake", req, start.StateToken, "UA", "127.0.0.1")
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) {
+		assert.Nil(t, res.MfaChallenge)
+		assert.Equal(t, int64(10), res.Body.User.ID)
+		assert.True(t, res.Body.User.EmailVerified)
+		assert.NotEmpty(t, res.Body.Token.AccessToken)
+		assert.NotEmpty(t, res.RefreshTokenPlain)
+		assert.NotEmpty(t, res.CsrfTokenPlain)
+	}
+
+	m.identities.AssertExpectations(t)
+}
+
+// 紐付け済み => そのユーザーでログイン
+func TestOidcUsecase_CallbackLogin_LinkedUser(t *testing.T) {
+	ctx := context.Background()
+	idp := newFakeIdp(t)
+	uc, _, m := newOidcUC(t, idp)
+
+	user := &model.User{ID: 5, Email: "user@test.com", Role: model.RoleUser, IsActive: true}
+
+	start, err := uc.AuthorizeLogin(ctx, "fake")
+	assert.NoError(t, err)
+	req := idp.approve(t, start.Body.AuthorizationURL, fakeIdpUser{sub: "idp-5", email: "other@idp.test", emailVerified: true})
+
+	m.identities.On("FindByProviderSubject", mock.Anything, "fake", "idp-5").Return(model.UserIdentity{ID: "ident-5", UserID: 5}, true, nil)
+	m.identities.On("TouchLastLogin", mock.Anything, "ident-5", mock.AnythingOfType("time.Time")).Return(nil)
+	m.users.On("FindByID", mock.Anything, int64(5)).Return(user, nil)
+	m.users.On("Update", mock.Anything, user).Return(nil)
+	m.rt.On("Create", mock.Anything, mock.AnythingOfType("model.RefreshToken")).Return(nil)
+
+	res, err := uc.CallbackLogin(ctx, "fake", req, start.StateToken, "UA", "")
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) {
+		assert.Equal(t, int64(5), res.Body.User.ID)
+	}
+
+	//メールアドレスでは探さない
+	m.users.AssertNotCalled(t, "FindByEmail", mock.Anything, mock.Anything)
+	m.users.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// 2FAが有効なユーザーはMFAチャレンジ（cookieは出さない）
+func TestOidcUsecase_CallbackLogin_TotpUser_ReturnsChallenge(t *testing.T) {
+	ctx := context.Background()
+	idp := newFakeIdp(t)
+	uc, _, m := newOidcUC(t, idp)
+
+	enabled := time.Now()
+	user := &model.User{ID: 6, Email: "mfa@test.com", Role: model.RoleUser, IsActive: true, TotpEnabledAt: &enabled}
+
+	start, _ := uc.AuthorizeLogin(ctx, "fake")
+	req := idp.approve(t, start.Body.AuthorizationURL, fakeIdpUser{sub: "idp-6", email: "mfa@test.com", emailVerified: true})
+
+	m.identities.On("FindByProviderSubject", mock.Anything, "fake", "idp-6").Return(model.UserIdentity{ID: "ident-6", UserID: 6}, true, nil)
+	m.identities.On("TouchLastLogin", mock.Anything, "ident-6", mock.AnythingOfType("time.Time")).Return(nil)
+	m.users.On("FindByID", mock.Anything, int64(6)).Return(user, nil)
+
+	res, err := uc.CallbackLogin(ctx, "fake", req, start.StateToken, "UA", "")
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) && assert.NotNil(t, res.MfaChallenge) {
+		assert.True(t, res.MfaChallenge.MfaRequired)
+		assert.Empty(t, res.RefreshTokenPlain)
+	}
+	m.rt.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// 同じメールアドレスのアカウントがある => 自動で紐付けずに409
+func TestOidcUsecase_CallbackLogin_EmailTaken(t *testing.T) {
+	ctx := context.Background()
+	idp := newFakeIdp(t)
+	uc, _, m := newOidcUC(t, idp)
+
+	start, _ := uc.AuthorizeLogin(ctx, "fake")
+	req := idp.approve(t, start.Body.AuthorizationURL, fakeIdpUser{sub: "idp-9", email: "user@test.com", emailVerified: true})
+
+	m.identities.On("FindByProviderSubject", mock.Anything, "fake", "idp-9").Return(model.UserIdentity{}, false, nil)
+	m.users.On("FindByEmail", mock.Anything, "user@test.com").Return(&model.User{ID: 1, Email: "user@test.com"}, nil)
+
+	_, err := uc.CallbackLogin(ctx, "fake", req, start.StateToken, "UA", "")
+	assert.ErrorIs(t, err, usecase.ErrOidcAccountExists)
+	m.users.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// IdPがメールアドレスを確認していない => 作らない
+func TestOidcUsecase_CallbackLogin_UnverifiedEmail(t *testing.T) {
+	ctx := context.Background()
+	idp := newFakeIdp(t)
+	uc, _, m := newOidcUC(t, idp)
+
+	start, _ := uc.AuthorizeLogin(ctx, "fake")
+	req := idp.approve(t, start.Body.AuthorizationURL, fakeIdpUser{sub: "idp-7", email: "unverified@test.com", emailVerified: false})
+
+	m.identities.On("FindByProviderSubject", mock.Anything, "fake", "idp-7").Return(model.UserIdentity{}, false, nil)
+
+	_, err := uc.CallbackLogin(ctx, "fake", req, start.StateToken, "UA", "")
+	assert.ErrorIs(t, err, usecase.ErrEmailNotVerified)
+	m.users.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// stateが違う・cookieが無い・nonceが違う => 401
+func TestOidcUsecase_CallbackLogin_RejectsTamperedFlow(t *testing.T) {
+	ctx := context.Background()
+	idp := newFakeIdp(t)
+	uc, _, _ := newOidcUC(t, idp)
+
+	user := fakeIdpUser{sub: "idp-1", email: "a@test.com", emailVerified: true}
+
+	// stateが違う
+	start, _ := uc.AuthorizeLogin(ctx, "fake")
+	req := idp.approve(t, start.Body.AuthorizationURL, user)
+	req.State = "attacker-state"
+	_, err := uc.CallbackLogin(ctx, "fake", req, start.StateToken, "UA", "")
+	assert.ErrorIs(t, err, usecase.ErrUnauthorized)
+
+	// cookie（stateトークン）が無い
+	start, _ = uc.AuthorizeLogin(ctx, "fake")
+	req = idp.approve(t, start.Body.AuthorizationURL, user)
+	_, err = uc.CallbackLogin(ctx, "fake", req, "", "UA", "")
+	assert.ErrorIs(t, err, usecase.ErrUnauthorized)
+
+	// 別のフローのstateトークン（code_verifierが違うのでIdPが拒否）
+	start1, _ := uc.AuthorizeLogin(ctx, "fake")
+	start2, _ := uc.AuthorizeLogin(ctx, "fake")
+	req = idp.approve(t, start1.Body.AuthorizationURL, user)
+	_, err = uc.CallbackLogin(ctx, "fake", req, start2.StateToken, "UA", "")
+	assert.ErrorIs(t, err, usecase.ErrUnauthorized)
+
+	// id_tokenのnonceが違う（リプレイ）
+	idp.overrideNonce = "replayed-nonce"
+	start, _ = uc.AuthorizeLogin(ctx, "fake")
+	req = idp.approve(t, start.Body.AuthorizationURL, user)
+	_, err = uc.CallbackLogin(ctx, "fake", req, start.StateToken, "UA", "")
+	assert.ErrorIs(t, err, usecase.ErrUnauthorized)
+}
+
+func TestOidcUsecase_UnknownProvider(t *testing.T) {
+	idp := newFakeIdp(t)
+	uc, _, _ := newOidcUC(t, idp)
+
+	_, err := uc.AuthorizeLogin(context.Background(), "nope")
+	assert.ErrorIs(t, err, usecase.ErrOidcProviderNotFound)
+	assert.Equal(t, []string{"fake"}, uc.Providers().Providers)
+}
+
+// =====================
+// Link / Unlink
+// =====================
+
+func TestOidcUsecase_Link_Success(t *testing.T) {
+	ctx := context.Background()
+	idp := newFakeIdp(t)
+	uc, _, m := newOidcUC(t, idp)
+
+	user := &model.User{ID: 5, Email: "user@test.com", Role: model.RoleUser, IsActive: true}
+	m.users.On("FindByID", mock.Anything, int64(5)).Return(user, nil)
+
+	start, err := uc.AuthorizeLink(ctx, 5, "fake")
+	assert.NoError(t, err)
+	req := idp.approve(t, start.Body.AuthorizationURL, fakeIdpUser{sub: "idp-55", email: "user@idp.test", emailVerified: false})
+
+	m.identities.On("FindByProviderSubject", mock.Anything, "fake", "idp-55").Return(model.UserIdentity{}, false, nil)
+	m.identities.On("Create", mock.Anything, mock.MatchedBy(func(i *model.UserIdentity) bool {
+		return i.UserID == 5 && i.Subject == "idp-55" && i.Email == "user@idp.test"
+	})).Return(nil)
+
+	dto, err := uc.CallbackLink(ctx, 5, "fake", req, start.StateToken)
+	assert.NoError(t, err)
+	if assert.NotNil(t, dto) {
+		assert.Equal(t, "fake", dto.Provider)
+		assert.Equal(t, "user@idp.test", dto.Email)
+	}
+}
+
+// ログインフローのstateで紐付けはできない／別ユーザーのstateも使えない
+func TestOidcUsecase_Link_RejectsForeignState(t *testing.T) {
+	ctx := context.Background()
+	idp := newFakeIdp(t)
+	uc, _, m := newOidcUC(t, idp)
+
+	m.users.On("FindByID", mock.Anything, int64(5)).Return(&model.User{ID: 5, IsActive: true}, nil)
+	m.users.On("FindByID", mock.Anything, int64(6)).Return(&model.User{ID: 6, IsActive: true}, nil)
+	user := fakeIdpUser{sub: "idp-55", email: "x@test.com", emailVerified: true}
+
+	loginStart, _ := uc.AuthorizeLogin(ctx, "fake")
+	req := idp.approve(t, loginStart.Body.AuthorizationURL, user)
+	_, err := uc.CallbackLink(ctx, 5, "fake", req, loginStart.StateToken)
+	assert.ErrorIs(t, err, usecase.ErrUnauthorized)
+
+	otherStart, _ := uc.AuthorizeLink(ctx, 6, "fake")
+	req = idp.approve(t, otherStart.Body.AuthorizationURL, user)
+	_, err = uc.CallbackLink(ctx, 5, "fake", req, otherStart.StateToken)
+	assert.ErrorIs(t, err, usecase.ErrUnauthorized)
+}
+
+// 別ユーザーに紐付いたIdPアカウント => 409
+func TestOidcUsecase_Link_IdentityInUse(t *testing.T) {
+	ctx := context.Background()
+	idp := newFakeIdp(t)
+	uc, _, m := newOidcUC(t, idp)
+
+	m.users.On("FindByID", mock.Anything, int64(5)).Return(&model.User{ID: 5, IsActive: true}, nil)
+
+	start, _ := uc.AuthorizeLink(ctx, 5, "fake")
+	req := idp.approve(t, start.Body.AuthorizationURL, fakeIdpUser{sub: "idp-77", email: "x@test.com", emailVerified: true})
+	m.identities.On("FindByProviderSubject", mock.Anything, "fake", "idp-77").Return(model.UserIdentity{ID: "ident-77", UserID: 77}, true, nil)
+
+	_, err := uc.CallbackLink(ctx, 5, "fake", req, start.StateToken)
+	assert.ErrorIs(t, err, usecase.ErrOidcIdentityInUse)
+	m.identities.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// パスワード無しユーザーの最後の紐付けは外せない
+func TestOidcUsecase_Unlink(t *testing.T) {
+	ctx := context.Background()
+	idp := newFakeIdp(t)
+	uc, _, m := newOidcUC(t, idp)
+
+	m.users.On("FindByID", mock.Anything, int64(10)).Return(&model.User{ID: 10, PasswordHash: "", IsActive: true}, nil)
+	m.identities.On("ListByUserID", mock.Anything, int64(10)).Return([]model.UserIdentity{{ID: "ident-1", UserID: 10}}, nil)
+
+	_, err := uc.Unlink(ctx, 10, "ident-1")
+	assert.ErrorIs(t, err, usecase.ErrOidcLastLoginMethod)
+
+	_, err = uc.Unlink(ctx, 10, "ident-404")
+	assert.ErrorIs(t, err, usecase.ErrOidcIdentityNotFound)
+
+	// パスワードがあれば外せる
+	m2 := new(MockUserIdentityRepository)
+	users2 := new(MockUserRepository)
+	uc2 := usecase.NewOidcUsecase(config.Config{JWTSecret: "test-secret"},
+		newAuthUC(users2, new(MockRefreshTokenRepository), new(MockAuthValidator)), users2, m2, nil)
+	users2.On("FindByID", mock.Anything, int64(5)).Return(&model.User{ID: 5, PasswordHash: mustHash(t, "pw"), IsActive: true}, nil)
+	m2.On("ListByUserID", mock.Anything, int64(5)).Return([]model.UserIdentity{{ID: "ident-5", UserID: 5}}, nil)
+	m2.On("DeleteByIDForUser", mock.Anything, "ident-5", int64(5)).Return(true, nil)
+
+	res, err := uc2.Unlink(ctx, 5, "ident-5")
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+}
+
+// =====================
+// Handler: cookieの受け渡し
+// =====================
+
+// authorize で oidc_state cookie → callback で refresh / csrf cookie（/auth/login と同じ）
+func TestAuthHandler_OidcFlow_SetsSessionCookies(t *testing.T) {
+	idp := newFakeIdp(t)
+	uc, authUC, m := newOidcUC(t, idp)
+
+	user := &model.User{ID: 5, Email: "user@test.com", Role: model.RoleUser, IsActive: true}
+	m.identities.On("FindByProviderSubject", mock.Anything, "fake", "idp-5").Return(model.UserIdentity{ID: "ident-5", UserID: 5}, true, nil)
+	m.identities.On("TouchLastLogin", mock.Anything, "ident-5", mock.AnythingOfType("time.Time")).Return(nil)
+	m.users.On("FindByID", mock.Anything, int64(5)).Return(user, nil)
+	m.users.On("Update", mock.Anything, user).Return(nil)
+	m.rt.On("Create", mock.Anything, mock.AnythingOfType("model.RefreshToken")).Return(nil)
+
+	e := echo.New()
+	handler.NewAuthHandler(config.Config{JWTSecret: "test-secret"}, authUC, uc, nil, nil, nil, m.users).RegisterRoutes(e)
+
+	// 1) authorize
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/auth/oidc/fake/authorize", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var authz usecase.OidcAuthorizeResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &authz))
+	stateCookie := findCookie(rec.Result().Cookies(), "oidc_state")
+	if !assert.NotNil(t, stateCookie) {
+		return
+	}
+	assert.True(t, stateCookie.HttpOnly)
+
+	// 2) IdPでログイン
+	cb := idp.approve(t, authz.AuthorizationURL, fakeIdpUser{sub: "idp-5", email: "user@test.com", emailVerified: true})
+
+	// 3) callback
+	body, _ := json.Marshal(cb)
+	req := httptest.NewRequest(http.MethodPost, "/auth/oidc/fake/callback", strings.NewReader(string(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.AddCookie(stateCookie)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var login usecase.AuthLoginResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &login))
+	assert.Equal(t, int64(5), login.User.ID)
+	assert.NotEmpty(t, login.Token.AccessToken)
+
+	cookies := rec.Result().Cookies()
+	assert.NotNil(t, findCookie(cookies, "refresh_token"))
+	assert.NotNil(t, findCookie(cookies, "csrf_token"))
+	if cleared := findCookie(cookies, "oidc_state"); assert.NotNil(t, cleared) {
+		assert.Equal(t, "", cleared.Value)
+	}
+
+	// 同じstateは2回使えない（IdPのcodeも1回きり）
+	req = httptest.NewRequest(http.MethodPost, "/auth/oidc/fake/callback", strings.NewReader(string(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.AddCookie(stateCookie)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func findCookie(cookies []*http.Cookie, name string) *http.Cookie {
+	for _, c := range cookies {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package unit
+
+import (
+	"app/internal/domain/model"
+	"app/internal/usecase"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// =====================
+// ChangePassword
+// =====================
+
+// 成功 => hash更新 + tv++ + この端末の系列以外のrefresh削除 + この端末は同じ系列で回転
+func TestAuthUsecase_ChangePassword_Success(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newResetUC()
+
+	user := &model.User{ID: 1, Email: "user@test.com", PasswordHash: mustHash(t, "OldPassword1"), Role: model.RoleUser, TokenVersion: 3, IsActive: true}
+	current := model.RefreshToken{ID: "rt-current", FamilyID: "fam-current", UserID: 1, TokenHash: sessionHash("current-refresh"), ExpiresAt: time.Now().Add(time.Hour), MfaVerified: true}
+
+	m.v.On("ValidateChangePassword", mock.Anything, "OldPassword1", "NewPassword1").Return(nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(user, nil)
+	m.users.On("Update", mock.Anything, user).Return(nil)
+	m.users.On("IncrementTokenVersion", mock.Anything, int64(1)).Return(nil)
+	m.rt.On("FindByHash", mock.Anything, current.TokenHash).Return(current, true, nil)
+	m.rt.On("MarkUsed", mock.Anything, "rt-current").Return(nil)
+	m.rt.On("DeleteByUserIDExceptFamily", mock.Anything, int64(1), "fam-current").Return(int64(2), nil)
+	m.rt.On("Create", mock.Anything, mock.MatchedBy(func(rt model.RefreshToken) bool {
+		return rt.UserID == 1 && rt.ID != "rt-current" && rt.FamilyID == "fam-current" && rt.MfaVerified && rt.UserAgent == "UA"
+	})).Return(nil)
+
+	res, err := uc.ChangePassword(ctx, 1, usecase.ChangePasswordRequest{CurrentPassword: "OldPassword1", NewPassword: "NewPassword1"}, "current-refresh", "UA", "10.0.0.1")
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) {
+		assert.NotEmpty(t, res.Body.AccessToken)
+		assert.Equal(t, 4, res.Body.TokenVersion)
+		assert.NotEmpty(t, res.RefreshTokenPlain)
+		assert.NotEqual(t, "current-refresh", res.RefreshTokenPlain)
+		assert.NotEmpty(t, res.CsrfTokenPlain)
+	}
+
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("NewPassword1")))
+
+	m.users.AssertExpectations(t)
+	m.rt.AssertExpectations(t)
+}
+
+// refresh cookieが他人・期限切れ・使用済み => 全部消して新しい系列で発行
+func TestAuthUsecase_ChangePassword_UnusableRefresh_DeletesAll(t *testing.T) {
+	used := time.Now().Add(-time.Minute)
+	cases := map[string]model.RefreshToken{
+		"foreign": {ID: "rt-other-user", FamilyID: "fam-other", UserID: 2, ExpiresAt: time.Now().Add(time.Hour)},
+		"expired": {ID: "rt-expired", FamilyID: "fam-expired", UserID: 1, ExpiresAt: time.Now().Add(-time.Minute)},
+		"used":    {ID: "rt-used", FamilyID: "fam-used", UserID: 1, ExpiresAt: time.Now().Add(time.Hour), UsedAt: &used},
+	}
+	for name, rt := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			uc, m := newResetUC()
+
+			user := &model.User{ID: 1, Email: "user@test.com", PasswordHash: mustHash(t, "OldPassword1"), IsActive: true}
+
+			m.v.On("ValidateChangePassword", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			m.users.On("FindByID", mock.Anything, int64(1)).Return(user, nil)
+			m.users.On("Update", mock.Anything, user).Return(nil)
+			m.users.On("IncrementTokenVersion", mock.Anything, int64(1)).Return(nil)
+			m.rt.On("FindByHash", mock.Anything, mock.Anything).Return(rt, true, nil)
+			m.rt.On("DeleteByUserIDExceptFamily", mock.Anything, int64(1), "").Return(int64(3), nil)
+			m.rt.On("Create", mock.Anything, mock.MatchedBy(func(n model.RefreshToken) bool {
+				return n.FamilyID == n.ID
+			})).Return(nil)
+
+			_, err := uc.ChangePassword(ctx, 1, usecase.ChangePasswordRequest{CurrentPassword: "OldPassword1", NewPassword: "NewPassword1"}, "unusable-refresh", "UA", "")
+			assert.NoError(t, err)
+
+			m.rt.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything)
+			m.rt.AssertExpectations(t)
+		})
+	}
+}
+
+// 同時にrefreshされて使用済みにできなかった => その系列も残さない
+func TestAuthUsecase_ChangePassword_ConcurrentRefresh_DeletesAll(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newResetUC()
+
+	user := &model.User{ID: 1, Email: "user@test.com", PasswordHash: mustHash(t, "OldPassword1"), IsActive: true}
+	current := model.RefreshToken{ID: "rt-current", FamilyID: "fam-current", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+
+	m.v.On("ValidateChangePassword", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(user, nil)
+	m.users.On("Update", mock.Anything, user).Return(nil)
+	m.users.On("IncrementTokenVersion", mock.Anything, int64(1)).Return(nil)
+	m.rt.On("FindByHash", mock.Anything, mock.Anything).Return(current, true, nil)
+	m.rt.On("MarkUsed", mock.Anything, "rt-current").Return(assert.AnError)
+	m.rt.On("DeleteByUserIDExceptFamily", mock.Anything, int64(1), "").Return(int64(2), nil)
+	m.rt.On("Create", mock.Anything, mock.AnythingOfType("model.RefreshToken")).Return(nil)
+
+	_, err := uc.ChangePassword(ctx, 1, usecase.ChangePasswordRequest{CurrentPassword: "OldPassword1", NewPassword: "NewPassword1"}, "current-refresh", "UA", "")
+	assert.NoError(t, err)
+	m.rt.AssertExpectations(t)
+}
+
+// 現在のパスワード違い => 401、何も変えない
+func TestAuthUsecase_ChangePassword_WrongCurrentPassword(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newResetUC()
+
+	oldHash := mustHash(t, "OldPassword1")
+	user := &model.User{ID: 1, Email: "user@test.com", PasswordHash: oldHash, IsActive: true}
+
+	m.v.On("ValidateChangePassword", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(user, nil)
+
+	_, err := uc.ChangePassword(ctx, 1, usecase.ChangePasswordRequest{CurrentPassword: "WrongPassword", NewPassword: "NewPassword1"}, "", "UA", "")
+	assert.ErrorIs(t, err, usecase.ErrUnauthorized)
+	assert.Equal(t, oldHash, user.PasswordHash)
+
+	m.users.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	m.users.AssertNotCalled(t, "IncrementTokenVersion", mock.Anything, mock.Anything)
+	m.rt.AssertNotCalled(t, "DeleteByUserIDExceptFamily", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// 入力エラー（パスワードのルール違反）はvalidatorの結果をそのまま返す
+func TestAuthUsecase_ChangePassword_ValidationError(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newResetUC()
+
+	m.v.On("ValidateChangePassword", mock.Anything, "OldPassword1", "short").Return(usecase.ErrValidation)
+
+	_, err := uc.ChangePassword(ctx, 1, usecase.ChangePasswordRequest{CurrentPassword: "OldPassword1", NewPassword: "short"}, "", "UA", "")
+	assert.ErrorIs(t, err, usecase.ErrValidation)
+	m.users.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+}
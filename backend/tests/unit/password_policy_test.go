@@ -0,0 +1,273 @@
+package unit
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/handler"
+	infrarepo "app/internal/infra/repository"
+	"app/internal/security"
+	"app/internal/usecase"
+	"app/internal/validator"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// テスト用に軽いArgon2idのパラメータ
+var testArgon2Params = security.Argon2Params{MemoryKiB: 8 * 1024, Iterations: 1, Parallelism: 1}
+
+func sha1Upper(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// =====================
+// PasswordHasher
+// =====================
+
+func TestPasswordHasher_Bcrypt(t *testing.T) {
+	h := security.NewPasswordHasher(security.PasswordAlgoBcrypt, bcrypt.MinCost, testArgon2Params)
+
+	hash, err := h.Hash("CorrectPW123")
+	assert.NoError(t, err)
+
+	ok, err := h.Verify(hash, "CorrectPW123")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = h.Verify(hash, "WrongPW123")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.False(t, h.NeedsRehash(hash))
+	//コストを上げたら作り直し対象
+	assert.True(t, security.NewPasswordHasher(security.PasswordAlgoBcrypt, bcrypt.MinCost+1, testArgon2Params).NeedsRehash(hash))
+}
+
+func TestPasswordHasher_Argon2id(t *testing.T) {
+	h := security.NewPasswordHasher(security.PasswordAlgoArgon2id, 0, testArgon2Params)
+
+	hash, err := h.Hash("CorrectPW123")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=8192,t=1,p=1$"))
+
+	ok, err := h.Verify(hash, "CorrectPW123")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = h.Verify(hash, "WrongPW123")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.False(t, h.NeedsRehash(hash))
+	//パラメータが変わった・bcryptのままなら作り直し対象
+	stronger := security.NewPasswordHasher(security.PasswordAlgoArgon2id, 0, security.Argon2Params{MemoryKiB: 16 * 1024, Iterations: 1, Parallelism: 1})
+	assert.True(t, stronger.NeedsRehash(hash))
+	bcryptHash, _ := security.NewPasswordHasher(security.PasswordAlgoBcrypt, bcrypt.MinCost, testArgon2Params).Hash("CorrectPW123")
+	assert.True(t, h.NeedsRehash(bcryptHash))
+
+	//別形式のハッシュもアルゴリズムに関係なく照合できる
+	ok, err = h.Verify(bcryptHash, "CorrectPW123")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestPasswordHasher_UnknownFormat(t *testing.T) {
+	h := security.NewPasswordHasher(security.PasswordAlgoBcrypt, bcrypt.MinCost, testArgon2Params)
+
+	for _, hash := range []string{"plain", "$argon2id$v=19$m=0,t=1,p=1$AAAA$AAAA", "$argon2id$broken"} {
+		ok, err := h.Verify(hash, "x")
+		assert.False(t, ok)
+		assert.ErrorIs(t, err, security.ErrUnknownPasswordHash)
+	}
+}
+
+// =====================
+// BreachedPasswords
+// =====================
+
+func TestBreachedPasswords_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	content := "# comment\n" + sha1Upper("password123") + ":12345\n" + strings.ToLower(sha1Upper("letmein!")) + "\n"
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	b, err := security.LoadBreachedPasswords(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, b.Len())
+	assert.True(t, b.Contains("password123"))
+	assert.True(t, b.Contains("letmein!"))
+	assert.False(t, b.Contains("CorrectPW123"))
+}
+
+// rangeごとのファイル（<先頭5文字>.txt に 末尾35文字:件数）
+func TestBreachedPasswords_RangeDir(t *testing.T) {
+	dir := t.TempDir()
+	hash := sha1Upper("password123")
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(hash[5:]+":3\r\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0o600))
+
+	b, err := security.LoadBreachedPasswords(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, b.Len())
+	assert.True(t, b.Contains("password123"))
+	assert.False(t, b.Contains("password1234"))
+}
+
+func TestBreachedPasswords_InvalidLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("not-a-hash:1\n"), 0o600))
+
+	_, err := security.LoadBreachedPasswords(path)
+	assert.Error(t, err)
+}
+
+// =====================
+// Validator
+// =====================
+
+type fakeBreachedChecker map[string]bool
+
+func (f fakeBreachedChecker) Contains(password string) bool { return f[password] }
+
+func TestAuthValidator_PasswordRule(t *testing.T) {
+	users := new(MockUserRepository)
+	users.On("FindByEmail", mock.Anything, mock.Anything).Return((*model.User)(nil), nil)
+	v := validator.NewAuthValidator(users, fakeBreachedChecker{"password123": true})
+
+	cases := []struct {
+		name     string
+		password string
+		wantCode string
+	}{
+		{"ok", "CorrectPW123", ""},
+		{"too short", "short", validator.PasswordTooShort},
+		{"too long", strings.Repeat("a", 73), validator.PasswordTooLong},
+		{"breached", "password123", validator.PasswordBreached},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := v.ValidateRegister(context.Background(), "user@test.com", tc.password)
+			if tc.wantCode == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var pwErr *validator.PasswordRuleError
+			if assert.True(t, errors.As(err, &pwErr)) {
+				assert.Equal(t, tc.wantCode, pwErr.Code)
+			}
+			assert.ErrorIs(t, err, validator.ErrInvalidInput)
+
+			//再設定・変更も同じルール
+			var resetErr *validator.PasswordRuleError
+			assert.True(t, errors.As(v.ValidateResetPassword(context.Background(), "token", tc.password), &resetErr))
+			var changeErr *validator.PasswordRuleError
+			assert.True(t, errors.As(v.ValidateChangePassword(context.Background(), "OldPassword1", tc.password), &changeErr))
+		})
+	}
+}
+
+// =====================
+// Login（古いハッシュの作り直し）
+// =====================
+
+func TestAuthUsecase_Login_RehashesOutdatedHash(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	rtRepo := new(MockRefreshTokenRepository)
+	v := new(MockAuthValidator)
+
+	oldHash, _ := bcrypt.GenerateFromPassword([]byte("CorrectPW"), bcrypt.MinCost)
+	user := &model.User{ID: 1, Email: "user@test.com", PasswordHash: string(oldHash), Role: model.RoleUser, IsActive: true}
+
+	rtRepo.On("DeleteExpired", mock.Anything, mock.Anything).Return(int64(0), nil)
+	rtRepo.On("Create", mock.Anything, mock.AnythingOfType("model.RefreshToken")).Return(nil)
+	v.On("ValidateLogin", mock.Anything, "user@test.com", "CorrectPW").Return(nil)
+	userRepo.On("FindByEmail", mock.Anything, "user@test.com").Return(user, nil)
+	userRepo.On("Update", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
+
+	cfg := config.Config{
+		JWTSecret:                 "test-secret",
+		PasswordHashAlgo:          security.PasswordAlgoArgon2id,
+		PasswordArgon2MemoryKiB:   int(testArgon2Params.MemoryKiB),
+		PasswordArgon2Iterations:  int(testArgon2Params.Iterations),
+		PasswordArgon2Parallelism: int(testArgon2Params.Parallelism),
+	}
+	uc := usecase.NewAuthUsecase(cfg, userRepo, rtRepo, v, new(MockPasswordResetTokenRepository), new(MockEmailVerificationTokenRepository), new(MockMfaRecoveryCodeRepository), infrarepo.NewLoginAttemptMemoryRepository(), new(MockMailer), new(fakeSecurityEventRepository))
+
+	_, err := uc.Login(context.Background(), usecase.AuthLoginRequest{Email: "user@test.com", Password: "CorrectPW"}, "UA", "")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(user.PasswordHash, "$argon2id$"))
+
+	//作り直したハッシュでもログインできる
+	ok, err := cfg.PasswordHasher().Verify(user.PasswordHash, "CorrectPW")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// 今の設定と同じハッシュなら保存し直さない（last_loginの1回だけ）
+func TestAuthUsecase_Login_KeepsCurrentHash(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	rtRepo := new(MockRefreshTokenRepository)
+	v := new(MockAuthValidator)
+
+	hash := mustHash(t, "CorrectPW")
+	user := &model.User{ID: 1, Email: "user@test.com", PasswordHash: hash, Role: model.RoleUser, IsActive: true}
+
+	rtRepo.On("DeleteExpired", mock.Anything, mock.Anything).Return(int64(0), nil)
+	rtRepo.On("Create", mock.Anything, mock.AnythingOfType("model.RefreshToken")).Return(nil)
+	v.On("ValidateLogin", mock.Anything, "user@test.com", "CorrectPW").Return(nil)
+	userRepo.On("FindByEmail", mock.Anything, "user@test.com").Return(user, nil)
+	userRepo.On("Update", mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
+
+	_, err := newAuthUC(userRepo, rtRepo, v).Login(context.Background(), usecase.AuthLoginRequest{Email: "user@test.com", Password: "CorrectPW"}, "UA", "")
+	assert.NoError(t, err)
+	assert.Equal(t, hash, user.PasswordHash)
+	userRepo.AssertNumberOfCalls(t, "Update", 1)
+}
+
+// =====================
+// Handler（code + 言語ごとの文言）
+// =====================
+
+func TestAuthHandler_Register_PasswordRuleError(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	v := new(MockAuthValidator)
+	v.On("ValidateRegister", mock.Anything, "user@test.com", "password123").
+		Return(&validator.PasswordRuleError{Code: validator.PasswordBreached})
+
+	e := echo.New()
+	handler.NewAuthHandler(config.Config{JWTSecret: "test-secret"}, newAuthUC(userRepo, new(MockRefreshTokenRepository), v), nil, nil, nil, nil, userRepo).RegisterRoutes(e)
+
+	send := func(lang string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(`{"email":"user@test.com","password":"password123"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if lang != "" {
+			req.Header.Set("Accept-Language", lang)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"code":"password_breached"`)
+	assert.Contains(t, rec.Body.String(), `"error":"invalid input"`)
+	assert.Contains(t, rec.Body.String(), "データ流出")
+
+	rec = send("en-US,en;q=0.9,ja;q=0.8")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "data breach")
+}
@@ -0,0 +1,255 @@
+package unit
+
+import (
+	"app/internal/config"
+	"app/internal/domain/model"
+	infrarepo "app/internal/infra/repository"
+	"app/internal/usecase"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// =====================
+// Mock: PasswordResetTokenRepository
+// =====================
+
+type MockPasswordResetTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetTokenRepository) Create(ctx context.Context, token model.PasswordResetToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetTokenRepository) FindByHash(ctx context.Context, tokenHash string) (model.PasswordResetToken, bool, error) {
+	args := m.Called(ctx, tokenHash)
+	t, _ := args.Get(0).(model.PasswordResetToken)
+	return t, args.Bool(1), args.Error(2)
+}
+
+func (m *MockPasswordResetTokenRepository) MarkUsed(ctx context.Context, tokenID string) error {
+	args := m.Called(ctx, tokenID)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetTokenRepository) DeleteByUserID(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetTokenRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// =====================
+// Mock: Mailer
+// =====================
+
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(ctx context.Context, msg usecase.MailMessage) error {
+	args := m.Called(ctx, msg)
+	return args.Error(0)
+}
+
+// =====================
+// Helper
+// =====================
+
+type resetMocks struct {
+	users  *MockUserRepository
+	rt     *MockRefreshTokenRepository
+	v      *MockAuthValidator
+	resets *MockPasswordResetTokenRepository
+	mailer *MockMailer
+}
+
+func newResetUC() (*usecase.AuthUsecase, resetMocks) {
+	m := resetMocks{
+		users:  new(MockUserRepository),
+		rt:     new(MockRefreshTokenRepository),
+		v:      new(MockAuthValidator),
+		resets: new(MockPasswordResetTokenRepository),
+		mailer: new(MockMailer),
+	}
+	cfg := config.Config{JWTSecret: "test-secret", FEURL: "http://localhost:3000"}
+	return usecase.NewAuthUsecase(cfg, m.users, m.rt, m.v, m.resets, new(MockEmailVerificationTokenRepository), new(MockMfaRecoveryCodeRepository), infrarepo.NewLoginAttemptMemoryRepository(), m.mailer, new(fakeSecurityEventRepository)), m
+}
+
+// =====================
+// ForgotPassword
+// =====================
+
+// 登録済み => トークン保存 + メール送信（リンクに平文トークン）
+func TestAuthUsecase_ForgotPassword_SendsMail(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newResetUC()
+
+	email := "user@test.com"
+
+	m.v.On("ValidateForgotPassword", mock.Anything, email).Return(nil)
+	m.resets.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	m.users.On("FindByEmail", mock.Anything, email).Return(&model.User{ID: 1, Email: email, IsActive: true}, nil)
+	m.resets.On("DeleteByUserID", mock.Anything, int64(1)).Return(nil)
+
+	var saved model.PasswordResetToken
+	m.resets.On("Create", mock.Anything, mock.AnythingOfType("model.PasswordResetToken")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(model.PasswordResetToken) }).
+		Return(nil)
+
+	var sent usecase.MailMessage
+	m.mailer.On("Send", mock.Anything, mock.AnythingOfType("usecase.MailMessage")).
+		Run(func(args mock.Arguments) { sent = args.Get(1).(usecase.MailMessage) }).
+		Return(nil)
+
+	res, err := uc.ForgotPassword(ctx, usecase.ForgotPasswordRequest{Email: email})
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+
+	// 保存されるのはhashだけ、期限は未来
+	assert.Equal(t, int64(1), saved.UserID)
+	assert.NotEmpty(t, saved.TokenHash)
+	assert.True(t, saved.ExpiresAt.After(time.Now()))
+
+	// メールの宛先とリンク
+	assert.Equal(t, email, sent.To)
+	assert.True(t, strings.Contains(sent.Body, "http://localhost:3000/password/reset?token="))
+	assert.False(t, strings.Contains(sent.Body, saved.TokenHash))
+
+	m.users.AssertExpectations(t)
+	m.resets.AssertExpectations(t)
+	m.mailer.AssertExpectations(t)
+}
+
+// 未登録 => 同じレスポンス、メールは送らない
+func TestAuthUsecase_ForgotPassword_UnknownEmail_SameResponse(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newResetUC()
+
+	email := "nobody@test.com"
+
+	m.v.On("ValidateForgotPassword", mock.Anything, email).Return(nil)
+	m.resets.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	m.users.On("FindByEmail", mock.Anything, email).Return(nil, nil)
+
+	res, err := uc.ForgotPassword(ctx, usecase.ForgotPasswordRequest{Email: email})
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+
+	m.resets.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	m.mailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+// =====================
+// ResetPassword
+// =====================
+
+// 正常 => パスワード更新 + token_version+1 + refresh全削除
+func TestAuthUsecase_ResetPassword_Success_RevokesSessions(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newResetUC()
+
+	plain := "reset-plain"
+	newPW := "NewPassword1"
+
+	m.v.On("ValidateResetPassword", mock.Anything, plain, newPW).Return(nil)
+	m.resets.On("FindByHash", mock.Anything, mock.AnythingOfType("string")).Return(model.PasswordResetToken{
+		ID:        "prt-1",
+		UserID:    1,
+		ExpiresAt: time.Now().Add(10 * time.Minute),
+	}, true, nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(&model.User{
+		ID:           1,
+		Email:        "user@test.com",
+		PasswordHash: mustHash(t, "OldPassword1"),
+		IsActive:     true,
+	}, nil)
+	m.resets.On("MarkUsed", mock.Anything, "prt-1").Return(nil)
+	m.users.On("Update", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+		return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(newPW)) == nil
+	})).Return(nil)
+	m.users.On("IncrementTokenVersion", mock.Anything, int64(1)).Return(nil)
+	m.rt.On("DeleteByUserID", mock.Anything, int64(1)).Return(nil)
+	m.resets.On("DeleteByUserID", mock.Anything, int64(1)).Return(nil)
+
+	res, err := uc.ResetPassword(ctx, usecase.ResetPasswordRequest{Token: plain, NewPassword: newPW})
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+
+	m.users.AssertExpectations(t)
+	m.rt.AssertExpectations(t)
+	m.resets.AssertExpectations(t)
+}
+
+// 使用済み => ErrInvalidToken（パスワードは変わらない）
+func TestAuthUsecase_ResetPassword_UsedToken(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newResetUC()
+
+	usedAt := time.Now().Add(-1 * time.Minute)
+
+	m.v.On("ValidateResetPassword", mock.Anything, "used", "NewPassword1").Return(nil)
+	m.resets.On("FindByHash", mock.Anything, mock.AnythingOfType("string")).Return(model.PasswordResetToken{
+		ID:        "prt-used",
+		UserID:    1,
+		ExpiresAt: time.Now().Add(10 * time.Minute),
+		UsedAt:    &usedAt,
+	}, true, nil)
+
+	res, err := uc.ResetPassword(ctx, usecase.ResetPasswordRequest{Token: "used", NewPassword: "NewPassword1"})
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, usecase.ErrInvalidToken)
+
+	m.users.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	m.rt.AssertNotCalled(t, "DeleteByUserID", mock.Anything, mock.Anything)
+}
+
+// 期限切れ => ErrInvalidToken
+func TestAuthUsecase_ResetPassword_ExpiredToken(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newResetUC()
+
+	m.v.On("ValidateResetPassword", mock.Anything, "expired", "NewPassword1").Return(nil)
+	m.resets.On("FindByHash", mock.Anything, mock.AnythingOfType("string")).Return(model.PasswordResetToken{
+		ID:        "prt-exp",
+		UserID:    1,
+		ExpiresAt: time.Now().Add(-1 * time.Minute),
+	}, true, nil)
+
+	res, err := uc.ResetPassword(ctx, usecase.ResetPasswordRequest{Token: "expired", NewPassword: "NewPassword1"})
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, usecase.ErrInvalidToken)
+
+	m.resets.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything)
+}
+
+// 同時リクエストで先に使われた => ErrInvalidToken
+func TestAuthUsecase_ResetPassword_MarkUsedRace(t *testing.T) {
+	ctx := context.Background()
+	uc, m := newResetUC()
+
+	m.v.On("ValidateResetPassword", mock.Anything, "race", "NewPassword1").Return(nil)
+	m.resets.On("FindByHash", mock.Anything, mock.AnythingOfType("string")).Return(model.PasswordResetToken{
+		ID:        "prt-race",
+		UserID:    1,
+		ExpiresAt: time.Now().Add(10 * time.Minute),
+	}, true, nil)
+	m.users.On("FindByID", mock.Anything, int64(1)).Return(&model.User{ID: 1, IsActive: true}, nil)
+	m.resets.On("MarkUsed", mock.Anything, "prt-race").Return(assert.AnError)
+
+	res, err := uc.ResetPassword(ctx, usecase.ResetPasswordRequest{Token: "race", NewPassword: "NewPassword1"})
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, usecase.ErrInvalidToken)
+
+	m.users.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
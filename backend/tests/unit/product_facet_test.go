@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+	"app/internal/usecase"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func int64Ptr(v int64) *int64 { return &v }
+
+// =====================
+// GET /products?facets=true
+// =====================
+
+func TestProductUsecase_ListPublicProducts_FacetsUseSameFilters(t *testing.T) {
+	pRepo := new(ProdProductRepoMock)
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), newFakeCategoryRepo(sampleCategories()...), nil, newFakeImageRepo(), newFakeFileStorage())
+
+	//一覧と同じ条件（q・価格帯・カテゴリ）でファセットを数える
+	q := repo.ProductListQuery{Page: 1, Limit: 20, Q: "coffee", MinPrice: int64Ptr(500), MaxPrice: int64Ptr(8000), CategoryIDs: []int64{1, 2, 4, 3}}
+	pRepo.On("ListPublic", mock.Anything, q).Return([]model.Product{{ID: 1}}, int64(6), nil)
+	pRepo.On("FacetPublic", mock.Anything, q, []int64{1000, 3000, 5000, 10000}).Return(repo.ProductFacetCounts{
+		PriceBuckets: []int64{1, 3, 2, 0, 0},
+		InStock:      5,
+		OutOfStock:   1,
+		Categories: []repo.CategoryFacetCount{
+			{CategoryID: 3, Count: 2},
+			{CategoryID: 1, Count: 6},
+			{CategoryID: 2, Count: 2},
+			{CategoryID: 4, Count: 4},
+		},
+		Attributes: []repo.AttributeFacetCount{
+			{Name: "サイズ", Value: "S", Count: 1},
+			{Name: "サイズ", Value: "M", Count: 2},
+			{Name: "色", Value: "白", Count: 2},
+		},
+	}, nil)
+
+	out, err := uc.ListPublicProducts(context.Background(), usecase.ListProductsInput{
+		Page: 1, Limit: 20, Q: "coffee", MinPrice: int64Ptr(500), MaxPrice: int64Ptr(8000), Category: "food", Facets: true,
+	})
+	assert.NoError(t, err)
+	pRepo.AssertExpectations(t)
+
+	f := out.Facets
+	if !assert.NotNil(t, f) {
+		return
+	}
+
+	//価格帯：先頭は下限なし、末尾は上限なし
+	if assert.Len(t, f.Price, 5) {
+		assert.Nil(t, f.Price[0].Min)
+		assert.Equal(t, int64(1000), *f.Price[0].Max)
+		assert.Equal(t, int64(1000), *f.Price[1].Min)
+		assert.Equal(t, int64(3000), *f.Price[1].Max)
+		assert.Equal(t, int64(3), f.Price[1].Count)
+		assert.Equal(t, int64(10000), *f.Price[4].Min)
+		assert.Nil(t, f.Price[4].Max)
+	}
+
+	assert.Equal(t, usecase.StockFacet{InStock: 5, OutOfStock: 1}, f.Stock)
+
+	//カテゴリツリーの並び。0件（雑貨）は出さない
+	slugs := []string{}
+	for _, c := range f.Categories {
+		slugs = append(slugs, c.Slug)
+	}
+	assert.Equal(t, []string{"food", "drinks", "coffee", "sweets"}, slugs)
+	assert.Equal(t, int64(1), *f.Categories[1].ParentID)
+
+	//軸ごとにまとめる
+	assert.Equal(t, []usecase.AttributeFacet{
+		{Name: "サイズ", Values: []usecase.AttributeValueFacet{{Value: "S", Count: 1}, {Value: "M", Count: 2}}},
+		{Name: "色", Values: []usecase.AttributeValueFacet{{Value: "白", Count: 2}}},
+	}, f.Attributes)
+}
+
+func TestProductUsecase_ListPublicProducts_FacetsEmptyResult(t *testing.T) {
+	pRepo := new(ProdProductRepoMock)
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, newFakeImageRepo(), newFakeFileStorage())
+
+	q := repo.ProductListQuery{Page: 1, Limit: 20, Q: "none"}
+	pRepo.On("ListPublic", mock.Anything, q).Return([]model.Product{}, int64(0), nil)
+	pRepo.On("FacetPublic", mock.Anything, q, mock.Anything).Return(repo.ProductFacetCounts{PriceBuckets: []int64{0, 0, 0, 0, 0}}, nil)
+
+	out, err := uc.ListPublicProducts(context.Background(), usecase.ListProductsInput{Page: 1, Limit: 20, Q: "none", Facets: true})
+	assert.NoError(t, err)
+	//件数が無くても配列は空で返す（カテゴリの読み込みもしない）
+	assert.Len(t, out.Facets.Price, 5)
+	assert.NotNil(t, out.Facets.Categories)
+	assert.Empty(t, out.Facets.Categories)
+	assert.NotNil(t, out.Facets.Attributes)
+}
+
+func TestProductUsecase_ListPublicProducts_NoFacetsByDefault(t *testing.T) {
+	pRepo := new(ProdProductRepoMock)
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, newFakeImageRepo(), newFakeFileStorage())
+
+	q := repo.ProductListQuery{Page: 1, Limit: 20}
+	pRepo.On("ListPublic", mock.Anything, q).Return([]model.Product{}, int64(0), nil)
+
+	out, err := uc.ListPublicProducts(context.Background(), usecase.ListProductsInput{Page: 1, Limit: 20})
+	assert.NoError(t, err)
+	assert.Nil(t, out.Facets)
+	pRepo.AssertNotCalled(t, "FacetPublic", mock.Anything, mock.Anything, mock.Anything)
+}
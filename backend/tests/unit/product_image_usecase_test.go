@@ -0,0 +1,393 @@
+package unit
+
+import (
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/imaging"
+	repo "app/internal/repository"
+	"app/internal/usecase"
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =====================
+// Fake（メモリ上の画像メタデータとストレージ）
+// =====================
+
+type fakeImageRepo struct {
+	items  []model.ProductImage
+	nextID int64
+}
+
+func newFakeImageRepo(items ...model.ProductImage) *fakeImageRepo {
+	return &fakeImageRepo{items: items, nextID: 100}
+}
+
+func (r *fakeImageRepo) Create(ctx context.Context, img model.ProductImage) (model.ProductImage, error) {
+	r.nextID++
+	img.ID = r.nextID
+	r.items = append(r.items, img)
+	return img, nil
+}
+
+func (r *fakeImageRepo) FindByID(ctx context.Context, imageID int64) (model.ProductImage, error) {
+	for _, img := range r.items {
+		if img.ID == imageID {
+			return img, nil
+		}
+	}
+	return model.ProductImage{}, repo.ErrNotFound
+}
+
+func (r *fakeImageRepo) ListByProductID(ctx context.Context, productID int64) ([]model.ProductImage, error) {
+	return r.ListByProductIDs(ctx, []int64{productID})
+}
+
+func (r *fakeImageRepo) ListByProductIDs(ctx context.Context, productIDs []int64) ([]model.ProductImage, error) {
+	want := map[int64]bool{}
+	for _, id := range productIDs {
+		want[id] = true
+	}
+	var out []model.ProductImage
+	for _, img := range r.items {
+		if want[img.ProductID] {
+			out = append(out, img)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].ProductID != out[j].ProductID {
+			return out[i].ProductID < out[j].ProductID
+		}
+		return out[i].Position < out[j].Position
+	})
+	return out, nil
+}
+
+func (r *fakeImageRepo) UpdatePositions(ctx context.Context, productID int64, imageIDs []int64) error {
+	for pos, id := range imageIDs {
+		for i := range r.items {
+			if r.items[i].ID == id && r.items[i].ProductID == productID {
+				r.items[i].Position = pos
+			}
+		}
+	}
+	return nil
+}
+
+func (r *fakeImageRepo) Delete(ctx context.Context, imageID int64) error {
+	for i, img := range r.items {
+		if img.ID == imageID {
+			r.items = append(r.items[:i], r.items[i+1:]...)
+			return nil
+		}
+	}
+	return repo.ErrNotFound
+}
+
+type fakeFileStorage struct {
+	files        map[string][]byte
+	contentTypes map[string]string
+}
+
+func newFakeFileStorage() *fakeFileStorage {
+	return &fakeFileStorage{files: map[string][]byte{}, contentTypes: map[string]string{}}
+}
+
+func (s *fakeFileStorage) Put(ctx context.Context, key string, contentType string, data []byte) error {
+	s.files[key] = data
+	s.contentTypes[key] = contentType
+	return nil
+}
+
+func (s *fakeFileStorage) Delete(ctx context.Context, key string) error {
+	delete(s.files, key)
+	return nil
+}
+
+func (s *fakeFileStorage) URL(key string) string {
+	return "https://cdn.example.com/" + key
+}
+
+func pngBytes(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newImageUC(maxBytes, maxPerProduct int) (*usecase.ProductImageUsecase, *ProdProductRepoMock, *fakeImageRepo, *fakeFileStorage) {
+	pRepo := new(ProdProductRepoMock)
+	pRepo.On("FindByID", mock.Anything, int64(1)).Return(model.Product{ID: 1}, nil).Maybe()
+	pRepo.On("FindByID", mock.Anything, int64(99)).Return(model.Product{}, repo.ErrNotFound).Maybe()
+
+	iRepo := newFakeImageRepo()
+	st := newFakeFileStorage()
+	cfg := config.Config{ProductImageMaxBytes: maxBytes, ProductImageMaxPerProduct: maxPerProduct}
+	return usecase.NewProductImageUsecase(cfg, pRepo, iRepo, st), pRepo, iRepo, st
+}
+
+// =====================
+// Upload
+// =====================
+
+func TestProductImageUsecase_AdminUpload_StoresOriginalAndThumbnails(t *testing.T) {
+	uc, _, iRepo, st := newImageUC(5<<20, 10)
+	data := pngBytes(t, 1200, 600)
+
+	out, err := uc.AdminUpload(context.Background(), 1, 1, data)
+	assert.NoError(t, err)
+	assert.Equal(t, 1200, out.Width)
+	assert.Equal(t, 600, out.Height)
+	assert.Equal(t, 0, out.Position)
+	assert.True(t, strings.HasPrefix(out.URL, "https://cdn.example.com/products/1/"))
+	assert.True(t, strings.HasSuffix(out.URL, "/original.png"))
+	assert.Len(t, out.Thumbnails, 3)
+
+	//原寸はそのまま、縮小版は長辺に合わせて縦横比を保つ
+	assert.Len(t, st.files, 4)
+	assert.Equal(t, data, st.files[strings.TrimPrefix(out.URL, "https://cdn.example.com/")])
+	small := st.files[strings.TrimPrefix(out.Thumbnails["small"], "https://cdn.example.com/")]
+	cfg, err := png.DecodeConfig(bytes.NewReader(small))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 160, cfg.Width)
+		assert.Equal(t, 80, cfg.Height)
+	}
+	//mediumはlargeから縮小しても縦横比が崩れない
+	medium := st.files[strings.TrimPrefix(out.Thumbnails["medium"], "https://cdn.example.com/")]
+	cfg, err = png.DecodeConfig(bytes.NewReader(medium))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 480, cfg.Width)
+		assert.Equal(t, 240, cfg.Height)
+	}
+	//元より大きい縮小版は作らない（拡大しない）
+	large := st.files[strings.TrimPrefix(out.Thumbnails["large"], "https://cdn.example.com/")]
+	cfg, err = png.DecodeConfig(bytes.NewReader(large))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1024, cfg.Width)
+		assert.Equal(t, 512, cfg.Height)
+	}
+
+	//2枚目は末尾に並ぶ
+	out2, err := uc.AdminUpload(context.Background(), 1, 1, pngBytes(t, 10, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, out2.Position)
+	assert.Len(t, iRepo.items, 2)
+}
+
+func TestProductImageUsecase_AdminUpload_SniffsContentNotExtension(t *testing.T) {
+	uc, _, iRepo, st := newImageUC(5<<20, 10)
+
+	_, err := uc.AdminUpload(context.Background(), 1, 1, []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	he, ok := usecase.AsHTTPError(err)
+	if assert.True(t, ok) {
+		assert.Equal(t, 415, he.Status)
+	}
+	assert.Empty(t, iRepo.items)
+	assert.Empty(t, st.files)
+}
+
+func TestProductImageUsecase_AdminUpload_InvalidImage(t *testing.T) {
+	uc, _, _, st := newImageUC(5<<20, 10)
+
+	//PNGの先頭だけ合っていて中身が壊れている
+	data := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 64)...)
+	_, err := uc.AdminUpload(context.Background(), 1, 1, data)
+	assertErrContains(t, err, "invalid image")
+	assert.Empty(t, st.files)
+}
+
+func TestProductImageUsecase_AdminUpload_TooLarge(t *testing.T) {
+	uc, _, _, _ := newImageUC(100, 10)
+
+	_, err := uc.AdminUpload(context.Background(), 1, 1, pngBytes(t, 50, 50))
+	he, ok := usecase.AsHTTPError(err)
+	if assert.True(t, ok) {
+		assert.Equal(t, 413, he.Status)
+	}
+}
+
+func TestProductImageUsecase_AdminUpload_TooManyImages(t *testing.T) {
+	uc, _, _, _ := newImageUC(5<<20, 1)
+
+	_, err := uc.AdminUpload(context.Background(), 1, 1, pngBytes(t, 10, 10))
+	assert.NoError(t, err)
+
+	_, err = uc.AdminUpload(context.Background(), 1, 1, pngBytes(t, 10, 10))
+	assertErrContains(t, err, "too many images")
+}
+
+func TestProductImageUsecase_AdminUpload_ProductNotFound(t *testing.T) {
+	uc, _, _, _ := newImageUC(5<<20, 10)
+
+	_, err := uc.AdminUpload(context.Background(), 1, 99, pngBytes(t, 10, 10))
+	assertErrContains(t, err, "not found")
+}
+
+// =====================
+// Reorder / Delete
+// =====================
+
+func TestProductImageUsecase_AdminReorder(t *testing.T) {
+	uc, _, iRepo, _ := newImageUC(5<<20, 10)
+	ctx := context.Background()
+	a, _ := uc.AdminUpload(ctx, 1, 1, pngBytes(t, 10, 10))
+	b, _ := uc.AdminUpload(ctx, 1, 1, pngBytes(t, 10, 10))
+	c, _ := uc.AdminUpload(ctx, 1, 1, pngBytes(t, 10, 10))
+
+	//全部の画像をちょうど1回ずつ
+	for _, ids := range [][]int64{{a.ID, b.ID}, {a.ID, b.ID, b.ID}, {a.ID, b.ID, 999}} {
+		_, err := uc.AdminReorder(ctx, 1, 1, ids)
+		assertErrContains(t, err, "exactly once")
+	}
+
+	out, err := uc.AdminReorder(ctx, 1, 1, []int64{c.ID, a.ID, b.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, c.ID, out.Items[0].ID)
+	assert.Equal(t, 0, out.Items[0].Position)
+
+	list, _ := iRepo.ListByProductID(ctx, 1)
+	assert.Equal(t, []int64{c.ID, a.ID, b.ID}, []int64{list[0].ID, list[1].ID, list[2].ID})
+}
+
+func TestProductImageUsecase_AdminDelete_RemovesFilesAndRepacks(t *testing.T) {
+	uc, _, iRepo, st := newImageUC(5<<20, 10)
+	ctx := context.Background()
+	a, _ := uc.AdminUpload(ctx, 1, 1, pngBytes(t, 10, 10))
+	b, _ := uc.AdminUpload(ctx, 1, 1, pngBytes(t, 10, 10))
+	assert.Len(t, st.files, 8)
+
+	assert.NoError(t, uc.AdminDelete(ctx, 1, 1, a.ID))
+	assert.Len(t, st.files, 4)
+
+	list, _ := iRepo.ListByProductID(ctx, 1)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, b.ID, list[0].ID)
+		assert.Equal(t, 0, list[0].Position)
+	}
+}
+
+func TestProductImageUsecase_AdminDelete_OtherProductsImage(t *testing.T) {
+	uc, _, iRepo, _ := newImageUC(5<<20, 10)
+	iRepo.items = []model.ProductImage{{ID: 5, ProductID: 2, KeyPrefix: "products/2/x", Format: imaging.FormatJPEG}}
+
+	err := uc.AdminDelete(context.Background(), 1, 1, 5)
+	assertErrContains(t, err, "not found")
+	assert.Len(t, iRepo.items, 1)
+}
+
+// =====================
+// GET /products（画像とメイン画像）
+// =====================
+
+func TestProductUsecase_ListPublicProducts_IncludesImages(t *testing.T) {
+	pRepo := new(ProdProductRepoMock)
+	iRepo := newFakeImageRepo(
+		model.ProductImage{ID: 11, ProductID: 1, KeyPrefix: "products/1/b", Format: imaging.FormatJPEG, Position: 1},
+		model.ProductImage{ID: 10, ProductID: 1, KeyPrefix: "products/1/a", Format: imaging.FormatGIF, Position: 0},
+	)
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, iRepo, newFakeFileStorage())
+
+	q := repo.ProductListQuery{Page: 1, Limit: 20}
+	pRepo.On("ListPublic", mock.Anything, q).Return([]model.Product{{ID: 1}, {ID: 2}}, int64(2), nil)
+
+	out, err := uc.ListPublicProducts(context.Background(), usecase.ListProductsInput{Page: 1, Limit: 20})
+	assert.NoError(t, err)
+
+	first := out.Items[0]
+	if assert.Len(t, first.Images, 2) && assert.NotNil(t, first.PrimaryImage) {
+		assert.Equal(t, int64(10), first.PrimaryImage.ID)
+		assert.Equal(t, "https://cdn.example.com/products/1/a/original.gif", first.PrimaryImage.URL)
+		//GIFの縮小版はPNG
+		assert.Equal(t, "https://cdn.example.com/products/1/a/small.png", first.PrimaryImage.Thumbnails["small"])
+		assert.Equal(t, "https://cdn.example.com/products/1/b/medium.jpg", first.Images[1].Thumbnails["medium"])
+	}
+
+	//画像の無い商品は空配列とnull
+	assert.NotNil(t, out.Items[1].Images)
+	assert.Empty(t, out.Items[1].Images)
+	assert.Nil(t, out.Items[1].PrimaryImage)
+}
+
+// =====================
+// imaging
+// =====================
+
+func TestImaging_Decode_RejectsTooManyPixels(t *testing.T) {
+	_, err := imaging.Decode(pngBytes(t, 100, 100), 100*99)
+	assert.Equal(t, imaging.ErrTooManyPixels, err)
+
+	img, err := imaging.Decode(pngBytes(t, 100, 100), 100*100)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, img.Bounds().Dx())
+}
+
+func TestImaging_Thumbnail_AveragesPixels(t *testing.T) {
+	//左半分が黒、右半分が白の4x2を2x1にすると黒と白の1画素ずつ
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			v := uint8(0)
+			if x >= 2 {
+				v = 255
+			}
+			src.Set(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+
+	dst := imaging.Thumbnail(src, 2)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), dst.Bounds())
+	assert.Equal(t, color.RGBA{A: 255}, dst.At(0, 0))
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, dst.At(1, 0))
+
+	//小さい画像はそのまま
+	assert.Same(t, src, imaging.Thumbnail(src, 10))
+}
+
+func TestImaging_ToRGBA(t *testing.T) {
+	//RGBAはコピーしない
+	rgba := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	assert.Same(t, rgba, imaging.ToRGBA(rgba))
+
+	//パレット画像は原点(0,0)のRGBAにする
+	pal := image.NewPaletted(image.Rect(3, 3, 5, 4), color.Palette{color.Black, color.White})
+	pal.SetColorIndex(4, 3, 1)
+	got := imaging.ToRGBA(pal)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), got.Bounds())
+	assert.Equal(t, color.RGBA{A: 255}, got.At(0, 0))
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, got.At(1, 0))
+}
+
+func TestImaging_Thumbnail_SubImage(t *testing.T) {
+	//原点が(0,0)でないRGBA（SubImage）も、その範囲だけで平均をとる
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		v := uint8(0)
+		if x >= 2 {
+			v = 255
+		}
+		src.Set(x, 0, color.RGBA{R: v, G: v, B: v, A: 255})
+		src.Set(x, 1, color.RGBA{R: v, G: v, B: v, A: 255})
+	}
+	sub := src.SubImage(image.Rect(2, 0, 4, 2)).(*image.RGBA)
+
+	dst := imaging.Thumbnail(sub, 1)
+	assert.Equal(t, image.Rect(0, 0, 1, 1), dst.Bounds())
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, dst.At(0, 0))
+}
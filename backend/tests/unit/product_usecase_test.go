@@ -25,6 +25,11 @@ func (m *ProdProductRepoMock) ListPublic(ctx context.Context, q repo.ProductList
 	return items, args.Get(1).(int64), args.Error(2)
 }
 
+func (m *ProdProductRepoMock) FacetPublic(ctx context.Context, q repo.ProductListQuery, priceBounds []int64) (repo.ProductFacetCounts, error) {
+	args := m.Called(ctx, q, priceBounds)
+	return args.Get(0).(repo.ProductFacetCounts), args.Error(1)
+}
+
 func (m *ProdProductRepoMock) SetActive(ctx context.Context, productID int64, isActive bool) error {
 	panic("not used in ProductUsecase tests")
 }
@@ -66,6 +71,19 @@ func (m *ProdInventoryRepoMock) IncreaseStock(ctx context.Context, productID int
 	panic("not used in ProductUsecase tests")
 }
 
+func (m *ProdInventoryRepoMock) SetVariantStock(ctx context.Context, variantID int64, newStock int64) error {
+	args := m.Called(ctx, variantID, newStock)
+	return args.Error(0)
+}
+
+func (m *ProdInventoryRepoMock) DecreaseVariantStockIfEnough(ctx context.Context, variantID int64, qty int64) (bool, error) {
+	panic("not used in ProductUsecase tests")
+}
+
+func (m *ProdInventoryRepoMock) IncreaseVariantStock(ctx context.Context, variantID int64, qty int64) error {
+	panic("not used in ProductUsecase tests")
+}
+
 func (m *ProdInventoryRepoMock) CreateAdjustment(ctx context.Context, adj model.InventoryAdjustment) error {
 	args := m.Called(ctx, adj)
 	return args.Error(0)
@@ -87,14 +105,14 @@ func (m *ProdAuditRepoMock) List(ctx context.Context, filter repo.AuditLogFilter
 // =====================
 
 func TestProductUsecase_ListPublicProducts_InvalidPage(t *testing.T) {
-	uc := usecase.NewProductUsecase(new(ProdProductRepoMock), new(ProdInventoryRepoMock), new(ProdAuditRepoMock))
+	uc := usecase.NewProductUsecase(new(ProdProductRepoMock), new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, nil, nil)
 
 	_, err := uc.ListPublicProducts(context.Background(), usecase.ListProductsInput{Page: 0, Limit: 20})
 	assertErrContains(t, err, "invalid page")
 }
 
 func TestProductUsecase_ListPublicProducts_InvalidLimit(t *testing.T) {
-	uc := usecase.NewProductUsecase(new(ProdProductRepoMock), new(ProdInventoryRepoMock), new(ProdAuditRepoMock))
+	uc := usecase.NewProductUsecase(new(ProdProductRepoMock), new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, nil, nil)
 
 	_, err := uc.ListPublicProducts(context.Background(), usecase.ListProductsInput{Page: 1, Limit: 101})
 	assertErrContains(t, err, "invalid limit")
@@ -104,7 +122,7 @@ func TestProductUsecase_ListPublicProducts_Success(t *testing.T) {
 	ctx := context.Background()
 
 	pRepo := new(ProdProductRepoMock)
-	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock))
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, newFakeImageRepo(), newFakeFileStorage())
 
 	in := usecase.ListProductsInput{Page: 1, Limit: 20, Q: "coffee", Sort: "new"}
 	q := repo.ProductListQuery{Page: 1, Limit: 20, Q: "coffee", Sort: "new"}
@@ -128,7 +146,7 @@ func TestProductUsecase_GetProductDetail_NotFound_WhenInactive(t *testing.T) {
 	ctx := context.Background()
 
 	pRepo := new(ProdProductRepoMock)
-	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock))
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, nil, nil)
 
 	pRepo.On("FindByID", mock.Anything, int64(1)).Return(model.Product{ID: 1, IsActive: false}, nil)
 
@@ -140,7 +158,7 @@ func TestProductUsecase_GetProductDetail_NotFound_WhenRepoNotFound(t *testing.T)
 	ctx := context.Background()
 
 	pRepo := new(ProdProductRepoMock)
-	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock))
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, nil, nil)
 
 	pRepo.On("FindByID", mock.Anything, int64(99)).Return(model.Product{}, repo.ErrNotFound)
 
@@ -152,7 +170,7 @@ func TestProductUsecase_GetProductDetail_Success(t *testing.T) {
 	ctx := context.Background()
 
 	pRepo := new(ProdProductRepoMock)
-	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock))
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, newFakeVariantRepo(), newFakeImageRepo(), newFakeFileStorage())
 
 	pRepo.On("FindByID", mock.Anything, int64(1)).Return(model.Product{ID: 1, IsActive: true}, nil)
 
@@ -168,14 +186,14 @@ func TestProductUsecase_GetProductDetail_Success(t *testing.T) {
 // =====================
 
 func TestProductUsecase_AdminCreateProduct_Unauthorized(t *testing.T) {
-	uc := usecase.NewProductUsecase(new(ProdProductRepoMock), new(ProdInventoryRepoMock), new(ProdAuditRepoMock))
+	uc := usecase.NewProductUsecase(new(ProdProductRepoMock), new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, nil, nil)
 
 	_, err := uc.AdminCreateProduct(context.Background(), 0, usecase.AdminCreateProductInput{Name: "x", Price: 1, Stock: 1})
 	assertErrContains(t, err, "unauthorized")
 }
 
 func TestProductUsecase_AdminCreateProduct_Validation(t *testing.T) {
-	uc := usecase.NewProductUsecase(new(ProdProductRepoMock), new(ProdInventoryRepoMock), new(ProdAuditRepoMock))
+	uc := usecase.NewProductUsecase(new(ProdProductRepoMock), new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, nil, nil)
 
 	_, err := uc.AdminCreateProduct(context.Background(), 1, usecase.AdminCreateProductInput{Name: " ", Price: 1, Stock: 1})
 	assertErrContains(t, err, "name required")
@@ -185,7 +203,7 @@ func TestProductUsecase_AdminCreateProduct_Success(t *testing.T) {
 	ctx := context.Background()
 
 	pRepo := new(ProdProductRepoMock)
-	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock))
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, nil, nil)
 
 	pRepo.On("Create", mock.Anything, mock.MatchedBy(func(p model.Product) bool {
 		return p.Name == "Coffee" && p.Price == 100 && p.Stock == 10
@@ -207,7 +225,7 @@ func TestProductUsecase_AdminUpdateProduct_NotFound(t *testing.T) {
 	ctx := context.Background()
 
 	pRepo := new(ProdProductRepoMock)
-	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock))
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, nil, nil)
 
 	pRepo.On("Update", mock.Anything, mock.AnythingOfType("model.Product")).Return(repo.ErrNotFound)
 
@@ -223,7 +241,7 @@ func TestProductUsecase_AdminDeleteProduct_Success(t *testing.T) {
 	ctx := context.Background()
 
 	pRepo := new(ProdProductRepoMock)
-	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock))
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, nil, nil)
 
 	pRepo.On("SoftDelete", mock.Anything, int64(1)).Return(nil)
 
@@ -238,7 +256,7 @@ func TestProductUsecase_AdminDeleteProduct_Success(t *testing.T) {
 // =====================
 
 func TestProductUsecase_AdminUpdateInventory_NegativeStock_S3(t *testing.T) {
-	uc := usecase.NewProductUsecase(new(ProdProductRepoMock), new(ProdInventoryRepoMock), new(ProdAuditRepoMock))
+	uc := usecase.NewProductUsecase(new(ProdProductRepoMock), new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, nil, nil)
 
 	err := uc.AdminUpdateInventory(context.Background(), 1, 1, -1, "reason")
 	assertErrContains(t, err, "stock must be >= 0")
@@ -252,7 +270,7 @@ func TestProductUsecase_AdminUpdateInventory_Success_S1(t *testing.T) {
 	iRepo := new(ProdInventoryRepoMock)
 	aRepo := new(ProdAuditRepoMock)
 
-	uc := usecase.NewProductUsecase(pRepo, iRepo, aRepo)
+	uc := usecase.NewProductUsecase(pRepo, iRepo, aRepo, nil, nil, nil, nil)
 
 	// beforeの在庫を読む
 	pRepo.On("FindByID", mock.Anything, int64(10)).Return(model.Product{ID: 10, Stock: 5, IsActive: true}, nil)
@@ -292,7 +310,7 @@ func TestProductUsecase_AdminUpdateInventory_DBError_OnSetStock(t *testing.T) {
 	iRepo := new(ProdInventoryRepoMock)
 	aRepo := new(ProdAuditRepoMock)
 
-	uc := usecase.NewProductUsecase(pRepo, iRepo, aRepo)
+	uc := usecase.NewProductUsecase(pRepo, iRepo, aRepo, nil, nil, nil, nil)
 
 	pRepo.On("FindByID", mock.Anything, int64(10)).Return(model.Product{ID: 10, Stock: 5, IsActive: true}, nil)
 
@@ -0,0 +1,493 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"testing"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =====================
+// Fake（メモリ上の軸・バリエーション）
+// =====================
+
+type fakeVariantRepo struct {
+	nextID   int64
+	options  map[int64][]model.ProductOption
+	variants map[int64]model.ProductVariant
+	deleted  map[int64]bool
+}
+
+func newFakeVariantRepo() *fakeVariantRepo {
+	return &fakeVariantRepo{
+		options:  map[int64][]model.ProductOption{},
+		variants: map[int64]model.ProductVariant{},
+		deleted:  map[int64]bool{},
+	}
+}
+
+func (r *fakeVariantRepo) id() int64 {
+	r.nextID++
+	return r.nextID
+}
+
+func (r *fakeVariantRepo) ListOptions(ctx context.Context, productID int64) ([]model.ProductOption, error) {
+	return r.options[productID], nil
+}
+
+func (r *fakeVariantRepo) ReplaceOptions(ctx context.Context, productID int64, names []string) ([]model.ProductOption, error) {
+	options := make([]model.ProductOption, 0, len(names))
+	for i, n := range names {
+		options = append(options, model.ProductOption{ID: r.id(), ProductID: productID, Name: n, Position: i})
+	}
+	r.options[productID] = options
+	return options, nil
+}
+
+func (r *fakeVariantRepo) ListByProductID(ctx context.Context, productID int64) ([]model.ProductVariant, error) {
+	out := []model.ProductVariant{}
+	for id, v := range r.variants {
+		if v.ProductID == productID && !r.deleted[id] {
+			out = append(out, v)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (r *fakeVariantRepo) FindByID(ctx context.Context, variantID int64) (model.ProductVariant, error) {
+	v, ok := r.variants[variantID]
+	if !ok || r.deleted[variantID] {
+		return model.ProductVariant{}, repo.ErrNotFound
+	}
+	return v, nil
+}
+
+func (r *fakeVariantRepo) FindBySKU(ctx context.Context, sku string) (model.ProductVariant, error) {
+	for _, v := range r.variants {
+		if v.SKU == sku {
+			return v, nil
+		}
+	}
+	return model.ProductVariant{}, repo.ErrNotFound
+}
+
+func (r *fakeVariantRepo) Create(ctx context.Context, v model.ProductVariant) (model.ProductVariant, error) {
+	v.ID = r.id()
+	for i := range v.Values {
+		v.Values[i].VariantID = v.ID
+	}
+	r.variants[v.ID] = v
+	return v, nil
+}
+
+func (r *fakeVariantRepo) Update(ctx context.Context, v model.ProductVariant) error {
+	cur, ok := r.variants[v.ID]
+	if !ok {
+		return repo.ErrNotFound
+	}
+	cur.SKU, cur.Price, cur.IsActive = v.SKU, v.Price, v.IsActive
+	r.variants[v.ID] = cur
+	return nil
+}
+
+func (r *fakeVariantRepo) SoftDelete(ctx context.Context, variantID int64) error {
+	if _, ok := r.variants[variantID]; !ok {
+		return repo.ErrNotFound
+	}
+	r.deleted[variantID] = true
+	return nil
+}
+
+// 軸（サイズ・色）と、公開中の S/白・M/白、非公開の L/黒 を持つ商品
+func seedTShirt(r *fakeVariantRepo, productID int64) (model.ProductVariant, model.ProductVariant, model.ProductVariant) {
+	opts, _ := r.ReplaceOptions(context.Background(), productID, []string{"サイズ", "色"})
+	size, color := opts[0].ID, opts[1].ID
+
+	mk := func(sku string, price int64, stock int64, active bool, s string, c string) model.ProductVariant {
+		v, _ := r.Create(context.Background(), model.ProductVariant{
+			ProductID: productID, SKU: sku, Price: price, Stock: stock, IsActive: active,
+			Values: []model.ProductVariantValue{{OptionID: size, Value: s}, {OptionID: color, Value: c}},
+		})
+		return v
+	}
+	return mk("TS-S-WH", 1500, 5, true, "S", "白"),
+		mk("TS-M-WH", 1600, 1, true, "M", "白"),
+		mk("TS-L-BK", 1700, 9, false, "L", "黒")
+}
+
+// 同じバリエーション（どちらもnilなら同じ）
+func sameVariantID(a *int64, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func newVariantUC(products map[int64]model.Product) (*usecase.ProductVariantUsecase, *fakeVariantRepo, *ProdInventoryRepoMock, *ProdAuditRepoMock) {
+	pRepo := new(ProdProductRepoMock)
+	for id, p := range products {
+		pRepo.On("FindByID", mock.Anything, id).Return(p, nil)
+	}
+	pRepo.On("FindByID", mock.Anything, mock.Anything).Return(model.Product{}, repo.ErrNotFound)
+
+	vRepo := newFakeVariantRepo()
+	iRepo := new(ProdInventoryRepoMock)
+	aRepo := new(ProdAuditRepoMock)
+	return usecase.NewProductVariantUsecase(pRepo, vRepo, iRepo, aRepo), vRepo, iRepo, aRepo
+}
+
+// =====================
+// Admin: 軸・バリエーション
+// =====================
+
+func TestProductVariantUsecase_ReplaceOptions_Validation(t *testing.T) {
+	uc, _, _, _ := newVariantUC(map[int64]model.Product{1: {ID: 1, IsActive: true}})
+	ctx := context.Background()
+
+	_, err := uc.AdminReplaceOptions(ctx, 1, 1, []string{"サイズ", " サイズ "})
+	assertErrContains(t, err, "duplicate option name")
+
+	_, err = uc.AdminReplaceOptions(ctx, 1, 1, []string{"a", "b", "c", "d"})
+	assertHTTPStatus(t, err, http.StatusBadRequest)
+
+	_, err = uc.AdminReplaceOptions(ctx, 1, 99, []string{"サイズ"})
+	assertHTTPStatus(t, err, http.StatusNotFound)
+
+	out, err := uc.AdminReplaceOptions(ctx, 1, 1, []string{"サイズ", "色"})
+	assert.NoError(t, err)
+	assert.Len(t, out.Options, 2)
+	assert.Equal(t, "サイズ", out.Options[0].Name)
+}
+
+func TestProductVariantUsecase_ReplaceOptions_ConflictWhenVariantsExist(t *testing.T) {
+	uc, vRepo, _, _ := newVariantUC(map[int64]model.Product{1: {ID: 1, IsActive: true}})
+	seedTShirt(vRepo, 1)
+
+	_, err := uc.AdminReplaceOptions(context.Background(), 1, 1, []string{"サイズ"})
+	assertHTTPStatus(t, err, http.StatusConflict)
+}
+
+func TestProductVariantUsecase_CreateVariant(t *testing.T) {
+	uc, vRepo, _, _ := newVariantUC(map[int64]model.Product{1: {ID: 1, IsActive: true}})
+	seedTShirt(vRepo, 1)
+	ctx := context.Background()
+
+	//軸が足りない・知らない軸
+	_, err := uc.AdminCreateVariant(ctx, 1, 1, usecase.AdminCreateVariantInput{SKU: "TS-XL", Options: map[string]string{"サイズ": "XL"}})
+	assertErrContains(t, err, "options must match")
+	_, err = uc.AdminCreateVariant(ctx, 1, 1, usecase.AdminCreateVariantInput{SKU: "TS-XL", Options: map[string]string{"サイズ": "XL", "素材": "綿"}})
+	assertErrContains(t, err, "options must match")
+
+	//同じ組み合わせ・同じSKU
+	_, err = uc.AdminCreateVariant(ctx, 1, 1, usecase.AdminCreateVariantInput{SKU: "TS-S-WH-2", Options: map[string]string{"サイズ": "S", "色": "白"}})
+	assertErrContains(t, err, "variant already exists")
+	_, err = uc.AdminCreateVariant(ctx, 1, 1, usecase.AdminCreateVariantInput{SKU: "TS-S-WH", Options: map[string]string{"サイズ": "S", "色": "黒"}})
+	assertErrContains(t, err, "sku already exists")
+
+	_, err = uc.AdminCreateVariant(ctx, 1, 1, usecase.AdminCreateVariantInput{SKU: "TS XL", Options: map[string]string{"サイズ": "XL", "色": "白"}})
+	assertErrContains(t, err, "invalid sku")
+
+	out, err := uc.AdminCreateVariant(ctx, 1, 1, usecase.AdminCreateVariantInput{
+		SKU: "TS-XL-WH", Price: 1800, Stock: 3, IsActive: true,
+		Options: map[string]string{"サイズ": " XL ", "色": "白"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "XL", out.Options["サイズ"])
+	assert.Equal(t, "サイズ: XL / 色: 白", out.OptionLabels)
+}
+
+func TestProductVariantUsecase_CreateVariant_RequiresOptions(t *testing.T) {
+	uc, _, _, _ := newVariantUC(map[int64]model.Product{1: {ID: 1, IsActive: true}})
+
+	_, err := uc.AdminCreateVariant(context.Background(), 1, 1, usecase.AdminCreateVariantInput{SKU: "A-1"})
+	assertErrContains(t, err, "set options before adding variants")
+}
+
+func TestProductVariantUsecase_UpdateVariantStock_RecordsAdjustmentAndAudit(t *testing.T) {
+	uc, vRepo, iRepo, aRepo := newVariantUC(map[int64]model.Product{1: {ID: 1, IsActive: true}})
+	s, _, _ := seedTShirt(vRepo, 1)
+
+	iRepo.On("SetVariantStock", mock.Anything, s.ID, int64(8)).Return(nil)
+	iRepo.On("CreateAdjustment", mock.Anything, mock.MatchedBy(func(adj model.InventoryAdjustment) bool {
+		return adj.ProductID == 1 && adj.VariantID != nil && *adj.VariantID == s.ID && adj.Delta == 3
+	})).Return(nil)
+	aRepo.On("Create", mock.Anything, mock.MatchedBy(func(l model.AuditLog) bool {
+		return l.ResourceType == model.AuditResourceProductVariant && l.ResourceID == s.ID &&
+			l.BeforeJSON == `{"product_id":1,"stock":5}` && l.AfterJSON == `{"product_id":1,"stock":8}`
+	})).Return(nil)
+
+	assert.NoError(t, uc.AdminUpdateVariantStock(context.Background(), 1, s.ID, 8, "restock"))
+	iRepo.AssertExpectations(t)
+	aRepo.AssertExpectations(t)
+}
+
+// =====================
+// GET /products/:id（バリエーション表）
+// =====================
+
+func TestProductUsecase_GetProductDetail_VariantMatrix(t *testing.T) {
+	pRepo := new(ProdProductRepoMock)
+	vRepo := newFakeVariantRepo()
+	s, m, _ := seedTShirt(vRepo, 1)
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, vRepo, newFakeImageRepo(), newFakeFileStorage())
+
+	pRepo.On("FindByID", mock.Anything, int64(1)).Return(model.Product{ID: 1, Name: "Tシャツ", IsActive: true}, nil)
+
+	out, err := uc.GetProductDetail(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Tシャツ", out.Name)
+
+	//非公開の L/黒 は出さない
+	assert.Equal(t, []usecase.ProductOptionOutput{
+		{Name: "サイズ", Values: []string{"S", "M"}},
+		{Name: "色", Values: []string{"白"}},
+	}, out.Options)
+	if assert.Len(t, out.Variants, 2) {
+		assert.Equal(t, s.ID, out.Variants[0].ID)
+		assert.Equal(t, int64(1500), out.Variants[0].Price)
+		assert.Equal(t, m.ID, out.Variants[1].ID)
+		assert.Equal(t, map[string]string{"サイズ": "M", "色": "白"}, out.Variants[1].Options)
+	}
+}
+
+// =====================
+// カート
+// =====================
+
+func newVariantCartUC(products map[int64]model.Product) (*usecase.CartUsecase, *fakeCartStore, *fakeVariantRepo) {
+	store := newFakeCartStore()
+	pRepo := new(ProdProductRepoMock)
+	for id, p := range products {
+		pRepo.On("FindByID", mock.Anything, id).Return(p, nil)
+	}
+	pRepo.On("FindByID", mock.Anything, mock.Anything).Return(model.Product{}, repo.ErrNotFound)
+
+	vRepo := newFakeVariantRepo()
+	uc := usecase.NewCartUsecase(config.Config{JWTSecret: "test-secret"}, store, store, pRepo, vRepo)
+	return uc, store, vRepo
+}
+
+func TestCartUsecase_AddToCart_Variant(t *testing.T) {
+	uc, _, vRepo := newVariantCartUC(map[int64]model.Product{
+		1: {ID: 1, Name: "Tシャツ", Price: 1000, Stock: 100, IsActive: true},
+		2: {ID: 2, Name: "マグ", Price: 800, Stock: 10, IsActive: true},
+	})
+	s, m, l := seedTShirt(vRepo, 1)
+	ctx := context.Background()
+
+	//バリエーションのある商品はvariant_id必須、無い商品には指定できない
+	_, err := uc.AddToCart(ctx, 7, usecase.AddCartInput{ProductID: 1, Quantity: 1})
+	assertErrContains(t, err, "variant_id required")
+	_, err = uc.AddToCart(ctx, 7, usecase.AddCartInput{ProductID: 2, VariantID: &s.ID, Quantity: 1})
+	assertErrContains(t, err, "invalid variant_id")
+	_, err = uc.AddToCart(ctx, 7, usecase.AddCartInput{ProductID: 1, VariantID: &l.ID, Quantity: 1})
+	assertErrContains(t, err, "invalid variant_id")
+
+	//在庫・価格はバリエーションのもの
+	_, err = uc.AddToCart(ctx, 7, usecase.AddCartInput{ProductID: 1, VariantID: &m.ID, Quantity: 2})
+	assertErrContains(t, err, "stock exceeded")
+
+	_, err = uc.AddToCart(ctx, 7, usecase.AddCartInput{ProductID: 1, VariantID: &s.ID, Quantity: 2})
+	assert.NoError(t, err)
+	out, err := uc.AddToCart(ctx, 7, usecase.AddCartInput{ProductID: 1, VariantID: &m.ID, Quantity: 1})
+	assert.NoError(t, err)
+
+	//バリエーションごとに別の明細
+	if assert.Len(t, out.Items, 2) {
+		assert.Equal(t, s.ID, *out.Items[0].VariantID)
+		assert.Equal(t, "サイズ: S / 色: 白", out.Items[0].OptionLabels)
+		assert.Equal(t, int64(1500), out.Items[0].Price)
+		assert.Equal(t, m.ID, *out.Items[1].VariantID)
+	}
+	assert.Equal(t, int64(1500*2+1600), out.Total)
+
+	//同じバリエーションは数量加算（在庫まで）
+	out, err = uc.AddToCart(ctx, 7, usecase.AddCartInput{ProductID: 1, VariantID: &s.ID, Quantity: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), out.Items[0].Quantity)
+	_, err = uc.AddToCart(ctx, 7, usecase.AddCartInput{ProductID: 1, VariantID: &s.ID, Quantity: 1})
+	assertErrContains(t, err, "stock exceeded")
+}
+
+func TestCartUsecase_MergeGuestCart_Variant(t *testing.T) {
+	uc, store, vRepo := newVariantCartUC(map[int64]model.Product{
+		1: {ID: 1, Name: "Tシャツ", Price: 1000, Stock: 100, IsActive: true},
+	})
+	s, m, _ := seedTShirt(vRepo, 1)
+	ctx := context.Background()
+
+	_, err := uc.AddToCart(ctx, 7, usecase.AddCartInput{ProductID: 1, VariantID: &s.ID, Quantity: 4})
+	assert.NoError(t, err)
+
+	guest, err := uc.AddToGuestCart(ctx, "", usecase.AddCartInput{ProductID: 1, VariantID: &s.ID, Quantity: 3})
+	assert.NoError(t, err)
+	_, err = uc.AddToGuestCart(ctx, guest.GuestToken, usecase.AddCartInput{ProductID: 1, VariantID: &m.ID, Quantity: 1})
+	assert.NoError(t, err)
+
+	assert.NoError(t, uc.MergeGuestCart(ctx, 7, guest.GuestToken))
+
+	cart, _ := store.FindActiveByUserID(ctx, 7)
+	items, _ := store.ListByCartID(ctx, cart.ID)
+	if assert.Len(t, items, 2) {
+		//S/白は在庫5で頭打ち、M/白はそのまま移る
+		assert.Equal(t, int64(5), items[0].Quantity)
+		assert.Equal(t, m.ID, *items[1].VariantID)
+		assert.Equal(t, "サイズ: M / 色: 白", items[1].OptionLabelsSnapshot)
+	}
+}
+
+// =====================
+// 注文（バリエーションごとに在庫を減らす）
+// =====================
+
+type fakeOrderTx struct{ repos repo.TxRepos }
+
+func (m *fakeOrderTx) WithinTx(ctx context.Context, fn func(r repo.TxRepos) error) error {
+	return fn(m.repos)
+}
+
+type fakeOrderTxRepos struct {
+	orders    *fakeOrderStore
+	items     *fakeOrderItemStore
+	carts     *fakeCartStore
+	inventory *fakeVariantInventory
+	products  repo.ProductRepository
+	variants  *fakeVariantRepo
+}
+
+func (r *fakeOrderTxRepos) Orders() repo.OrderRepository            { return r.orders }
+func (r *fakeOrderTxRepos) OrderItems() repo.OrderItemRepository    { return r.items }
+func (r *fakeOrderTxRepos) Carts() repo.CartRepository              { return r.carts }
+func (r *fakeOrderTxRepos) CartItems() repo.CartItemRepository      { return r.carts }
+func (r *fakeOrderTxRepos) Inventory() repo.InventoryRepository     { return r.inventory }
+func (r *fakeOrderTxRepos) Products() repo.ProductRepository        { return r.products }
+func (r *fakeOrderTxRepos) Variants() repo.ProductVariantRepository { return r.variants }
+
+type fakeOrderStore struct {
+	repo.OrderRepository
+	created []model.Order
+}
+
+func (s *fakeOrderStore) FindByIdempotencyKey(ctx context.Context, userID int64, key string) (model.Order, bool, error) {
+	return model.Order{}, false, nil
+}
+
+func (s *fakeOrderStore) Create(ctx context.Context, o model.Order) (int64, error) {
+	s.created = append(s.created, o)
+	return int64(len(s.created)), nil
+}
+
+type fakeOrderItemStore struct {
+	repo.OrderItemRepository
+	items []model.OrderItem
+}
+
+func (s *fakeOrderItemStore) CreateBulk(ctx context.Context, orderID int64, items []model.OrderItem) error {
+	s.items = append(s.items, items...)
+	return nil
+}
+
+// バリエーションの在庫はfakeVariantRepoを直接減らす。商品単位は呼ばれた回数だけ数える
+type fakeVariantInventory struct {
+	repo.InventoryRepository
+	variants        *fakeVariantRepo
+	productDecrease int
+}
+
+func (i *fakeVariantInventory) DecreaseStockIfEnough(ctx context.Context, productID int64, qty int64) (bool, error) {
+	i.productDecrease++
+	return true, nil
+}
+
+func (i *fakeVariantInventory) DecreaseVariantStockIfEnough(ctx context.Context, variantID int64, qty int64) (bool, error) {
+	v := i.variants.variants[variantID]
+	if v.Stock < qty {
+		return false, nil
+	}
+	v.Stock -= qty
+	i.variants.variants[variantID] = v
+	return true, nil
+}
+
+type fakeOrderAddressRepo struct{ repo.AddressRepository }
+
+func (r *fakeOrderAddressRepo) FindByID(ctx context.Context, addressID int64) (model.Address, error) {
+	return model.Address{ID: addressID, UserID: 7}, nil
+}
+
+func TestOrderUsecase_PlaceOrder_DecrementsVariantStock(t *testing.T) {
+	cartUC, store, vRepo := newVariantCartUC(map[int64]model.Product{
+		1: {ID: 1, Name: "Tシャツ", Price: 1000, Stock: 100, IsActive: true},
+	})
+	s, m, _ := seedTShirt(vRepo, 1)
+	ctx := context.Background()
+
+	_, err := cartUC.AddToCart(ctx, 7, usecase.AddCartInput{ProductID: 1, VariantID: &s.ID, Quantity: 2})
+	assert.NoError(t, err)
+	_, err = cartUC.AddToCart(ctx, 7, usecase.AddCartInput{ProductID: 1, VariantID: &m.ID, Quantity: 1})
+	assert.NoError(t, err)
+
+	pRepo := new(ProdProductRepoMock)
+	pRepo.On("FindByID", mock.Anything, int64(1)).Return(model.Product{ID: 1, Name: "Tシャツ", IsActive: true}, nil)
+	inv := &fakeVariantInventory{variants: vRepo}
+	repos := &fakeOrderTxRepos{
+		orders: &fakeOrderStore{}, items: &fakeOrderItemStore{}, carts: store,
+		inventory: inv, products: pRepo, variants: vRepo,
+	}
+	uc := usecase.NewOrderUsecase(&fakeOrderTx{repos: repos}, &fakeOrderAddressRepo{})
+
+	out, err := uc.PlaceOrder(ctx, 7, usecase.PlaceOrderInput{AddressID: 1, IdempotencyKey: "k1"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1500*2+1600), out.TotalPrice)
+
+	assert.Equal(t, int64(3), vRepo.variants[s.ID].Stock)
+	assert.Equal(t, int64(0), vRepo.variants[m.ID].Stock)
+	assert.Equal(t, 0, inv.productDecrease)
+
+	if assert.Len(t, repos.items.items, 2) {
+		assert.Equal(t, s.ID, *repos.items.items[0].VariantID)
+		assert.Equal(t, "TS-S-WH", repos.items.items[0].SKUSnapshot)
+		assert.Equal(t, "サイズ: S / 色: 白", repos.items.items[0].OptionLabelsSnapshot)
+	}
+	if assert.Len(t, out.Items, 2) {
+		assert.Equal(t, "TS-M-WH", out.Items[1].SKU)
+		assert.Equal(t, "サイズ: M / 色: 白", out.Items[1].OptionLabels)
+	}
+}
+
+func TestOrderUsecase_PlaceOrder_VariantOutOfStock(t *testing.T) {
+	cartUC, store, vRepo := newVariantCartUC(map[int64]model.Product{
+		1: {ID: 1, Name: "Tシャツ", Price: 1000, Stock: 100, IsActive: true},
+	})
+	_, m, _ := seedTShirt(vRepo, 1)
+	ctx := context.Background()
+
+	_, err := cartUC.AddToCart(ctx, 7, usecase.AddCartInput{ProductID: 1, VariantID: &m.ID, Quantity: 1})
+	assert.NoError(t, err)
+
+	//カートに入れた後に売り切れた
+	v := vRepo.variants[m.ID]
+	v.Stock = 0
+	vRepo.variants[m.ID] = v
+
+	pRepo := new(ProdProductRepoMock)
+	pRepo.On("FindByID", mock.Anything, int64(1)).Return(model.Product{ID: 1, Name: "Tシャツ", IsActive: true}, nil)
+	repos := &fakeOrderTxRepos{
+		orders: &fakeOrderStore{}, items: &fakeOrderItemStore{}, carts: store,
+		inventory: &fakeVariantInventory{variants: vRepo}, products: pRepo, variants: vRepo,
+	}
+	uc := usecase.NewOrderUsecase(&fakeOrderTx{repos: repos}, &fakeOrderAddressRepo{})
+
+	_, err = uc.PlaceOrder(ctx, 7, usecase.PlaceOrderInput{AddressID: 1, IdempotencyKey: "k1"})
+	assertErrContains(t, err, "out of stock")
+	assert.Empty(t, repos.orders.created)
+}
@@ -0,0 +1,247 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	"app/internal/middleware"
+	repo "app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =====================
+// Mock: RolePermissionRepository
+// =====================
+
+type MockRolePermissionRepository struct{ mock.Mock }
+
+func (m *MockRolePermissionRepository) ListAll(ctx context.Context) ([]model.RolePermission, error) {
+	args := m.Called(ctx)
+	list, _ := args.Get(0).([]model.RolePermission)
+	return list, args.Error(1)
+}
+
+func (m *MockRolePermissionRepository) ListByRole(ctx context.Context, role model.Role) ([]string, error) {
+	args := m.Called(ctx, role)
+	perms, _ := args.Get(0).([]string)
+	return perms, args.Error(1)
+}
+
+func (m *MockRolePermissionRepository) HasPermission(ctx context.Context, role model.Role, permission string) (bool, error) {
+	args := m.Called(ctx, role, permission)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRolePermissionRepository) ReplaceRole(ctx context.Context, role model.Role, permissions []string) error {
+	args := m.Called(ctx, role, permissions)
+	return args.Error(0)
+}
+
+func (m *MockRolePermissionRepository) DeleteRole(ctx context.Context, role model.Role) (bool, error) {
+	args := m.Called(ctx, role)
+	return args.Bool(0), args.Error(1)
+}
+
+type rbacMocks struct {
+	roles *MockRolePermissionRepository
+	users *MockUserRepository
+	audit *AdminAuditRepoMock
+}
+
+func newRbacUC() (*usecase.RbacUsecase, rbacMocks) {
+	m := rbacMocks{
+		roles: new(MockRolePermissionRepository),
+		users: new(MockUserRepository),
+		audit: new(AdminAuditRepoMock),
+	}
+	return usecase.NewRbacUsecase(m.roles, m.users, m.audit), m
+}
+
+// =====================
+// HasPermission
+// =====================
+
+// ADMINは全部、USERは何も無し、スタッフはDBの対応表
+func TestRbacUsecase_HasPermission(t *testing.T) {
+	uc, m := newRbacUC()
+	ctx := context.Background()
+
+	ok, err := uc.HasPermission(ctx, "ADMIN", model.PermUsersWrite)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = uc.HasPermission(ctx, "USER", model.PermOrdersRead)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	m.roles.On("HasPermission", mock.Anything, model.RoleInventoryManager, model.PermInventoryWrite).Return(true, nil)
+	m.roles.On("HasPermission", mock.Anything, model.RoleInventoryManager, model.PermUsersWrite).Return(false, nil)
+
+	ok, err = uc.HasPermission(ctx, "INVENTORY_MANAGER", model.PermInventoryWrite)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = uc.HasPermission(ctx, "INVENTORY_MANAGER", model.PermUsersWrite)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+// =====================
+// SeedDefaults
+// =====================
+
+func TestRbacUsecase_SeedDefaults_OnlyWhenEmpty(t *testing.T) {
+	uc, m := newRbacUC()
+	m.roles.On("ListAll", mock.Anything).Return([]model.RolePermission{}, nil).Once()
+	m.roles.On("ReplaceRole", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	assert.NoError(t, uc.SeedDefaults(context.Background()))
+	m.roles.AssertNumberOfCalls(t, "ReplaceRole", len(model.DefaultRolePermissions))
+
+	//2回目（行がある）は何もしない
+	m.roles.On("ListAll", mock.Anything).Return([]model.RolePermission{{Role: model.RoleSupportAgent, Permission: model.PermUsersRead}}, nil).Once()
+	assert.NoError(t, uc.SeedDefaults(context.Background()))
+	m.roles.AssertNumberOfCalls(t, "ReplaceRole", len(model.DefaultRolePermissions))
+}
+
+// =====================
+// ListRoles / PutRole / DeleteRole
+// =====================
+
+func TestRbacUsecase_ListRoles_GroupsByRole(t *testing.T) {
+	uc, m := newRbacUC()
+	m.roles.On("ListAll", mock.Anything).Return([]model.RolePermission{
+		{Role: model.RoleInventoryManager, Permission: model.PermInventoryWrite},
+		{Role: model.RoleInventoryManager, Permission: model.PermProductsWrite},
+		{Role: model.RoleOrderOperator, Permission: model.PermOrdersRead},
+	}, nil)
+
+	out, err := uc.ListRoles(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, out, 2) {
+		assert.Equal(t, model.RoleInventoryManager, out[0].Role)
+		assert.Equal(t, []string{model.PermInventoryWrite, model.PermProductsWrite}, out[0].Permissions)
+		assert.Equal(t, model.RoleOrderOperator, out[1].Role)
+	}
+}
+
+// 置き換え + 監査ログ
+func TestRbacUsecase_PutRole_Success(t *testing.T) {
+	uc, m := newRbacUC()
+
+	m.roles.On("ListByRole", mock.Anything, model.Role("WAREHOUSE_PART_TIME")).Return([]string{}, nil)
+	m.roles.On("ReplaceRole", mock.Anything, model.Role("WAREHOUSE_PART_TIME"), []string{model.PermInventoryWrite}).Return(nil)
+	m.audit.On("Create", mock.Anything, mock.MatchedBy(func(l model.AuditLog) bool {
+		return l.ActorUserID == 1 &&
+			l.Action == model.AuditActionUpdateRolePermissions &&
+			l.ResourceType == model.AuditResourceRole &&
+			l.AfterJSON == `{"role":"WAREHOUSE_PART_TIME","permissions":["inventory.write"]}`
+	})).Return(nil)
+
+	out, err := uc.PutRole(context.Background(), 1, "warehouse_part_time", []string{"inventory.write", "inventory.write"})
+	assert.NoError(t, err)
+	assert.Equal(t, model.Role("WAREHOUSE_PART_TIME"), out.Role)
+
+	m.roles.AssertExpectations(t)
+	m.audit.AssertExpectations(t)
+}
+
+func TestRbacUsecase_PutRole_Rejects(t *testing.T) {
+	uc, m := newRbacUC()
+
+	_, err := uc.PutRole(context.Background(), 1, "ADMIN", []string{model.PermUsersRead})
+	assertErrContains(t, err, "built-in role")
+
+	_, err = uc.PutRole(context.Background(), 1, "bad role!", []string{model.PermUsersRead})
+	assertErrContains(t, err, "invalid role")
+
+	_, err = uc.PutRole(context.Background(), 1, "SUPPORT_AGENT", []string{"users.delete"})
+	assertErrContains(t, err, "invalid permission")
+
+	_, err = uc.PutRole(context.Background(), 1, "SUPPORT_AGENT", nil)
+	assertErrContains(t, err, "permissions required")
+
+	m.roles.AssertNotCalled(t, "ReplaceRole", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// ユーザーに割り当て中のロールは消せない
+func TestRbacUsecase_DeleteRole_AssignedToUsers_Conflict(t *testing.T) {
+	uc, m := newRbacUC()
+	role := model.RoleOrderOperator
+
+	m.roles.On("ListByRole", mock.Anything, role).Return([]string{model.PermOrdersRead}, nil)
+	m.users.On("List", mock.Anything, repo.AdminUserListFilter{Page: 1, Limit: 1, Role: &role}).Return([]model.User{{ID: 9}}, int64(1), nil)
+
+	err := uc.DeleteRole(context.Background(), 1, "ORDER_OPERATOR")
+	assertErrContains(t, err, "assigned")
+	m.roles.AssertNotCalled(t, "DeleteRole", mock.Anything, mock.Anything)
+}
+
+// =====================
+// middleware: StaffRoleGuard + RequirePermission
+// =====================
+
+type fakePermissionChecker struct {
+	allowed map[string]bool
+	err     error
+}
+
+func (f fakePermissionChecker) HasPermission(ctx context.Context, role string, permission string) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	return role == "ADMIN" || f.allowed[role+" "+permission], nil
+}
+
+func newPermissionEcho(perms middleware.PermissionChecker) (*echo.Echo, config.Config) {
+	e := echo.New()
+	cfg := config.Config{JWTSecret: "test-secret"}
+
+	e.PUT("/admin/inventory/1", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"ok": "true"})
+	}, middleware.AuthJWT(cfg), middleware.StaffRoleGuard(cfg), middleware.RequirePermission(perms, model.PermInventoryWrite))
+	return e, cfg
+}
+
+func TestMiddleware_RequirePermission(t *testing.T) {
+	e, cfg := newPermissionEcho(fakePermissionChecker{allowed: map[string]bool{"INVENTORY_MANAGER inventory.write": true}})
+
+	cases := []struct {
+		role string
+		want int
+		msg  string
+	}{
+		{"INVENTORY_MANAGER", http.StatusOK, ""},
+		{"ADMIN", http.StatusOK, ""},
+		{"SUPPORT_AGENT", http.StatusForbidden, "permission denied"},
+		{"USER", http.StatusForbidden, "staff only"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.role, func(t *testing.T) {
+			token := mustMakeJWT(t, cfg.JWTSecret, 1, tc.role, 0, jwt.SigningMethodHS256)
+			rec := runRequest(t, e, http.MethodPut, "/admin/inventory/1", "Bearer "+token)
+			assert.Equal(t, tc.want, rec.Code)
+			if tc.msg != "" {
+				assert.Equal(t, tc.msg, decodeMWError(t, rec).Error)
+			}
+		})
+	}
+}
+
+func TestMiddleware_RequirePermission_CheckerError_500(t *testing.T) {
+	e, cfg := newPermissionEcho(fakePermissionChecker{err: errors.New("db down")})
+
+	token := mustMakeJWT(t, cfg.JWTSecret, 1, "INVENTORY_MANAGER", 0, jwt.SigningMethodHS256)
+	rec := runRequest(t, e, http.MethodPut, "/admin/inventory/1", "Bearer "+token)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
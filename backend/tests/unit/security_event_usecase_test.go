@@ -0,0 +1,230 @@
+package unit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	infrarepo "app/internal/infra/repository"
+	repo "app/internal/repository"
+	"app/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =====================
+// Fake: SecurityEventRepository（保存したイベントを見るだけ）
+// =====================
+
+type fakeSecurityEventRepository struct {
+	mu         sync.Mutex
+	events     []model.SecurityEvent
+	lastFilter repo.SecurityEventFilter
+}
+
+func (r *fakeSecurityEventRepository) Create(ctx context.Context, ev model.SecurityEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ev.ID = int64(len(r.events) + 1)
+	r.events = append(r.events, ev)
+	return nil
+}
+
+func (r *fakeSecurityEventRepository) List(ctx context.Context, filter repo.SecurityEventFilter) ([]model.SecurityEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastFilter = filter
+	return r.events, nil
+}
+
+func (r *fakeSecurityEventRepository) AnonymizeByUserID(ctx context.Context, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.events {
+		if r.events[i].UserID != nil && *r.events[i].UserID == userID {
+			r.events[i].Email = ""
+			r.events[i].IP = ""
+			r.events[i].UserAgent = ""
+		}
+	}
+	return nil
+}
+
+func (r *fakeSecurityEventRepository) only(t *testing.T) model.SecurityEvent {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !assert.Len(t, r.events, 1) {
+		t.FailNow()
+	}
+	return r.events[0]
+}
+
+func newAuthUCWithEvents(userRepo *MockUserRepository, rtRepo *MockRefreshTokenRepository, v *MockAuthValidator) (*usecase.AuthUsecase, *fakeSecurityEventRepository) {
+	events := new(fakeSecurityEventRepository)
+	cfg := config.Config{JWTSecret: "test-secret"}
+	uc := usecase.NewAuthUsecase(cfg, userRepo, rtRepo, v, new(MockPasswordResetTokenRepository), new(MockEmailVerificationTokenRepository), new(MockMfaRecoveryCodeRepository), infrarepo.NewLoginAttemptMemoryRepository(), new(MockMailer), events)
+	return uc, events
+}
+
+// =====================
+// AuthUsecaseからの記録
+// =====================
+
+// ログイン成功：ユーザー・IP・UA・端末（系列）を残す
+func TestSecurityEvent_Login_Success(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	rtRepo := new(MockRefreshTokenRepository)
+	v := new(MockAuthValidator)
+
+	rtRepo.On("DeleteExpired", mock.Anything, mock.Anything).Return(int64(0), nil)
+	v.On("ValidateLogin", mock.Anything, "user@test.com", "CorrectPW").Return(nil)
+	userRepo.On("FindByEmail", mock.Anything, "user@test.com").Return(&model.User{
+		ID: 1, Email: "user@test.com", PasswordHash: mustHash(t, "CorrectPW"), Role: model.RoleUser, IsActive: true,
+	}, nil)
+	userRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+	var created model.RefreshToken
+	rtRepo.On("Create", mock.Anything, mock.AnythingOfType("model.RefreshToken")).Run(func(args mock.Arguments) {
+		created = args.Get(1).(model.RefreshToken)
+	}).Return(nil)
+
+	uc, events := newAuthUCWithEvents(userRepo, rtRepo, v)
+	_, err := uc.Login(context.Background(), usecase.AuthLoginRequest{Email: "user@test.com", Password: "CorrectPW"}, "UA", "203.0.113.1")
+	assert.NoError(t, err)
+
+	ev := events.only(t)
+	assert.Equal(t, model.SecurityEventLogin, ev.Type)
+	assert.Equal(t, model.SecurityOutcomeSuccess, ev.Outcome)
+	assert.Equal(t, int64(1), *ev.UserID)
+	assert.Equal(t, "203.0.113.1", ev.IP)
+	assert.Equal(t, "UA", ev.UserAgent)
+	assert.Equal(t, created.FamilyID, ev.FamilyID)
+	assert.False(t, ev.CreatedAt.IsZero())
+}
+
+// ログイン失敗：理由を残す（存在しないメールアドレスはuser_idなし）
+func TestSecurityEvent_Login_Failures(t *testing.T) {
+	cases := []struct {
+		name       string
+		user       *model.User
+		wantReason string
+		wantUserID bool
+	}{
+		{"unknown email", nil, "unknown_email", false},
+		{"wrong password", &model.User{ID: 1, Email: "user@test.com", PasswordHash: mustHash(t, "CorrectPW"), IsActive: true}, "invalid_password", true},
+		{"inactive", &model.User{ID: 1, Email: "user@test.com", IsActive: false}, "inactive", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(MockUserRepository)
+			rtRepo := new(MockRefreshTokenRepository)
+			v := new(MockAuthValidator)
+
+			rtRepo.On("DeleteExpired", mock.Anything, mock.Anything).Return(int64(0), nil)
+			v.On("ValidateLogin", mock.Anything, "user@test.com", "WrongPW").Return(nil)
+			userRepo.On("FindByEmail", mock.Anything, "user@test.com").Return(tc.user, nil)
+
+			uc, events := newAuthUCWithEvents(userRepo, rtRepo, v)
+			_, err := uc.Login(context.Background(), usecase.AuthLoginRequest{Email: "user@test.com", Password: "WrongPW"}, "UA", "203.0.113.1")
+			assert.Error(t, err)
+
+			ev := events.only(t)
+			assert.Equal(t, model.SecurityEventLogin, ev.Type)
+			assert.Equal(t, model.SecurityOutcomeFailure, ev.Outcome)
+			assert.Equal(t, tc.wantReason, ev.Reason)
+			assert.Equal(t, "user@test.com", ev.Email)
+			assert.Equal(t, tc.wantUserID, ev.UserID != nil)
+		})
+	}
+}
+
+// refresh再利用：どの端末（系列）かを残す
+func TestSecurityEvent_RefreshReuse(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	rtRepo := new(MockRefreshTokenRepository)
+	v := new(MockAuthValidator)
+
+	usedAt := time.Now().Add(-time.Minute)
+	rtRepo.On("DeleteExpired", mock.Anything, mock.Anything).Return(int64(0), nil)
+	v.On("ValidateRefresh", mock.Anything, "used", "UA").Return(nil)
+	rtRepo.On("FindByHash", mock.Anything, mock.Anything).Return(model.RefreshToken{
+		ID: "rt-used", UserID: 7, FamilyID: "fam-7", UserAgent: "UA", ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt,
+	}, true, nil)
+	rtRepo.On("DeleteByFamilyID", mock.Anything, "fam-7").Return(int64(2), nil)
+
+	uc, events := newAuthUCWithEvents(userRepo, rtRepo, v)
+	_, err := uc.Refresh(context.Background(), "used", "UA", "198.51.100.9")
+	assert.ErrorIs(t, err, usecase.ErrSecurityIncident)
+
+	ev := events.only(t)
+	assert.Equal(t, model.SecurityEventRefreshReuse, ev.Type)
+	assert.Equal(t, model.SecurityOutcomeFailure, ev.Outcome)
+	assert.Equal(t, "revoke_family", ev.Reason)
+	assert.Equal(t, int64(7), *ev.UserID)
+	assert.Equal(t, "fam-7", ev.FamilyID)
+	assert.Equal(t, "198.51.100.9", ev.IP)
+}
+
+// 強制ログアウト：操作した管理者を残す
+func TestSecurityEvent_ForceLogout_RecordsActor(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	rtRepo := new(MockRefreshTokenRepository)
+	v := new(MockAuthValidator)
+
+	v.On("ValidateForceLogout", mock.Anything, int64(10)).Return(nil)
+	userRepo.On("IncrementTokenVersion", mock.Anything, int64(10)).Return(nil)
+	rtRepo.On("DeleteByUserID", mock.Anything, int64(10)).Return(nil)
+	userRepo.On("FindByID", mock.Anything, int64(10)).Return(&model.User{ID: 10, TokenVersion: 1}, nil)
+
+	uc, events := newAuthUCWithEvents(userRepo, rtRepo, v)
+	_, err := uc.ForceLogout(context.Background(), 1, 10, "AdminUA", "10.0.0.1")
+	assert.NoError(t, err)
+
+	ev := events.only(t)
+	assert.Equal(t, model.SecurityEventForceLogout, ev.Type)
+	assert.Equal(t, int64(10), *ev.UserID)
+	assert.Equal(t, int64(1), *ev.ActorUserID)
+}
+
+// =====================
+// SecurityEventUsecase（一覧）
+// =====================
+
+// 本人の一覧は必ず自分のuser_idで絞る
+func TestSecurityEventUsecase_ListMine_FiltersByUser(t *testing.T) {
+	events := new(fakeSecurityEventRepository)
+	uc := usecase.NewSecurityEventUsecase(events)
+
+	out, err := uc.ListMine(context.Background(), 5, 2, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, out.Page)
+	assert.Equal(t, 10, out.Limit)
+	if assert.NotNil(t, events.lastFilter.UserID) {
+		assert.Equal(t, int64(5), *events.lastFilter.UserID)
+	}
+	assert.Equal(t, 10, events.lastFilter.Offset)
+}
+
+func TestSecurityEventUsecase_AdminList_Filters(t *testing.T) {
+	events := new(fakeSecurityEventRepository)
+	uc := usecase.NewSecurityEventUsecase(events)
+
+	_, err := uc.AdminList(context.Background(), usecase.AdminSecurityEventListInput{Type: "LOGIN", Outcome: "FAILURE", IP: "203.0.113.1"})
+	assert.NoError(t, err)
+	assert.Equal(t, model.SecurityEventLogin, *events.lastFilter.Type)
+	assert.Equal(t, model.SecurityOutcomeFailure, *events.lastFilter.Outcome)
+	assert.Equal(t, "203.0.113.1", events.lastFilter.IP)
+	assert.Nil(t, events.lastFilter.UserID)
+
+	_, err = uc.AdminList(context.Background(), usecase.AdminSecurityEventListInput{Type: "NOPE"})
+	assertErrContains(t, err, "invalid type")
+
+	_, err = uc.AdminList(context.Background(), usecase.AdminSecurityEventListInput{Limit: 1000})
+	assertErrContains(t, err, "invalid limit")
+}
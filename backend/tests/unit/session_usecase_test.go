@@ -0,0 +1,167 @@
+package unit
+
+import (
+	"app/internal/domain/model"
+	"app/internal/usecase"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// usecase.hashToken と同じ（refresh平文 → DB保存hash）
+func sessionHash(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// セッションID（refresh tokenのID）はUUID
+const (
+	sessionIDMine  = "6f1c2a4e-8b3d-4e5f-9a01-23456789abcd"
+	sessionIDOther = "0b9e8d7c-6a5f-4e3d-8c2b-1a0f9e8d7c6b"
+)
+
+func assertHTTPStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	he, ok := usecase.AsHTTPError(err)
+	if assert.True(t, ok, "expected HTTPError, got %v", err) {
+		assert.Equal(t, status, he.Status)
+	}
+}
+
+// 一覧：cookieのrefreshと一致するものだけ current=true / IDは系列ID
+func TestSessionUsecase_List_MarksCurrent(t *testing.T) {
+	ctx := context.Background()
+	rtRepo := new(MockRefreshTokenRepository)
+	uc := usecase.NewSessionUsecase(rtRepo)
+
+	now := time.Now()
+	rtRepo.On("ListActiveByUserID", mock.Anything, int64(1), mock.AnythingOfType("time.Time")).Return([]model.RefreshToken{
+		{ID: "rt-2b", FamilyID: sessionIDOther, UserID: 1, TokenHash: sessionHash("plain-2"), UserAgent: "Phone", CreatedAt: now},
+		{ID: "rt-1c", FamilyID: sessionIDMine, UserID: 1, TokenHash: sessionHash("plain-1"), UserAgent: "PC", CreatedAt: now.Add(-time.Hour)},
+	}, nil)
+
+	out, err := uc.List(ctx, 1, "plain-1")
+	assert.NoError(t, err)
+	if assert.Len(t, out.Items, 2) {
+		assert.False(t, out.Items[0].Current)
+		assert.True(t, out.Items[1].Current)
+		assert.Equal(t, "PC", out.Items[1].UserAgent)
+		assert.Equal(t, sessionIDMine, out.Items[1].ID)
+	}
+}
+
+// 他人のセッション（自分の一覧に無い）=> 404 / 削除されない
+func TestSessionUsecase_Revoke_OtherUsersSession_NotFound(t *testing.T) {
+	ctx := context.Background()
+	rtRepo := new(MockRefreshTokenRepository)
+	uc := usecase.NewSessionUsecase(rtRepo)
+
+	rtRepo.On("ListActiveByUserID", mock.Anything, int64(1), mock.AnythingOfType("time.Time")).Return([]model.RefreshToken{
+		{ID: "rt-1c", FamilyID: sessionIDMine, UserID: 1},
+	}, nil)
+
+	isCurrent, err := uc.Revoke(ctx, 1, sessionIDOther, "")
+	assert.False(t, isCurrent)
+	assertHTTPStatus(t, err, http.StatusNotFound)
+
+	rtRepo.AssertNotCalled(t, "DeleteByFamilyID", mock.Anything, mock.Anything)
+	rtRepo.AssertNotCalled(t, "DeleteByID", mock.Anything, mock.Anything)
+}
+
+// UUIDでないID => 404 / DBは見ない
+func TestSessionUsecase_Revoke_InvalidID_NotFound(t *testing.T) {
+	ctx := context.Background()
+	rtRepo := new(MockRefreshTokenRepository)
+	uc := usecase.NewSessionUsecase(rtRepo)
+
+	for _, id := range []string{"", "rt-1", "not-a-uuid"} {
+		isCurrent, err := uc.Revoke(ctx, 1, id, "")
+		assert.False(t, isCurrent)
+		assertHTTPStatus(t, err, http.StatusNotFound)
+	}
+
+	rtRepo.AssertNotCalled(t, "ListActiveByUserID", mock.Anything, mock.Anything, mock.Anything)
+	rtRepo.AssertNotCalled(t, "DeleteByFamilyID", mock.Anything, mock.Anything)
+}
+
+// 自分のセッション => 系列ごと削除 / 現在の端末ならtrue
+func TestSessionUsecase_Revoke_Current(t *testing.T) {
+	ctx := context.Background()
+	rtRepo := new(MockRefreshTokenRepository)
+	uc := usecase.NewSessionUsecase(rtRepo)
+
+	rtRepo.On("ListActiveByUserID", mock.Anything, int64(1), mock.AnythingOfType("time.Time")).Return([]model.RefreshToken{
+		{ID: "rt-1c", FamilyID: sessionIDMine, UserID: 1, TokenHash: sessionHash("plain-1")},
+	}, nil)
+	rtRepo.On("DeleteByFamilyID", mock.Anything, sessionIDMine).Return(int64(3), nil)
+
+	isCurrent, err := uc.Revoke(ctx, 1, sessionIDMine, "plain-1")
+	assert.NoError(t, err)
+	assert.True(t, isCurrent)
+
+	rtRepo.AssertExpectations(t)
+	rtRepo.AssertNotCalled(t, "DeleteByID", mock.Anything, mock.Anything)
+}
+
+// 系列導入前のtoken => 自分のIDがセッションID / その1本だけ削除
+func TestSessionUsecase_Revoke_LegacyToken(t *testing.T) {
+	ctx := context.Background()
+	rtRepo := new(MockRefreshTokenRepository)
+	uc := usecase.NewSessionUsecase(rtRepo)
+
+	rtRepo.On("ListActiveByUserID", mock.Anything, int64(1), mock.AnythingOfType("time.Time")).Return([]model.RefreshToken{
+		{ID: sessionIDOther, UserID: 1, TokenHash: sessionHash("plain-2")},
+	}, nil)
+	rtRepo.On("DeleteByID", mock.Anything, sessionIDOther).Return(nil)
+
+	isCurrent, err := uc.Revoke(ctx, 1, sessionIDOther, "plain-1")
+	assert.NoError(t, err)
+	assert.False(t, isCurrent)
+
+	rtRepo.AssertExpectations(t)
+	rtRepo.AssertNotCalled(t, "DeleteByFamilyID", mock.Anything, mock.Anything)
+}
+
+// 他の端末を全部失効 => 現在の端末の系列だけ残す / 件数はセッション数
+func TestSessionUsecase_RevokeOthers_KeepsCurrent(t *testing.T) {
+	ctx := context.Background()
+	rtRepo := new(MockRefreshTokenRepository)
+	uc := usecase.NewSessionUsecase(rtRepo)
+
+	rtRepo.On("ListActiveByUserID", mock.Anything, int64(1), mock.AnythingOfType("time.Time")).Return([]model.RefreshToken{
+		{ID: "rt-1c", FamilyID: sessionIDMine, UserID: 1, TokenHash: sessionHash("plain-1")},
+		{ID: "rt-2b", FamilyID: sessionIDOther, UserID: 1, TokenHash: sessionHash("plain-2")},
+		{ID: "rt-legacy", UserID: 1, TokenHash: sessionHash("plain-3")},
+	}, nil)
+	rtRepo.On("DeleteByUserIDExceptFamily", mock.Anything, int64(1), sessionIDMine).Return(int64(4), nil)
+
+	out, err := uc.RevokeOthers(ctx, 1, "plain-1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), out.Revoked)
+
+	rtRepo.AssertExpectations(t)
+}
+
+// cookie無し => 全セッションが対象
+func TestSessionUsecase_RevokeOthers_NoCookie_RevokesAll(t *testing.T) {
+	ctx := context.Background()
+	rtRepo := new(MockRefreshTokenRepository)
+	uc := usecase.NewSessionUsecase(rtRepo)
+
+	rtRepo.On("ListActiveByUserID", mock.Anything, int64(1), mock.AnythingOfType("time.Time")).Return([]model.RefreshToken{
+		{ID: "rt-1c", FamilyID: sessionIDMine, UserID: 1, TokenHash: sessionHash("plain-1")},
+	}, nil)
+	rtRepo.On("DeleteByUserIDExceptFamily", mock.Anything, int64(1), "").Return(int64(2), nil)
+
+	out, err := uc.RevokeOthers(ctx, 1, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), out.Revoked)
+
+	rtRepo.AssertExpectations(t)
+}
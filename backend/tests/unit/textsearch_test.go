@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"app/internal/domain/model"
+	repo "app/internal/repository"
+	"app/internal/textsearch"
+	"app/internal/usecase"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =====================
+// 正規化・検索語
+// =====================
+
+func TestTextsearch_Normalize(t *testing.T) {
+	cases := map[string]string{
+		"Coffee Beans": "coffee beans",
+		//全角英数・全角スペース → 半角
+		"ＣＯＦＦＥＥ　１００ｇ": "coffee 100g",
+		//カタカナ・半角カナ → ひらがな（長音はそのまま）
+		"コーヒー":    "こーひー",
+		"ｺｰﾋｰ":    "こーひー",
+		"ドリップバッグ": "どりっぷばっぐ",
+		//漢字はそのまま
+		"深煎り珈琲": "深煎り珈琲",
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, textsearch.Normalize(in), in)
+	}
+}
+
+func TestTextsearch_Terms(t *testing.T) {
+	assert.Equal(t, []string{"こーひー", "豆", "beans"}, textsearch.Terms("  コーヒー　豆 Beans こーひー "))
+	assert.Empty(t, textsearch.Terms(" 　 "))
+	assert.Len(t, textsearch.Terms("a b c d e f g"), textsearch.MaxTerms)
+}
+
+func TestTextsearch_EscapeLike(t *testing.T) {
+	assert.Equal(t, `100\%\_off\\`, textsearch.EscapeLike(`100%_off\`))
+}
+
+// =====================
+// ハイライト
+// =====================
+
+func TestTextsearch_Highlight_Japanese(t *testing.T) {
+	//検索語はひらがなでも、元の表記（カタカナ・半角カナ）のまま囲む
+	got, ok := textsearch.Highlight("深煎りのコーヒー豆と、ｺｰﾋｰミル", textsearch.Terms("こーひー"), 0)
+	assert.True(t, ok)
+	assert.Equal(t, "深煎りの<mark>コーヒー</mark>豆と、<mark>ｺｰﾋｰ</mark>ミル", got)
+}
+
+func TestTextsearch_Highlight_EnglishCaseInsensitiveAndEscaped(t *testing.T) {
+	got, ok := textsearch.Highlight("<b>Fresh</b> COFFEE & beans", textsearch.Terms("coffee BEANS"), 0)
+	assert.True(t, ok)
+	assert.Equal(t, "&lt;b&gt;Fresh&lt;/b&gt; <mark>COFFEE</mark> &amp; <mark>beans</mark>", got)
+}
+
+func TestTextsearch_Highlight_SnippetAroundFirstMatch(t *testing.T) {
+	text := "ああああああああああああああああああああ珈琲いいいいいいいいいいいいいいいいいいいい"
+
+	got, ok := textsearch.Highlight(text, textsearch.Terms("珈琲"), 10)
+	assert.True(t, ok)
+	assert.Equal(t, "…ああ<mark>珈琲</mark>いいいいいい…", got)
+
+	//一致が無ければ先頭から
+	got, ok = textsearch.Highlight(text, textsearch.Terms("紅茶"), 5)
+	assert.False(t, ok)
+	assert.Equal(t, "あああああ…", got)
+}
+
+// =====================
+// GET /products?q=（sort=relevance・ハイライト）
+// =====================
+
+func TestProductUsecase_ListPublicProducts_RelevanceWithHighlight(t *testing.T) {
+	pRepo := new(ProdProductRepoMock)
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, newFakeImageRepo(), newFakeFileStorage())
+
+	q := repo.ProductListQuery{Page: 1, Limit: 20, Q: "コーヒー", Sort: "relevance"}
+	pRepo.On("ListPublic", mock.Anything, q).Return([]model.Product{
+		{ID: 1, Name: "ブレンドコーヒー", Description: "毎朝のこーひーに"},
+		{ID: 2, Name: "マグカップ", Description: "Coffee mug"},
+	}, int64(2), nil)
+
+	out, err := uc.ListPublicProducts(context.Background(), usecase.ListProductsInput{Page: 1, Limit: 20, Q: " コーヒー ", Sort: "relevance"})
+	assert.NoError(t, err)
+	if assert.NotNil(t, out.Items[0].Highlight) {
+		assert.Equal(t, "ブレンド<mark>コーヒー</mark>", out.Items[0].Highlight.Name)
+		assert.Equal(t, "毎朝の<mark>こーひー</mark>に", out.Items[0].Highlight.Description)
+	}
+	//一致が無くても抜粋は返す
+	assert.Equal(t, "マグカップ", out.Items[1].Highlight.Name)
+	pRepo.AssertExpectations(t)
+}
+
+func TestProductUsecase_ListPublicProducts_NoHighlightWithoutQuery(t *testing.T) {
+	pRepo := new(ProdProductRepoMock)
+	uc := usecase.NewProductUsecase(pRepo, new(ProdInventoryRepoMock), new(ProdAuditRepoMock), nil, nil, newFakeImageRepo(), newFakeFileStorage())
+
+	q := repo.ProductListQuery{Page: 1, Limit: 20, Sort: "relevance"}
+	pRepo.On("ListPublic", mock.Anything, q).Return([]model.Product{{ID: 1, Name: "A"}}, int64(1), nil)
+
+	out, err := uc.ListPublicProducts(context.Background(), usecase.ListProductsInput{Page: 1, Limit: 20, Sort: "relevance"})
+	assert.NoError(t, err)
+	assert.Nil(t, out.Items[0].Highlight)
+}
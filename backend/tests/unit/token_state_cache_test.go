@@ -0,0 +1,259 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"app/internal/config"
+	"app/internal/domain/model"
+	infrarepo "app/internal/infra/repository"
+	"app/internal/middleware"
+	"app/internal/repository"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// =====================
+// Fake: Publisher
+// =====================
+
+type fakeTokenStatePublisher struct {
+	published []int64
+	err       error
+}
+
+func (p *fakeTokenStatePublisher) Publish(ctx context.Context, userID int64) error {
+	p.published = append(p.published, userID)
+	return p.err
+}
+
+func newCachedUserRepo(ttl time.Duration) (repository.UserRepository, *MockUserRepoForMiddleware, *fakeTokenStatePublisher, repository.TokenStateCache) {
+	inner := new(MockUserRepoForMiddleware)
+	pub := &fakeTokenStatePublisher{}
+	cache := infrarepo.NewTokenStateMemoryCache(ttl, 100)
+	return infrarepo.NewTokenStateCachedUserRepository(inner, cache, pub), inner, pub, cache
+}
+
+// =====================
+// TokenVersionGuard: is_active
+// =====================
+
+// 無効化されたユーザーはtvが一致していても401
+func TestMiddleware_TokenVersionGuard_Unauthorized_Inactive(t *testing.T) {
+	e := echo.New()
+	cfg := config.Config{JWTSecret: "test-secret"}
+	userRepo := new(MockUserRepoForMiddleware)
+
+	raw := mustMakeJWT(t, cfg.JWTSecret, 1, "USER", 5, jwt.SigningMethodHS256)
+	userRepo.On("FindTokenState", mock.Anything, int64(1)).Return(&model.TokenState{UserID: 1, TokenVersion: 5, IsActive: false}, nil)
+
+	e.GET("/protected", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"ok": "true"})
+	}, middleware.AuthJWT(cfg), middleware.TokenVersionGuard(userRepo))
+
+	rec := runRequest(t, e, http.MethodGet, "/protected", "Bearer "+raw)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	userRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+}
+
+// =====================
+// キャッシュ付きUserRepository
+// =====================
+
+// 2回目はDBを読まない
+func TestTokenStateCachedUserRepository_Hit(t *testing.T) {
+	repo, inner, _, _ := newCachedUserRepo(time.Minute)
+	inner.On("FindTokenState", mock.Anything, int64(1)).Return(&model.TokenState{UserID: 1, TokenVersion: 3, IsActive: true}, nil).Once()
+
+	for i := 0; i < 3; i++ {
+		st, err := repo.FindTokenState(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, st.TokenVersion)
+	}
+	inner.AssertNumberOfCalls(t, "FindTokenState", 1)
+}
+
+// 見つからない・エラーはキャッシュしない
+func TestTokenStateCachedUserRepository_NotFoundOrError_NotCached(t *testing.T) {
+	repo, inner, _, _ := newCachedUserRepo(time.Minute)
+	inner.On("FindTokenState", mock.Anything, int64(1)).Return(nil, nil)
+	inner.On("FindTokenState", mock.Anything, int64(2)).Return(nil, errors.New("db down"))
+
+	for i := 0; i < 2; i++ {
+		st, err := repo.FindTokenState(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Nil(t, st)
+
+		_, err = repo.FindTokenState(context.Background(), 2)
+		assert.Error(t, err)
+	}
+	inner.AssertNumberOfCalls(t, "FindTokenState", 4)
+}
+
+// token_version++ したら即座に破棄して他ノードにも通知
+func TestTokenStateCachedUserRepository_IncrementTokenVersion_Invalidates(t *testing.T) {
+	repo, inner, pub, _ := newCachedUserRepo(time.Minute)
+	ctx := context.Background()
+
+	inner.On("FindTokenState", mock.Anything, int64(1)).Return(&model.TokenState{UserID: 1, TokenVersion: 3, IsActive: true}, nil).Once()
+	inner.On("IncrementTokenVersion", mock.Anything, int64(1)).Return(nil)
+	inner.On("FindTokenState", mock.Anything, int64(1)).Return(&model.TokenState{UserID: 1, TokenVersion: 4, IsActive: true}, nil).Once()
+
+	st, _ := repo.FindTokenState(ctx, 1)
+	assert.Equal(t, 3, st.TokenVersion)
+
+	assert.NoError(t, repo.IncrementTokenVersion(ctx, 1))
+	assert.Equal(t, []int64{1}, pub.published)
+
+	st, _ = repo.FindTokenState(ctx, 1)
+	assert.Equal(t, 4, st.TokenVersion)
+	inner.AssertExpectations(t)
+}
+
+// 無効化（Update）も破棄する。通知に失敗しても更新は成功のまま
+func TestTokenStateCachedUserRepository_Update_Invalidates(t *testing.T) {
+	repo, inner, pub, _ := newCachedUserRepo(time.Minute)
+	pub.err = errors.New("notify failed")
+	ctx := context.Background()
+
+	inner.On("FindTokenState", mock.Anything, int64(1)).Return(&model.TokenState{UserID: 1, TokenVersion: 3, IsActive: true}, nil).Once()
+	inner.On("Update", mock.Anything, mock.Anything).Return(nil)
+	inner.On("FindTokenState", mock.Anything, int64(1)).Return(&model.TokenState{UserID: 1, TokenVersion: 3, IsActive: false}, nil).Once()
+
+	_, _ = repo.FindTokenState(ctx, 1)
+	assert.NoError(t, repo.Update(ctx, &model.User{ID: 1, IsActive: false}))
+
+	st, _ := repo.FindTokenState(ctx, 1)
+	assert.False(t, st.IsActive)
+	assert.Equal(t, []int64{1}, pub.published)
+}
+
+// =====================
+// メモリキャッシュ
+// =====================
+
+func TestTokenStateMemoryCache_TTL(t *testing.T) {
+	cache := infrarepo.NewTokenStateMemoryCache(20*time.Millisecond, 100)
+	ctx := context.Background()
+
+	cache.Set(ctx, model.TokenState{UserID: 1, TokenVersion: 1, IsActive: true}, cache.Generation())
+	_, ok := cache.Get(ctx, 1)
+	assert.True(t, ok)
+
+	time.Sleep(30 * time.Millisecond)
+	_, ok = cache.Get(ctx, 1)
+	assert.False(t, ok)
+}
+
+// DBを読んでいる間に破棄があったら、読んだ値（古いかもしれない）は入れない
+func TestTokenStateMemoryCache_SetAfterInvalidate_Dropped(t *testing.T) {
+	cache := infrarepo.NewTokenStateMemoryCache(time.Minute, 100)
+	ctx := context.Background()
+
+	gen := cache.Generation()
+	cache.Invalidate(ctx, 1)
+	cache.Set(ctx, model.TokenState{UserID: 1, TokenVersion: 1, IsActive: true}, gen)
+
+	_, ok := cache.Get(ctx, 1)
+	assert.False(t, ok)
+}
+
+// 上限を超えて増えない
+func TestTokenStateMemoryCache_MaxEntries(t *testing.T) {
+	cache := infrarepo.NewTokenStateMemoryCache(time.Minute, 2)
+	ctx := context.Background()
+
+	for id := int64(1); id <= 3; id++ {
+		cache.Set(ctx, model.TokenState{UserID: id, IsActive: true}, cache.Generation())
+	}
+
+	_, ok1 := cache.Get(ctx, 1)
+	_, ok2 := cache.Get(ctx, 2)
+	_, ok3 := cache.Get(ctx, 3)
+	assert.True(t, ok1)
+	assert.True(t, ok2)
+	assert.False(t, ok3)
+
+	//Clearで全部消える
+	cache.Clear(ctx)
+	_, ok1 = cache.Get(ctx, 1)
+	assert.False(t, ok1)
+}
+
+// =====================
+// Benchmark: TokenVersionGuard（キャッシュ無し / あり）
+// go test ./tests/unit/ -run '^$' -bench TokenVersionGuard -benchmem
+// =====================
+
+// DBの往復を模した待ち時間
+const benchDBLatency = 200 * time.Microsecond
+
+type slowTokenStateRepo struct {
+	repository.UserRepository
+	reads atomic.Int64
+}
+
+func (r *slowTokenStateRepo) FindTokenState(ctx context.Context, id int64) (*model.TokenState, error) {
+	r.reads.Add(1)
+	time.Sleep(benchDBLatency)
+	return &model.TokenState{UserID: id, TokenVersion: 0, IsActive: true}, nil
+}
+
+func benchmarkTokenVersionGuard(b *testing.B, userRepo repository.UserRepository) {
+	e := echo.New()
+	cfg := config.Config{JWTSecret: "test-secret"}
+	e.GET("/protected", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, middleware.AuthJWT(cfg), middleware.TokenVersionGuard(userRepo))
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": 1, "role": "USER", "tv": 0, "iat": 1, "exp": 9999999999})
+	raw, err := token.SignedString([]byte(cfg.JWTSecret))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+raw)
+		rec := newBenchRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.code != http.StatusOK {
+			b.Fatalf("status=%d", rec.code)
+		}
+	}
+}
+
+func BenchmarkTokenVersionGuard_NoCache(b *testing.B) {
+	inner := &slowTokenStateRepo{}
+	benchmarkTokenVersionGuard(b, inner)
+	b.ReportMetric(float64(inner.reads.Load())/float64(b.N), "db_reads/op")
+}
+
+func BenchmarkTokenVersionGuard_Cached(b *testing.B) {
+	inner := &slowTokenStateRepo{}
+	cache := infrarepo.NewTokenStateMemoryCache(30*time.Second, 100000)
+	benchmarkTokenVersionGuard(b, infrarepo.NewTokenStateCachedUserRepository(inner, cache, nil))
+	b.ReportMetric(float64(inner.reads.Load())/float64(b.N), "db_reads/op")
+}
+
+// httptest.ResponseRecorderより軽い（ボディを溜めない）
+type benchRecorder struct {
+	header http.Header
+	code   int
+}
+
+func newBenchRecorder() *benchRecorder {
+	return &benchRecorder{header: http.Header{}, code: http.StatusOK}
+}
+
+func (r *benchRecorder) Header() http.Header         { return r.header }
+func (r *benchRecorder) Write(b []byte) (int, error) { return len(b), nil }
+func (r *benchRecorder) WriteHeader(code int)        { r.code = code }